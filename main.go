@@ -4,6 +4,8 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	// the timezones of the clients are loaded even when the system has no timezone database
+	_ "time/tzdata"
 
 	"github.com/notes-project/api/pkg/database"
 	"github.com/notes-project/api/pkg/server"
@@ -31,7 +33,9 @@ func main() {
 		os.Exit(1)
 	}
 
-	dbConfig := database.NewDatabaseConfiguration(envConfig.DatabaseUri, envConfig.DatabaseName, envConfig.DatabaseCollection)
+	revisionsRetention := database.NewRevisionsRetention(envConfig.RevisionsMaxCount, envConfig.RevisionsMaxAge)
+
+	dbConfig := database.NewDatabaseConfiguration(envConfig.DatabaseDriver, envConfig.DatabaseUri, envConfig.DatabaseName, envConfig.DatabaseCollection, revisionsRetention, envConfig.TitleLocale)
 
 	database := database.NewDatabaseFactory().NewDatabase(dbConfig)
 
@@ -41,7 +45,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	serverConfig := server.NewServerConfiguration(envConfig.ServerPort, envConfig.ServerTlsPort, *tlsCertLocation, *tlsKeyLocation, database)
+	serverConfig := server.NewServerConfiguration(envConfig.ServerPort, envConfig.ServerTlsPort, *tlsCertLocation, *tlsKeyLocation, database, envConfig.TrashRetention, envConfig.MaxPageSize)
 
 	server := server.NewServerFactory().NewServer(serverConfig)
 
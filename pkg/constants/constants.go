@@ -3,3 +3,11 @@ package constants
 const (
 	DateFormat = "02-Jan-2006"
 )
+
+// supported values for the database driver configuration
+const (
+	MongoDriver    = "mongo"
+	MemoryDriver   = "memory"
+	FileDriver     = "file"
+	PostgresDriver = "postgres"
+)
@@ -0,0 +1,222 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/notes-project/api/pkg/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fields of model.Note whose distinct values are counted by CountValues, along with the category
+const (
+	TagsField = noteTagsKey
+)
+
+// fields of model.ValueCount that can be used in a SortField of the counted values
+const (
+	ValueField = "value"
+	CountField = "count"
+)
+
+var (
+	// the most used values are listed first when no sort is provided
+	defaultCountSort = []SortField{{Field: CountField, Descending: true}}
+
+	// the fields of model.ValueCount that can be sorted along with the kind of their values
+	countSortFields = map[string]int{
+		ValueField: stringSortValue,
+		CountField: countSortValue,
+	}
+
+	// the fields of model.Note whose values can be counted
+	countedFields = map[string]bool{
+		TagsField:     true,
+		CategoryField: true,
+	}
+)
+
+// getCountedField returns an error when the distinct values of the field can't be counted
+func getCountedField(field string) error {
+	if !countedFields[field] {
+		return fmt.Errorf("failed to count the values of '%s', the field can't be counted", field)
+	}
+
+	return nil
+}
+
+// getCountSort returns the sort of the counted values, the values with equal counts are ordered by value,
+// which is unique, so it's appended to the sort when it's missing
+func getCountSort(sortBy []SortField) ([]SortField, error) {
+	if len(sortBy) == 0 {
+		sortBy = defaultCountSort
+	}
+
+	sorted := false
+
+	for _, field := range sortBy {
+		if _, ok := countSortFields[field.Field]; !ok {
+			return nil, fmt.Errorf("failed to sort values by '%s', the field can't be sorted", field.Field)
+		}
+
+		sorted = sorted || field.Field == ValueField
+	}
+
+	if sorted {
+		return sortBy, nil
+	}
+
+	return append(append([]SortField{}, sortBy...), SortField{Field: ValueField}), nil
+}
+
+// countPosition returns the position of the counted value in the sort, the value is unique so it's also the id
+func countPosition(count model.ValueCount, sortBy []SortField) pagePosition {
+	values := make([]interface{}, 0, len(sortBy))
+	for _, field := range sortBy {
+		if field.Field == CountField {
+			values = append(values, count.Count)
+		} else {
+			values = append(values, count.Value)
+		}
+	}
+
+	return pagePosition{Values: values, ID: count.Value}
+}
+
+// cutCounts returns the counted values of the page and the cursor of the next page, like cutPage
+func cutCounts(counts []model.ValueCount, sortBy []SortField, limit int64) ([]model.ValueCount, string) {
+	if limit <= 0 || int64(len(counts)) <= limit {
+		return counts, ""
+	}
+
+	counts = counts[:limit]
+
+	return counts, encodePosition(countPosition(counts[limit-1], sortBy), sortBy)
+}
+
+// countValues counts the notes that have each distinct value of the field, a note is counted once
+// for every distinct tag it has and the empty values are not counted
+func countValues(notes []model.Note, field string) []model.ValueCount {
+	counts := map[string]int64{}
+	order := []string{}
+
+	count := func(value string) {
+		if value == "" {
+			return
+		}
+
+		if counts[value] == 0 {
+			order = append(order, value)
+		}
+
+		counts[value]++
+	}
+
+	for _, note := range notes {
+		if field == CategoryField {
+			count(note.Category)
+			continue
+		}
+
+		for _, tag := range uniqueTags(note.Tags) {
+			count(tag)
+		}
+	}
+
+	result := make([]model.ValueCount, 0, len(order))
+	for _, value := range order {
+		result = append(result, model.ValueCount{Value: value, Count: counts[value]})
+	}
+
+	return result
+}
+
+func (d *database) CountValues(field string, tags TagFilter, category string, timeRange TimeRange, sortBy []SortField, page Page) ([]model.ValueCount, string, error) {
+	err := getCountedField(field)
+	if err != nil {
+		return []model.ValueCount{}, "", err
+	}
+
+	sortBy, err = getCountSort(sortBy)
+	if err != nil {
+		return []model.ValueCount{}, "", err
+	}
+
+	position, err := decodeCursor(page.Cursor, sortBy)
+	if err != nil {
+		return []model.ValueCount{}, "", err
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			getLiveFilter(),
+			getTagsFilter(tags),
+			getCategoryFilter(category),
+			getTimeRangeFilter(timeRange),
+		}}},
+	}
+
+	if field == TagsField {
+		// a note is counted once for each of its distinct tags
+		pipeline = append(pipeline,
+			bson.D{{Key: "$project", Value: bson.D{
+				{Key: noteTagsKey, Value: bson.D{{Key: "$setUnion", Value: bson.A{
+					bson.D{{Key: "$ifNull", Value: bson.A{"$" + noteTagsKey, bson.A{}}}},
+				}}}},
+			}}},
+			bson.D{{Key: "$unwind", Value: "$" + noteTagsKey}},
+		)
+	}
+
+	pipeline = append(pipeline,
+		bson.D{{Key: "$match", Value: bson.D{{Key: field, Value: bson.D{{Key: "$nin", Value: bson.A{nil, ""}}}}}}},
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: noteIDKey, Value: "$" + field},
+			{Key: CountField, Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		bson.D{{Key: "$project", Value: bson.D{
+			{Key: noteIDKey, Value: 0},
+			{Key: ValueField, Value: "$" + noteIDKey},
+			{Key: CountField, Value: 1},
+		}}},
+	)
+
+	if position != nil {
+		// the values are unique, so they order the values with equal counts
+		operator := "$gt"
+		if idDescending(sortBy) {
+			operator = "$lt"
+		}
+
+		valueAfterFilter := bson.E{Key: ValueField, Value: bson.D{{Key: operator, Value: position.ID}}}
+
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.D{getPositionFilter(position, sortBy, valueAfterFilter)}}})
+	}
+
+	sortOption := bson.D{}
+	for _, field := range sortBy {
+		sortOption = append(sortOption, bson.E{Key: field.Field, Value: sortDirection(field.Descending)})
+	}
+
+	pipeline = append(pipeline, bson.D{{Key: "$sort", Value: sortOption}})
+
+	if page.Limit > 0 {
+		// the extra value tells whether there is a next page
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: page.Limit + 1}})
+	}
+
+	cursor, err := d.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return []model.ValueCount{}, "", fmt.Errorf("failed to count the values of '%s' in collection, error: %w", field, err)
+	}
+
+	counts := []model.ValueCount{}
+	err = cursor.All(ctx, &counts)
+	if err != nil {
+		return []model.ValueCount{}, "", fmt.Errorf("failed to count the values of '%s' in collection, error: %w", field, err)
+	}
+
+	counts, next := cutCounts(counts, sortBy, page.Limit)
+
+	return counts, next, nil
+}
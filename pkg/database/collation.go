@@ -0,0 +1,119 @@
+package database
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/notes-project/api/pkg/model"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+	"golang.org/x/text/unicode/norm"
+)
+
+// titleCollation matches the titles of the notes ignoring the case and orders them in the order of a locale,
+// a nil collation matches them ignoring the case too but orders them byte by byte
+type titleCollation struct {
+	locale string
+
+	// the casers and the collators keep a state, so they can't be used concurrently
+	mu       sync.Mutex
+	fold     cases.Caser
+	collator *collate.Collator
+}
+
+// newTitleCollation returns the collation of the locale, nil when the locale is empty
+func newTitleCollation(locale string) *titleCollation {
+	if locale == "" {
+		return nil
+	}
+
+	tag := language.Make(locale)
+
+	return &titleCollation{
+		locale:   locale,
+		fold:     cases.Fold(),
+		collator: collate.New(tag),
+	}
+}
+
+// key returns the key of the title, the titles with the same key are the same title
+func (c *titleCollation) key(title string) string {
+	if c == nil {
+		return foldTitle(cases.Fold(), title)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return foldTitle(c.fold, title)
+}
+
+// foldTitle returns the title without its case, composed again because folding can decompose the characters
+func foldTitle(fold cases.Caser, title string) string {
+	return norm.NFC.String(fold.String(title))
+}
+
+// compare returns a negative number when the title a is ordered before b, a positive number
+// when it's ordered after b and zero when they are the same, the titles the locale doesn't tell apart
+// are ordered byte by byte so the order is always the same
+func (c *titleCollation) compare(a, b string) int {
+	if c == nil {
+		return strings.Compare(a, b)
+	}
+
+	c.mu.Lock()
+	result := c.collator.CompareString(a, b)
+	c.mu.Unlock()
+
+	if result != 0 {
+		return result
+	}
+
+	return strings.Compare(a, b)
+}
+
+// normalizeText returns the text in the Unicode normalization form C without its leading and trailing spaces,
+// so the texts that look the same are stored the same way whatever form the clients send
+func normalizeText(text string) string {
+	return strings.TrimSpace(norm.NFC.String(text))
+}
+
+// NormalizeTitle returns the title as it's stored, so the titles sent in different forms can be compared
+func NormalizeTitle(title string) string {
+	return normalizeText(title)
+}
+
+// TitleKey returns the key the databases match the title with, so the titles that are the same note
+// have the same key
+func TitleKey(title string) string {
+	return foldTitle(cases.Fold(), normalizeText(title))
+}
+
+// NormalizeValue returns the tag or the category as it's compared by a rename, so a value is never renamed
+// to the same value sent in another form
+func NormalizeValue(value string) string {
+	return normalizeText(value)
+}
+
+// normalizeTexts returns the normalized texts, nil when there are none
+func normalizeTexts(texts []string) []string {
+	if texts == nil {
+		return nil
+	}
+
+	normalized := make([]string, 0, len(texts))
+	for _, text := range texts {
+		normalized = append(normalized, normalizeText(text))
+	}
+
+	return normalized
+}
+
+// normalizeNote returns the note with its title and tags normalized
+func normalizeNote(note model.Note) model.Note {
+	note.Title = normalizeText(note.Title)
+	note.Tags = normalizeTexts(note.Tags)
+
+	return note
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"github.com/notes-project/api/pkg/model"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Collation", func() {
+
+	Describe("titleCollation", func() {
+		It("should match the titles ignoring the case and order them byte by byte without a locale", func() {
+			var titles *titleCollation = newTitleCollation("")
+
+			Expect(titles).To(BeNil())
+			Expect(titles.key("Standup")).To(Equal(titles.key("STANDUP")))
+			Expect(titles.key("Émile")).NotTo(Equal(titles.key("Emile")))
+			Expect(titles.compare("Standup", "standup")).To(BeNumerically("<", 0))
+			Expect(titles.compare("émile", "f")).To(BeNumerically(">", 0))
+		})
+
+		It("should match the titles ignoring the case with a locale", func() {
+			titles := newTitleCollation("en")
+
+			Expect(titles.key("Standup")).To(Equal(titles.key("STANDUP")))
+			Expect(titles.key("Straße")).To(Equal(titles.key("STRASSE")))
+			Expect(titles.key("Émile")).NotTo(Equal(titles.key("Emile")))
+		})
+
+		It("should order the titles in the order of the locale", func() {
+			titles := newTitleCollation("en")
+
+			Expect(titles.compare("émile", "f")).To(BeNumerically("<", 0))
+			Expect(titles.compare("b", "A")).To(BeNumerically(">", 0))
+			Expect(titles.compare("a", "a")).To(BeZero())
+		})
+
+		It("should order the titles the locale doesn't tell apart byte by byte", func() {
+			titles := newTitleCollation("en")
+
+			Expect(titles.compare("e\u0301", "\u00e9")).To(BeNumerically("<", 0))
+			Expect(titles.compare("\u00e9", "e\u0301")).To(BeNumerically(">", 0))
+		})
+	})
+
+	Describe("TitleKey", func() {
+		It("should return the same key for the titles that are the same note", func() {
+			Expect(TitleKey(" Standup")).To(Equal(TitleKey("STANDUP")))
+			Expect(TitleKey("Cafe\u0301")).To(Equal(TitleKey("CAF\u00c9")))
+			Expect(TitleKey("Émile")).NotTo(Equal(TitleKey("Emile")))
+		})
+	})
+
+	Describe("normalizeText", func() {
+		It("should compose the characters and trim the spaces", func() {
+			Expect(normalizeText("  Cafe\u0301 \t")).To(Equal("Caf\u00e9"))
+		})
+
+		It("should keep a normalized text as it is", func() {
+			Expect(normalizeText("Caf\u00e9")).To(Equal("Caf\u00e9"))
+		})
+	})
+
+	Describe("normalizeNote", func() {
+		It("should normalize the title and the tags only", func() {
+			note := normalizeNote(model.Note{
+				Title:       " Cafe\u0301",
+				Description: " Cafe\u0301",
+				Tags:        []string{" re\u0301sume\u0301 ", "go"},
+			})
+
+			Expect(note).To(Equal(model.Note{
+				Title:       "Caf\u00e9",
+				Description: " Cafe\u0301",
+				Tags:        []string{"r\u00e9sum\u00e9", "go"},
+			}))
+		})
+
+		It("should keep the missing tags missing", func() {
+			Expect(normalizeNote(model.Note{Title: "test"}).Tags).To(BeNil())
+		})
+	})
+
+})
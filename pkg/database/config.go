@@ -1,15 +1,58 @@
 package database
 
+import "time"
+
 type databaseConfiguration struct {
+	driver         string
 	connectionUri  string
 	databaseName   string
 	collectionName string
+
+	revisionsRetention revisionsRetention
+
+	// the locale of the titles, which are then unique and found ignoring the case and sorted in the order
+	// of the locale, empty keeps the titles unique and sorted byte by byte
+	titleLocale string
+}
+
+// revisionsRetention limits the revisions kept for each note, a zero value means no limit
+type revisionsRetention struct {
+	maxCount int64
+	maxAge   time.Duration
 }
 
-func NewDatabaseConfiguration(connectionUri, databaseName, collectionName string) databaseConfiguration {
+func NewDatabaseConfiguration(driver, connectionUri, databaseName, collectionName string, revisionsRetention revisionsRetention, titleLocale string) databaseConfiguration {
 	return databaseConfiguration{
-		connectionUri:  connectionUri,
-		databaseName:   databaseName,
-		collectionName: collectionName,
+		driver:             driver,
+		connectionUri:      connectionUri,
+		databaseName:       databaseName,
+		collectionName:     collectionName,
+		revisionsRetention: revisionsRetention,
+		titleLocale:        titleLocale,
+	}
+}
+
+func NewRevisionsRetention(maxCount int64, maxAge time.Duration) revisionsRetention {
+	return revisionsRetention{
+		maxCount: maxCount,
+		maxAge:   maxAge,
 	}
 }
+
+// oldestNumber returns the number of the oldest revision to keep, given the number of the latest one
+func (r revisionsRetention) oldestNumber(latestNumber int64) int64 {
+	if r.maxCount <= 0 || latestNumber <= r.maxCount {
+		return 1
+	}
+
+	return latestNumber - r.maxCount + 1
+}
+
+// oldestTime returns the creation time of the oldest revision to keep, the zero time when the age is not limited
+func (r revisionsRetention) oldestTime(now time.Time) time.Time {
+	if r.maxAge <= 0 {
+		return time.Time{}
+	}
+
+	return now.Add(-r.maxAge)
+}
@@ -1,6 +1,8 @@
 package database
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -8,17 +10,46 @@ import (
 var _ = Describe("DatabaseConfig", func() {
 
 	Describe("NewDatabaseConfiguration", func() {
+		driver := "driver"
 		connectionUri := "connectionUri"
 		databaseName := "databaseName"
 		collectionName := "collectionName"
+		retention := NewRevisionsRetention(1, time.Hour)
+		titleLocale := "en"
 
 		It("should return a new databese configuration object", func() {
-			dbConfig := NewDatabaseConfiguration(connectionUri, databaseName, collectionName)
+			dbConfig := NewDatabaseConfiguration(driver, connectionUri, databaseName, collectionName, retention, titleLocale)
 
 			Expect(dbConfig).NotTo(BeNil())
+			Expect(dbConfig.driver).To(Equal(driver))
 			Expect(dbConfig.connectionUri).To(Equal(connectionUri))
 			Expect(dbConfig.databaseName).To(Equal(databaseName))
 			Expect(dbConfig.collectionName).To(Equal(collectionName))
+			Expect(dbConfig.revisionsRetention).To(Equal(retention))
+			Expect(dbConfig.titleLocale).To(Equal(titleLocale))
+		})
+	})
+
+	Describe("revisionsRetention", func() {
+		It("should keep all the revisions when there are no limits", func() {
+			retention := NewRevisionsRetention(0, 0)
+
+			Expect(retention.oldestNumber(10)).To(Equal(int64(1)))
+			Expect(retention.oldestTime(time.Now())).To(BeZero())
+		})
+
+		It("should keep the latest revisions up to the max count", func() {
+			retention := NewRevisionsRetention(3, 0)
+
+			Expect(retention.oldestNumber(2)).To(Equal(int64(1)))
+			Expect(retention.oldestNumber(10)).To(Equal(int64(8)))
+		})
+
+		It("should keep the revisions up to the max age", func() {
+			now := time.Now()
+			retention := NewRevisionsRetention(0, time.Hour)
+
+			Expect(retention.oldestTime(now)).To(Equal(now.Add(-time.Hour)))
 		})
 	})
 
@@ -2,11 +2,16 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/notes-project/api/pkg/adapters"
 	facademongo "github.com/notes-project/api/pkg/facade/go.mongodb.org/mongo-driver/mongo"
+	"github.com/notes-project/api/pkg/filter"
 	"github.com/notes-project/api/pkg/model"
+	"github.com/notes-project/api/pkg/search"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -15,17 +20,80 @@ import (
 )
 
 type Database interface {
+	driver
+
+	// the notes found by a search are listed in pages like the filtered notes, by relevance when no sort is provided,
+	// the query is parsed with search.ParseQuery and the error wraps search.ErrInvalidQuery when it's invalid
+	SearchNotes(query string, where filter.Expr, tags TagFilter, category string, timeRange TimeRange, sortBy []SortField, page Page) ([]model.SearchHit, string, error)
+}
+
+// driver stores the notes, every implementation is wrapped by searchDatabase which searches the notes it stores
+type driver interface {
 	Connect() error
 
 	IsReady() bool
 
 	AddNote(note model.Note) error
-	UpdateNote(noteTitle string, updatedNote model.Note) error
+	// the notes are added independently of each other, the error of each note is nil when it's added
+	// or the error AddNote would return, the returned error is set when the batch failed as a whole
+	AddNotes(notes []model.Note) ([]error, error)
+	// the expected version makes the update conditional, it returns ErrVersionConflict
+	// when it's not the current version of the note, unless it's AnyVersion. The note keeps its creation time,
+	// and its update time is the one of the updated note when it's set, the time of the update otherwise
+	UpdateNote(noteTitle string, updatedNote model.Note, expectedVersion int64) error
+	UpdateNoteByID(noteID string, updatedNote model.Note, expectedVersion int64) error
+	// the note with the title is replaced, or added when there is none, in a single atomic change.
+	// The title of the note is the provided one, and the id, the version and the timestamps are set by
+	// the database. It returns true when the note was added.
+	UpsertNote(noteTitle string, note model.Note) (bool, error)
+	// the patch updates only the fields it sets, the other fields of the stored note are left as they are
+	PatchNote(noteTitle string, patch NotePatch, expectedVersion int64) error
+	PatchNoteByID(noteID string, patch NotePatch, expectedVersion int64) error
+	// the added tags that the note doesn't have are appended to its tags and the removed tags are pulled from them,
+	// in a single atomic change that returns the resulting tags. A change that is already applied doesn't update the note.
+	ChangeTags(noteTitle string, add, remove []string, expectedVersion int64) ([]string, error)
+	// the tag or the category is renamed in every note that has it, and merged into the new value when other notes
+	// already have it, the renamed tags are deduplicated. A dry run only counts the notes that would be changed.
+	RenameValue(field, from, to string, dryRun bool) (RenameResult, error)
 	GetNote(noteTitle string) (model.Note, error)
+	GetNoteByID(noteID string) (model.Note, error)
+	// the projected notes only have the fields of the projection along with their id, a nil projection reads all of them
+	GetProjectedNote(noteTitle string, fields Projection) (model.Note, error)
+	GetProjectedNoteByID(noteID string, fields Projection) (model.Note, error)
 	GetNotes() ([]model.Note, error)
-	GetNotesFiltered(tags []string, category, date string) ([]model.Note, error)
-	DeleteNote(noteTitle string) error
+	// the filtered notes are listed in pages, along with the cursor of the next page which is empty on the last page,
+	// they are sorted by the fields in order, by creation time when no sort is provided. The notes also match the
+	// parsed filter where, which is compiled to a query of the backend, a nil filter matches all of them.
+	// The notes only have the fields of the projection and the sorted fields, a nil projection reads all of them
+	GetNotesFiltered(where filter.Expr, tags TagFilter, category string, timeRange TimeRange, sortBy []SortField, page Page, fields Projection) ([]model.Note, string, error)
+	// the filtered notes are streamed to each one by one in the sorted order as they are read from the database,
+	// without loading all of them at once. The streaming stops at the first error, which is returned
+	StreamNotes(where filter.Expr, tags TagFilter, category string, timeRange TimeRange, sortBy []SortField, each func(note model.Note) error) error
+	// the distinct values of the tags or the category of the filtered notes are listed in pages along with the number
+	// of notes that have them, they are sorted by the value or the count, the most used ones first when no sort is provided
+	CountValues(field string, tags TagFilter, category string, timeRange TimeRange, sortBy []SortField, page Page) ([]model.ValueCount, string, error)
+	// the expected version makes the delete conditional the same way as the update
+	DeleteNote(noteTitle string, expectedVersion int64) error
+	DeleteNoteByID(noteID string, expectedVersion int64) error
 	DeleteNotes() error
+	// the operations are applied in order and the error of each one is nil when it's applied, the error of a single
+	// change otherwise. When atomic, they are applied in a transaction: either all of them are applied, or none is
+	// and the error of every operation but the failed one is ErrNotApplied. The returned error is set when the batch
+	// failed as a whole, such as when an atomic batch could not be committed.
+	ApplyOperations(operations []Operation, atomic bool) ([]error, error)
+
+	// the deleted notes are moved to the trash, where they are kept until they are purged
+	GetTrash() ([]model.Note, error)
+	RestoreNote(noteTitle string) error
+	PurgeNote(noteTitle string) error
+	PurgeTrash(deletedBefore time.Time) (int64, error)
+
+	GetRevisions(noteTitle string) ([]model.Revision, error)
+	GetRevision(noteTitle string, number int64) (model.Revision, error)
+
+	// the index of the title and the description of the notes that are not in the trash, which the database
+	// keeps up to date along with the notes
+	searchIndex() search.Index
 }
 
 type database struct {
@@ -35,17 +103,175 @@ type database struct {
 	// populated automatically inside the Connect() method
 	client     adapters.DbClient
 	collection adapters.DbCollection
+	revisions  adapters.DbCollection
+	// the counters of the revision numbers of the notes
+	revisionCounters adapters.DbCollection
+
+	// set on the copy of the database that changes the notes in a transaction
+	sessionCtx context.Context
 }
 
 const (
-	// used as a primary key
-	noteTitlePrimaryKey = "title"
+	// the title of the note from model.Note, it has a unique index
+	noteTitleKey = "title"
+	// the immutable id of the note from model.Note, used as a primary key
+	noteIDKey = "_id"
+	// the version of the note from model.Note, incremented on every update
+	noteVersionKey = "version"
+	// the description, category and tags of the note from model.Note
+	noteDescriptionKey = "description"
+	noteCategoryKey    = "category"
+	noteTagsKey        = "tags"
+	// the times the note from model.Note was created and last updated
+	noteCreatedAtKey = "createdAt"
+	noteUpdatedAtKey = "updatedAt"
+	// the legacy date of the note from model.Note, only stored before the timestamps were introduced
+	noteDateKey = "date"
+	// the time the note from model.Note was moved to the trash, missing for the notes that are not in the trash
+	noteDeletedAtKey = "deletedAt"
+
+	// name of the unique index on the title created before the notes could be moved to the trash
+	legacyTitleIndexName = "title_-1"
+	// names of the unique index on the title and the deletion time, which used to match the titles byte by byte
+	// without a locale of the titles, and matches them ignoring the case with the collation of the locale
+	titleIndexName         = "title_-1_deletedAt_-1"
+	collatedTitleIndexName = "title_-1_deletedAt_-1_collated"
+	// locale of the collation that matches the titles ignoring the case when the titles have no locale
+	defaultMongoTitleLocale = "en"
+	// name of the text index of the title and the description, a collection has at most one text index
+	searchIndexName = "title_text_description_text"
+
+	// error codes returned by MongoDB when an index or a collection doesn't exist
+	indexNotFoundErrorCode     = 27
+	namespaceNotFoundErrorCode = 26
+	// error codes returned by MongoDB when an index with the same name or keys exists with other options
+	indexOptionsConflictErrorCode  = 85
+	indexKeySpecsConflictErrorCode = 86
+
+	// strength of the collations that compare the base letters and their accents, but not their case
+	caseInsensitiveStrength = 2
+
+	// the revisions of the notes are stored in a separate collection with this suffix
+	revisionsCollectionSuffix = "_revisions"
+	// the revision counters of the notes are stored in a separate collection with this suffix
+	revisionCountersCollectionSuffix = "_revision_counters"
+
+	// two upserts of the same missing note can both try to add it, the one rejected by the unique index
+	// is tried again, which then replaces the note added by the other one
+	maxUpsertAttempts = 2
+
+	// the notes moved to the trash together are read by batches of this size to save their revisions
+	deleteNotesBatchSize = 1000
+)
+
+const (
+	// expected version that skips the version check of an update or delete
+	AnyVersion int64 = -1
+)
+
+// fields of model.Note that can be used in a TimeRange, and in a SortField along with the title and category
+const (
+	CreatedAtField = noteCreatedAtKey
+	UpdatedAtField = noteUpdatedAtKey
+	TitleField     = noteTitleKey
+	CategoryField  = noteCategoryKey
 )
 
+// TimeRange matches the notes whose time in the field is in the range [From, To),
+// a zero bound leaves the range open on that side
+type TimeRange struct {
+	Field string
+	From  time.Time
+	To    time.Time
+}
+
+// TagFilter matches the notes by their tags, a zero filter matches all the notes
+type TagFilter struct {
+	// the notes must have all of these tags, or any of them when Any is set
+	Tags []string
+	Any  bool
+	// the notes must have none of these tags
+	Excluded []string
+	// matches only the notes without tags
+	Untagged bool
+}
+
+// contains returns true when the time is in the range
+func (r TimeRange) contains(t time.Time) bool {
+	return (r.From.IsZero() || !t.Before(r.From)) && (r.To.IsZero() || t.Before(r.To))
+}
+
+// updateTime returns the update time of the updated note, the time of the update when it's not set
+func updateTime(updatedNote model.Note) time.Time {
+	if updatedNote.UpdatedAt.IsZero() {
+		return model.NewTimestamp()
+	}
+
+	return updatedNote.UpdatedAt
+}
+
+// newNote sets the fields of a note that is added to the database, which are not set yet
+func newNote(note model.Note) model.Note {
+	if note.ID == "" {
+		note.ID = model.NewNoteID()
+	}
+
+	if note.Version == 0 {
+		note.Version = 1
+	}
+
+	if note.UpdatedAt.IsZero() {
+		note.UpdatedAt = model.NewTimestamp()
+	}
+
+	if note.CreatedAt.IsZero() {
+		note.CreatedAt = note.UpdatedAt
+	}
+
+	// the notes are moved to the trash only by the deletes and the date is rendered from the timestamps
+	note.DeletedAt = nil
+	note.Date = ""
+
+	return note
+}
+
 var (
 	ctx = context.Background()
 )
 
+// context returns the context of the changes of the notes, which is the session of the transaction when there is one
+func (d *database) context() context.Context {
+	if d.sessionCtx != nil {
+		return d.sessionCtx
+	}
+
+	return ctx
+}
+
+// inTransaction runs the changes on a copy of the database that makes them in a transaction, so a note and its
+// revision are saved together, or in the transaction of the database when it's already such a copy
+func (d *database) inTransaction(run func(transaction *database) error) error {
+	if d.sessionCtx != nil {
+		return run(d)
+	}
+
+	session, err := d.client.StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start a session, error: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	// the transaction is retried when it fails with a transient error, such as a write conflict
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		transaction := *d
+		transaction.sessionCtx = sessionCtx
+
+		return nil, run(&transaction)
+	})
+
+	return err
+}
+
 func (d *database) Connect() error {
 
 	if d.client != nil {
@@ -70,13 +296,37 @@ func (d *database) Connect() error {
 
 	db := facademongo.GetClientInstace().Database(d.client.(*mongo.Client), d.databaseName)
 
-	d.collection = facademongo.GetDatabaseInstace().Collection(db, d.collectionName)
+	collection := facademongo.GetDatabaseInstace().Collection(db, d.collectionName)
+
+	d.collection = collection
+	d.revisions = facademongo.GetDatabaseInstace().Collection(db, d.collectionName+revisionsCollectionSuffix)
+	d.revisionCounters = facademongo.GetDatabaseInstace().Collection(db, d.collectionName+revisionCountersCollectionSuffix)
 
 	err = d.setUniqueIndexes()
 	if err != nil {
 		return err
 	}
 
+	err = d.setRevisionsIndexes()
+	if err != nil {
+		return err
+	}
+
+	err = d.setSortIndexes()
+	if err != nil {
+		return err
+	}
+
+	err = d.setSearchIndex()
+	if err != nil {
+		return err
+	}
+
+	err = d.migrateDates(collection)
+	if err != nil {
+		return err
+	}
+
 	d.logger.Info("Successfully connected to the database")
 
 	return nil
@@ -85,18 +335,182 @@ func (d *database) Connect() error {
 func (d *database) setUniqueIndexes() error {
 	indexView := d.collection.Indexes()
 
+	// the title used to be unique on its own, which would prevent reusing the title of a note in the trash
+	_, err := facademongo.GetIndexViewInstace().DropOne(indexView, ctx, legacyTitleIndexName)
+	if err != nil && !isIndexNotFoundError(err) {
+		return fmt.Errorf("failed to drop the legacy '%s' index, error: %w", legacyTitleIndexName, err)
+	}
+
+	// the index that matched the titles byte by byte would still reject the titles that only differ by their case
+	_, err = facademongo.GetIndexViewInstace().DropOne(indexView, ctx, titleIndexName)
+	if err != nil && !isIndexNotFoundError(err) {
+		return fmt.Errorf("failed to drop the '%s' index, error: %w", titleIndexName, err)
+	}
+
+	index := mongo.IndexModel{
+		// the title filed of the note from model.Note, the notes that are not in the trash have no deletion time,
+		// so the title is unique among them while the notes in the trash can share their title
+		Keys: bson.D{
+			{Key: noteTitleKey, Value: -1},
+			{Key: noteDeletedAtKey, Value: -1},
+		},
+		Options: options.Index().SetUnique(true).SetName(collatedTitleIndexName).SetCollation(d.titleCollationOption()),
+	}
+
+	_, err = facademongo.GetIndexViewInstace().CreateOne(indexView, ctx, index)
+	if isIndexConflictError(err) {
+		// the index was created with the collation of another locale
+		_, err = facademongo.GetIndexViewInstace().DropOne(indexView, ctx, collatedTitleIndexName)
+		if err != nil {
+			return fmt.Errorf("failed to drop the '%s' index, error: %w", collatedTitleIndexName, err)
+		}
+
+		_, err = facademongo.GetIndexViewInstace().CreateOne(indexView, ctx, index)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set '%s' as a unique collection index, error: %w", noteTitleKey, err)
+	}
+
+	d.logger.Info(fmt.Sprintf("Successfully set '%s' as a unique collection index", noteTitleKey))
+
+	return nil
+}
+
+// titleCollationOption returns the collation that matches the titles ignoring the case, the same as the one
+// of the unique index on the title, with the default locale when the titles have no locale
+func (d *database) titleCollationOption() *options.Collation {
+	locale := defaultMongoTitleLocale
+	if d.titleLocale != "" {
+		locale = mongoLocale(d.titleLocale)
+	}
+
+	return &options.Collation{
+		Locale:   locale,
+		Strength: caseInsensitiveStrength,
+	}
+}
+
+// sortCollationOption returns the collation that orders the titles in the order of their locale
+// when the notes are sorted by title, nil when they are sorted byte by byte
+func (d *database) sortCollationOption(sortBy []SortField) *options.Collation {
+	if d.titleLocale == "" {
+		return nil
+	}
+
+	for _, field := range sortBy {
+		if field.Field == TitleField {
+			return &options.Collation{Locale: mongoLocale(d.titleLocale)}
+		}
+	}
+
+	return nil
+}
+
+// mongoLocale converts the BCP 47 locale into the ICU locale of the MongoDB collations
+func mongoLocale(locale string) string {
+	return strings.ReplaceAll(locale, "-", "_")
+}
+
+// isIndexNotFoundError returns true when the dropped index or its collection doesn't exist
+func isIndexNotFoundError(err error) bool {
+	var commandErr mongo.CommandError
+	if !errors.As(err, &commandErr) {
+		return false
+	}
+
+	return commandErr.Code == indexNotFoundErrorCode || commandErr.Code == namespaceNotFoundErrorCode
+}
+
+// isIndexConflictError returns true when the created index already exists with other options
+func isIndexConflictError(err error) bool {
+	var commandErr mongo.CommandError
+	if !errors.As(err, &commandErr) {
+		return false
+	}
+
+	return commandErr.Code == indexOptionsConflictErrorCode || commandErr.Code == indexKeySpecsConflictErrorCode
+}
+
+func (d *database) setRevisionsIndexes() error {
+	indexView := d.revisions.Indexes()
+
 	_, err := facademongo.GetIndexViewInstace().CreateOne(indexView, ctx, mongo.IndexModel{
-		// the title filed of the note from model.Note
+		// every revision number is used once per note
 		Keys: bson.D{
-			{Key: noteTitlePrimaryKey, Value: -1},
+			{Key: revisionNoteIDKey, Value: 1},
+			{Key: revisionNumberKey, Value: 1},
 		},
 		Options: options.Index().SetUnique(true),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to set '%s' as a unique collection index, error: %w", noteTitlePrimaryKey, err)
+		return fmt.Errorf("failed to set '%s' and '%s' as a unique revisions index, error: %w", revisionNoteIDKey, revisionNumberKey, err)
+	}
+
+	_, err = facademongo.GetIndexViewInstace().CreateOne(indexView, ctx, mongo.IndexModel{
+		// used to find the revisions of deleted notes by their title
+		Keys: bson.D{
+			{Key: revisionNoteTitleKey, Value: 1},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set '%s' as a revisions index, error: %w", revisionNoteTitleKey, err)
+	}
+
+	d.logger.Info("Successfully set the revisions collection indexes")
+
+	return nil
+}
+
+// setSortIndexes indexes every field that can be sorted along with the id, which orders the notes with equal values,
+// the same index is used to sort a single field in both directions
+func (d *database) setSortIndexes() error {
+	indexView := d.collection.Indexes()
+
+	for _, field := range []string{noteCreatedAtKey, noteUpdatedAtKey, noteTitleKey, noteCategoryKey} {
+		_, err := facademongo.GetIndexViewInstace().CreateOne(indexView, ctx, mongo.IndexModel{
+			Keys: bson.D{
+				{Key: field, Value: 1},
+				{Key: noteIDKey, Value: 1},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to set '%s' as a sort index, error: %w", field, err)
+		}
+	}
+
+	d.logger.Info("Successfully set the sort indexes")
+
+	return nil
+}
+
+// setSearchIndex sets the text index that finds the notes matching a search, the words are indexed as they are,
+// without the stop words and the stemming of a language, so every word can be searched like with the other databases
+func (d *database) setSearchIndex() error {
+	indexView := d.collection.Indexes()
+
+	index := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: noteTitleKey, Value: "text"},
+			{Key: noteDescriptionKey, Value: "text"},
+		},
+		Options: options.Index().SetName(searchIndexName).SetDefaultLanguage("none"),
+	}
+
+	_, err := facademongo.GetIndexViewInstace().CreateOne(indexView, ctx, index)
+	if isIndexConflictError(err) {
+		// the index was created with other options by a previous version
+		_, err = facademongo.GetIndexViewInstace().DropOne(indexView, ctx, searchIndexName)
+		if err != nil {
+			return fmt.Errorf("failed to drop the '%s' index, error: %w", searchIndexName, err)
+		}
+
+		_, err = facademongo.GetIndexViewInstace().CreateOne(indexView, ctx, index)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set '%s' and '%s' as a text index, error: %w", noteTitleKey, noteDescriptionKey, err)
 	}
 
-	d.logger.Info(fmt.Sprintf("Successfully set '%s' as a unique collection index", noteTitlePrimaryKey))
+	d.logger.Info("Successfully set the search index")
 
 	return nil
 }
@@ -1,7 +1,9 @@
 package database
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	facademongo "github.com/notes-project/api/pkg/facade/go.mongodb.org/mongo-driver/mongo"
@@ -9,7 +11,9 @@ import (
 	mockfacademongo "github.com/notes-project/api/pkg/mock/facade/go.mongodb.org/mongo-driver/mongo"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 )
 
@@ -19,6 +23,7 @@ var _ = Describe("Database", func() {
 		ctrl *gomock.Controller
 
 		mockFacadeIndexView   *mockfacademongo.MockIndexView
+		mockFacadeCollection  *mockfacademongo.MockCollection
 		mockFacadeDatabase    *mockfacademongo.MockDatabase
 		mockFacadeMongoClient *mockfacademongo.MockClient
 		mockDbClient          *mockadapters.MockDbClient
@@ -31,6 +36,7 @@ var _ = Describe("Database", func() {
 		ctrl = gomock.NewController(GinkgoT())
 
 		mockFacadeIndexView = mockfacademongo.NewMockIndexView(ctrl)
+		mockFacadeCollection = mockfacademongo.NewMockCollection(ctrl)
 		mockFacadeDatabase = mockfacademongo.NewMockDatabase(ctrl)
 		mockFacadeMongoClient = mockfacademongo.NewMockClient(ctrl)
 
@@ -48,6 +54,7 @@ var _ = Describe("Database", func() {
 		}
 
 		facademongo.SetIndexViewInstance(mockFacadeIndexView)
+		facademongo.SetCollectionInstance(mockFacadeCollection)
 		facademongo.SetClientInstance(mockFacadeMongoClient)
 		facademongo.SetDatabaseInstance(mockFacadeDatabase)
 	})
@@ -79,23 +86,236 @@ var _ = Describe("Database", func() {
 			Expect(err).To(HaveOccurred())
 		})
 
+		It("should return an error when failed to drop the legacy title index", func() {
+			mockFacadeMongoClient.EXPECT().Connect(gomock.Any(), gomock.Any()).Return(&mongo.Client{}, nil)
+			mockFacadeMongoClient.EXPECT().Ping(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			mockFacadeMongoClient.EXPECT().Database(gomock.Any(), gomock.Any()).Return(&mongo.Database{})
+			mockFacadeDatabase.EXPECT().Collection(gomock.Any(), gomock.Any()).Return(&mongo.Collection{}).Times(3)
+			mockFacadeIndexView.EXPECT().DropOne(gomock.Any(), gomock.Any(), legacyTitleIndexName).Return(nil, errors.New(""))
+
+			err := dbInstance.Connect()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should ignore the legacy title index when it doesn't exist", func() {
+			mockFacadeMongoClient.EXPECT().Connect(gomock.Any(), gomock.Any()).Return(&mongo.Client{}, nil)
+			mockFacadeMongoClient.EXPECT().Ping(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			mockFacadeMongoClient.EXPECT().Database(gomock.Any(), gomock.Any()).Return(&mongo.Database{})
+			mockFacadeDatabase.EXPECT().Collection(gomock.Any(), gomock.Any()).Return(&mongo.Collection{}).Times(3)
+			mockFacadeIndexView.EXPECT().DropOne(gomock.Any(), gomock.Any(), legacyTitleIndexName).Return(
+				nil, mongo.CommandError{Code: indexNotFoundErrorCode},
+			)
+			mockFacadeIndexView.EXPECT().DropOne(gomock.Any(), gomock.Any(), titleIndexName).Return(
+				nil, mongo.CommandError{Code: indexNotFoundErrorCode},
+			)
+			mockFacadeIndexView.EXPECT().CreateOne(gomock.Any(), gomock.Any(), gomock.Any()).Return("", nil).Times(8)
+			mockFacadeCollection.EXPECT().Find(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				mongo.NewCursorFromDocuments(nil, nil, nil),
+			)
+
+			err := dbInstance.Connect()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
 		It("should return an error when failed to create a new indexe", func() {
 			mockFacadeMongoClient.EXPECT().Connect(gomock.Any(), gomock.Any()).Return(&mongo.Client{}, nil)
 			mockFacadeMongoClient.EXPECT().Ping(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
 			mockFacadeMongoClient.EXPECT().Database(gomock.Any(), gomock.Any()).Return(&mongo.Database{})
-			mockFacadeDatabase.EXPECT().Collection(gomock.Any(), gomock.Any()).Return(&mongo.Collection{})
+			mockFacadeDatabase.EXPECT().Collection(gomock.Any(), gomock.Any()).Return(&mongo.Collection{}).Times(3)
+			mockFacadeIndexView.EXPECT().DropOne(gomock.Any(), gomock.Any(), legacyTitleIndexName).Return(nil, nil)
+			mockFacadeIndexView.EXPECT().DropOne(gomock.Any(), gomock.Any(), titleIndexName).Return(nil, nil)
 			mockFacadeIndexView.EXPECT().CreateOne(gomock.Any(), gomock.Any(), gomock.Any()).Return("", errors.New(""))
 
 			err := dbInstance.Connect()
 			Expect(err).To(HaveOccurred())
 		})
 
+		It("should return an error when failed to create the revisions indexes", func() {
+			mockFacadeMongoClient.EXPECT().Connect(gomock.Any(), gomock.Any()).Return(&mongo.Client{}, nil)
+			mockFacadeMongoClient.EXPECT().Ping(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			mockFacadeMongoClient.EXPECT().Database(gomock.Any(), gomock.Any()).Return(&mongo.Database{})
+			mockFacadeDatabase.EXPECT().Collection(gomock.Any(), gomock.Any()).Return(&mongo.Collection{}).Times(3)
+			mockFacadeIndexView.EXPECT().DropOne(gomock.Any(), gomock.Any(), legacyTitleIndexName).Return(nil, nil)
+			mockFacadeIndexView.EXPECT().DropOne(gomock.Any(), gomock.Any(), titleIndexName).Return(nil, nil)
+			gomock.InOrder(
+				mockFacadeIndexView.EXPECT().CreateOne(gomock.Any(), gomock.Any(), gomock.Any()).Return("", nil),
+				mockFacadeIndexView.EXPECT().CreateOne(gomock.Any(), gomock.Any(), gomock.Any()).Return("", errors.New("")),
+			)
+
+			err := dbInstance.Connect()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should return an error when failed to create the sort indexes", func() {
+			mockFacadeMongoClient.EXPECT().Connect(gomock.Any(), gomock.Any()).Return(&mongo.Client{}, nil)
+			mockFacadeMongoClient.EXPECT().Ping(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			mockFacadeMongoClient.EXPECT().Database(gomock.Any(), gomock.Any()).Return(&mongo.Database{})
+			mockFacadeDatabase.EXPECT().Collection(gomock.Any(), gomock.Any()).Return(&mongo.Collection{}).Times(3)
+			mockFacadeIndexView.EXPECT().DropOne(gomock.Any(), gomock.Any(), legacyTitleIndexName).Return(nil, nil)
+			mockFacadeIndexView.EXPECT().DropOne(gomock.Any(), gomock.Any(), titleIndexName).Return(nil, nil)
+			gomock.InOrder(
+				mockFacadeIndexView.EXPECT().CreateOne(gomock.Any(), gomock.Any(), gomock.Any()).Return("", nil).Times(3),
+				mockFacadeIndexView.EXPECT().CreateOne(gomock.Any(), gomock.Any(), gomock.Any()).Return("", errors.New("")),
+			)
+
+			err := dbInstance.Connect()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should return an error when failed to create the search index", func() {
+			mockFacadeMongoClient.EXPECT().Connect(gomock.Any(), gomock.Any()).Return(&mongo.Client{}, nil)
+			mockFacadeMongoClient.EXPECT().Ping(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			mockFacadeMongoClient.EXPECT().Database(gomock.Any(), gomock.Any()).Return(&mongo.Database{})
+			mockFacadeDatabase.EXPECT().Collection(gomock.Any(), gomock.Any()).Return(&mongo.Collection{}).Times(3)
+			mockFacadeIndexView.EXPECT().DropOne(gomock.Any(), gomock.Any(), legacyTitleIndexName).Return(nil, nil)
+			mockFacadeIndexView.EXPECT().DropOne(gomock.Any(), gomock.Any(), titleIndexName).Return(nil, nil)
+			gomock.InOrder(
+				mockFacadeIndexView.EXPECT().CreateOne(gomock.Any(), gomock.Any(), gomock.Any()).Return("", nil).Times(7),
+				mockFacadeIndexView.EXPECT().CreateOne(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ mongo.IndexView, _ context.Context, index mongo.IndexModel, _ ...*options.CreateIndexesOptions) (string, error) {
+						Expect(*index.Options.Name).To(Equal(searchIndexName))
+						Expect(*index.Options.DefaultLanguage).To(Equal("none"))
+
+						return "", errors.New("")
+					},
+				),
+			)
+
+			err := dbInstance.Connect()
+			Expect(err).To(HaveOccurred())
+		})
+
 		It("should return nil when no error occurred", func() {
 			mockFacadeMongoClient.EXPECT().Connect(gomock.Any(), gomock.Any()).Return(&mongo.Client{}, nil)
 			mockFacadeMongoClient.EXPECT().Ping(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
 			mockFacadeMongoClient.EXPECT().Database(gomock.Any(), gomock.Any()).Return(&mongo.Database{})
-			mockFacadeDatabase.EXPECT().Collection(gomock.Any(), gomock.Any()).Return(&mongo.Collection{})
-			mockFacadeIndexView.EXPECT().CreateOne(gomock.Any(), gomock.Any(), gomock.Any()).Return("", nil)
+			mockFacadeDatabase.EXPECT().Collection(gomock.Any(), gomock.Any()).Return(&mongo.Collection{}).Times(3)
+			mockFacadeIndexView.EXPECT().DropOne(gomock.Any(), gomock.Any(), legacyTitleIndexName).Return(nil, nil)
+			mockFacadeIndexView.EXPECT().DropOne(gomock.Any(), gomock.Any(), titleIndexName).Return(nil, nil)
+			mockFacadeIndexView.EXPECT().CreateOne(gomock.Any(), gomock.Any(), gomock.Any()).Return("", nil).Times(8)
+			mockFacadeCollection.EXPECT().Find(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				mongo.NewCursorFromDocuments(nil, nil, nil),
+			)
+
+			err := dbInstance.Connect()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should set the unique index on the title ignoring the case without a locale of the titles", func() {
+			mockFacadeMongoClient.EXPECT().Connect(gomock.Any(), gomock.Any()).Return(&mongo.Client{}, nil)
+			mockFacadeMongoClient.EXPECT().Ping(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			mockFacadeMongoClient.EXPECT().Database(gomock.Any(), gomock.Any()).Return(&mongo.Database{})
+			mockFacadeDatabase.EXPECT().Collection(gomock.Any(), gomock.Any()).Return(&mongo.Collection{}).Times(3)
+			mockFacadeIndexView.EXPECT().DropOne(gomock.Any(), gomock.Any(), legacyTitleIndexName).Return(nil, nil)
+			mockFacadeIndexView.EXPECT().DropOne(gomock.Any(), gomock.Any(), titleIndexName).Return(nil, nil)
+			gomock.InOrder(
+				mockFacadeIndexView.EXPECT().CreateOne(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ mongo.IndexView, _ context.Context, index mongo.IndexModel, _ ...*options.CreateIndexesOptions) (string, error) {
+						Expect(*index.Options.Name).To(Equal(collatedTitleIndexName))
+						Expect(index.Options.Collation).To(Equal(&options.Collation{Locale: defaultMongoTitleLocale, Strength: caseInsensitiveStrength}))
+
+						return collatedTitleIndexName, nil
+					},
+				),
+				mockFacadeIndexView.EXPECT().CreateOne(gomock.Any(), gomock.Any(), gomock.Any()).Return("", nil).Times(7),
+			)
+			mockFacadeCollection.EXPECT().Find(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				mongo.NewCursorFromDocuments(nil, nil, nil),
+			)
+
+			err := dbInstance.Connect()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should set the unique index on the title with the collation of the locale of the titles", func() {
+			dbInstance.titleLocale = "de-CH"
+
+			mockFacadeMongoClient.EXPECT().Connect(gomock.Any(), gomock.Any()).Return(&mongo.Client{}, nil)
+			mockFacadeMongoClient.EXPECT().Ping(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			mockFacadeMongoClient.EXPECT().Database(gomock.Any(), gomock.Any()).Return(&mongo.Database{})
+			mockFacadeDatabase.EXPECT().Collection(gomock.Any(), gomock.Any()).Return(&mongo.Collection{}).Times(3)
+			mockFacadeIndexView.EXPECT().DropOne(gomock.Any(), gomock.Any(), legacyTitleIndexName).Return(nil, nil)
+			mockFacadeIndexView.EXPECT().DropOne(gomock.Any(), gomock.Any(), titleIndexName).Return(nil, nil)
+			gomock.InOrder(
+				mockFacadeIndexView.EXPECT().CreateOne(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ mongo.IndexView, _ context.Context, index mongo.IndexModel, _ ...*options.CreateIndexesOptions) (string, error) {
+						Expect(*index.Options.Name).To(Equal(collatedTitleIndexName))
+						Expect(index.Options.Collation).To(Equal(&options.Collation{Locale: "de_CH", Strength: caseInsensitiveStrength}))
+
+						return collatedTitleIndexName, nil
+					},
+				),
+				mockFacadeIndexView.EXPECT().CreateOne(gomock.Any(), gomock.Any(), gomock.Any()).Return("", nil).Times(7),
+			)
+			mockFacadeCollection.EXPECT().Find(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				mongo.NewCursorFromDocuments(nil, nil, nil),
+			)
+
+			err := dbInstance.Connect()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should set the unique index on the title again when it has the collation of another locale", func() {
+			dbInstance.titleLocale = "en"
+
+			mockFacadeMongoClient.EXPECT().Connect(gomock.Any(), gomock.Any()).Return(&mongo.Client{}, nil)
+			mockFacadeMongoClient.EXPECT().Ping(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			mockFacadeMongoClient.EXPECT().Database(gomock.Any(), gomock.Any()).Return(&mongo.Database{})
+			mockFacadeDatabase.EXPECT().Collection(gomock.Any(), gomock.Any()).Return(&mongo.Collection{}).Times(3)
+			mockFacadeIndexView.EXPECT().DropOne(gomock.Any(), gomock.Any(), legacyTitleIndexName).Return(nil, nil)
+			mockFacadeIndexView.EXPECT().DropOne(gomock.Any(), gomock.Any(), titleIndexName).Return(nil, nil)
+			gomock.InOrder(
+				mockFacadeIndexView.EXPECT().CreateOne(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+					"", mongo.CommandError{Code: indexOptionsConflictErrorCode},
+				),
+				mockFacadeIndexView.EXPECT().DropOne(gomock.Any(), gomock.Any(), collatedTitleIndexName).Return(nil, nil),
+				mockFacadeIndexView.EXPECT().CreateOne(gomock.Any(), gomock.Any(), gomock.Any()).Return("", nil).Times(8),
+			)
+			mockFacadeCollection.EXPECT().Find(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				mongo.NewCursorFromDocuments(nil, nil, nil),
+			)
+
+			err := dbInstance.Connect()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should return an error when failed to find the notes without timestamps", func() {
+			mockFacadeMongoClient.EXPECT().Connect(gomock.Any(), gomock.Any()).Return(&mongo.Client{}, nil)
+			mockFacadeMongoClient.EXPECT().Ping(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			mockFacadeMongoClient.EXPECT().Database(gomock.Any(), gomock.Any()).Return(&mongo.Database{})
+			mockFacadeDatabase.EXPECT().Collection(gomock.Any(), gomock.Any()).Return(&mongo.Collection{}).Times(3)
+			mockFacadeIndexView.EXPECT().DropOne(gomock.Any(), gomock.Any(), legacyTitleIndexName).Return(nil, nil)
+			mockFacadeIndexView.EXPECT().DropOne(gomock.Any(), gomock.Any(), titleIndexName).Return(nil, nil)
+			mockFacadeIndexView.EXPECT().CreateOne(gomock.Any(), gomock.Any(), gomock.Any()).Return("", nil).Times(8)
+			mockFacadeCollection.EXPECT().Find(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New(""))
+
+			err := dbInstance.Connect()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should migrate the dates of the notes without timestamps", func() {
+			mockFacadeMongoClient.EXPECT().Connect(gomock.Any(), gomock.Any()).Return(&mongo.Client{}, nil)
+			mockFacadeMongoClient.EXPECT().Ping(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			mockFacadeMongoClient.EXPECT().Database(gomock.Any(), gomock.Any()).Return(&mongo.Database{})
+			mockFacadeDatabase.EXPECT().Collection(gomock.Any(), gomock.Any()).Return(&mongo.Collection{}).Times(3)
+			mockFacadeIndexView.EXPECT().DropOne(gomock.Any(), gomock.Any(), legacyTitleIndexName).Return(nil, nil)
+			mockFacadeIndexView.EXPECT().DropOne(gomock.Any(), gomock.Any(), titleIndexName).Return(nil, nil)
+			mockFacadeIndexView.EXPECT().CreateOne(gomock.Any(), gomock.Any(), gomock.Any()).Return("", nil).Times(8)
+			mockFacadeCollection.EXPECT().Find(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				mongo.NewCursorFromDocuments([]interface{}{bson.D{{Key: "_id", Value: "id"}, {Key: "date", Value: "02-Jan-2023"}}}, nil, nil),
+			)
+
+			timestamp := time.Date(2023, time.January, 2, 0, 0, 0, 0, time.Local).UTC()
+			update := bson.D{
+				{Key: "$set", Value: bson.D{
+					{Key: noteCreatedAtKey, Value: timestamp},
+					{Key: noteUpdatedAtKey, Value: timestamp},
+				}},
+				{Key: "$unset", Value: bson.D{{Key: noteDateKey, Value: ""}}},
+			}
+			mockFacadeCollection.EXPECT().UpdateOne(gomock.Any(), gomock.Any(), bson.D{{Key: noteIDKey, Value: "id"}}, update).Return(
+				&mongo.UpdateResult{ModifiedCount: 1}, nil,
+			)
 
 			err := dbInstance.Connect()
 			Expect(err).NotTo(HaveOccurred())
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrVersionConflict is returned when a note is changed with an expected version
+// that is not the current version of the note
+var ErrVersionConflict = errors.New("note version does not match the expected version")
+
+// ErrInvalidCursor is returned when the cursor of a page was not returned by a previous page
+var ErrInvalidCursor = errors.New("page cursor is invalid")
+
+const (
+	// error code returned by MongoDB when a unique index is violated
+	duplicateKeyErrorCode = 11000
+)
+
+// newDuplicateKeyError returns an error that satisfies mongo.IsDuplicateKeyError,
+// so the database implementations that are not backed by MongoDB report
+// a violated unique key the same way the MongoDB implementation does.
+func newDuplicateKeyError(key, value string) error {
+	return mongo.WriteException{
+		WriteErrors: []mongo.WriteError{
+			{
+				Code:    duplicateKeyErrorCode,
+				Message: fmt.Sprintf("E11000 duplicate key error, key: { %s: \"%s\" }", key, value),
+			},
+		},
+	}
+}
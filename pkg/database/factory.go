@@ -3,6 +3,7 @@ package database
 import (
 	"sync"
 
+	"github.com/notes-project/api/pkg/constants"
 	"go.uber.org/zap"
 )
 
@@ -25,10 +26,28 @@ var (
 func (df databaseFactory) NewDatabase(dbConfig databaseConfiguration) Database {
 
 	once.Do(func() {
-		databaseInstance = &database{
-			databaseConfiguration: dbConfig,
-			logger:                zap.L().Named("Database"),
+		logger := zap.L().Named("Database")
+
+		titles := newTitleCollation(dbConfig.titleLocale)
+
+		var d driver
+
+		switch dbConfig.driver {
+		case constants.MemoryDriver:
+			d = newMemoryDatabase(dbConfig.revisionsRetention, titles, logger)
+		case constants.FileDriver:
+			d = newFileDatabase(dbConfig, logger)
+		case constants.PostgresDriver:
+			d = newPostgresDatabase(dbConfig, logger)
+		default:
+			d = &database{
+				databaseConfiguration: dbConfig,
+				logger:                logger,
+			}
 		}
+
+		// every implementation is searched the same way, and stores the titles and tags normalized the same way
+		databaseInstance = newNormalizedDatabase(newSearchDatabase(d, titles))
 	})
 
 	return databaseInstance
@@ -0,0 +1,576 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/notes-project/api/pkg/model"
+	"go.uber.org/zap"
+)
+
+/*
+	File-backed implementation of the Database interface.
+
+	The notes are kept in memory and every change is appended to a log file inside
+	the data directory and synced to disk before it is applied. On startup the notes
+	are restored from the latest snapshot followed by the log. The log is periodically
+	compacted into a new snapshot, which is written to a temporary file and atomically
+	renamed, so a crash at any point leaves either the old or the new snapshot in place.
+
+	Every line of the log and the snapshot is prefixed with its CRC-32 checksum.
+	A process killed in the middle of a write leaves an incomplete last line,
+	which is detected and discarded on the next startup.
+*/
+
+type fileDatabase struct {
+	*memoryDatabase
+	databaseConfiguration
+
+	log *os.File
+	// size of the log up to the last complete record
+	logSize int64
+	// number of records in the log since the last compaction
+	logRecords int
+	// sequence number of the last record written to the log
+	recordSequence uint64
+	// set when the log could not be restored to a consistent state after a failed write
+	logErr error
+
+	compactionTicker *time.Ticker
+}
+
+const (
+	logFileExtension      = ".log"
+	snapshotFileExtension = ".snapshot"
+	tempFileExtension     = ".tmp"
+
+	// the log is compacted when it has at least compactionMinRecords records
+	// and more than compactionRatio records per stored note
+	compactionMinRecords = 1000
+	compactionRatio      = 2
+
+	compactionInterval = 10 * time.Minute
+)
+
+// fileRecord is a single line of the log
+type fileRecord struct {
+	Sequence uint64   `json:"sequence"`
+	Changes  []change `json:"changes"`
+}
+
+// snapshotHeader is the first line of the snapshot, followed by one line per note,
+// including the notes in the trash, and then one line per revision
+type snapshotHeader struct {
+	// sequence number of the last log record included in the snapshot
+	Sequence  uint64 `json:"sequence"`
+	Notes     int    `json:"notes"`
+	Revisions int    `json:"revisions,omitempty"`
+}
+
+func newFileDatabase(dbConfig databaseConfiguration, logger *zap.Logger) *fileDatabase {
+	return &fileDatabase{
+		memoryDatabase:        newMemoryDatabase(dbConfig.revisionsRetention, newTitleCollation(dbConfig.titleLocale), logger),
+		databaseConfiguration: dbConfig,
+	}
+}
+
+func (f *fileDatabase) Connect() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.log != nil {
+		f.logger.Info("Database already started")
+		return nil
+	}
+
+	err := os.MkdirAll(f.connectionUri, 0700)
+	if err != nil {
+		return fmt.Errorf("failed to create data directory '%s', error: %w", f.connectionUri, err)
+	}
+
+	err = f.loadSnapshot()
+	if err != nil {
+		return err
+	}
+
+	err = f.replayLog()
+	if err != nil {
+		return err
+	}
+
+	f.journal = f
+
+	err = f.migrateNotes()
+	if err != nil {
+		return err
+	}
+
+	f.compactionTicker = time.NewTicker(compactionInterval)
+	go f.compactPeriodically()
+
+	f.logger.Info(fmt.Sprintf("Successfully loaded %d notes from data directory '%s'", len(f.notes), f.connectionUri))
+
+	return nil
+}
+
+// migrateNotes generates the ids, versions and timestamps of the notes stored before the notes had them.
+// Must be called with the write lock held.
+func (f *fileDatabase) migrateNotes() error {
+	now := time.Now().UTC()
+
+	var changes []change
+	for title, stored := range f.notes {
+		if stored.note.ID == "" || stored.note.Version == 0 || stored.note.CreatedAt.IsZero() {
+			note := stored.note
+			if note.ID == "" {
+				note.ID = model.NewNoteID()
+			}
+			if note.Version == 0 {
+				note.Version = 1
+			}
+			if note.CreatedAt.IsZero() {
+				note.CreatedAt = legacyTimestamp(note.Date, now)
+				note.UpdatedAt = note.CreatedAt
+				note.Date = ""
+			}
+
+			changes = append(changes, putChange(title, note))
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	err := f.commit(changes...)
+	if err != nil {
+		return fmt.Errorf("failed to migrate %d notes, error: %w", len(changes), err)
+	}
+
+	f.logger.Info(fmt.Sprintf("Successfully migrated %d notes", len(changes)))
+
+	return nil
+}
+
+func (f *fileDatabase) IsReady() bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.log != nil && f.logErr == nil
+}
+
+func (f *fileDatabase) logPath() string {
+	return filepath.Join(f.connectionUri, f.collectionName+logFileExtension)
+}
+
+func (f *fileDatabase) snapshotPath() string {
+	return filepath.Join(f.connectionUri, f.collectionName+snapshotFileExtension)
+}
+
+func (f *fileDatabase) loadSnapshot() error {
+	file, err := os.Open(f.snapshotPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot, error: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+
+	// the snapshot is renamed into place only once it's complete, so any invalid line is an error
+	line, err := readLine(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot header, error: %w", err)
+	}
+
+	header := snapshotHeader{}
+	err = json.Unmarshal(line, &header)
+	if err != nil {
+		return fmt.Errorf("failed to decode snapshot header, error: %w", err)
+	}
+
+	for i := 0; i < header.Notes; i++ {
+		line, err = readLine(reader)
+		if err != nil {
+			return fmt.Errorf("failed to read note %d from snapshot, error: %w", i, err)
+		}
+
+		note := model.Note{}
+		err = json.Unmarshal(line, &note)
+		if err != nil {
+			return fmt.Errorf("failed to decode note %d from snapshot, error: %w", i, err)
+		}
+
+		f.loadNote(note)
+	}
+
+	for i := 0; i < header.Revisions; i++ {
+		line, err = readLine(reader)
+		if err != nil {
+			return fmt.Errorf("failed to read revision %d from snapshot, error: %w", i, err)
+		}
+
+		revision := model.Revision{}
+		err = json.Unmarshal(line, &revision)
+		if err != nil {
+			return fmt.Errorf("failed to decode revision %d from snapshot, error: %w", i, err)
+		}
+
+		f.apply(change{Op: changeOpPutRevision, Revision: &revision})
+	}
+
+	f.recordSequence = header.Sequence
+
+	return nil
+}
+
+// loadNote adds a note read from the snapshot to the notes or to the trash.
+// Must be called with the write lock held.
+func (f *fileDatabase) loadNote(note model.Note) {
+	if note.DeletedAt == nil {
+		f.apply(putChange(note.Title, note))
+		return
+	}
+
+	f.sequence++
+	f.trash[note.ID] = memoryNote{sequence: f.sequence, note: note}
+}
+
+func (f *fileDatabase) replayLog() error {
+	file, err := os.OpenFile(f.logPath(), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open log, error: %w", err)
+	}
+
+	reader := bufio.NewReader(file)
+
+	var (
+		offset     int64
+		invalidErr error
+	)
+
+	for {
+		line, err := readLine(reader)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// only the last record can be incomplete after a crash, so keep reading
+			// to make sure there are no valid records after the invalid one
+			if invalidErr == nil {
+				invalidErr = err
+			}
+			continue
+		}
+
+		if invalidErr != nil {
+			file.Close()
+			return fmt.Errorf("log is corrupted at offset %d, error: %w", offset, invalidErr)
+		}
+
+		record := fileRecord{}
+		err = json.Unmarshal(line, &record)
+		if err != nil {
+			file.Close()
+			return fmt.Errorf("failed to decode log record at offset %d, error: %w", offset, err)
+		}
+
+		offset += int64(len(line)) + checksumPrefixLength + 1
+
+		// records before the snapshot can remain in the log when the process stopped during compaction
+		if record.Sequence <= f.recordSequence {
+			continue
+		}
+
+		for _, c := range record.Changes {
+			f.apply(c)
+		}
+
+		f.recordSequence = record.Sequence
+		f.logRecords++
+	}
+
+	if invalidErr != nil {
+		f.logger.Warn(fmt.Sprintf("Discarding incomplete record at the end of the log at offset %d, error: %s", offset, invalidErr))
+
+		err = truncateAndSync(file, offset)
+		if err != nil {
+			file.Close()
+			return fmt.Errorf("failed to discard incomplete log record, error: %w", err)
+		}
+	}
+
+	_, err = file.Seek(offset, io.SeekStart)
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to seek to the end of the log, error: %w", err)
+	}
+
+	f.log = file
+	f.logSize = offset
+
+	return nil
+}
+
+// write appends the changes to the log as a single record, it implements the journal interface
+func (f *fileDatabase) write(changes []change) error {
+	if f.logErr != nil {
+		return fmt.Errorf("log is unavailable, error: %w", f.logErr)
+	}
+
+	record := fileRecord{
+		Sequence: f.recordSequence + 1,
+		Changes:  changes,
+	}
+
+	line, err := encodeLine(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode log record, error: %w", err)
+	}
+
+	_, err = f.log.Write(line)
+	if err == nil {
+		err = f.log.Sync()
+	}
+	if err != nil {
+		// remove the partially written record, so the following records are not appended after it
+		truncateErr := truncateAndSync(f.log, f.logSize)
+		if truncateErr == nil {
+			_, truncateErr = f.log.Seek(f.logSize, io.SeekStart)
+		}
+		if truncateErr != nil {
+			f.logErr = truncateErr
+			f.logger.Error(fmt.Sprintf("Failed to restore log after a failed write, error: %s", truncateErr))
+		}
+
+		return fmt.Errorf("failed to write log record, error: %w", err)
+	}
+
+	f.logSize += int64(len(line))
+	f.recordSequence = record.Sequence
+	f.logRecords++
+
+	if f.logRecords >= compactionMinRecords && f.logRecords > compactionRatio*(len(f.notes)+len(f.trash)) {
+		// the record is already persisted, a failed compaction is retried later
+		err = f.compact()
+		if err != nil {
+			f.logger.Error(fmt.Sprintf("Failed to compact log, error: %s", err))
+		}
+	}
+
+	return nil
+}
+
+func (f *fileDatabase) compactPeriodically() {
+	for range f.compactionTicker.C {
+		f.mu.Lock()
+
+		if f.logRecords > 0 && f.logErr == nil {
+			err := f.compact()
+			if err != nil {
+				f.logger.Error(fmt.Sprintf("Failed to compact log, error: %s", err))
+			}
+		}
+
+		f.mu.Unlock()
+	}
+}
+
+// compact writes all the notes to a new snapshot and empties the log.
+// Must be called with the write lock held.
+func (f *fileDatabase) compact() error {
+	stored := make([]memoryNote, 0, len(f.notes)+len(f.trash))
+	for _, note := range f.notes {
+		stored = append(stored, note)
+	}
+	for _, note := range f.trash {
+		stored = append(stored, note)
+	}
+
+	// keeps the insertion order after the notes are loaded from the snapshot
+	sort.Slice(stored, func(i, j int) bool {
+		return stored[i].sequence < stored[j].sequence
+	})
+
+	// the revisions of a note are already sorted by their number
+	revisions := []model.Revision{}
+	for _, noteRevisions := range f.revisions {
+		revisions = append(revisions, noteRevisions...)
+	}
+
+	tempPath := f.snapshotPath() + tempFileExtension
+
+	file, err := os.OpenFile(tempPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot, error: %w", err)
+	}
+
+	header := snapshotHeader{Sequence: f.recordSequence, Notes: len(stored), Revisions: len(revisions)}
+
+	err = writeSnapshot(file, header, stored, revisions)
+	if err == nil {
+		err = file.Sync()
+	}
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to write snapshot, error: %w", err)
+	}
+
+	err = os.Rename(tempPath, f.snapshotPath())
+	if err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to replace snapshot, error: %w", err)
+	}
+
+	err = syncDir(f.connectionUri)
+	if err != nil {
+		return fmt.Errorf("failed to sync data directory, error: %w", err)
+	}
+
+	// from here on the snapshot contains every record, the ones left in the log are skipped on startup
+	err = truncateAndSync(f.log, 0)
+	if err == nil {
+		_, err = f.log.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		f.logErr = err
+		return fmt.Errorf("failed to truncate log, error: %w", err)
+	}
+
+	f.logSize = 0
+	f.logRecords = 0
+
+	f.logger.Info(fmt.Sprintf("Successfully compacted log into a snapshot of %d notes", len(stored)))
+
+	return nil
+}
+
+func writeSnapshot(w io.Writer, header snapshotHeader, stored []memoryNote, revisions []model.Revision) error {
+	writer := bufio.NewWriter(w)
+
+	line, err := encodeLine(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(line)
+	if err != nil {
+		return err
+	}
+
+	for _, note := range stored {
+		line, err = encodeLine(note.note)
+		if err != nil {
+			return err
+		}
+
+		_, err = writer.Write(line)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, revision := range revisions {
+		line, err = encodeLine(revision)
+		if err != nil {
+			return err
+		}
+
+		_, err = writer.Write(line)
+		if err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
+
+const (
+	// 8 hex characters of the checksum followed by a space
+	checksumPrefixLength = 9
+)
+
+var (
+	errIncompleteLine  = errors.New("line is incomplete")
+	errChecksumInvalid = errors.New("checksum does not match")
+)
+
+// encodeLine returns the JSON encoding of the value prefixed with its checksum and terminated with a new line
+func encodeLine(value interface{}) ([]byte, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	line := make([]byte, 0, checksumPrefixLength+len(data)+1)
+	line = append(line, fmt.Sprintf("%08x ", crc32.ChecksumIEEE(data))...)
+	line = append(line, data...)
+	line = append(line, '\n')
+
+	return line, nil
+}
+
+// readLine returns the JSON data of the next line after verifying its checksum,
+// io.EOF is returned only when there are no more bytes to read
+func readLine(reader *bufio.Reader) ([]byte, error) {
+	line, err := reader.ReadBytes('\n')
+	if errors.Is(err, io.EOF) && len(line) > 0 {
+		return nil, errIncompleteLine
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	if len(line) < checksumPrefixLength || line[checksumPrefixLength-1] != ' ' {
+		return nil, errChecksumInvalid
+	}
+
+	checksum, err := strconv.ParseUint(string(line[:checksumPrefixLength-1]), 16, 32)
+	if err != nil {
+		return nil, errChecksumInvalid
+	}
+
+	data := line[checksumPrefixLength:]
+	if crc32.ChecksumIEEE(data) != uint32(checksum) {
+		return nil, errChecksumInvalid
+	}
+
+	return data, nil
+}
+
+func truncateAndSync(file *os.File, size int64) error {
+	err := file.Truncate(size)
+	if err != nil {
+		return err
+	}
+
+	return file.Sync()
+}
+
+// syncDir makes a rename inside the directory durable
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	return dir.Sync()
+}
@@ -0,0 +1,359 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/notes-project/api/pkg/model"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("FileDatabase", func() {
+
+	var (
+		dataDir string
+
+		dbInstance *fileDatabase
+	)
+
+	openDatabase := func() *fileDatabase {
+		db := newFileDatabase(databaseConfiguration{
+			connectionUri:  dataDir,
+			collectionName: "notes",
+		}, zap.L())
+
+		Expect(db.Connect()).To(Succeed())
+
+		return db
+	}
+
+	BeforeEach(func() {
+		dataDir = filepath.Join(GinkgoT().TempDir(), "data")
+
+		dbInstance = openDatabase()
+	})
+
+	Describe("Connect", func() {
+		It("should create the data directory and the log", func() {
+			Expect(filepath.Join(dataDir, "notes.log")).To(BeARegularFile())
+		})
+
+		It("should return nil when already connected", func() {
+			err := dbInstance.Connect()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should return an error when the data directory can't be created", func() {
+			file := filepath.Join(GinkgoT().TempDir(), "file")
+			Expect(os.WriteFile(file, nil, 0600)).To(Succeed())
+
+			db := newFileDatabase(databaseConfiguration{
+				connectionUri:  file,
+				collectionName: "notes",
+			}, zap.L())
+
+			err := db.Connect()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("IsReady", func() {
+		It("should return true when connected", func() {
+			Expect(dbInstance.IsReady()).To(BeTrue())
+		})
+
+		It("should return false when not connected", func() {
+			db := newFileDatabase(databaseConfiguration{}, zap.L())
+			Expect(db.IsReady()).To(BeFalse())
+		})
+	})
+
+	Describe("Persistence", func() {
+		It("should restore the notes after a restart", func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "id1", Title: "test1", Tags: []string{"a"}})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{ID: "id2", Title: "test2"})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{ID: "id3", Title: "test3"})).To(Succeed())
+			Expect(dbInstance.UpdateNote("test1", model.Note{Title: "test4", Tags: []string{"b"}}, AnyVersion)).To(Succeed())
+			Expect(dbInstance.DeleteNote("test2", AnyVersion)).To(Succeed())
+
+			restored := openDatabase()
+
+			notes, err := restored.GetNotes()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(withoutTimestamps(notes...)).To(Equal([]model.Note{
+				{ID: "id1", Title: "test4", Tags: []string{"b"}, Version: 2},
+				{ID: "id3", Title: "test3", Version: 1},
+			}))
+		})
+
+		It("should restore the operations of an atomic batch only when it's applied", func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "id1", Title: "test1"})).To(Succeed())
+
+			_, err := dbInstance.ApplyOperations([]Operation{
+				{Type: OperationCreate, Note: model.Note{ID: "id2", Title: "test2"}},
+				{Type: OperationUpdate, NoteID: "id1", Note: model.Note{Title: "test3"}, ExpectedVersion: 1},
+			}, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			errs, err := dbInstance.ApplyOperations([]Operation{
+				{Type: OperationCreate, Note: model.Note{ID: "id4", Title: "test4"}},
+				{Type: OperationDelete, NoteID: "missing", ExpectedVersion: AnyVersion},
+			}, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(errs[0]).To(MatchError(ErrNotApplied))
+
+			restored := openDatabase()
+
+			notes, err := restored.GetNotes()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(withoutTimestamps(notes...)).To(Equal([]model.Note{
+				{ID: "id1", Title: "test3", Version: 2},
+				{ID: "id2", Title: "test2", Version: 1},
+			}))
+		})
+
+		It("should restore the revisions after a restart", func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "id1", Title: "test1"})).To(Succeed())
+			Expect(dbInstance.UpdateNote("test1", model.Note{Title: "test2"}, AnyVersion)).To(Succeed())
+			Expect(dbInstance.DeleteNote("test2", AnyVersion)).To(Succeed())
+
+			dbInstance.mu.Lock()
+			err := dbInstance.compact()
+			dbInstance.mu.Unlock()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(dbInstance.AddNote(model.Note{ID: "id3", Title: "test3"})).To(Succeed())
+			Expect(dbInstance.DeleteNote("test3", AnyVersion)).To(Succeed())
+
+			restored := openDatabase()
+
+			revisions, err := restored.GetRevisions("test2")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revisions).To(HaveLen(2))
+			Expect(withoutTimestamps(revisions[0].Note)).To(Equal([]model.Note{{ID: "id1", Title: "test1", Version: 1}}))
+			Expect(withoutTimestamps(revisions[1].Note)).To(Equal([]model.Note{{ID: "id1", Title: "test2", Version: 2}}))
+
+			revisions, err = restored.GetRevisions("test3")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revisions).To(HaveLen(1))
+		})
+
+		It("should restore an empty database after all notes were deleted", func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "id1", Title: "test1"})).To(Succeed())
+			Expect(dbInstance.DeleteNotes()).To(Succeed())
+
+			restored := openDatabase()
+
+			notes, err := restored.GetNotes()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(BeEmpty())
+		})
+
+		It("should restore the trash after a restart", func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "id1", Title: "test1"})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{ID: "id2", Title: "test2"})).To(Succeed())
+			Expect(dbInstance.DeleteNote("test1", AnyVersion)).To(Succeed())
+
+			dbInstance.mu.Lock()
+			err := dbInstance.compact()
+			dbInstance.mu.Unlock()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(dbInstance.DeleteNote("test2", AnyVersion)).To(Succeed())
+
+			restored := openDatabase()
+
+			trash, err := restored.GetTrash()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(trash).To(HaveLen(2))
+
+			Expect(restored.RestoreNote("test1")).To(Succeed())
+			Expect(restored.RestoreNote("test2")).To(Succeed())
+
+			// the restored notes are back in their insertion order
+			notes, err := restored.GetNotes()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(withoutTimestamps(notes...)).To(Equal([]model.Note{
+				{ID: "id1", Title: "test1", Version: 1},
+				{ID: "id2", Title: "test2", Version: 1},
+			}))
+		})
+
+		It("should keep the title unique after a restart", func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "test"})).To(Succeed())
+
+			restored := openDatabase()
+
+			err := restored.AddNote(model.Note{Title: "test"})
+			Expect(mongo.IsDuplicateKeyError(err)).To(BeTrue())
+		})
+
+		It("should discard an incomplete record at the end of the log", func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "id1", Title: "test1"})).To(Succeed())
+
+			// simulates a process killed in the middle of a write
+			log, err := os.OpenFile(filepath.Join(dataDir, "notes.log"), os.O_WRONLY|os.O_APPEND, 0600)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = log.WriteString(`00000000 {"sequence":2,"changes":[{"op":"put","key":"test2","no`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(log.Close()).To(Succeed())
+
+			restored := openDatabase()
+
+			notes, err := restored.GetNotes()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(withoutTimestamps(notes...)).To(Equal([]model.Note{{ID: "id1", Title: "test1", Version: 1}}))
+
+			Expect(restored.AddNote(model.Note{ID: "id3", Title: "test3"})).To(Succeed())
+
+			restoredAgain := openDatabase()
+
+			notes, err = restoredAgain.GetNotes()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(withoutTimestamps(notes...)).To(Equal([]model.Note{{ID: "id1", Title: "test1", Version: 1}, {ID: "id3", Title: "test3", Version: 1}}))
+		})
+
+		It("should return an error when a record in the middle of the log is corrupted", func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "id1", Title: "test1"})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{ID: "id2", Title: "test2"})).To(Succeed())
+
+			logPath := filepath.Join(dataDir, "notes.log")
+			data, err := os.ReadFile(logPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			data[len(data)/4] ^= 0xff
+			Expect(os.WriteFile(logPath, data, 0600)).To(Succeed())
+
+			db := newFileDatabase(databaseConfiguration{
+				connectionUri:  dataDir,
+				collectionName: "notes",
+			}, zap.L())
+
+			err = db.Connect()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should assign ids, versions and timestamps to the notes stored without them", func() {
+			log, err := os.OpenFile(filepath.Join(dataDir, "notes.log"), os.O_WRONLY|os.O_APPEND, 0600)
+			Expect(err).NotTo(HaveOccurred())
+			line, err := encodeLine(fileRecord{
+				Sequence: 1,
+				Changes:  []change{putChange("test", model.Note{Title: "test", Date: "02-Jan-2023"})},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = log.Write(line)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(log.Close()).To(Succeed())
+
+			restored := openDatabase()
+
+			note, err := restored.GetNote("test")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.ID).NotTo(BeEmpty())
+			Expect(note.Version).To(Equal(int64(1)))
+			Expect(note.CreatedAt).To(Equal(time.Date(2023, time.January, 2, 0, 0, 0, 0, time.Local).UTC()))
+			Expect(note.UpdatedAt).To(Equal(note.CreatedAt))
+			Expect(note.Date).To(BeEmpty())
+
+			restoredAgain := openDatabase()
+
+			noteAgain, err := restoredAgain.GetNoteByID(note.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(noteAgain.Title).To(Equal("test"))
+		})
+
+		It("should not apply a change when it failed to be written", func() {
+			Expect(dbInstance.log.Close()).To(Succeed())
+
+			err := dbInstance.AddNote(model.Note{Title: "test"})
+			Expect(err).To(HaveOccurred())
+
+			_, err = dbInstance.GetNote("test")
+			Expect(err).To(MatchError(mongo.ErrNoDocuments))
+
+			Expect(dbInstance.IsReady()).To(BeFalse())
+		})
+	})
+
+	Describe("compact", func() {
+		It("should move the notes into the snapshot and empty the log", func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "id1", Title: "test1"})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{ID: "id2", Title: "test2"})).To(Succeed())
+			Expect(dbInstance.UpdateNote("test1", model.Note{Title: "test1", Description: "updated"}, AnyVersion)).To(Succeed())
+
+			dbInstance.mu.Lock()
+			err := dbInstance.compact()
+			dbInstance.mu.Unlock()
+			Expect(err).NotTo(HaveOccurred())
+
+			info, err := os.Stat(filepath.Join(dataDir, "notes.log"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Size()).To(BeZero())
+
+			Expect(dbInstance.AddNote(model.Note{ID: "id3", Title: "test3"})).To(Succeed())
+
+			restored := openDatabase()
+
+			notes, err := restored.GetNotes()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(withoutTimestamps(notes...)).To(Equal([]model.Note{
+				{ID: "id1", Title: "test1", Description: "updated", Version: 2},
+				{ID: "id2", Title: "test2", Version: 1},
+				{ID: "id3", Title: "test3", Version: 1},
+			}))
+		})
+
+		It("should skip the log records included in the snapshot when the log was not emptied", func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "id1", Title: "test1"})).To(Succeed())
+			Expect(dbInstance.UpdateNote("test1", model.Note{Title: "test2"}, AnyVersion)).To(Succeed())
+
+			logPath := filepath.Join(dataDir, "notes.log")
+			data, err := os.ReadFile(logPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			dbInstance.mu.Lock()
+			err = dbInstance.compact()
+			dbInstance.mu.Unlock()
+			Expect(err).NotTo(HaveOccurred())
+
+			// simulates a process killed after the snapshot was written but before the log was emptied
+			Expect(os.WriteFile(logPath, data, 0600)).To(Succeed())
+
+			restored := openDatabase()
+
+			notes, err := restored.GetNotes()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(withoutTimestamps(notes...)).To(Equal([]model.Note{{ID: "id1", Title: "test2", Version: 2}}))
+		})
+
+		It("should compact the log automatically when it grows", func() {
+			for i := 0; i < compactionMinRecords; i++ {
+				Expect(dbInstance.AddNote(model.Note{Title: "test"})).To(Succeed())
+				Expect(dbInstance.DeleteNote("test", AnyVersion)).To(Succeed())
+			}
+
+			Expect(dbInstance.logRecords).To(BeNumerically("<", compactionMinRecords))
+			Expect(filepath.Join(dataDir, "notes.snapshot")).To(BeARegularFile())
+		})
+	})
+
+})
+
+var _ = Describe("FileDatabaseNotes", func() {
+
+	describeNotesBehavior(func() Database {
+		db := newSearchDatabase(newFileDatabase(databaseConfiguration{
+			connectionUri:  GinkgoT().TempDir(),
+			collectionName: "notes",
+		}, zap.L()), nil)
+
+		Expect(db.Connect()).To(Succeed())
+
+		return db
+	})
+
+})
@@ -0,0 +1,201 @@
+package database
+
+import (
+	"sync"
+
+	"github.com/notes-project/api/pkg/model"
+	"github.com/notes-project/api/pkg/search"
+	"go.uber.org/zap"
+)
+
+/*
+	In-memory implementation of the Database interface.
+
+	Mirrors the behavior of the MongoDB implementation, including the unique title
+	and the errors returned for missing or duplicate notes, so the API can be run
+	without any outside services. The data is lost when the process stops,
+	unless a journal is set to persist the changes.
+*/
+
+type memoryDatabase struct {
+	logger *zap.Logger
+
+	mu sync.RWMutex
+	// notes keyed by the key of their title, which is unique
+	notes map[string]memoryNote
+	// titles of the notes keyed by their id
+	ids map[string]string
+	// matches the titles ignoring the case when it's set
+	titles *titleCollation
+	// incremented on every insert, used to return the notes in insertion order
+	sequence uint64
+	// deleted notes keyed by their id, the title is not unique in the trash
+	trash map[string]memoryNote
+	// search index of the notes that are not in the trash, changed along with them
+	index search.MemoryIndex
+
+	// revisions keyed by the id of their note, sorted by their number
+	revisions          map[string][]model.Revision
+	revisionsRetention revisionsRetention
+
+	// optional, persists every change before it is applied
+	journal journal
+}
+
+type memoryNote struct {
+	sequence uint64
+	note     model.Note
+}
+
+// journal persists the changes made to the notes, it is always called with the write lock held
+type journal interface {
+	write(changes []change) error
+}
+
+const (
+	changeOpPut    = "put"
+	changeOpDelete = "delete"
+	changeOpClear  = "clear"
+
+	changeOpTrash   = "trash"
+	changeOpRestore = "restore"
+	changeOpPurge   = "purge"
+
+	changeOpPutRevision    = "putRevision"
+	changeOpDeleteRevision = "deleteRevision"
+)
+
+// change is a single modification of the notes or their revisions
+type change struct {
+	Op string `json:"op"`
+	// title of the note before the change, empty for clear,
+	// or the id of the note for restore, purge and deleteRevision
+	Key string `json:"key,omitempty"`
+	// the note after the change, only for put and trash
+	Note *model.Note `json:"note,omitempty"`
+	// the saved revision, only for putRevision
+	Revision *model.Revision `json:"revision,omitempty"`
+	// number of the deleted revision, only for deleteRevision
+	Number int64 `json:"number,omitempty"`
+}
+
+func newMemoryDatabase(revisionsRetention revisionsRetention, titles *titleCollation, logger *zap.Logger) *memoryDatabase {
+	return &memoryDatabase{
+		logger:             logger,
+		notes:              map[string]memoryNote{},
+		ids:                map[string]string{},
+		titles:             titles,
+		trash:              map[string]memoryNote{},
+		index:              search.NewMemoryIndex(),
+		revisions:          map[string][]model.Revision{},
+		revisionsRetention: revisionsRetention,
+	}
+}
+
+func (m *memoryDatabase) Connect() error {
+	m.logger.Info("Using in-memory database, notes will not be persisted")
+
+	return nil
+}
+
+func (m *memoryDatabase) IsReady() bool {
+	return true
+}
+
+func (m *memoryDatabase) searchIndex() search.Index {
+	return m.index
+}
+
+// commit persists the changes to the journal, when there is one, and applies them to the notes.
+// Must be called with the write lock held.
+func (m *memoryDatabase) commit(changes ...change) error {
+	if m.journal != nil {
+		err := m.journal.write(changes)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, c := range changes {
+		m.apply(c)
+	}
+
+	return nil
+}
+
+// apply modifies the notes without persisting the change.
+// Must be called with the write lock held.
+func (m *memoryDatabase) apply(c change) {
+	switch c.Op {
+	case changeOpPut:
+		key := m.titles.key(c.Key)
+
+		stored, exist := m.notes[key]
+		if exist {
+			delete(m.notes, key)
+		} else {
+			m.sequence++
+			stored.sequence = m.sequence
+		}
+
+		stored.note = copyNote(*c.Note)
+		m.notes[m.titles.key(stored.note.Title)] = stored
+		m.ids[stored.note.ID] = stored.note.Title
+		m.index.Put(stored.note)
+	case changeOpDelete:
+		key := m.titles.key(c.Key)
+
+		m.index.Remove(m.notes[key].note.ID)
+		delete(m.ids, m.notes[key].note.ID)
+		delete(m.notes, key)
+	case changeOpClear:
+		m.notes = map[string]memoryNote{}
+		m.ids = map[string]string{}
+		m.index.Reset(nil)
+	case changeOpTrash:
+		// the note keeps its sequence, so a restored note is back in its place
+		key := m.titles.key(c.Key)
+
+		stored := m.notes[key]
+		m.index.Remove(stored.note.ID)
+		delete(m.ids, stored.note.ID)
+		delete(m.notes, key)
+
+		stored.note = copyNote(*c.Note)
+		m.trash[stored.note.ID] = stored
+	case changeOpRestore:
+		stored := m.trash[c.Key]
+		delete(m.trash, c.Key)
+
+		stored.note.DeletedAt = nil
+		m.notes[m.titles.key(stored.note.Title)] = stored
+		m.ids[stored.note.ID] = stored.note.Title
+		m.index.Put(stored.note)
+	case changeOpPurge:
+		delete(m.trash, c.Key)
+	case changeOpPutRevision:
+		revision := copyRevision(*c.Revision)
+		m.revisions[revision.NoteID] = append(m.revisions[revision.NoteID], revision)
+	case changeOpDeleteRevision:
+		revisions := m.revisions[c.Key]
+		for i, revision := range revisions {
+			if revision.Number == c.Number {
+				m.revisions[c.Key] = append(revisions[:i:i], revisions[i+1:]...)
+				break
+			}
+		}
+		if len(m.revisions[c.Key]) == 0 {
+			delete(m.revisions, c.Key)
+		}
+	}
+}
+
+func putChange(key string, note model.Note) change {
+	note = copyNote(note)
+
+	return change{
+		Op:   changeOpPut,
+		Key:  key,
+		Note: &note,
+	}
+}
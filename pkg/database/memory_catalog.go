@@ -0,0 +1,45 @@
+package database
+
+import (
+	"sort"
+
+	"github.com/notes-project/api/pkg/model"
+)
+
+func (m *memoryDatabase) CountValues(field string, tags TagFilter, category string, timeRange TimeRange, sortBy []SortField, page Page) ([]model.ValueCount, string, error) {
+	err := getCountedField(field)
+	if err != nil {
+		return []model.ValueCount{}, "", err
+	}
+
+	sortBy, err = getCountSort(sortBy)
+	if err != nil {
+		return []model.ValueCount{}, "", err
+	}
+
+	position, err := decodeCursor(page.Cursor, sortBy)
+	if err != nil {
+		return []model.ValueCount{}, "", err
+	}
+
+	notes := m.findNotes(func(note model.Note) bool {
+		return matchesTags(note, tags) &&
+			(category == "" || note.Category == category) &&
+			matchesTimeRange(note, timeRange)
+	})
+
+	counts := []model.ValueCount{}
+	for _, count := range countValues(notes, field) {
+		if position == nil || comparePositions(countPosition(count, sortBy), *position, sortBy, nil) > 0 {
+			counts = append(counts, count)
+		}
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		return comparePositions(countPosition(counts[i], sortBy), countPosition(counts[j], sortBy), sortBy, nil) < 0
+	})
+
+	counts, next := cutCounts(counts, sortBy, page.Limit)
+
+	return counts, next, nil
+}
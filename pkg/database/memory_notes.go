@@ -0,0 +1,469 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/notes-project/api/pkg/filter"
+	"github.com/notes-project/api/pkg/model"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (m *memoryDatabase) AddNote(note model.Note) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.addNote(note)
+}
+
+// addNote must be called with the write lock held
+func (m *memoryDatabase) addNote(note model.Note) error {
+	note = newNote(note)
+
+	if _, exist := m.notes[m.titles.key(note.Title)]; exist {
+		return fmt.Errorf("failed to add note %v to the collection, error: %w", note, newDuplicateKeyError(noteTitleKey, note.Title))
+	}
+
+	_, exist := m.ids[note.ID]
+	_, trashed := m.trash[note.ID]
+	if exist || trashed {
+		return fmt.Errorf("failed to add note %v to the collection, error: %w", note, newDuplicateKeyError(noteIDKey, note.ID))
+	}
+
+	err := m.commit(putChange(note.Title, note))
+	if err != nil {
+		return fmt.Errorf("failed to add note %v to the collection, error: %w", note, err)
+	}
+
+	m.logger.Info(fmt.Sprintf("Successfully added note '%s' to the collection", note.Title))
+
+	return nil
+}
+
+func (m *memoryDatabase) AddNotes(notes []model.Note) ([]error, error) {
+	errs := make([]error, 0, len(notes))
+	for _, note := range notes {
+		errs = append(errs, m.AddNote(note))
+	}
+
+	return errs, nil
+}
+
+func (m *memoryDatabase) UpdateNote(noteTitle string, updatedNote model.Note, expectedVersion int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.replaceNote(noteTitle, fmt.Sprintf("'%s'", noteTitle), updatedNote, expectedVersion)
+}
+
+func (m *memoryDatabase) UpdateNoteByID(noteID string, updatedNote model.Note, expectedVersion int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.replaceNoteByID(noteID, updatedNote, expectedVersion)
+}
+
+// replaceNoteByID must be called with the write lock held
+func (m *memoryDatabase) replaceNoteByID(noteID string, updatedNote model.Note, expectedVersion int64) error {
+	noteTitle, exist := m.ids[noteID]
+	if !exist {
+		return mongo.ErrNoDocuments
+	}
+
+	return m.replaceNote(noteTitle, fmt.Sprintf("with id '%s'", noteID), updatedNote, expectedVersion)
+}
+
+// replaceNote must be called with the write lock held
+func (m *memoryDatabase) replaceNote(noteTitle, noteRef string, updatedNote model.Note, expectedVersion int64) error {
+	stored, exist := m.notes[m.titles.key(noteTitle)]
+	if !exist {
+		return mongo.ErrNoDocuments
+	}
+
+	if !matchesVersion(stored.note, expectedVersion) {
+		return fmt.Errorf("failed to update note %s, error: %w", noteRef, ErrVersionConflict)
+	}
+
+	// the id and the creation time are immutable
+	updatedNote.ID = stored.note.ID
+	updatedNote.Version = stored.note.Version + 1
+	updatedNote.CreatedAt = stored.note.CreatedAt
+	updatedNote.UpdatedAt = updateTime(updatedNote)
+	updatedNote.DeletedAt = nil
+	updatedNote.Date = ""
+
+	if m.titles.key(updatedNote.Title) != m.titles.key(noteTitle) {
+		if _, exist := m.notes[m.titles.key(updatedNote.Title)]; exist {
+			return fmt.Errorf("failed to update note %s, error: %w", noteRef, newDuplicateKeyError(noteTitleKey, updatedNote.Title))
+		}
+	}
+
+	changes := append(m.revisionChanges(stored.note), putChange(noteTitle, updatedNote))
+
+	err := m.commit(changes...)
+	if err != nil {
+		return fmt.Errorf("failed to update note %s, error: %w", noteRef, err)
+	}
+
+	return nil
+}
+
+func (m *memoryDatabase) UpsertNote(noteTitle string, note model.Note) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	note.Title = noteTitle
+
+	if _, exist := m.notes[m.titles.key(noteTitle)]; exist {
+		return false, m.replaceNote(noteTitle, fmt.Sprintf("'%s'", noteTitle), note, AnyVersion)
+	}
+
+	// same as the generated fields of an upsert in MongoDB
+	note.ID = ""
+	note.Version = 0
+	note.CreatedAt = time.Time{}
+	note.UpdatedAt = time.Time{}
+
+	err := m.addNote(note)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (m *memoryDatabase) PatchNote(noteTitle string, patch NotePatch, expectedVersion int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.patchNote(noteTitle, fmt.Sprintf("'%s'", noteTitle), patch, expectedVersion)
+}
+
+func (m *memoryDatabase) PatchNoteByID(noteID string, patch NotePatch, expectedVersion int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	noteTitle, exist := m.ids[noteID]
+	if !exist {
+		return mongo.ErrNoDocuments
+	}
+
+	return m.patchNote(noteTitle, fmt.Sprintf("with id '%s'", noteID), patch, expectedVersion)
+}
+
+// patchNote must be called with the write lock held, the stored note is replaced with its patched copy
+func (m *memoryDatabase) patchNote(noteTitle, noteRef string, patch NotePatch, expectedVersion int64) error {
+	stored, exist := m.notes[m.titles.key(noteTitle)]
+	if !exist {
+		return mongo.ErrNoDocuments
+	}
+
+	note := patch.apply(copyNote(stored.note))
+	// the patched note is stamped with the time of the patch, not the one of the stored note
+	note.UpdatedAt = time.Time{}
+
+	return m.replaceNote(noteTitle, noteRef, note, expectedVersion)
+}
+
+func (m *memoryDatabase) ChangeTags(noteTitle string, add, remove []string, expectedVersion int64) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	noteRef := fmt.Sprintf("'%s'", noteTitle)
+
+	stored, exist := m.notes[m.titles.key(noteTitle)]
+	if !exist {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	if !matchesVersion(stored.note, expectedVersion) {
+		return nil, fmt.Errorf("failed to update the tags of note %s, error: %w", noteRef, ErrVersionConflict)
+	}
+
+	tags, changed := changeTags(stored.note.Tags, add, remove)
+	if !changed {
+		return tags, nil
+	}
+
+	note := copyNote(stored.note)
+	note.Tags = tags
+	note.UpdatedAt = time.Time{}
+
+	// the version was already checked while holding the lock
+	err := m.replaceNote(noteTitle, noteRef, note, AnyVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return copyNote(note).Tags, nil
+}
+
+func (m *memoryDatabase) GetNote(noteTitle string) (model.Note, error) {
+	return m.GetProjectedNote(noteTitle, nil)
+}
+
+func (m *memoryDatabase) GetProjectedNote(noteTitle string, fields Projection) (model.Note, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.findNote(noteTitle, fmt.Sprintf("'%s'", noteTitle), fields)
+}
+
+func (m *memoryDatabase) GetNoteByID(noteID string) (model.Note, error) {
+	return m.GetProjectedNoteByID(noteID, nil)
+}
+
+func (m *memoryDatabase) GetProjectedNoteByID(noteID string, fields Projection) (model.Note, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	noteTitle, exist := m.ids[noteID]
+	if !exist {
+		return model.Note{}, fmt.Errorf("failed to find note with id '%s', error: %w", noteID, mongo.ErrNoDocuments)
+	}
+
+	return m.findNote(noteTitle, fmt.Sprintf("with id '%s'", noteID), fields)
+}
+
+// findNote must be called with the read lock held
+func (m *memoryDatabase) findNote(noteTitle, noteRef string, fields Projection) (model.Note, error) {
+	stored, exist := m.notes[m.titles.key(noteTitle)]
+	if !exist {
+		return model.Note{}, fmt.Errorf("failed to find note %s, error: %w", noteRef, mongo.ErrNoDocuments)
+	}
+
+	return projectNote(copyNote(stored.note), fields), nil
+}
+
+func (m *memoryDatabase) GetNotes() ([]model.Note, error) {
+	return m.findNotes(func(note model.Note) bool {
+		return true
+	}), nil
+}
+
+func (m *memoryDatabase) GetNotesFiltered(where filter.Expr, tags TagFilter, category string, timeRange TimeRange, sortBy []SortField, page Page, fields Projection) ([]model.Note, string, error) {
+	sortBy, err := getSort(sortBy)
+	if err != nil {
+		return []model.Note{}, "", err
+	}
+
+	position, err := decodeCursor(page.Cursor, sortBy)
+	if err != nil {
+		return []model.Note{}, "", err
+	}
+
+	notes := m.findNotes(func(note model.Note) bool {
+		return matchesWhere(note, where) &&
+			matchesTags(note, tags) &&
+			(category == "" || note.Category == category) &&
+			matchesTimeRange(note, timeRange) &&
+			(position == nil || comparePositions(notePosition(note, sortBy), *position, sortBy, m.titles) > 0)
+	})
+
+	// same order as in getSortOption
+	sort.Slice(notes, func(i, j int) bool {
+		return comparePositions(notePosition(notes[i], sortBy), notePosition(notes[j], sortBy), sortBy, m.titles) < 0
+	})
+
+	notes, next := cutPage(notes, sortBy, page.Limit)
+
+	// same fields as in getProjectionOption
+	fields = fields.withSort(sortBy)
+	for i := range notes {
+		notes[i] = projectNote(notes[i], fields)
+	}
+
+	return notes, next, nil
+}
+
+func (m *memoryDatabase) StreamNotes(where filter.Expr, tags TagFilter, category string, timeRange TimeRange, sortBy []SortField, each func(note model.Note) error) error {
+	sortBy, err := getSort(sortBy)
+	if err != nil {
+		return err
+	}
+
+	// the notes are already in memory, they are copied so each is called without the lock held
+	notes := m.findNotes(func(note model.Note) bool {
+		return matchesWhere(note, where) &&
+			matchesTags(note, tags) &&
+			(category == "" || note.Category == category) &&
+			matchesTimeRange(note, timeRange)
+	})
+
+	sort.Slice(notes, func(i, j int) bool {
+		return comparePositions(notePosition(notes[i], sortBy), notePosition(notes[j], sortBy), sortBy, m.titles) < 0
+	})
+
+	for _, note := range notes {
+		err = each(note)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// matchesTimeRange mirrors the filter from getTimeRangeFilter
+func matchesTimeRange(note model.Note, timeRange TimeRange) bool {
+	switch timeRange.Field {
+	case CreatedAtField:
+		return timeRange.contains(note.CreatedAt)
+	case UpdatedAtField:
+		return timeRange.contains(note.UpdatedAt)
+	}
+
+	return true
+}
+
+// findNotes returns copies of the notes accepted by the filter in insertion order
+func (m *memoryDatabase) findNotes(filter func(note model.Note) bool) []model.Note {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	matched := make([]memoryNote, 0, len(m.notes))
+	for _, stored := range m.notes {
+		if filter(stored.note) {
+			matched = append(matched, stored)
+		}
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].sequence < matched[j].sequence
+	})
+
+	notes := make([]model.Note, 0, len(matched))
+	for _, stored := range matched {
+		notes = append(notes, copyNote(stored.note))
+	}
+
+	return notes
+}
+
+// matchesTags mirrors the filter from getTagsFilter
+func matchesTags(note model.Note, tags TagFilter) bool {
+	if tags.Untagged {
+		return len(note.Tags) == 0
+	}
+
+	for _, tag := range tags.Excluded {
+		if containsString(note.Tags, tag) {
+			return false
+		}
+	}
+
+	if len(tags.Tags) == 0 {
+		return true
+	}
+
+	matched := 0
+	for _, tag := range tags.Tags {
+		if containsString(note.Tags, tag) {
+			matched++
+		}
+	}
+
+	if tags.Any {
+		return matched > 0
+	}
+
+	return matched == len(tags.Tags)
+}
+
+func (m *memoryDatabase) DeleteNote(noteTitle string, expectedVersion int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.deleteNote(noteTitle, fmt.Sprintf("'%s'", noteTitle), expectedVersion)
+}
+
+func (m *memoryDatabase) DeleteNoteByID(noteID string, expectedVersion int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.deleteNoteByID(noteID, expectedVersion)
+}
+
+// deleteNoteByID must be called with the write lock held
+func (m *memoryDatabase) deleteNoteByID(noteID string, expectedVersion int64) error {
+	noteTitle, exist := m.ids[noteID]
+	if !exist {
+		return mongo.ErrNoDocuments
+	}
+
+	return m.deleteNote(noteTitle, fmt.Sprintf("with id '%s'", noteID), expectedVersion)
+}
+
+// deleteNote must be called with the write lock held
+func (m *memoryDatabase) deleteNote(noteTitle, noteRef string, expectedVersion int64) error {
+	stored, exist := m.notes[m.titles.key(noteTitle)]
+	if !exist {
+		return mongo.ErrNoDocuments
+	}
+
+	if !matchesVersion(stored.note, expectedVersion) {
+		return fmt.Errorf("failed to delete note %s, error: %w", noteRef, ErrVersionConflict)
+	}
+
+	changes := append(m.revisionChanges(stored.note), trashChange(stored.note, time.Now().UTC()))
+
+	err := m.commit(changes...)
+	if err != nil {
+		return fmt.Errorf("failed to delete note %s from collection, error: %w", noteRef, err)
+	}
+
+	return nil
+}
+
+func (m *memoryDatabase) DeleteNotes() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.notes) == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	deletedAt := time.Now().UTC()
+
+	changes := []change{}
+	for _, stored := range m.notes {
+		changes = append(changes, m.revisionChanges(stored.note)...)
+		changes = append(changes, trashChange(stored.note, deletedAt))
+	}
+
+	err := m.commit(changes...)
+	if err != nil {
+		return fmt.Errorf("failed to delete notes from collection, error: %w", err)
+	}
+
+	return nil
+}
+
+func matchesVersion(note model.Note, expectedVersion int64) bool {
+	return expectedVersion == AnyVersion || note.Version == expectedVersion
+}
+
+// copyNote returns a deep copy of the note, so callers can't modify the stored notes
+func copyNote(note model.Note) model.Note {
+	if note.Tags != nil {
+		note.Tags = append([]string{}, note.Tags...)
+	}
+
+	if note.DeletedAt != nil {
+		deletedAt := *note.DeletedAt
+		note.DeletedAt = &deletedAt
+	}
+
+	return note
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
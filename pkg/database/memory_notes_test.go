@@ -0,0 +1,19 @@
+package database
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("MemoryDatabaseNotes", func() {
+
+	describeNotesBehavior(func() Database {
+		db := newSearchDatabase(newMemoryDatabase(revisionsRetention{}, nil, zap.L()), nil)
+
+		Expect(db.Connect()).To(Succeed())
+
+		return db
+	})
+
+})
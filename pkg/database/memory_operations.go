@@ -0,0 +1,132 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/notes-project/api/pkg/model"
+)
+
+func (m *memoryDatabase) ApplyOperations(operations []Operation, atomic bool) ([]error, error) {
+	if !atomic {
+		return applyEach(m, operations), nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// the changes are applied as they are made, so the next operations see them,
+	// and persisted all at once when every operation succeeded
+	snapshot := m.snapshot()
+	journal := m.journal
+	staged := &stagedJournal{}
+
+	m.journal = staged
+	errs, applied := applyAll(memoryTransaction{m}, operations)
+	m.journal = journal
+
+	if !applied {
+		m.restore(snapshot)
+		return errs, nil
+	}
+
+	if journal != nil && len(staged.changes) > 0 {
+		err := journal.write(staged.changes)
+		if err != nil {
+			m.restore(snapshot)
+			return nil, fmt.Errorf("failed to apply %d operations, error: %w", len(operations), err)
+		}
+	}
+
+	return errs, nil
+}
+
+// memoryTransaction changes the notes while the write lock is held by ApplyOperations
+type memoryTransaction struct {
+	m *memoryDatabase
+}
+
+func (t memoryTransaction) AddNote(note model.Note) error {
+	return t.m.addNote(note)
+}
+
+func (t memoryTransaction) UpdateNote(noteTitle string, updatedNote model.Note, expectedVersion int64) error {
+	return t.m.replaceNote(noteTitle, fmt.Sprintf("'%s'", noteTitle), updatedNote, expectedVersion)
+}
+
+func (t memoryTransaction) UpdateNoteByID(noteID string, updatedNote model.Note, expectedVersion int64) error {
+	return t.m.replaceNoteByID(noteID, updatedNote, expectedVersion)
+}
+
+func (t memoryTransaction) DeleteNote(noteTitle string, expectedVersion int64) error {
+	return t.m.deleteNote(noteTitle, fmt.Sprintf("'%s'", noteTitle), expectedVersion)
+}
+
+func (t memoryTransaction) DeleteNoteByID(noteID string, expectedVersion int64) error {
+	return t.m.deleteNoteByID(noteID, expectedVersion)
+}
+
+// stagedJournal keeps the changes of a transaction until they are all persisted
+type stagedJournal struct {
+	changes []change
+}
+
+func (s *stagedJournal) write(changes []change) error {
+	s.changes = append(s.changes, changes...)
+	return nil
+}
+
+// memorySnapshot is the state of the notes before a transaction, restored when it's rolled back
+type memorySnapshot struct {
+	notes     map[string]memoryNote
+	ids       map[string]string
+	sequence  uint64
+	trash     map[string]memoryNote
+	revisions map[string][]model.Revision
+}
+
+// snapshot must be called with the write lock held. The changes replace the stored notes and revisions
+// instead of modifying them, so copying the maps is enough to keep them.
+func (m *memoryDatabase) snapshot() memorySnapshot {
+	snapshot := memorySnapshot{
+		notes:     make(map[string]memoryNote, len(m.notes)),
+		ids:       make(map[string]string, len(m.ids)),
+		sequence:  m.sequence,
+		trash:     make(map[string]memoryNote, len(m.trash)),
+		revisions: make(map[string][]model.Revision, len(m.revisions)),
+	}
+
+	for title, stored := range m.notes {
+		snapshot.notes[title] = stored
+	}
+
+	for id, title := range m.ids {
+		snapshot.ids[id] = title
+	}
+
+	for id, stored := range m.trash {
+		snapshot.trash[id] = stored
+	}
+
+	for id, revisions := range m.revisions {
+		snapshot.revisions[id] = revisions
+	}
+
+	return snapshot
+}
+
+// restore must be called with the write lock held, the search index is rebuilt from the restored notes
+// because it was changed along with them
+func (m *memoryDatabase) restore(snapshot memorySnapshot) {
+	m.notes = snapshot.notes
+	m.ids = snapshot.ids
+	m.sequence = snapshot.sequence
+	m.trash = snapshot.trash
+	m.revisions = snapshot.revisions
+
+	notes := make([]model.Note, 0, len(m.notes))
+	for _, stored := range m.notes {
+		notes = append(notes, stored.note)
+	}
+
+	m.index.Reset(notes)
+}
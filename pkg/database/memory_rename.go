@@ -0,0 +1,50 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/notes-project/api/pkg/model"
+)
+
+func (m *memoryDatabase) RenameValue(field, from, to string, dryRun bool) (RenameResult, error) {
+	err := getRename(field, from, to)
+	if err != nil {
+		return RenameResult{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := RenameResult{}
+	changes := []change{}
+
+	for noteTitle, stored := range m.notes {
+		result.Merged = result.Merged || hasValue(stored.note, field, to)
+
+		if !hasValue(stored.note, field, from) {
+			continue
+		}
+
+		result.Changed++
+
+		// same as the fields set by replaceNote
+		note := renameValue(stored.note, field, from, to)
+		note.Version = stored.note.Version + 1
+		note.UpdatedAt = model.NewTimestamp()
+
+		changes = append(changes, m.revisionChanges(stored.note)...)
+		changes = append(changes, putChange(noteTitle, note))
+	}
+
+	if dryRun || len(changes) == 0 {
+		return result, nil
+	}
+
+	// all the notes are renamed at once
+	err = m.commit(changes...)
+	if err != nil {
+		return RenameResult{}, fmt.Errorf("failed to rename '%s' to '%s', error: %w", from, to, err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/notes-project/api/pkg/model"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (m *memoryDatabase) GetRevisions(noteTitle string) ([]model.Revision, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	noteID, err := m.findRevisionsNoteID(noteTitle)
+	if err != nil {
+		return []model.Revision{}, err
+	}
+
+	oldestTime := m.revisionsRetention.oldestTime(time.Now())
+
+	revisions := []model.Revision{}
+	for _, revision := range m.revisions[noteID] {
+		if revision.CreatedAt.Before(oldestTime) {
+			continue
+		}
+		revisions = append(revisions, copyRevision(revision))
+	}
+
+	return revisions, nil
+}
+
+func (m *memoryDatabase) GetRevision(noteTitle string, number int64) (model.Revision, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	noteID, err := m.findRevisionsNoteID(noteTitle)
+	if err != nil {
+		return model.Revision{}, err
+	}
+
+	oldestTime := m.revisionsRetention.oldestTime(time.Now())
+
+	for _, revision := range m.revisions[noteID] {
+		if revision.Number == number && !revision.CreatedAt.Before(oldestTime) {
+			return copyRevision(revision), nil
+		}
+	}
+
+	return model.Revision{}, fmt.Errorf("failed to find revision %d of note '%s', error: %w", number, noteTitle, mongo.ErrNoDocuments)
+}
+
+// findRevisionsNoteID returns the id of the note with the title,
+// or of the most recently deleted note with the title when there is no such note,
+// it must be called with the read lock held
+func (m *memoryDatabase) findRevisionsNoteID(noteTitle string) (string, error) {
+	if stored, exist := m.notes[m.titles.key(noteTitle)]; exist {
+		return stored.note.ID, nil
+	}
+
+	var latest *model.Revision
+	for noteID := range m.revisions {
+		revisions := m.revisions[noteID]
+		for i := range revisions {
+			if m.titles.key(revisions[i].Note.Title) != m.titles.key(noteTitle) {
+				continue
+			}
+			if latest == nil || revisions[i].CreatedAt.After(latest.CreatedAt) {
+				latest = &revisions[i]
+			}
+		}
+	}
+
+	if latest == nil {
+		return "", fmt.Errorf("failed to find revisions of note '%s', error: %w", noteTitle, mongo.ErrNoDocuments)
+	}
+
+	return latest.NoteID, nil
+}
+
+// revisionChanges returns the changes saving the previous version of an updated or deleted note
+// and removing the revisions of the note that exceed the retention,
+// it must be called with the write lock held
+func (m *memoryDatabase) revisionChanges(note model.Note) []change {
+	revisions := m.revisions[note.ID]
+
+	number := int64(1)
+	if len(revisions) > 0 {
+		number = revisions[len(revisions)-1].Number + 1
+	}
+
+	now := time.Now().UTC()
+
+	changes := []change{{
+		Op: changeOpPutRevision,
+		Revision: &model.Revision{
+			NoteID:    note.ID,
+			Number:    number,
+			CreatedAt: now,
+			Note:      copyNote(note),
+		},
+	}}
+
+	oldestNumber := m.revisionsRetention.oldestNumber(number)
+	oldestTime := m.revisionsRetention.oldestTime(now)
+
+	for _, revision := range revisions {
+		if revision.Number < oldestNumber || revision.CreatedAt.Before(oldestTime) {
+			changes = append(changes, change{Op: changeOpDeleteRevision, Key: note.ID, Number: revision.Number})
+		}
+	}
+
+	return changes
+}
+
+// copyRevision returns a deep copy of the revision, so callers can't modify the stored revisions
+func copyRevision(revision model.Revision) model.Revision {
+	revision.Note = copyNote(revision.Note)
+
+	return revision
+}
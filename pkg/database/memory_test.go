@@ -0,0 +1,123 @@
+package database
+
+import (
+	"time"
+
+	"github.com/notes-project/api/pkg/model"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("MemoryDatabase", func() {
+
+	var (
+		dbInstance *memoryDatabase
+	)
+
+	BeforeEach(func() {
+		dbInstance = newMemoryDatabase(revisionsRetention{}, nil, zap.L())
+	})
+
+	Describe("Connect", func() {
+		It("should return nil", func() {
+			err := dbInstance.Connect()
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Describe("IsReady", func() {
+		It("should return true", func() {
+			isReady := dbInstance.IsReady()
+			Expect(isReady).To(BeTrue())
+		})
+	})
+
+	Describe("Revisions retention", func() {
+		It("should keep only the latest revisions when the count is limited", func() {
+			dbInstance = newMemoryDatabase(NewRevisionsRetention(2, 0), nil, zap.L())
+
+			Expect(dbInstance.AddNote(model.Note{Title: "test"})).To(Succeed())
+			for i := 0; i < 4; i++ {
+				Expect(dbInstance.UpdateNote("test", model.Note{Title: "test"}, AnyVersion)).To(Succeed())
+			}
+
+			revisions, err := dbInstance.GetRevisions("test")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revisions).To(HaveLen(2))
+			Expect(revisions[0].Number).To(Equal(int64(3)))
+			Expect(revisions[1].Number).To(Equal(int64(4)))
+		})
+
+		It("should not return the revisions older than the maximum age", func() {
+			dbInstance = newMemoryDatabase(NewRevisionsRetention(0, time.Hour), nil, zap.L())
+
+			Expect(dbInstance.AddNote(model.Note{ID: "id", Title: "test"})).To(Succeed())
+			Expect(dbInstance.UpdateNote("test", model.Note{Title: "test"}, AnyVersion)).To(Succeed())
+			Expect(dbInstance.UpdateNote("test", model.Note{Title: "test"}, AnyVersion)).To(Succeed())
+
+			dbInstance.revisions["id"][0].CreatedAt = time.Now().Add(-2 * time.Hour)
+
+			revisions, err := dbInstance.GetRevisions("test")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revisions).To(HaveLen(1))
+			Expect(revisions[0].Number).To(Equal(int64(2)))
+
+			_, err = dbInstance.GetRevision("test", 1)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Title collation", func() {
+		BeforeEach(func() {
+			dbInstance = newMemoryDatabase(revisionsRetention{}, newTitleCollation("en"), zap.L())
+
+			Expect(dbInstance.AddNote(model.Note{ID: "id", Title: "Standup"})).To(Succeed())
+		})
+
+		It("should keep the title unique ignoring the case", func() {
+			err := dbInstance.AddNote(model.Note{Title: "STANDUP"})
+			Expect(mongo.IsDuplicateKeyError(err)).To(BeTrue())
+		})
+
+		It("should find the note ignoring the case of the title", func() {
+			note, err := dbInstance.GetNote("standup")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.Title).To(Equal("Standup"))
+		})
+
+		It("should change the case of the title of a note", func() {
+			Expect(dbInstance.UpdateNote("standup", model.Note{Title: "StandUp"}, AnyVersion)).To(Succeed())
+
+			note, err := dbInstance.GetNoteByID("id")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.Title).To(Equal("StandUp"))
+		})
+
+		It("should restore and purge the notes in the trash ignoring the case of the title", func() {
+			Expect(dbInstance.DeleteNote("STANDUP", AnyVersion)).To(Succeed())
+			Expect(dbInstance.RestoreNote("standup")).To(Succeed())
+
+			revisions, err := dbInstance.GetRevisions("standUp")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revisions).To(HaveLen(1))
+
+			Expect(dbInstance.DeleteNote("Standup", AnyVersion)).To(Succeed())
+			Expect(dbInstance.PurgeNote("STANDUP")).To(Succeed())
+		})
+
+		It("should sort the titles in the order of the locale", func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "fika"})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{Title: "\u00e9quipe"})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{Title: "Zebra"})).To(Succeed())
+
+			notes, _, err := dbInstance.GetNotesFiltered(nil, TagFilter{}, "", TimeRange{}, []SortField{{Field: TitleField}}, Page{Limit: 2}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(2))
+			Expect(notes[0].Title).To(Equal("\u00e9quipe"))
+			Expect(notes[1].Title).To(Equal("fika"))
+		})
+	})
+
+})
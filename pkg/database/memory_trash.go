@@ -0,0 +1,130 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/notes-project/api/pkg/model"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (m *memoryDatabase) GetTrash() ([]model.Note, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	notes := make([]model.Note, 0, len(m.trash))
+	for _, stored := range m.trash {
+		notes = append(notes, copyNote(stored.note))
+	}
+
+	// same as in GetTrash of the MongoDB implementation, the most recently deleted notes come first,
+	// the trash is a map so the notes deleted at the same time are ordered by their id
+	sort.Slice(notes, func(i, j int) bool {
+		if !notes[i].DeletedAt.Equal(*notes[j].DeletedAt) {
+			return notes[i].DeletedAt.After(*notes[j].DeletedAt)
+		}
+
+		return notes[i].ID < notes[j].ID
+	})
+
+	return notes, nil
+}
+
+func (m *memoryDatabase) RestoreNote(noteTitle string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var restored *memoryNote
+	for _, stored := range m.trash {
+		stored := stored
+		if m.titles.key(stored.note.Title) != m.titles.key(noteTitle) {
+			continue
+		}
+		if restored == nil || stored.note.DeletedAt.After(*restored.note.DeletedAt) {
+			restored = &stored
+		}
+	}
+
+	if restored == nil {
+		return mongo.ErrNoDocuments
+	}
+
+	if _, exist := m.notes[m.titles.key(noteTitle)]; exist {
+		return fmt.Errorf("failed to restore note '%s' from trash, error: %w", noteTitle, newDuplicateKeyError(noteTitleKey, noteTitle))
+	}
+
+	err := m.commit(change{Op: changeOpRestore, Key: restored.note.ID})
+	if err != nil {
+		return fmt.Errorf("failed to restore note '%s' from trash, error: %w", noteTitle, err)
+	}
+
+	m.logger.Info(fmt.Sprintf("Successfully restored note '%s' from trash", noteTitle))
+
+	return nil
+}
+
+func (m *memoryDatabase) PurgeNote(noteTitle string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	changes := m.purgeChanges(func(note model.Note) bool {
+		return m.titles.key(note.Title) == m.titles.key(noteTitle)
+	})
+
+	if len(changes) == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	err := m.commit(changes...)
+	if err != nil {
+		return fmt.Errorf("failed to purge note '%s' from trash, error: %w", noteTitle, err)
+	}
+
+	return nil
+}
+
+func (m *memoryDatabase) PurgeTrash(deletedBefore time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	changes := m.purgeChanges(func(note model.Note) bool {
+		return note.DeletedAt.Before(deletedBefore)
+	})
+
+	if len(changes) == 0 {
+		return 0, nil
+	}
+
+	err := m.commit(changes...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge notes from trash, error: %w", err)
+	}
+
+	return int64(len(changes)), nil
+}
+
+// purgeChanges returns the changes removing the notes accepted by the filter from the trash,
+// it must be called with the write lock held
+func (m *memoryDatabase) purgeChanges(filter func(note model.Note) bool) []change {
+	changes := []change{}
+	for noteID, stored := range m.trash {
+		if filter(stored.note) {
+			changes = append(changes, change{Op: changeOpPurge, Key: noteID})
+		}
+	}
+
+	return changes
+}
+
+// trashChange returns the change moving the note to the trash
+func trashChange(note model.Note, deletedAt time.Time) change {
+	note = copyNote(note)
+	note.DeletedAt = &deletedAt
+
+	return change{
+		Op:   changeOpTrash,
+		Key:  note.Title,
+		Note: &note,
+	}
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"time"
+
+	"github.com/notes-project/api/pkg/filter"
+	"github.com/notes-project/api/pkg/model"
+)
+
+// matchesWhere mirrors the filter from getWhereFilter
+func matchesWhere(note model.Note, where filter.Expr) bool {
+	switch e := where.(type) {
+	case filter.Conjunction:
+		for _, expr := range e.Exprs {
+			if !matchesWhere(note, expr) {
+				return false
+			}
+		}
+
+		return true
+
+	case filter.Disjunction:
+		for _, expr := range e.Exprs {
+			if matchesWhere(note, expr) {
+				return true
+			}
+		}
+
+		return false
+
+	case filter.Negation:
+		return !matchesWhere(note, e.Expr)
+
+	case filter.Comparison:
+		return matchesComparison(note, e)
+	}
+
+	return true
+}
+
+// matchesComparison mirrors the filter from getComparisonFilter
+func matchesComparison(note model.Note, comparison filter.Comparison) bool {
+	switch comparison.Field {
+	case filter.CreatedAtField:
+		return compareTime(note.CreatedAt, comparison)
+	case filter.UpdatedAtField:
+		return compareTime(note.UpdatedAt, comparison)
+	case filter.TagField:
+		if comparison.Operator == filter.Exists {
+			return len(note.Tags) > 0
+		}
+
+		matched := false
+		for _, tag := range note.Tags {
+			matched = matched || containsString(comparison.Values, tag)
+		}
+
+		return matched != (comparison.Operator == filter.Ne)
+	}
+
+	value := noteText(note, comparison.Field)
+
+	switch comparison.Operator {
+	case filter.Exists:
+		return value != ""
+	case filter.Ne:
+		return !containsString(comparison.Values, value)
+	}
+
+	return containsString(comparison.Values, value)
+}
+
+func compareTime(t time.Time, comparison filter.Comparison) bool {
+	switch comparison.Operator {
+	case filter.Lt:
+		return t.Before(comparison.Time)
+	case filter.Le:
+		return !t.After(comparison.Time)
+	case filter.Gt:
+		return t.After(comparison.Time)
+	case filter.Ge:
+		return !t.Before(comparison.Time)
+	}
+
+	return false
+}
+
+// noteText returns the text field of the note
+func noteText(note model.Note, field string) string {
+	switch field {
+	case filter.TitleField:
+		return note.Title
+	case filter.DescriptionField:
+		return note.Description
+	case filter.CategoryField:
+		return note.Category
+	}
+
+	return ""
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/notes-project/api/pkg/constants"
+	facademongo "github.com/notes-project/api/pkg/facade/go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// legacyTimestamp returns the time of a date stored before the timestamps were introduced,
+// the dates were formatted in the local timezone of the server, so they're parsed in it too
+func legacyTimestamp(date string, fallback time.Time) time.Time {
+	timestamp, err := time.ParseInLocation(constants.DateFormat, date, time.Local)
+	if err != nil {
+		return fallback
+	}
+
+	return timestamp.UTC()
+}
+
+// legacyNote has the fields of the notes stored before the timestamps were introduced,
+// the id can be an ObjectID, so it's kept as is
+type legacyNote struct {
+	ID   interface{} `bson:"_id"`
+	Date string      `bson:"date"`
+}
+
+// migrateDates sets the timestamps of the notes stored before they were introduced from their date,
+// which is not stored anymore. The notes in the trash are migrated too.
+func (d *database) migrateDates(collection *mongo.Collection) error {
+	cursor, err := facademongo.GetCollectionInstance().Find(collection, ctx, bson.D{
+		{Key: noteCreatedAtKey, Value: bson.D{{Key: "$exists", Value: false}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to find the notes without timestamps, error: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	now := time.Now().UTC()
+	migrated := 0
+
+	for cursor.Next(ctx) {
+		note := legacyNote{}
+
+		err = cursor.Decode(&note)
+		if err != nil {
+			return fmt.Errorf("failed to decode note without timestamps, error: %w", err)
+		}
+
+		timestamp := legacyTimestamp(note.Date, now)
+
+		_, err = facademongo.GetCollectionInstance().UpdateOne(collection, ctx,
+			bson.D{{Key: noteIDKey, Value: note.ID}},
+			bson.D{
+				{Key: "$set", Value: bson.D{
+					{Key: noteCreatedAtKey, Value: timestamp},
+					{Key: noteUpdatedAtKey, Value: timestamp},
+				}},
+				{Key: "$unset", Value: bson.D{{Key: noteDateKey, Value: ""}}},
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to migrate the date of note with id '%v', error: %w", note.ID, err)
+		}
+
+		migrated++
+	}
+
+	err = cursor.Err()
+	if err != nil {
+		return fmt.Errorf("failed to find the notes without timestamps, error: %w", err)
+	}
+
+	if migrated > 0 {
+		d.logger.Info(fmt.Sprintf("Successfully migrated the dates of %d notes to timestamps", migrated))
+	}
+
+	return nil
+}
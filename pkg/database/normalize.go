@@ -0,0 +1,178 @@
+package database
+
+import (
+	"github.com/notes-project/api/pkg/filter"
+	"github.com/notes-project/api/pkg/model"
+)
+
+/*
+	Normalization of the titles and tags of the notes stored by any Database implementation.
+
+	The clients can send the same text in different Unicode forms, such as an accented letter
+	either composed or followed by a combining accent, or with spaces around it. The titles
+	and the tags are stored normalized by normalizeText, and the titles and tags the notes are
+	looked up or filtered by are normalized the same way, so they match whatever form is sent.
+*/
+
+type normalizedDatabase struct {
+	Database
+}
+
+func newNormalizedDatabase(d Database) *normalizedDatabase {
+	return &normalizedDatabase{Database: d}
+}
+
+func (n *normalizedDatabase) AddNote(note model.Note) error {
+	return n.Database.AddNote(normalizeNote(note))
+}
+
+func (n *normalizedDatabase) AddNotes(notes []model.Note) ([]error, error) {
+	normalized := make([]model.Note, 0, len(notes))
+	for _, note := range notes {
+		normalized = append(normalized, normalizeNote(note))
+	}
+
+	return n.Database.AddNotes(normalized)
+}
+
+func (n *normalizedDatabase) UpdateNote(noteTitle string, updatedNote model.Note, expectedVersion int64) error {
+	return n.Database.UpdateNote(normalizeText(noteTitle), normalizeNote(updatedNote), expectedVersion)
+}
+
+func (n *normalizedDatabase) UpdateNoteByID(noteID string, updatedNote model.Note, expectedVersion int64) error {
+	return n.Database.UpdateNoteByID(noteID, normalizeNote(updatedNote), expectedVersion)
+}
+
+func (n *normalizedDatabase) UpsertNote(noteTitle string, note model.Note) (bool, error) {
+	return n.Database.UpsertNote(normalizeText(noteTitle), normalizeNote(note))
+}
+
+func (n *normalizedDatabase) PatchNote(noteTitle string, patch NotePatch, expectedVersion int64) error {
+	return n.Database.PatchNote(normalizeText(noteTitle), normalizePatch(patch), expectedVersion)
+}
+
+func (n *normalizedDatabase) PatchNoteByID(noteID string, patch NotePatch, expectedVersion int64) error {
+	return n.Database.PatchNoteByID(noteID, normalizePatch(patch), expectedVersion)
+}
+
+// normalizePatch returns the patch with the title and tags it sets normalized
+func normalizePatch(patch NotePatch) NotePatch {
+	if patch.Title != nil {
+		title := normalizeText(*patch.Title)
+		patch.Title = &title
+	}
+
+	if patch.Tags != nil {
+		tags := normalizeTexts(*patch.Tags)
+		patch.Tags = &tags
+	}
+
+	return patch
+}
+
+func (n *normalizedDatabase) ChangeTags(noteTitle string, add, remove []string, expectedVersion int64) ([]string, error) {
+	return n.Database.ChangeTags(normalizeText(noteTitle), normalizeTexts(add), normalizeTexts(remove), expectedVersion)
+}
+
+func (n *normalizedDatabase) RenameValue(field, from, to string, dryRun bool) (RenameResult, error) {
+	// only the tags are normalized, the categories are stored as they are sent
+	if field == TagsField {
+		from = normalizeText(from)
+		to = normalizeText(to)
+	}
+
+	return n.Database.RenameValue(field, from, to, dryRun)
+}
+
+func (n *normalizedDatabase) GetNote(noteTitle string) (model.Note, error) {
+	return n.Database.GetNote(normalizeText(noteTitle))
+}
+
+func (n *normalizedDatabase) GetProjectedNote(noteTitle string, fields Projection) (model.Note, error) {
+	return n.Database.GetProjectedNote(normalizeText(noteTitle), fields)
+}
+
+func (n *normalizedDatabase) GetNotesFiltered(where filter.Expr, tags TagFilter, category string, timeRange TimeRange, sortBy []SortField, page Page, fields Projection) ([]model.Note, string, error) {
+	return n.Database.GetNotesFiltered(normalizeWhere(where), normalizeTagFilter(tags), category, timeRange, sortBy, page, fields)
+}
+
+func (n *normalizedDatabase) StreamNotes(where filter.Expr, tags TagFilter, category string, timeRange TimeRange, sortBy []SortField, each func(note model.Note) error) error {
+	return n.Database.StreamNotes(normalizeWhere(where), normalizeTagFilter(tags), category, timeRange, sortBy, each)
+}
+
+func (n *normalizedDatabase) CountValues(field string, tags TagFilter, category string, timeRange TimeRange, sortBy []SortField, page Page) ([]model.ValueCount, string, error) {
+	return n.Database.CountValues(field, normalizeTagFilter(tags), category, timeRange, sortBy, page)
+}
+
+func (n *normalizedDatabase) SearchNotes(query string, where filter.Expr, tags TagFilter, category string, timeRange TimeRange, sortBy []SortField, page Page) ([]model.SearchHit, string, error) {
+	return n.Database.SearchNotes(query, normalizeWhere(where), normalizeTagFilter(tags), category, timeRange, sortBy, page)
+}
+
+// normalizeTagFilter returns the filter with its tags normalized
+func normalizeTagFilter(tags TagFilter) TagFilter {
+	tags.Tags = normalizeTexts(tags.Tags)
+	tags.Excluded = normalizeTexts(tags.Excluded)
+
+	return tags
+}
+
+// normalizeWhere returns the filter with the titles and the tags it compares normalized
+func normalizeWhere(where filter.Expr) filter.Expr {
+	switch e := where.(type) {
+	case filter.Conjunction:
+		return filter.Conjunction{Exprs: normalizeWheres(e.Exprs)}
+	case filter.Disjunction:
+		return filter.Disjunction{Exprs: normalizeWheres(e.Exprs)}
+	case filter.Negation:
+		return filter.Negation{Expr: normalizeWhere(e.Expr)}
+	case filter.Comparison:
+		if e.Field == filter.TitleField || e.Field == filter.TagField {
+			e.Values = normalizeTexts(e.Values)
+		}
+
+		return e
+	}
+
+	return where
+}
+
+func normalizeWheres(exprs []filter.Expr) []filter.Expr {
+	normalized := make([]filter.Expr, 0, len(exprs))
+	for _, expr := range exprs {
+		normalized = append(normalized, normalizeWhere(expr))
+	}
+
+	return normalized
+}
+
+func (n *normalizedDatabase) DeleteNote(noteTitle string, expectedVersion int64) error {
+	return n.Database.DeleteNote(normalizeText(noteTitle), expectedVersion)
+}
+
+func (n *normalizedDatabase) ApplyOperations(operations []Operation, atomic bool) ([]error, error) {
+	normalized := make([]Operation, 0, len(operations))
+	for _, operation := range operations {
+		operation.NoteTitle = normalizeText(operation.NoteTitle)
+		operation.Note = normalizeNote(operation.Note)
+
+		normalized = append(normalized, operation)
+	}
+
+	return n.Database.ApplyOperations(normalized, atomic)
+}
+
+func (n *normalizedDatabase) RestoreNote(noteTitle string) error {
+	return n.Database.RestoreNote(normalizeText(noteTitle))
+}
+
+func (n *normalizedDatabase) PurgeNote(noteTitle string) error {
+	return n.Database.PurgeNote(normalizeText(noteTitle))
+}
+
+func (n *normalizedDatabase) GetRevisions(noteTitle string) ([]model.Revision, error) {
+	return n.Database.GetRevisions(normalizeText(noteTitle))
+}
+
+func (n *normalizedDatabase) GetRevision(noteTitle string, number int64) (model.Revision, error) {
+	return n.Database.GetRevision(normalizeText(noteTitle), number)
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"time"
+
+	"github.com/notes-project/api/pkg/filter"
+	"github.com/notes-project/api/pkg/model"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("NormalizedDatabase", func() {
+
+	var (
+		dbInstance Database
+	)
+
+	BeforeEach(func() {
+		dbInstance = newNormalizedDatabase(newSearchDatabase(newMemoryDatabase(revisionsRetention{}, nil, zap.L()), nil))
+
+		Expect(dbInstance.Connect()).To(Succeed())
+		Expect(dbInstance.AddNote(model.Note{ID: "id", Title: " Cafe\u0301 ", Tags: []string{" re\u0301sume\u0301"}})).To(Succeed())
+	})
+
+	It("should store the title and the tags normalized", func() {
+		note, err := dbInstance.GetNoteByID("id")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(note.Title).To(Equal("Caf\u00e9"))
+		Expect(note.Tags).To(Equal([]string{"r\u00e9sum\u00e9"}))
+	})
+
+	It("should keep the title unique whatever form it's sent in", func() {
+		err := dbInstance.AddNote(model.Note{Title: "Caf\u00e9"})
+		Expect(mongo.IsDuplicateKeyError(err)).To(BeTrue())
+	})
+
+	It("should find the note by its title in any form", func() {
+		note, err := dbInstance.GetNote("Cafe\u0301")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(note.ID).To(Equal("id"))
+	})
+
+	It("should filter the notes by their tags in any form", func() {
+		notes, _, err := dbInstance.GetNotesFiltered(nil, TagFilter{Tags: []string{"re\u0301sume\u0301 "}}, "", TimeRange{}, nil, Page{}, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(notes).To(HaveLen(1))
+	})
+
+	It("should filter the notes by the titles and the tags of a structured filter in any form", func() {
+		where, err := filter.Parse("title = \" Cafe\u0301\" AND tag in (\"re\u0301sume\u0301 \")", time.UTC)
+		Expect(err).NotTo(HaveOccurred())
+
+		notes, _, err := dbInstance.GetNotesFiltered(where, TagFilter{}, "", TimeRange{}, nil, Page{}, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(notes).To(HaveLen(1))
+	})
+
+	It("should normalize the patched title and the changed tags", func() {
+		title := "The\u0301 "
+		Expect(dbInstance.PatchNote("Caf\u00e9", NotePatch{Title: &title}, AnyVersion)).To(Succeed())
+
+		tags, err := dbInstance.ChangeTags("Th\u00e9", []string{" re\u0301sume\u0301", "cafe\u0301 "}, nil, AnyVersion)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tags).To(Equal([]string{"r\u00e9sum\u00e9", "caf\u00e9"}))
+	})
+
+	It("should normalize the titles of the operations", func() {
+		errs, err := dbInstance.ApplyOperations([]Operation{
+			{Type: OperationCreate, Note: model.Note{Title: "Cre\u0300me "}},
+			{Type: OperationDelete, NoteTitle: "Cafe\u0301", ExpectedVersion: AnyVersion},
+		}, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(errs).To(Equal([]error{nil, nil}))
+
+		_, err = dbInstance.GetNote("Cr\u00e8me")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(dbInstance.RestoreNote(" Caf\u00e9")).To(Succeed())
+	})
+
+})
@@ -1,15 +1,22 @@
 package database
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/notes-project/api/pkg/filter"
 	"github.com/notes-project/api/pkg/model"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func (d *database) AddNote(note model.Note) error {
-	_, err := d.collection.InsertOne(ctx, note)
+	note = newNote(note)
+
+	_, err := d.collection.InsertOne(d.context(), note)
 
 	if err != nil {
 		return fmt.Errorf("failed to add note %v to the collection, error: %w", note, err)
@@ -20,34 +27,371 @@ func (d *database) AddNote(note model.Note) error {
 	return nil
 }
 
-func (d *database) UpdateNote(noteTitle string, updatedNote model.Note) error {
-	result, err := d.collection.ReplaceOne(ctx, bson.D{
-		{
-			Key:   noteTitlePrimaryKey,
-			Value: noteTitle,
+func (d *database) AddNotes(notes []model.Note) ([]error, error) {
+	errs := make([]error, len(notes))
+	if len(notes) == 0 {
+		return errs, nil
+	}
+
+	documents := make([]interface{}, 0, len(notes))
+	for _, note := range notes {
+		documents = append(documents, newNote(note))
+	}
+
+	// unordered, so the notes after a duplicate are still inserted
+	_, err := d.collection.InsertMany(d.context(), documents, options.InsertMany().SetOrdered(false))
+	if err != nil {
+		var bulkErr mongo.BulkWriteException
+		if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+			return nil, fmt.Errorf("failed to add %d notes to the collection, error: %w", len(notes), err)
+		}
+
+		for _, writeErr := range bulkErr.WriteErrors {
+			// same error as the one returned by InsertOne, so it's reported the same way as by AddNote
+			errs[writeErr.Index] = fmt.Errorf("failed to add note %v to the collection, error: %w",
+				documents[writeErr.Index], mongo.WriteException{WriteErrors: []mongo.WriteError{writeErr.WriteError}})
+		}
+	}
+
+	d.logger.Info(fmt.Sprintf("Successfully added %d of %d notes to the collection", countAdded(errs), len(notes)))
+
+	return errs, nil
+}
+
+// countAdded returns the number of notes added by AddNotes
+func countAdded(errs []error) int {
+	added := 0
+	for _, err := range errs {
+		if err == nil {
+			added++
+		}
+	}
+
+	return added
+}
+
+func (d *database) UpdateNote(noteTitle string, updatedNote model.Note, expectedVersion int64) error {
+	return d.replaceNote(getTitleFilter(noteTitle), fmt.Sprintf("'%s'", noteTitle), updatedNote, expectedVersion)
+}
+
+func (d *database) UpdateNoteByID(noteID string, updatedNote model.Note, expectedVersion int64) error {
+	return d.replaceNote(getIDFilter(noteID), fmt.Sprintf("with id '%s'", noteID), updatedNote, expectedVersion)
+}
+
+func (d *database) replaceNote(filter bson.D, noteRef string, updatedNote model.Note, expectedVersion int64) error {
+	// the id and the creation time are immutable and the version is incremented by the update,
+	// they are omitted from the replaced fields
+	updatedNote.ID = ""
+	updatedNote.Version = 0
+	updatedNote.CreatedAt = time.Time{}
+	updatedNote.UpdatedAt = updateTime(updatedNote)
+	updatedNote.DeletedAt = nil
+	updatedNote.Date = ""
+
+	return d.setNoteFields(filter, noteRef, updatedNote, expectedVersion)
+}
+
+func (d *database) UpsertNote(noteTitle string, note model.Note) (bool, error) {
+	for attempt := 1; ; attempt++ {
+		var created bool
+
+		// an upsert that lost the race to add the note fails its transaction, so it's tried again in a new one
+		err := d.inTransaction(func(transaction *database) error {
+			var err error
+			created, err = transaction.upsertNote(noteTitle, note)
+
+			return err
+		})
+		if mongo.IsDuplicateKeyError(err) && attempt < maxUpsertAttempts {
+			continue
+		}
+
+		return created, err
+	}
+}
+
+// upsertNote replaces or adds the note with the title and saves the revision of the replaced note
+func (d *database) upsertNote(noteTitle string, note model.Note) (bool, error) {
+	now := model.NewTimestamp()
+	previous := model.Note{}
+
+	// the filter on the title sets the title of an added note, along with the generated fields
+	err := d.collection.FindOneAndUpdate(d.context(),
+		getTitleFilter(noteTitle),
+		bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: noteTitleKey, Value: noteTitle},
+				{Key: noteDescriptionKey, Value: note.Description},
+				{Key: noteCategoryKey, Value: note.Category},
+				{Key: noteTagsKey, Value: note.Tags},
+				{Key: noteUpdatedAtKey, Value: now},
+			}},
+			{Key: "$setOnInsert", Value: bson.D{
+				{Key: noteIDKey, Value: model.NewNoteID()},
+				{Key: noteCreatedAtKey, Value: now},
+			}},
+			{Key: "$inc", Value: bson.D{{Key: noteVersionKey, Value: 1}}},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before).SetCollation(d.titleCollationOption()),
+	).Decode(&previous)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		d.logger.Info(fmt.Sprintf("Successfully added note '%s' to the collection", noteTitle))
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to upsert note '%s', error: %w", noteTitle, err)
+	}
+
+	err = d.saveRevision(d.context(), previous)
+	if err != nil {
+		return false, err
+	}
+
+	return false, nil
+}
+
+func (d *database) PatchNote(noteTitle string, patch NotePatch, expectedVersion int64) error {
+	return d.patchNote(getTitleFilter(noteTitle), fmt.Sprintf("'%s'", noteTitle), patch, expectedVersion)
+}
+
+func (d *database) PatchNoteByID(noteID string, patch NotePatch, expectedVersion int64) error {
+	return d.patchNote(getIDFilter(noteID), fmt.Sprintf("with id '%s'", noteID), patch, expectedVersion)
+}
+
+func (d *database) patchNote(filter bson.D, noteRef string, patch NotePatch, expectedVersion int64) error {
+	fields := bson.D{}
+
+	if patch.Title != nil {
+		fields = append(fields, bson.E{Key: noteTitleKey, Value: *patch.Title})
+	}
+
+	if patch.Description != nil {
+		fields = append(fields, bson.E{Key: noteDescriptionKey, Value: *patch.Description})
+	}
+
+	if patch.Category != nil {
+		fields = append(fields, bson.E{Key: noteCategoryKey, Value: *patch.Category})
+	}
+
+	if patch.Tags != nil {
+		fields = append(fields, bson.E{Key: noteTagsKey, Value: *patch.Tags})
+	}
+
+	fields = append(fields, bson.E{Key: noteUpdatedAtKey, Value: model.NewTimestamp()})
+
+	return d.setNoteFields(filter, noteRef, fields, expectedVersion)
+}
+
+func (d *database) ChangeTags(noteTitle string, add, remove []string, expectedVersion int64) ([]string, error) {
+	var tags []string
+
+	// the changed note is saved along with its revision
+	err := d.inTransaction(func(transaction *database) error {
+		var err error
+		tags, err = transaction.changeNoteTags(noteTitle, add, remove, expectedVersion)
+
+		return err
+	})
+
+	return tags, err
+}
+
+func (d *database) changeNoteTags(noteTitle string, add, remove []string, expectedVersion int64) ([]string, error) {
+	filter := getTitleFilter(noteTitle)
+	noteRef := fmt.Sprintf("'%s'", noteTitle)
+
+	// the collation of the title would also match the tags ignoring their case,
+	// so the note is found by its title first and then changed by its id
+	note, err := d.findNote(filter, nil)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, mongo.ErrNoDocuments
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update the tags of note %s, error: %w", noteRef, err)
+	}
+
+	filter = getIDFilter(note.ID)
+
+	add = uniqueTags(add)
+	remove = uniqueTags(remove)
+
+	// only a note whose tags change is updated, so a change that is already applied doesn't create a new version
+	changes := bson.A{}
+
+	if len(add) > 0 {
+		changes = append(changes, bson.D{{Key: noteTagsKey, Value: bson.D{{Key: "$not", Value: bson.D{{Key: "$all", Value: add}}}}}})
+	}
+
+	if len(remove) > 0 {
+		changes = append(changes, bson.D{{Key: noteTagsKey, Value: bson.D{{Key: "$in", Value: remove}}}})
+	}
+
+	if len(changes) == 0 {
+		return d.currentTags(filter, noteRef, expectedVersion)
+	}
+
+	changeFilter := append(bson.D{}, getVersionFilter(filter, expectedVersion)...)
+	changeFilter = append(changeFilter, bson.E{Key: "$or", Value: changes})
+
+	tags := bson.D{{Key: "$ifNull", Value: bson.A{"$" + noteTagsKey, bson.A{}}}}
+
+	previous := model.Note{}
+
+	// the tags are computed by the update from the stored ones, the literals keep the tags starting with '$' as they are
+	err = d.collection.FindOneAndUpdate(d.context(),
+		changeFilter,
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.D{
+				{Key: noteTagsKey, Value: bson.D{{Key: "$concatArrays", Value: bson.A{
+					bson.D{{Key: "$filter", Value: bson.D{
+						{Key: "input", Value: tags},
+						{Key: "cond", Value: bson.D{{Key: "$not", Value: bson.A{
+							bson.D{{Key: "$in", Value: bson.A{"$$this", bson.D{{Key: "$literal", Value: remove}}}}},
+						}}}},
+					}}},
+					bson.D{{Key: "$filter", Value: bson.D{
+						{Key: "input", Value: bson.D{{Key: "$literal", Value: add}}},
+						{Key: "cond", Value: bson.D{{Key: "$not", Value: bson.A{
+							bson.D{{Key: "$in", Value: bson.A{"$$this", tags}}},
+						}}}},
+					}}},
+				}}}},
+				{Key: noteUpdatedAtKey, Value: model.NewTimestamp()},
+				{Key: noteVersionKey, Value: bson.D{{Key: "$add", Value: bson.A{
+					bson.D{{Key: "$ifNull", Value: bson.A{"$" + noteVersionKey, 0}}}, 1,
+				}}}},
+			}}},
 		},
-	},
-		updatedNote,
-	)
+		options.FindOneAndUpdate().SetReturnDocument(options.Before),
+	).Decode(&previous)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return d.currentTags(filter, noteRef, expectedVersion)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update the tags of note %s, error: %w", noteRef, err)
+	}
+
+	err = d.saveRevision(d.context(), previous)
+	if err != nil {
+		return nil, err
+	}
+
+	changed, _ := changeTags(previous.Tags, add, remove)
+
+	return changed, nil
+}
+
+// currentTags returns the tags of the note when a change of its tags didn't update it, which is either
+// because the change is already applied, or because the note doesn't exist or doesn't have the expected version
+func (d *database) currentTags(filter bson.D, noteRef string, expectedVersion int64) ([]string, error) {
+	note, err := d.findNote(filter, nil)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, mongo.ErrNoDocuments
+	}
 	if err != nil {
-		return fmt.Errorf("failed to update note '%s', error: %w", noteTitle, err)
+		return nil, fmt.Errorf("failed to update the tags of note %s, error: %w", noteRef, err)
+	}
+
+	if !matchesVersion(note, expectedVersion) {
+		return nil, fmt.Errorf("failed to update the tags of note %s, error: %w", noteRef, ErrVersionConflict)
+	}
+
+	tags, _ := changeTags(note.Tags, nil, nil)
+
+	return tags, nil
+}
+
+// setNoteFields sets the fields of the note, either a whole note or only some of its fields,
+// and increments its version in a single atomic update, saved along with its revision
+func (d *database) setNoteFields(filter bson.D, noteRef string, fields interface{}, expectedVersion int64) error {
+	return d.inTransaction(func(transaction *database) error {
+		return transaction.updateNoteFields(filter, noteRef, fields, expectedVersion)
+	})
+}
+
+func (d *database) updateNoteFields(filter bson.D, noteRef string, fields interface{}, expectedVersion int64) error {
+	previous := model.Note{}
+
+	err := d.collection.FindOneAndUpdate(d.context(),
+		getVersionFilter(filter, expectedVersion),
+		bson.D{
+			{Key: "$set", Value: fields},
+			{Key: "$inc", Value: bson.D{{Key: noteVersionKey, Value: 1}}},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.Before).SetCollation(d.filterCollationOption(filter)),
+	).Decode(&previous)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return d.versionConflictOrMissing(filter, noteRef, expectedVersion, "update")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update note %s, error: %w", noteRef, err)
+	}
+
+	return d.saveRevision(d.context(), previous)
+}
+
+// versionConflictOrMissing tells apart a note that doesn't exist from a note
+// that didn't match the expected version, after a conditional change matched no note
+func (d *database) versionConflictOrMissing(filter bson.D, noteRef string, expectedVersion int64, action string) error {
+	if expectedVersion == AnyVersion {
+		return mongo.ErrNoDocuments
 	}
 
-	if result.MatchedCount == 0 {
+	_, err := d.findNote(filter, nil)
+	if errors.Is(err, mongo.ErrNoDocuments) {
 		return mongo.ErrNoDocuments
 	}
+	if err != nil {
+		return fmt.Errorf("failed to %s note %s, error: %w", action, noteRef, err)
+	}
 
-	return nil
+	return fmt.Errorf("failed to %s note %s, error: %w", action, noteRef, ErrVersionConflict)
+}
+
+// getVersionFilter restricts the filter to the expected version of the note
+func getVersionFilter(filter bson.D, expectedVersion int64) bson.D {
+	if expectedVersion == AnyVersion {
+		return filter
+	}
+
+	var version interface{} = expectedVersion
+	// the notes stored before the versions were introduced have no version, which is read as 0
+	if expectedVersion == 0 {
+		version = nil
+	}
+
+	versionFilter := append(bson.D{}, filter...)
+
+	return append(versionFilter, bson.E{Key: noteVersionKey, Value: version})
 }
 
 func (d *database) GetNote(noteTitle string) (model.Note, error) {
-	result := d.collection.FindOne(ctx, bson.D{
-		{
-			Key:   noteTitlePrimaryKey,
-			Value: noteTitle,
-		},
-	},
-	)
+	return d.findNote(getTitleFilter(noteTitle), nil)
+}
+
+func (d *database) GetNoteByID(noteID string) (model.Note, error) {
+	return d.findNote(getIDFilter(noteID), nil)
+}
+
+func (d *database) GetProjectedNote(noteTitle string, fields Projection) (model.Note, error) {
+	return d.findNote(getTitleFilter(noteTitle), fields)
+}
+
+func (d *database) GetProjectedNoteByID(noteID string, fields Projection) (model.Note, error) {
+	return d.findNote(getIDFilter(noteID), fields)
+}
+
+func (d *database) findNote(filter bson.D, fields Projection) (model.Note, error) {
+	findOptions := []*options.FindOneOptions{}
+	if collation := d.filterCollationOption(filter); collation != nil {
+		findOptions = append(findOptions, options.FindOne().SetCollation(collation))
+	}
+
+	if fields != nil {
+		findOptions = append(findOptions, options.FindOne().SetProjection(getProjectionOption(fields)))
+	}
+
+	result := d.collection.FindOne(d.context(), filter, findOptions...)
 
 	note := model.Note{}
 
@@ -59,8 +403,69 @@ func (d *database) GetNote(noteTitle string) (model.Note, error) {
 	return note, nil
 }
 
+// filterCollationOption returns the collation of the filter, the filters on the title match it
+// the same way as the unique index on the title
+func (d *database) filterCollationOption(filter bson.D) *options.Collation {
+	if len(filter) == 0 || filter[0].Key != noteTitleKey {
+		return nil
+	}
+
+	return d.titleCollationOption()
+}
+
+func getTitleFilter(noteTitle string) bson.D {
+	return bson.D{
+		{
+			Key:   noteTitleKey,
+			Value: noteTitle,
+		},
+		getLiveFilter(),
+	}
+}
+
+// getLiveFilter excludes the notes in the trash
+func getLiveFilter() bson.E {
+	return bson.E{
+		Key: noteDeletedAtKey,
+		Value: bson.D{
+			{
+				Key:   "$exists",
+				Value: false,
+			},
+		},
+	}
+}
+
+func getIDFilter(noteID string) bson.D {
+	// notes created before the ids were generated by the API have an ObjectID,
+	// which is decoded into its hex representation
+	objectID, err := primitive.ObjectIDFromHex(noteID)
+	if err != nil {
+		return bson.D{
+			{
+				Key:   noteIDKey,
+				Value: noteID,
+			},
+			getLiveFilter(),
+		}
+	}
+
+	return bson.D{
+		{
+			Key: noteIDKey,
+			Value: bson.D{
+				{
+					Key:   "$in",
+					Value: bson.A{noteID, objectID},
+				},
+			},
+		},
+		getLiveFilter(),
+	}
+}
+
 func (d *database) GetNotes() ([]model.Note, error) {
-	cursor, err := d.collection.Find(ctx, bson.D{})
+	cursor, err := d.collection.Find(ctx, bson.D{getLiveFilter()})
 	if err != nil {
 		return []model.Note{}, fmt.Errorf("failed to get notes from collection, error: %w", err)
 	}
@@ -75,56 +480,236 @@ func (d *database) GetNotes() ([]model.Note, error) {
 	return notes, nil
 }
 
-func (d *database) GetNotesFiltered(tags []string, category, date string) ([]model.Note, error) {
+func (d *database) GetNotesFiltered(where filter.Expr, tags TagFilter, category string, timeRange TimeRange, sortBy []SortField, page Page, fields Projection) ([]model.Note, string, error) {
+	sortBy, err := getSort(sortBy)
+	if err != nil {
+		return []model.Note{}, "", err
+	}
+
+	position, err := decodeCursor(page.Cursor, sortBy)
+	if err != nil {
+		return []model.Note{}, "", err
+	}
 
+	whereFilter := getWhereFilter(where)
 	tagsFilter := getTagsFilter(tags)
 	categoryFilter := getCategoryFilter(category)
-	dateFilter := getDateFilter(date)
+	timeRangeFilter := getTimeRangeFilter(timeRange)
+	pageFilter := getPageFilter(position, sortBy)
+
+	findOptions := options.Find().SetSort(getSortOption(sortBy)).SetCollation(d.sortCollationOption(sortBy))
+	if fields != nil {
+		findOptions.SetProjection(getProjectionOption(fields.withSort(sortBy)))
+	}
+
+	if page.Limit > 0 {
+		// the extra note tells whether there is a next page
+		findOptions.SetLimit(page.Limit + 1)
+	}
 
 	cursor, err := d.collection.Find(ctx, bson.D{
+		getLiveFilter(),
+		whereFilter,
 		tagsFilter,
 		categoryFilter,
-		dateFilter,
-	})
+		timeRangeFilter,
+		pageFilter,
+	}, findOptions)
 	if err != nil {
-		return []model.Note{}, fmt.Errorf("failed to get notes from collection, error: %w", err)
+		return []model.Note{}, "", fmt.Errorf("failed to get notes from collection, error: %w", err)
 	}
 
 	var notes []model.Note
 	err = cursor.All(ctx, &notes)
 	if err != nil {
-		return []model.Note{}, fmt.Errorf("failed to get notes from collection, error: %w", err)
+		return []model.Note{}, "", fmt.Errorf("failed to get notes from collection, error: %w", err)
 	}
 
-	return notes, nil
+	notes, next := cutPage(notes, sortBy, page.Limit)
+
+	return notes, next, nil
+}
+
+func (d *database) StreamNotes(where filter.Expr, tags TagFilter, category string, timeRange TimeRange, sortBy []SortField, each func(note model.Note) error) error {
+	sortBy, err := getSort(sortBy)
+	if err != nil {
+		return err
+	}
+
+	cursor, err := d.collection.Find(ctx, bson.D{
+		getLiveFilter(),
+		getWhereFilter(where),
+		getTagsFilter(tags),
+		getCategoryFilter(category),
+		getTimeRangeFilter(timeRange),
+	}, options.Find().SetSort(getSortOption(sortBy)).SetCollation(d.sortCollationOption(sortBy)))
+	if err != nil {
+		return fmt.Errorf("failed to stream notes from collection, error: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	// the cursor reads the notes by batches, only one batch is in memory at a time
+	for cursor.Next(ctx) {
+		note := model.Note{}
+
+		err = cursor.Decode(&note)
+		if err != nil {
+			return fmt.Errorf("failed to decode note into object, error: %w", err)
+		}
+
+		err = each(note)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = cursor.Err()
+	if err != nil {
+		return fmt.Errorf("failed to stream notes from collection, error: %w", err)
+	}
+
+	return nil
 }
 
-func getTagsFilter(tags []string) bson.E {
-	// an empty slice is considered a slice with 1 element with the value ""
-	// beause the empty query parameter ?tags= results in that slice when split by ','
-	if len(tags) == 1 && tags[0] == "" {
+// getSortOption orders the notes by the sorted fields then by id
+func getSortOption(sortBy []SortField) bson.D {
+	option := bson.D{}
+	for _, field := range sortBy {
+		option = append(option, bson.E{Key: field.Field, Value: sortDirection(field.Descending)})
+	}
+
+	return append(option, bson.E{Key: noteIDKey, Value: sortDirection(idDescending(sortBy))})
+}
+
+func sortDirection(descending bool) int {
+	if descending {
+		return -1
+	}
+
+	return 1
+}
+
+// getPageFilter matches the notes listed after the position: the notes whose first sorted field is after the
+// value of the position, or with the same first value and whose second field is after, and so on up to the id
+func getPageFilter(position *pagePosition, sortBy []SortField) bson.E {
+	if position == nil {
 		return bson.E{}
 	}
 
+	return getPositionFilter(position, sortBy, getIDAfterFilter(position.ID, idDescending(sortBy)))
+}
+
+// getPositionFilter matches the documents after the position in the sort, idAfterFilter matches the ids after its id
+func getPositionFilter(position *pagePosition, sortBy []SortField, idAfterFilter bson.E) bson.E {
+
+	branches := bson.A{}
+	equal := bson.D{}
+
+	for i, field := range sortBy {
+		operator := "$gt"
+		if field.Descending {
+			operator = "$lt"
+		}
+
+		branch := append(bson.D{}, equal...)
+		branch = append(branch, bson.E{Key: field.Field, Value: bson.D{{Key: operator, Value: position.Values[i]}}})
+		branches = append(branches, branch)
+
+		equal = append(equal, bson.E{Key: field.Field, Value: position.Values[i]})
+	}
+
+	branches = append(branches, append(equal, idAfterFilter))
+
 	return bson.E{
-		Key: "tags",
-		Value: bson.D{
-			{
-				Key:   "$all",
-				Value: tags,
+		Key:   "$or",
+		Value: branches,
+	}
+}
+
+// getIDAfterFilter matches the ids sorted after the id. The notes created before the ids were generated
+// by the API have an ObjectID, which MongoDB sorts after all the string ids and compares only to ObjectIDs.
+func getIDAfterFilter(noteID string, descending bool) bson.E {
+	objectID, err := primitive.ObjectIDFromHex(noteID)
+	if err == nil {
+		if !descending {
+			return bson.E{Key: noteIDKey, Value: bson.D{{Key: "$gt", Value: objectID}}}
+		}
+
+		return bson.E{
+			Key: "$or",
+			Value: bson.A{
+				bson.D{{Key: noteIDKey, Value: bson.D{{Key: "$lt", Value: objectID}}}},
+				bson.D{{Key: noteIDKey, Value: bson.D{{Key: "$type", Value: "string"}}}},
 			},
+		}
+	}
+
+	if descending {
+		return bson.E{Key: noteIDKey, Value: bson.D{{Key: "$lt", Value: noteID}}}
+	}
+
+	return bson.E{
+		Key: "$or",
+		Value: bson.A{
+			bson.D{{Key: noteIDKey, Value: bson.D{{Key: "$gt", Value: noteID}}}},
+			bson.D{{Key: noteIDKey, Value: bson.D{{Key: "$type", Value: "objectId"}}}},
 		},
 	}
 }
 
-func getDateFilter(date string) bson.E {
-	if date == "" {
+func getTagsFilter(tags TagFilter) bson.E {
+	// the first tag of the notes without tags doesn't exist, whether they have an empty array or no tags at all,
+	// and they don't have any of the excluded tags
+	if tags.Untagged {
+		return bson.E{
+			Key:   noteTagsKey + ".0",
+			Value: bson.D{{Key: "$exists", Value: false}},
+		}
+	}
+
+	operators := bson.D{}
+
+	if len(tags.Tags) > 0 {
+		operator := "$all"
+		if tags.Any {
+			operator = "$in"
+		}
+
+		operators = append(operators, bson.E{Key: operator, Value: tags.Tags})
+	}
+
+	if len(tags.Excluded) > 0 {
+		operators = append(operators, bson.E{Key: "$nin", Value: tags.Excluded})
+	}
+
+	if len(operators) == 0 {
 		return bson.E{}
 	}
 
 	return bson.E{
-		Key:   "date",
-		Value: date,
+		Key:   noteTagsKey,
+		Value: operators,
+	}
+}
+
+func getTimeRangeFilter(timeRange TimeRange) bson.E {
+	bounds := bson.D{}
+
+	if !timeRange.From.IsZero() {
+		bounds = append(bounds, bson.E{Key: "$gte", Value: timeRange.From})
+	}
+
+	if !timeRange.To.IsZero() {
+		bounds = append(bounds, bson.E{Key: "$lt", Value: timeRange.To})
+	}
+
+	if len(bounds) == 0 {
+		return bson.E{}
+	}
+
+	return bson.E{
+		Key:   timeRange.Field,
+		Value: bounds,
 	}
 }
 
@@ -134,42 +719,116 @@ func getCategoryFilter(category string) bson.E {
 	}
 
 	return bson.E{
-		Key:   "category",
+		Key:   noteCategoryKey,
 		Value: category,
 	}
 }
 
-func (d *database) DeleteNote(noteTitle string) error {
-	result, err := d.collection.DeleteOne(ctx,
-		bson.D{
-			{
-				Key:   noteTitlePrimaryKey,
-				Value: noteTitle,
-			},
-		},
-	)
+func (d *database) DeleteNote(noteTitle string, expectedVersion int64) error {
+	return d.deleteNote(getTitleFilter(noteTitle), fmt.Sprintf("'%s'", noteTitle), expectedVersion)
+}
 
-	if err != nil {
-		return fmt.Errorf("failed to delete note '%s' from collection, error: %w", noteTitle, err)
-	}
+func (d *database) DeleteNoteByID(noteID string, expectedVersion int64) error {
+	return d.deleteNote(getIDFilter(noteID), fmt.Sprintf("with id '%s'", noteID), expectedVersion)
+}
 
-	if result.DeletedCount == 0 {
-		return mongo.ErrNoDocuments
+// deleteNote moves the note to the trash, along with its revision
+func (d *database) deleteNote(filter bson.D, noteRef string, expectedVersion int64) error {
+	return d.inTransaction(func(transaction *database) error {
+		return transaction.trashNote(filter, noteRef, expectedVersion)
+	})
+}
+
+func (d *database) trashNote(filter bson.D, noteRef string, expectedVersion int64) error {
+	previous := model.Note{}
+
+	err := d.collection.FindOneAndUpdate(d.context(),
+		getVersionFilter(filter, expectedVersion),
+		getTrashUpdate(time.Now().UTC()),
+		options.FindOneAndUpdate().SetReturnDocument(options.Before).SetCollation(d.filterCollationOption(filter)),
+	).Decode(&previous)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return d.versionConflictOrMissing(filter, noteRef, expectedVersion, "delete")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete note %s from collection, error: %w", noteRef, err)
 	}
 
-	return nil
+	return d.saveRevision(d.context(), previous)
 }
 
+// DeleteNotes moves all the notes to the trash with one update, after saving their revisions
+// from a cursor, so only one batch of notes is in memory at a time
 func (d *database) DeleteNotes() error {
-	result, err := d.collection.DeleteMany(ctx, bson.D{})
+	return d.inTransaction(func(transaction *database) error {
+		return transaction.trashNotes()
+	})
+}
 
+func (d *database) trashNotes() error {
+	cursor, err := d.collection.Find(d.context(), bson.D{getLiveFilter()}, options.Find().SetBatchSize(deleteNotesBatchSize))
 	if err != nil {
-		return fmt.Errorf("failed to delete notes  from collection, error: %w", err)
+		return fmt.Errorf("failed to delete notes from collection, error: %w", err)
 	}
+	defer cursor.Close(d.context())
+
+	for cursor.Next(d.context()) {
+		note := model.Note{}
 
-	if result.DeletedCount == 0 {
+		err = cursor.Decode(&note)
+		if err != nil {
+			return fmt.Errorf("failed to decode note into object, error: %w", err)
+		}
+
+		err = d.saveRevision(d.context(), note)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = cursor.Err()
+	if err != nil {
+		return fmt.Errorf("failed to delete notes from collection, error: %w", err)
+	}
+
+	// the notes added after the cursor was read conflict with the transaction, which is then tried again,
+	// so every note moved to the trash has a revision
+	result, err := d.collection.UpdateMany(d.context(), bson.D{getLiveFilter()}, getTrashUpdate(time.Now().UTC()))
+	if err != nil {
+		return fmt.Errorf("failed to delete notes from collection, error: %w", err)
+	}
+
+	if result.ModifiedCount == 0 {
 		return mongo.ErrNoDocuments
 	}
 
 	return nil
 }
+
+func (d *database) ApplyOperations(operations []Operation, atomic bool) ([]error, error) {
+	if !atomic {
+		return applyEach(d, operations), nil
+	}
+
+	var errs []error
+
+	// the transaction is retried when it fails with a transient error, so the errors are set by the last attempt
+	err := d.inTransaction(func(transaction *database) error {
+		var applied bool
+
+		errs, applied = applyAll(transaction, operations)
+		if !applied {
+			return ErrNotApplied
+		}
+
+		return nil
+	})
+	if errors.Is(err, ErrNotApplied) {
+		return errs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply %d operations, error: %w", len(operations), err)
+	}
+
+	return errs, nil
+}
@@ -0,0 +1,1652 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/notes-project/api/pkg/filter"
+	"github.com/notes-project/api/pkg/model"
+	"github.com/notes-project/api/pkg/search"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+	Behavior shared by all the Database implementations that can be tested without mocks.
+*/
+
+// withoutTimestamps leaves out the times set when the notes are stored from the compared notes
+func withoutTimestamps(notes ...model.Note) []model.Note {
+	for i := range notes {
+		notes[i].CreatedAt = time.Time{}
+		notes[i].UpdatedAt = time.Time{}
+	}
+
+	return notes
+}
+
+func describeNotesBehavior(newDatabase func() Database) {
+
+	var (
+		dbInstance Database
+	)
+
+	BeforeEach(func() {
+		dbInstance = newDatabase()
+	})
+
+	Describe("AddNote", func() {
+		It("should add a note to the database", func() {
+			err := dbInstance.AddNote(model.Note{Title: "test"})
+			Expect(err).NotTo(HaveOccurred())
+
+			note, err := dbInstance.GetNote("test")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.Title).To(Equal("test"))
+		})
+
+		It("should generate an id when the note has none", func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "test"})).To(Succeed())
+
+			note, err := dbInstance.GetNote("test")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.ID).NotTo(BeEmpty())
+		})
+
+		It("should return a duplicate key error when the id already exists", func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "id", Title: "test1"})).To(Succeed())
+
+			err := dbInstance.AddNote(model.Note{ID: "id", Title: "test2"})
+			Expect(mongo.IsDuplicateKeyError(err)).To(BeTrue())
+		})
+
+		It("should return a duplicate key error when the title already exists", func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "test"})).To(Succeed())
+
+			err := dbInstance.AddNote(model.Note{Title: "test"})
+			Expect(mongo.IsDuplicateKeyError(err)).To(BeTrue())
+		})
+
+		It("should return a duplicate key error when the title already exists in another case without a locale", func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "Standup"})).To(Succeed())
+
+			err := dbInstance.AddNote(model.Note{Title: "standup"})
+			Expect(mongo.IsDuplicateKeyError(err)).To(BeTrue())
+		})
+
+		It("should set the creation and update times when the note has none", func() {
+			before := time.Now().Add(-time.Second)
+			Expect(dbInstance.AddNote(model.Note{Title: "test", Date: "01-Jan-2023"})).To(Succeed())
+
+			note, err := dbInstance.GetNote("test")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.CreatedAt).To(BeTemporally(">", before))
+			Expect(note.UpdatedAt).To(Equal(note.CreatedAt))
+			Expect(note.CreatedAt.Location()).To(Equal(time.UTC))
+			Expect(note.Date).To(BeEmpty())
+		})
+
+		It("should not share the tags with the caller", func() {
+			tags := []string{"test"}
+			Expect(dbInstance.AddNote(model.Note{Title: "test", Tags: tags})).To(Succeed())
+
+			tags[0] = "changed"
+
+			note, err := dbInstance.GetNote("test")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.Tags).To(Equal([]string{"test"}))
+		})
+	})
+
+	Describe("AddNotes", func() {
+		It("should add every note that is not a duplicate", func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "test1"})).To(Succeed())
+
+			errs, err := dbInstance.AddNotes([]model.Note{{Title: "test1"}, {Title: "test2"}, {Title: "test2"}, {Title: "test3"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(errs).To(HaveLen(4))
+			Expect(mongo.IsDuplicateKeyError(errs[0])).To(BeTrue())
+			Expect(errs[1]).NotTo(HaveOccurred())
+			Expect(mongo.IsDuplicateKeyError(errs[2])).To(BeTrue())
+			Expect(errs[3]).NotTo(HaveOccurred())
+
+			notes, err := dbInstance.GetNotes()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(3))
+
+			hits, _, err := dbInstance.SearchNotes("test3", nil, TagFilter{}, "", TimeRange{}, nil, Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hits).To(HaveLen(1))
+			Expect(hits[0].Version).To(Equal(int64(1)))
+		})
+	})
+
+	Describe("UpdateNote", func() {
+		BeforeEach(func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "test1", Description: "old"})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{Title: "test2"})).To(Succeed())
+		})
+
+		It("should replace the note when it is in the database", func() {
+			err := dbInstance.UpdateNote("test1", model.Note{Title: "test1", Description: "new"}, AnyVersion)
+			Expect(err).NotTo(HaveOccurred())
+
+			note, err := dbInstance.GetNote("test1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.Description).To(Equal("new"))
+		})
+
+		It("should keep the creation time and set the update time of the note", func() {
+			created := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+			Expect(dbInstance.AddNote(model.Note{Title: "test3", CreatedAt: created, UpdatedAt: created})).To(Succeed())
+
+			err := dbInstance.UpdateNote("test3", model.Note{Title: "test3", CreatedAt: time.Now()}, AnyVersion)
+			Expect(err).NotTo(HaveOccurred())
+
+			note, err := dbInstance.GetNote("test3")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.CreatedAt).To(Equal(created))
+			Expect(note.UpdatedAt).To(BeTemporally(">", created))
+		})
+
+		It("should set the update time of the updated note when it has one", func() {
+			created := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+			updated := created.Add(time.Hour)
+			Expect(dbInstance.AddNote(model.Note{Title: "test3", CreatedAt: created, UpdatedAt: created})).To(Succeed())
+
+			err := dbInstance.UpdateNote("test3", model.Note{Title: "test3", UpdatedAt: updated}, AnyVersion)
+			Expect(err).NotTo(HaveOccurred())
+
+			note, err := dbInstance.GetNote("test3")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.CreatedAt).To(Equal(created))
+			Expect(note.UpdatedAt).To(Equal(updated))
+		})
+
+		It("should keep the id of the note", func() {
+			before, err := dbInstance.GetNote("test1")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = dbInstance.UpdateNote("test1", model.Note{ID: "other", Title: "test1"}, AnyVersion)
+			Expect(err).NotTo(HaveOccurred())
+
+			after, err := dbInstance.GetNote("test1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(after.ID).To(Equal(before.ID))
+		})
+
+		It("should allow changing the title of the note", func() {
+			err := dbInstance.UpdateNote("test1", model.Note{Title: "test3"}, AnyVersion)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = dbInstance.GetNote("test1")
+			Expect(err).To(MatchError(mongo.ErrNoDocuments))
+
+			notes, err := dbInstance.GetNotes()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes[0].Title).To(Equal("test3"))
+		})
+
+		It("should return a duplicate key error when the new title already exists", func() {
+			err := dbInstance.UpdateNote("test1", model.Note{Title: "test2"}, AnyVersion)
+			Expect(mongo.IsDuplicateKeyError(err)).To(BeTrue())
+		})
+
+		It("should return an error when note to update is not in database", func() {
+			err := dbInstance.UpdateNote("missing", model.Note{Title: "missing"}, AnyVersion)
+			Expect(err).To(MatchError(mongo.ErrNoDocuments))
+		})
+	})
+
+	Describe("UpdateNoteByID", func() {
+		BeforeEach(func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "id1", Title: "test1", Description: "old"})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{ID: "id2", Title: "test2"})).To(Succeed())
+		})
+
+		It("should replace the note and keep its id", func() {
+			err := dbInstance.UpdateNoteByID("id1", model.Note{ID: "other", Title: "test3", Description: "new"}, AnyVersion)
+			Expect(err).NotTo(HaveOccurred())
+
+			note, err := dbInstance.GetNoteByID("id1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.Title).To(Equal("test3"))
+			Expect(note.Description).To(Equal("new"))
+
+			_, err = dbInstance.GetNoteByID("other")
+			Expect(err).To(MatchError(mongo.ErrNoDocuments))
+		})
+
+		It("should return a duplicate key error when the new title already exists", func() {
+			err := dbInstance.UpdateNoteByID("id1", model.Note{Title: "test2"}, AnyVersion)
+			Expect(mongo.IsDuplicateKeyError(err)).To(BeTrue())
+		})
+
+		It("should return an error when note to update is not in database", func() {
+			err := dbInstance.UpdateNoteByID("missing", model.Note{Title: "missing"}, AnyVersion)
+			Expect(err).To(MatchError(mongo.ErrNoDocuments))
+		})
+	})
+
+	Describe("UpsertNote", func() {
+		It("should add the note with the title when it is not in the database", func() {
+			created, err := dbInstance.UpsertNote("test", model.Note{ID: "other", Title: "ignored", Description: "new", Tags: []string{"a"}, Version: 7})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created).To(BeTrue())
+
+			note, err := dbInstance.GetNote("test")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.ID).NotTo(BeEmpty())
+			Expect(note.ID).NotTo(Equal("other"))
+			Expect(note.Description).To(Equal("new"))
+			Expect(note.Tags).To(Equal([]string{"a"}))
+			Expect(note.Version).To(Equal(int64(1)))
+			Expect(note.CreatedAt).NotTo(BeZero())
+			Expect(note.UpdatedAt).To(Equal(note.CreatedAt))
+
+			_, err = dbInstance.GetNote("ignored")
+			Expect(err).To(MatchError(mongo.ErrNoDocuments))
+		})
+
+		It("should replace the note with the title and keep its id", func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "id", Title: "test", Description: "old", Category: "work"})).To(Succeed())
+
+			created, err := dbInstance.UpsertNote("test", model.Note{Description: "new"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created).To(BeFalse())
+
+			note, err := dbInstance.GetNote("test")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.ID).To(Equal("id"))
+			Expect(note.Description).To(Equal("new"))
+			Expect(note.Category).To(BeEmpty())
+			Expect(note.Version).To(Equal(int64(2)))
+
+			revisions, err := dbInstance.GetRevisions("test")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revisions).To(HaveLen(1))
+			Expect(revisions[0].Note.Description).To(Equal("old"))
+
+			hits, _, err := dbInstance.SearchNotes("new", nil, TagFilter{}, "", TimeRange{}, nil, Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hits).To(HaveLen(1))
+		})
+
+		It("should add a new note when the note with the title is in the trash", func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "id", Title: "test", Description: "old"})).To(Succeed())
+			Expect(dbInstance.DeleteNote("test", AnyVersion)).To(Succeed())
+
+			created, err := dbInstance.UpsertNote("test", model.Note{Description: "new"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created).To(BeTrue())
+
+			note, err := dbInstance.GetNote("test")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.ID).NotTo(Equal("id"))
+		})
+
+		It("should add the note once when upserted concurrently", func() {
+			wg := sync.WaitGroup{}
+			createdCount := 0
+			mu := sync.Mutex{}
+
+			for i := 0; i < 5; i++ {
+				wg.Add(1)
+
+				go func(i int) {
+					defer GinkgoRecover()
+					defer wg.Done()
+
+					created, err := dbInstance.UpsertNote("test", model.Note{Description: fmt.Sprintf("%d", i)})
+					Expect(err).NotTo(HaveOccurred())
+
+					if created {
+						mu.Lock()
+						createdCount++
+						mu.Unlock()
+					}
+				}(i)
+			}
+
+			wg.Wait()
+
+			Expect(createdCount).To(Equal(1))
+
+			note, err := dbInstance.GetNote("test")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.Version).To(Equal(int64(5)))
+		})
+	})
+
+	Describe("ChangeTags", func() {
+		BeforeEach(func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "id", Title: "test", Description: "old", Tags: []string{"a", "b"}})).To(Succeed())
+		})
+
+		It("should add the missing tags and remove the removed ones in order", func() {
+			tags, err := dbInstance.ChangeTags("test", []string{"c", "a", "d", "c"}, []string{"b"}, 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tags).To(Equal([]string{"a", "c", "d"}))
+
+			note, err := dbInstance.GetNote("test")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.Tags).To(Equal([]string{"a", "c", "d"}))
+			Expect(note.Description).To(Equal("old"))
+			Expect(note.Version).To(Equal(int64(2)))
+
+			revisions, err := dbInstance.GetRevisions("test")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revisions).To(HaveLen(1))
+			Expect(revisions[0].Note.Tags).To(Equal([]string{"a", "b"}))
+
+			notes, _, err := dbInstance.GetNotesFiltered(nil, TagFilter{Tags: []string{"d"}}, "", TimeRange{}, nil, Page{}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(1))
+
+			hits, _, err := dbInstance.SearchNotes("old", nil, TagFilter{Tags: []string{"d"}}, "", TimeRange{}, nil, Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hits).To(HaveLen(1))
+		})
+
+		It("should not update the note when the change is already applied", func() {
+			tags, err := dbInstance.ChangeTags("test", []string{"a"}, []string{"c"}, AnyVersion)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tags).To(Equal([]string{"a", "b"}))
+
+			note, err := dbInstance.GetNote("test")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.Version).To(Equal(int64(1)))
+		})
+
+		It("should remove all the tags", func() {
+			tags, err := dbInstance.ChangeTags("test", nil, []string{"a", "b"}, AnyVersion)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tags).To(BeEmpty())
+			Expect(tags).NotTo(BeNil())
+		})
+
+		It("should keep every change made concurrently", func() {
+			wg := sync.WaitGroup{}
+
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+
+				go func(i int) {
+					defer GinkgoRecover()
+					defer wg.Done()
+
+					_, err := dbInstance.ChangeTags("test", []string{fmt.Sprintf("t%d", i)}, nil, AnyVersion)
+					Expect(err).NotTo(HaveOccurred())
+				}(i)
+			}
+
+			wg.Wait()
+
+			note, err := dbInstance.GetNote("test")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.Tags).To(HaveLen(12))
+			Expect(note.Version).To(Equal(int64(11)))
+		})
+
+		It("should return a version conflict when the note has another version", func() {
+			_, err := dbInstance.ChangeTags("test", []string{"c"}, nil, 2)
+			Expect(err).To(MatchError(ErrVersionConflict))
+
+			_, err = dbInstance.ChangeTags("test", []string{"a"}, nil, 2)
+			Expect(err).To(MatchError(ErrVersionConflict))
+		})
+
+		It("should return an error when the note is not in database", func() {
+			_, err := dbInstance.ChangeTags("missing", []string{"a"}, nil, AnyVersion)
+			Expect(err).To(MatchError(mongo.ErrNoDocuments))
+		})
+	})
+
+	Describe("PatchNote", func() {
+		BeforeEach(func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "id1", Title: "test1", Description: "old", Category: "work", Tags: []string{"a", "b"}})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{ID: "id2", Title: "test2", Description: "other"})).To(Succeed())
+		})
+
+		It("should only change the patched fields and keep a revision", func() {
+			tags := []string{"b", "c"}
+
+			err := dbInstance.PatchNote("test1", NotePatch{Tags: &tags}, 1)
+			Expect(err).NotTo(HaveOccurred())
+
+			note, err := dbInstance.GetNoteByID("id1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.Title).To(Equal("test1"))
+			Expect(note.Description).To(Equal("old"))
+			Expect(note.Category).To(Equal("work"))
+			Expect(note.Tags).To(Equal([]string{"b", "c"}))
+			Expect(note.Version).To(Equal(int64(2)))
+			Expect(note.UpdatedAt).NotTo(BeTemporally("<", note.CreatedAt))
+
+			revisions, err := dbInstance.GetRevisions("test1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revisions).To(HaveLen(1))
+			Expect(revisions[0].Note.Tags).To(Equal([]string{"a", "b"}))
+		})
+
+		It("should rename the note found by its id and index it for the search", func() {
+			title := "renamed"
+			category := ""
+
+			err := dbInstance.PatchNoteByID("id1", NotePatch{Title: &title, Category: &category}, AnyVersion)
+			Expect(err).NotTo(HaveOccurred())
+
+			note, err := dbInstance.GetNote("renamed")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.ID).To(Equal("id1"))
+			Expect(note.Description).To(Equal("old"))
+			Expect(note.Category).To(BeEmpty())
+
+			hits, _, err := dbInstance.SearchNotes("renamed", nil, TagFilter{}, "", TimeRange{}, nil, Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hits).To(HaveLen(1))
+		})
+
+		It("should return a duplicate key error when the new title already exists", func() {
+			title := "test2"
+
+			err := dbInstance.PatchNote("test1", NotePatch{Title: &title}, AnyVersion)
+			Expect(mongo.IsDuplicateKeyError(err)).To(BeTrue())
+		})
+
+		It("should return a version conflict when the note has another version", func() {
+			description := "new"
+
+			err := dbInstance.PatchNoteByID("id1", NotePatch{Description: &description}, 2)
+			Expect(err).To(MatchError(ErrVersionConflict))
+
+			note, err := dbInstance.GetNoteByID("id1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.Description).To(Equal("old"))
+		})
+
+		It("should return an error when the note to patch is not in database", func() {
+			Expect(dbInstance.PatchNote("missing", NotePatch{}, AnyVersion)).To(MatchError(mongo.ErrNoDocuments))
+			Expect(dbInstance.PatchNoteByID("missing", NotePatch{}, AnyVersion)).To(MatchError(mongo.ErrNoDocuments))
+		})
+	})
+
+	Describe("GetNote", func() {
+		It("should return an error when the note is not in database", func() {
+			_, err := dbInstance.GetNote("missing")
+			Expect(err).To(MatchError(mongo.ErrNoDocuments))
+		})
+	})
+
+	Describe("GetProjectedNote", func() {
+		It("should only read the fields of the projection", func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "test", Description: "long", Category: "work", Tags: []string{"a"}})).To(Succeed())
+
+			note, err := dbInstance.GetProjectedNote("test", Projection{"title", "date"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.ID).NotTo(BeEmpty())
+			Expect(note.Title).To(Equal("test"))
+			Expect(note.UpdatedAt).NotTo(BeZero())
+			Expect(note.Description).To(BeEmpty())
+			Expect(note.Tags).To(BeEmpty())
+
+			note, err = dbInstance.GetProjectedNoteByID(note.ID, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.Description).To(Equal("long"))
+		})
+
+		It("should return an error when the note doesn't exist", func() {
+			_, err := dbInstance.GetProjectedNote("missing", Projection{"title"})
+			Expect(errors.Is(err, mongo.ErrNoDocuments)).To(BeTrue())
+		})
+	})
+
+	Describe("GetNoteByID", func() {
+		It("should return the note with the id", func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "id", Title: "test"})).To(Succeed())
+
+			note, err := dbInstance.GetNoteByID("id")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.Title).To(Equal("test"))
+		})
+
+		It("should return an error when the note is not in database", func() {
+			_, err := dbInstance.GetNoteByID("missing")
+			Expect(err).To(MatchError(mongo.ErrNoDocuments))
+		})
+	})
+
+	Describe("GetNotes", func() {
+		It("should return an empty slice when there are no notes", func() {
+			notes, err := dbInstance.GetNotes()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).NotTo(BeNil())
+			Expect(notes).To(BeEmpty())
+		})
+
+		It("should return the notes in insertion order", func() {
+			for i := 0; i < 10; i++ {
+				Expect(dbInstance.AddNote(model.Note{Title: fmt.Sprintf("test%d", i)})).To(Succeed())
+			}
+
+			notes, err := dbInstance.GetNotes()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(10))
+			for i, note := range notes {
+				Expect(note.Title).To(Equal(fmt.Sprintf("test%d", i)))
+			}
+		})
+	})
+
+	Describe("GetNotesFiltered", func() {
+		day := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+		BeforeEach(func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "test1", Category: "work", UpdatedAt: day.Add(time.Hour), Tags: []string{"a", "b"}})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{Title: "test2", Category: "home", UpdatedAt: day, Tags: []string{"a"}})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{Title: "test3", Category: "work", UpdatedAt: day.AddDate(0, 0, 1)})).To(Succeed())
+		})
+
+		It("should return all notes when no filters are provided", func() {
+			notes, _, err := dbInstance.GetNotesFiltered(nil, TagFilter{}, "", TimeRange{}, nil, Page{}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(3))
+		})
+
+		It("should return the notes that contain all the tags", func() {
+			notes, _, err := dbInstance.GetNotesFiltered(nil, TagFilter{Tags: []string{"a", "b"}}, "", TimeRange{}, nil, Page{}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(1))
+			Expect(notes[0].Title).To(Equal("test1"))
+		})
+
+		It("should return the notes that contain any of the tags", func() {
+			notes, _, err := dbInstance.GetNotesFiltered(nil, TagFilter{Tags: []string{"b", "c"}, Any: true}, "", TimeRange{}, nil, Page{}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(1))
+			Expect(notes[0].Title).To(Equal("test1"))
+
+			notes, _, err = dbInstance.GetNotesFiltered(nil, TagFilter{Tags: []string{"a", "b"}, Any: true}, "", TimeRange{}, nil, Page{}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(2))
+		})
+
+		It("should return the notes that contain none of the excluded tags", func() {
+			notes, _, err := dbInstance.GetNotesFiltered(nil, TagFilter{Excluded: []string{"b"}}, "", TimeRange{}, nil, Page{}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(2))
+			Expect(notes[0].Title).To(Equal("test2"))
+			Expect(notes[1].Title).To(Equal("test3"))
+
+			notes, _, err = dbInstance.GetNotesFiltered(nil, TagFilter{Tags: []string{"a"}, Excluded: []string{"b"}}, "", TimeRange{}, nil, Page{}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(1))
+			Expect(notes[0].Title).To(Equal("test2"))
+		})
+
+		It("should return the notes without tags", func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "test4", Tags: []string{}, UpdatedAt: day.AddDate(0, 0, 2)})).To(Succeed())
+
+			notes, _, err := dbInstance.GetNotesFiltered(nil, TagFilter{Untagged: true}, "", TimeRange{}, nil, Page{}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(2))
+			Expect(notes[0].Title).To(Equal("test3"))
+			Expect(notes[1].Title).To(Equal("test4"))
+		})
+
+		It("should return the notes that match the category", func() {
+			notes, _, err := dbInstance.GetNotesFiltered(nil, TagFilter{}, "work", TimeRange{}, nil, Page{}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(2))
+		})
+
+		It("should return the notes updated in the time range, excluding its end", func() {
+			notes, _, err := dbInstance.GetNotesFiltered(nil, TagFilter{}, "", TimeRange{Field: UpdatedAtField, From: day, To: day.AddDate(0, 0, 1)}, nil, Page{}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(2))
+			Expect(notes[0].Title).To(Equal("test2"))
+			Expect(notes[1].Title).To(Equal("test1"))
+		})
+
+		It("should return the notes created in the time range", func() {
+			notes, _, err := dbInstance.GetNotesFiltered(nil, TagFilter{}, "", TimeRange{Field: CreatedAtField, From: day.AddDate(0, 0, 1)}, nil, Page{}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(1))
+			Expect(notes[0].Title).To(Equal("test3"))
+		})
+
+		It("should return the notes that match all the filters", func() {
+			notes, _, err := dbInstance.GetNotesFiltered(nil, TagFilter{Tags: []string{"a"}}, "work", TimeRange{Field: UpdatedAtField, From: day, To: day.AddDate(0, 0, 1)}, nil, Page{}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(1))
+			Expect(notes[0].Title).To(Equal("test1"))
+		})
+
+		Context("with a structured filter", func() {
+			// titles returns the titles of the notes that match the filter in order of creation
+			titles := func(text string) []string {
+				where, err := filter.Parse(text, time.UTC)
+				Expect(err).NotTo(HaveOccurred())
+
+				notes, _, err := dbInstance.GetNotesFiltered(where, TagFilter{}, "", TimeRange{}, nil, Page{}, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				titles := []string{}
+				for _, note := range notes {
+					titles = append(titles, note.Title)
+				}
+
+				return titles
+			}
+
+			It("should return the notes that match the comparisons combined with AND, OR and NOT", func() {
+				Expect(titles("category = work AND NOT tag = b")).To(Equal([]string{"test3"}))
+				Expect(titles("tag = b OR category = home")).To(Equal([]string{"test2", "test1"}))
+				Expect(titles("category != work AND (tag = a OR tag = c)")).To(Equal([]string{"test2"}))
+				Expect(titles("NOT (tag = a AND tag = b)")).To(Equal([]string{"test2", "test3"}))
+			})
+
+			It("should return the notes whose field is any of the values", func() {
+				Expect(titles("category IN (home, other)")).To(Equal([]string{"test2"}))
+				Expect(titles("tag IN (b, c)")).To(Equal([]string{"test1"}))
+				Expect(titles("title in (test3, TEST1)")).To(Equal([]string{"test3"}))
+			})
+
+			It("should return the notes whose field is not empty", func() {
+				Expect(titles("tag EXISTS")).To(Equal([]string{"test2", "test1"}))
+				Expect(titles("NOT tags exists")).To(Equal([]string{"test3"}))
+				Expect(titles("description exists")).To(BeEmpty())
+				Expect(titles(`category = ""`)).To(BeEmpty())
+			})
+
+			It("should return the notes whose time is in the bounds", func() {
+				Expect(titles("updatedAt >= 2023-01-01T01:00:00Z")).To(Equal([]string{"test1", "test3"}))
+				Expect(titles("updatedAt > 2023-01-01T01:00:00Z")).To(Equal([]string{"test3"}))
+				Expect(titles("createdAt < 01-Jan-2023 OR createdAt <= 2023-01-01T00:00:00Z")).To(Equal([]string{"test2"}))
+			})
+
+			It("should combine the structured filter with the other filters", func() {
+				where, err := filter.Parse("tag = a", time.UTC)
+				Expect(err).NotTo(HaveOccurred())
+
+				notes, _, err := dbInstance.GetNotesFiltered(where, TagFilter{}, "work", TimeRange{}, nil, Page{}, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(notes).To(HaveLen(1))
+				Expect(notes[0].Title).To(Equal("test1"))
+			})
+		})
+
+		It("should only read the fields of the projection and the sorted fields", func() {
+			notes, _, err := dbInstance.GetNotesFiltered(nil, TagFilter{}, "", TimeRange{}, []SortField{{Field: CategoryField}}, Page{}, Projection{"title", "tags"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(3))
+
+			for _, note := range notes {
+				Expect(note.ID).NotTo(BeEmpty())
+				Expect(note.Title).NotTo(BeEmpty())
+				Expect(note.Category).NotTo(BeEmpty())
+				Expect(note.Description).To(BeEmpty())
+				Expect(note.UpdatedAt).To(BeZero())
+				Expect(note.Version).To(BeZero())
+			}
+
+			Expect(notes[0].Tags).To(Equal([]string{"a"}))
+		})
+
+		It("should return the projected notes by pages", func() {
+			notes, next, err := dbInstance.GetNotesFiltered(nil, TagFilter{}, "", TimeRange{}, nil, Page{Limit: 2}, Projection{"id"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(2))
+			Expect(notes[0].Title).To(BeEmpty())
+
+			notes, _, err = dbInstance.GetNotesFiltered(nil, TagFilter{}, "", TimeRange{}, nil, Page{Limit: 2, Cursor: next}, Projection{"id"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(1))
+			Expect(notes[0].CreatedAt).To(Equal(day.AddDate(0, 0, 1)))
+		})
+
+		It("should return the notes by pages in order of creation", func() {
+			notes, next, err := dbInstance.GetNotesFiltered(nil, TagFilter{}, "", TimeRange{}, nil, Page{Limit: 2}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(2))
+			Expect(notes[0].Title).To(Equal("test2"))
+			Expect(notes[1].Title).To(Equal("test1"))
+			Expect(next).NotTo(BeEmpty())
+
+			notes, next, err = dbInstance.GetNotesFiltered(nil, TagFilter{}, "", TimeRange{}, nil, Page{Limit: 2, Cursor: next}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(1))
+			Expect(notes[0].Title).To(Equal("test3"))
+			Expect(next).To(BeEmpty())
+		})
+
+		It("should not return a next cursor when the last page is full", func() {
+			notes, next, err := dbInstance.GetNotesFiltered(nil, TagFilter{}, "", TimeRange{}, nil, Page{Limit: 3}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(3))
+			Expect(next).To(BeEmpty())
+		})
+
+		It("should not shift the pages when a note is added while paging", func() {
+			notes, next, err := dbInstance.GetNotesFiltered(nil, TagFilter{}, "", TimeRange{}, nil, Page{Limit: 2}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(2))
+
+			Expect(dbInstance.AddNote(model.Note{Title: "test0", UpdatedAt: day.Add(-time.Hour)})).To(Succeed())
+
+			notes, _, err = dbInstance.GetNotesFiltered(nil, TagFilter{}, "", TimeRange{}, nil, Page{Limit: 2, Cursor: next}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(1))
+			Expect(notes[0].Title).To(Equal("test3"))
+		})
+
+		It("should order the notes created at the same time by id", func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "b", Title: "test5", UpdatedAt: day.AddDate(0, 0, 2)})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{ID: "a", Title: "test4", UpdatedAt: day.AddDate(0, 0, 2)})).To(Succeed())
+
+			notes, next, err := dbInstance.GetNotesFiltered(nil, TagFilter{}, "", TimeRange{Field: CreatedAtField, From: day.AddDate(0, 0, 2)}, nil, Page{Limit: 1}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(1))
+			Expect(notes[0].Title).To(Equal("test4"))
+
+			notes, _, err = dbInstance.GetNotesFiltered(nil, TagFilter{}, "", TimeRange{Field: CreatedAtField, From: day.AddDate(0, 0, 2)}, nil, Page{Limit: 1, Cursor: next}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(1))
+			Expect(notes[0].Title).To(Equal("test5"))
+		})
+
+		It("should sort the notes by the fields in order", func() {
+			notes, _, err := dbInstance.GetNotesFiltered(nil, TagFilter{}, "", TimeRange{}, []SortField{{Field: TitleField, Descending: true}}, Page{}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(3))
+			Expect(notes[0].Title).To(Equal("test3"))
+			Expect(notes[1].Title).To(Equal("test2"))
+			Expect(notes[2].Title).To(Equal("test1"))
+
+			notes, _, err = dbInstance.GetNotesFiltered(nil, TagFilter{}, "", TimeRange{}, []SortField{{Field: CategoryField, Descending: true}, {Field: UpdatedAtField}}, Page{}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(3))
+			Expect(notes[0].Title).To(Equal("test1"))
+			Expect(notes[1].Title).To(Equal("test3"))
+			Expect(notes[2].Title).To(Equal("test2"))
+		})
+
+		It("should return the sorted notes by pages", func() {
+			sortBy := []SortField{{Field: CategoryField}, {Field: UpdatedAtField, Descending: true}}
+
+			notes, next, err := dbInstance.GetNotesFiltered(nil, TagFilter{}, "", TimeRange{}, sortBy, Page{Limit: 2}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(2))
+			Expect(notes[0].Title).To(Equal("test2"))
+			Expect(notes[1].Title).To(Equal("test3"))
+
+			notes, next, err = dbInstance.GetNotesFiltered(nil, TagFilter{}, "", TimeRange{}, sortBy, Page{Limit: 2, Cursor: next}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(1))
+			Expect(notes[0].Title).To(Equal("test1"))
+			Expect(next).To(BeEmpty())
+		})
+
+		It("should return an error when the cursor was returned with another sort", func() {
+			_, next, err := dbInstance.GetNotesFiltered(nil, TagFilter{}, "", TimeRange{}, nil, Page{Limit: 1}, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, _, err = dbInstance.GetNotesFiltered(nil, TagFilter{}, "", TimeRange{}, []SortField{{Field: TitleField}}, Page{Limit: 1, Cursor: next}, nil)
+			Expect(err).To(MatchError(ErrInvalidCursor))
+		})
+
+		It("should return an error when the field can't be sorted", func() {
+			_, _, err := dbInstance.GetNotesFiltered(nil, TagFilter{}, "", TimeRange{}, []SortField{{Field: "description"}}, Page{}, nil)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should return an error when the cursor is invalid", func() {
+			_, _, err := dbInstance.GetNotesFiltered(nil, TagFilter{}, "", TimeRange{}, nil, Page{Limit: 2, Cursor: "invalid"}, nil)
+			Expect(err).To(MatchError(ErrInvalidCursor))
+		})
+	})
+
+	Describe("StreamNotes", func() {
+		// stream returns the titles of the streamed notes in order
+		stream := func(where filter.Expr, tags TagFilter, category string, sortBy []SortField) []string {
+			titles := []string{}
+
+			err := dbInstance.StreamNotes(where, tags, category, TimeRange{}, sortBy, func(note model.Note) error {
+				titles = append(titles, note.Title)
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			return titles
+		}
+
+		BeforeEach(func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "test1", Description: "first", Category: "work", Tags: []string{"a"}})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{Title: "test2", Category: "home"})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{Title: "test3", Category: "work"})).To(Succeed())
+		})
+
+		It("should stream all the notes with all their fields", func() {
+			notes := []model.Note{}
+
+			err := dbInstance.StreamNotes(nil, TagFilter{}, "", TimeRange{}, []SortField{{Field: TitleField}}, func(note model.Note) error {
+				notes = append(notes, note)
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(3))
+			Expect(notes[0].ID).NotTo(BeEmpty())
+			Expect(notes[0].Description).To(Equal("first"))
+			Expect(notes[0].Tags).To(Equal([]string{"a"}))
+			Expect(notes[0].Version).To(Equal(int64(1)))
+			Expect(notes[0].CreatedAt).NotTo(BeZero())
+		})
+
+		It("should stream the notes that match the filters in the sort order", func() {
+			where, err := filter.Parse("title != test2", time.UTC)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stream(nil, TagFilter{}, "work", []SortField{{Field: TitleField, Descending: true}})).To(Equal([]string{"test3", "test1"}))
+			Expect(stream(where, TagFilter{Untagged: true}, "", nil)).To(Equal([]string{"test3"}))
+			Expect(stream(nil, TagFilter{}, "", []SortField{{Field: TitleField}})).To(Equal([]string{"test1", "test2", "test3"}))
+		})
+
+		It("should not stream the notes in the trash", func() {
+			Expect(dbInstance.DeleteNote("test1", AnyVersion)).To(Succeed())
+
+			Expect(stream(nil, TagFilter{}, "", []SortField{{Field: TitleField}})).To(Equal([]string{"test2", "test3"}))
+		})
+
+		It("should stop at the first error of the callback", func() {
+			errStop := errors.New("stop")
+			count := 0
+
+			err := dbInstance.StreamNotes(nil, TagFilter{}, "", TimeRange{}, nil, func(note model.Note) error {
+				count++
+				return errStop
+			})
+			Expect(err).To(MatchError(errStop))
+			Expect(count).To(Equal(1))
+		})
+	})
+
+	Describe("CountValues", func() {
+		day := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+		BeforeEach(func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "test1", Category: "work", UpdatedAt: day, Tags: []string{"a", "b", "a"}})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{Title: "test2", Category: "home", UpdatedAt: day, Tags: []string{"b", "c"}})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{Title: "test3", Category: "work", UpdatedAt: day.AddDate(0, 0, 1), Tags: []string{"b", ""}})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{Title: "test4", UpdatedAt: day.AddDate(0, 0, 1)})).To(Succeed())
+		})
+
+		It("should count the notes of every distinct tag, the most used first", func() {
+			counts, next, err := dbInstance.CountValues(TagsField, TagFilter{}, "", TimeRange{}, nil, Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(next).To(BeEmpty())
+			Expect(counts).To(Equal([]model.ValueCount{
+				{Value: "b", Count: 3},
+				{Value: "a", Count: 1},
+				{Value: "c", Count: 1},
+			}))
+		})
+
+		It("should count the notes of every category that is set", func() {
+			counts, _, err := dbInstance.CountValues(CategoryField, TagFilter{}, "", TimeRange{}, []SortField{{Field: ValueField}}, Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(counts).To(Equal([]model.ValueCount{
+				{Value: "home", Count: 1},
+				{Value: "work", Count: 2},
+			}))
+		})
+
+		It("should only count the filtered notes", func() {
+			counts, _, err := dbInstance.CountValues(TagsField, TagFilter{Tags: []string{"b"}}, "work", TimeRange{Field: UpdatedAtField, From: day, To: day.AddDate(0, 0, 1)}, nil, Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(counts).To(Equal([]model.ValueCount{
+				{Value: "a", Count: 1},
+				{Value: "b", Count: 1},
+			}))
+		})
+
+		It("should not count the notes in the trash", func() {
+			Expect(dbInstance.DeleteNote("test2", AnyVersion)).To(Succeed())
+
+			counts, _, err := dbInstance.CountValues(TagsField, TagFilter{}, "", TimeRange{}, nil, Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(counts).To(Equal([]model.ValueCount{
+				{Value: "b", Count: 2},
+				{Value: "a", Count: 1},
+			}))
+		})
+
+		It("should return the counted values by pages", func() {
+			sortBy := []SortField{{Field: CountField}, {Field: ValueField, Descending: true}}
+
+			counts, next, err := dbInstance.CountValues(TagsField, TagFilter{}, "", TimeRange{}, sortBy, Page{Limit: 2})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(counts).To(Equal([]model.ValueCount{
+				{Value: "c", Count: 1},
+				{Value: "a", Count: 1},
+			}))
+			Expect(next).NotTo(BeEmpty())
+
+			counts, next, err = dbInstance.CountValues(TagsField, TagFilter{}, "", TimeRange{}, sortBy, Page{Limit: 2, Cursor: next})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(counts).To(Equal([]model.ValueCount{
+				{Value: "b", Count: 3},
+			}))
+			Expect(next).To(BeEmpty())
+		})
+
+		It("should return an error when the cursor was returned with another sort", func() {
+			_, next, err := dbInstance.CountValues(TagsField, TagFilter{}, "", TimeRange{}, nil, Page{Limit: 1})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, _, err = dbInstance.CountValues(TagsField, TagFilter{}, "", TimeRange{}, []SortField{{Field: ValueField}}, Page{Limit: 1, Cursor: next})
+			Expect(err).To(MatchError(ErrInvalidCursor))
+		})
+
+		It("should return an error when the field can't be counted or sorted", func() {
+			_, _, err := dbInstance.CountValues(TitleField, TagFilter{}, "", TimeRange{}, nil, Page{})
+			Expect(err).To(HaveOccurred())
+
+			_, _, err = dbInstance.CountValues(TagsField, TagFilter{}, "", TimeRange{}, []SortField{{Field: TitleField}}, Page{})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("RenameValue", func() {
+		BeforeEach(func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "test1", Category: "work", Tags: []string{"a", "b", "c"}})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{Title: "test2", Category: "job", Tags: []string{"b"}})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{Title: "test3", Category: "home", Tags: []string{"c"}})).To(Succeed())
+		})
+
+		It("should rename the tag in place and keep a revision of the changed notes", func() {
+			result, err := dbInstance.RenameValue(TagsField, "b", "d", false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(RenameResult{Changed: 2}))
+
+			note, err := dbInstance.GetNote("test1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.Tags).To(Equal([]string{"a", "d", "c"}))
+			Expect(note.Version).To(Equal(int64(2)))
+
+			revisions, err := dbInstance.GetRevisions("test1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revisions).To(HaveLen(1))
+			Expect(revisions[0].Note.Tags).To(Equal([]string{"a", "b", "c"}))
+
+			note, err = dbInstance.GetNote("test3")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.Version).To(Equal(int64(1)))
+		})
+
+		It("should merge the tag into an existing tag without duplicates", func() {
+			result, err := dbInstance.RenameValue(TagsField, "a", "c", false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(RenameResult{Changed: 1, Merged: true}))
+
+			note, err := dbInstance.GetNote("test1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.Tags).To(Equal([]string{"c", "b"}))
+		})
+
+		It("should merge the category into an existing category", func() {
+			result, err := dbInstance.RenameValue(CategoryField, "job", "work", false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(RenameResult{Changed: 1, Merged: true}))
+
+			notes, _, err := dbInstance.GetNotesFiltered(nil, TagFilter{}, "work", TimeRange{}, nil, Page{}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(2))
+		})
+
+		It("should only count the notes that would change in a dry run", func() {
+			result, err := dbInstance.RenameValue(TagsField, "c", "b", true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(RenameResult{Changed: 2, Merged: true}))
+
+			note, err := dbInstance.GetNote("test3")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.Tags).To(Equal([]string{"c"}))
+			Expect(note.Version).To(Equal(int64(1)))
+		})
+
+		It("should not rename the notes in the trash", func() {
+			Expect(dbInstance.DeleteNote("test3", AnyVersion)).To(Succeed())
+
+			result, err := dbInstance.RenameValue(CategoryField, "home", "house", false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(RenameResult{}))
+		})
+
+		It("should return an error when the values are the same or the field can't be renamed", func() {
+			_, err := dbInstance.RenameValue(TagsField, "a", "a", false)
+			Expect(err).To(HaveOccurred())
+
+			_, err = dbInstance.RenameValue(TagsField, "a", "", false)
+			Expect(err).To(HaveOccurred())
+
+			_, err = dbInstance.RenameValue(TitleField, "test1", "test4", false)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("DeleteNote", func() {
+		It("should delete the note when it is in database", func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "test"})).To(Succeed())
+
+			err := dbInstance.DeleteNote("test", AnyVersion)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = dbInstance.GetNote("test")
+			Expect(err).To(MatchError(mongo.ErrNoDocuments))
+		})
+
+		It("should return an error when the note is not in database", func() {
+			err := dbInstance.DeleteNote("missing", AnyVersion)
+			Expect(err).To(MatchError(mongo.ErrNoDocuments))
+		})
+	})
+
+	Describe("DeleteNoteByID", func() {
+		It("should delete the note when it is in database", func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "id", Title: "test"})).To(Succeed())
+
+			err := dbInstance.DeleteNoteByID("id", AnyVersion)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = dbInstance.GetNote("test")
+			Expect(err).To(MatchError(mongo.ErrNoDocuments))
+		})
+
+		It("should return an error when the note is not in database", func() {
+			err := dbInstance.DeleteNoteByID("missing", AnyVersion)
+			Expect(err).To(MatchError(mongo.ErrNoDocuments))
+		})
+	})
+
+	Describe("DeleteNotes", func() {
+		It("should delete all the notes", func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "test1"})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{Title: "test2"})).To(Succeed())
+
+			err := dbInstance.DeleteNotes()
+			Expect(err).NotTo(HaveOccurred())
+
+			notes, err := dbInstance.GetNotes()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(BeEmpty())
+		})
+
+		It("should return an error when there are no notes in database", func() {
+			err := dbInstance.DeleteNotes()
+			Expect(err).To(MatchError(mongo.ErrNoDocuments))
+		})
+	})
+
+	Describe("Versions", func() {
+		It("should start the notes from the first version", func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "test"})).To(Succeed())
+
+			note, err := dbInstance.GetNote("test")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.Version).To(Equal(int64(1)))
+		})
+
+		It("should increment the version on every update", func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "id", Title: "test"})).To(Succeed())
+			Expect(dbInstance.UpdateNote("test", model.Note{Title: "test", Version: 10}, AnyVersion)).To(Succeed())
+			Expect(dbInstance.UpdateNoteByID("id", model.Note{Title: "test"}, AnyVersion)).To(Succeed())
+
+			note, err := dbInstance.GetNote("test")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.Version).To(Equal(int64(3)))
+		})
+
+		It("should update the note when the expected version is the current one", func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "id", Title: "test"})).To(Succeed())
+			Expect(dbInstance.UpdateNote("test", model.Note{Title: "test"}, 1)).To(Succeed())
+			Expect(dbInstance.UpdateNoteByID("id", model.Note{Title: "test", Description: "updated"}, 2)).To(Succeed())
+
+			note, err := dbInstance.GetNote("test")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.Description).To(Equal("updated"))
+		})
+
+		It("should not update the note when the expected version is stale", func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "id", Title: "test"})).To(Succeed())
+			Expect(dbInstance.UpdateNote("test", model.Note{Title: "test", Description: "first"}, 1)).To(Succeed())
+
+			err := dbInstance.UpdateNote("test", model.Note{Title: "test", Description: "second"}, 1)
+			Expect(err).To(MatchError(ErrVersionConflict))
+
+			err = dbInstance.UpdateNoteByID("id", model.Note{Title: "test", Description: "second"}, 1)
+			Expect(err).To(MatchError(ErrVersionConflict))
+
+			note, err := dbInstance.GetNote("test")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.Description).To(Equal("first"))
+			Expect(note.Version).To(Equal(int64(2)))
+		})
+
+		It("should not delete the note when the expected version is stale", func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "id", Title: "test"})).To(Succeed())
+			Expect(dbInstance.UpdateNote("test", model.Note{Title: "test"}, AnyVersion)).To(Succeed())
+
+			Expect(dbInstance.DeleteNote("test", 1)).To(MatchError(ErrVersionConflict))
+			Expect(dbInstance.DeleteNoteByID("id", 1)).To(MatchError(ErrVersionConflict))
+
+			Expect(dbInstance.DeleteNoteByID("id", 2)).To(Succeed())
+		})
+
+		It("should return an error when the note with an expected version does not exist", func() {
+			Expect(dbInstance.UpdateNote("test", model.Note{Title: "test"}, 1)).To(MatchError(mongo.ErrNoDocuments))
+			Expect(dbInstance.DeleteNote("test", 1)).To(MatchError(mongo.ErrNoDocuments))
+		})
+
+		It("should let only one of the concurrent updates with the same expected version succeed", func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "test"})).To(Succeed())
+
+			var (
+				wg        sync.WaitGroup
+				mu        sync.Mutex
+				succeeded int
+			)
+
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					defer GinkgoRecover()
+
+					err := dbInstance.UpdateNote("test", model.Note{Title: "test", Description: fmt.Sprint(i)}, 1)
+					if err == nil {
+						mu.Lock()
+						succeeded++
+						mu.Unlock()
+						return
+					}
+
+					Expect(err).To(MatchError(ErrVersionConflict))
+				}(i)
+			}
+
+			wg.Wait()
+			Expect(succeeded).To(Equal(1))
+		})
+	})
+
+	Describe("GetRevisions", func() {
+		It("should return no revisions when the note was never changed", func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "test"})).To(Succeed())
+
+			revisions, err := dbInstance.GetRevisions("test")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revisions).To(BeEmpty())
+		})
+
+		It("should keep the previous versions of an updated note", func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "id", Title: "test", Description: "first"})).To(Succeed())
+			Expect(dbInstance.UpdateNote("test", model.Note{Title: "test", Description: "second"}, AnyVersion)).To(Succeed())
+			Expect(dbInstance.UpdateNoteByID("id", model.Note{Title: "renamed", Description: "third"}, AnyVersion)).To(Succeed())
+
+			revisions, err := dbInstance.GetRevisions("renamed")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revisions).To(HaveLen(2))
+
+			Expect(revisions[0].NoteID).To(Equal("id"))
+			Expect(revisions[0].Number).To(Equal(int64(1)))
+			Expect(withoutTimestamps(revisions[0].Note)).To(Equal([]model.Note{{ID: "id", Title: "test", Description: "first", Version: 1}}))
+			Expect(revisions[0].CreatedAt).NotTo(BeZero())
+
+			Expect(revisions[1].Number).To(Equal(int64(2)))
+			Expect(withoutTimestamps(revisions[1].Note)).To(Equal([]model.Note{{ID: "id", Title: "test", Description: "second", Version: 2}}))
+		})
+
+		It("should keep the last version of a deleted note", func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "id", Title: "test"})).To(Succeed())
+			Expect(dbInstance.DeleteNote("test", AnyVersion)).To(Succeed())
+
+			revisions, err := dbInstance.GetRevisions("test")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revisions).To(HaveLen(1))
+			Expect(withoutTimestamps(revisions[0].Note)).To(Equal([]model.Note{{ID: "id", Title: "test", Version: 1}}))
+		})
+
+		It("should keep the last version of every note when all notes are deleted", func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "test1"})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{Title: "test2"})).To(Succeed())
+			Expect(dbInstance.DeleteNotes()).To(Succeed())
+
+			for _, title := range []string{"test1", "test2"} {
+				revisions, err := dbInstance.GetRevisions(title)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(revisions).To(HaveLen(1))
+			}
+		})
+
+		It("should return the revisions of the current note when a deleted note had the same title", func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "id1", Title: "test"})).To(Succeed())
+			Expect(dbInstance.DeleteNote("test", AnyVersion)).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{ID: "id2", Title: "test"})).To(Succeed())
+
+			revisions, err := dbInstance.GetRevisions("test")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revisions).To(BeEmpty())
+		})
+
+		It("should return an error when the note never existed", func() {
+			_, err := dbInstance.GetRevisions("test")
+			Expect(err).To(MatchError(mongo.ErrNoDocuments))
+		})
+	})
+
+	Describe("GetRevision", func() {
+		It("should return the revision with the number", func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "test", Description: "first"})).To(Succeed())
+			Expect(dbInstance.UpdateNote("test", model.Note{Title: "test", Description: "second"}, AnyVersion)).To(Succeed())
+
+			revision, err := dbInstance.GetRevision("test", 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revision.Number).To(Equal(int64(1)))
+			Expect(revision.Note.Description).To(Equal("first"))
+		})
+
+		It("should return an error when the revision does not exist", func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "test"})).To(Succeed())
+			Expect(dbInstance.UpdateNote("test", model.Note{Title: "test"}, AnyVersion)).To(Succeed())
+
+			_, err := dbInstance.GetRevision("test", 2)
+			Expect(err).To(MatchError(mongo.ErrNoDocuments))
+		})
+	})
+
+	Describe("Trash", func() {
+		It("should move the deleted note to the trash", func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "test", Tags: []string{"tag"}})).To(Succeed())
+			Expect(dbInstance.DeleteNote("test", AnyVersion)).To(Succeed())
+
+			trash, err := dbInstance.GetTrash()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(trash).To(HaveLen(1))
+			Expect(trash[0].Title).To(Equal("test"))
+			Expect(trash[0].DeletedAt).NotTo(BeNil())
+
+			notes, _, err := dbInstance.GetNotesFiltered(nil, TagFilter{Tags: []string{"tag"}}, "", TimeRange{}, nil, Page{}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(BeEmpty())
+		})
+
+		It("should move all the notes to the trash", func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "test1"})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{Title: "test2"})).To(Succeed())
+			Expect(dbInstance.DeleteNotes()).To(Succeed())
+
+			trash, err := dbInstance.GetTrash()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(trash).To(HaveLen(2))
+		})
+
+		It("should list the most recently deleted notes first, then by their id", func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "c", Title: "test1"})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{ID: "a", Title: "test2"})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{ID: "b", Title: "test3"})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{ID: "d", Title: "test4"})).To(Succeed())
+			Expect(dbInstance.DeleteNote("test4", AnyVersion)).To(Succeed())
+			Expect(dbInstance.DeleteNotes()).To(Succeed())
+
+			trash, err := dbInstance.GetTrash()
+			Expect(err).NotTo(HaveOccurred())
+
+			ids := []string{}
+			for _, note := range trash {
+				ids = append(ids, note.ID)
+			}
+			Expect(ids).To(Equal([]string{"a", "b", "c", "d"}))
+		})
+
+		It("should allow reusing the title of a note in the trash", func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "test"})).To(Succeed())
+			Expect(dbInstance.DeleteNote("test", AnyVersion)).To(Succeed())
+
+			Expect(dbInstance.AddNote(model.Note{Title: "test"})).To(Succeed())
+		})
+
+		It("should not find the note in the trash by its id", func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "id", Title: "test"})).To(Succeed())
+			Expect(dbInstance.DeleteNote("test", AnyVersion)).To(Succeed())
+
+			_, err := dbInstance.GetNoteByID("id")
+			Expect(err).To(MatchError(mongo.ErrNoDocuments))
+
+			err = dbInstance.UpdateNoteByID("id", model.Note{Title: "test"}, AnyVersion)
+			Expect(err).To(MatchError(mongo.ErrNoDocuments))
+		})
+
+		It("should restore the note with its id and version", func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "id", Title: "test"})).To(Succeed())
+			Expect(dbInstance.DeleteNote("test", AnyVersion)).To(Succeed())
+
+			Expect(dbInstance.RestoreNote("test")).To(Succeed())
+
+			note, err := dbInstance.GetNote("test")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.ID).To(Equal("id"))
+			Expect(note.Version).To(Equal(int64(1)))
+			Expect(note.DeletedAt).To(BeNil())
+
+			trash, err := dbInstance.GetTrash()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(trash).To(BeEmpty())
+		})
+
+		It("should return a duplicate key error when restoring a note whose title is in use", func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "test"})).To(Succeed())
+			Expect(dbInstance.DeleteNote("test", AnyVersion)).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{Title: "test"})).To(Succeed())
+
+			err := dbInstance.RestoreNote("test")
+			Expect(mongo.IsDuplicateKeyError(err)).To(BeTrue())
+		})
+
+		It("should return an error when restoring a note that is not in the trash", func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "test"})).To(Succeed())
+
+			err := dbInstance.RestoreNote("test")
+			Expect(err).To(MatchError(mongo.ErrNoDocuments))
+		})
+
+		It("should purge every note in the trash with the title", func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "test"})).To(Succeed())
+			Expect(dbInstance.DeleteNote("test", AnyVersion)).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{Title: "test"})).To(Succeed())
+			Expect(dbInstance.DeleteNote("test", AnyVersion)).To(Succeed())
+
+			Expect(dbInstance.PurgeNote("test")).To(Succeed())
+
+			trash, err := dbInstance.GetTrash()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(trash).To(BeEmpty())
+
+			err = dbInstance.PurgeNote("test")
+			Expect(err).To(MatchError(mongo.ErrNoDocuments))
+		})
+
+		It("should not purge a note that is not in the trash", func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "test"})).To(Succeed())
+
+			err := dbInstance.PurgeNote("test")
+			Expect(err).To(MatchError(mongo.ErrNoDocuments))
+
+			_, err = dbInstance.GetNote("test")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should purge only the notes deleted before the time", func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "test"})).To(Succeed())
+			Expect(dbInstance.DeleteNote("test", AnyVersion)).To(Succeed())
+
+			purged, err := dbInstance.PurgeTrash(time.Now().Add(-time.Hour))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(purged).To(BeZero())
+
+			purged, err = dbInstance.PurgeTrash(time.Now().Add(time.Second))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(purged).To(Equal(int64(1)))
+
+			trash, err := dbInstance.GetTrash()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(trash).To(BeEmpty())
+		})
+	})
+
+	Describe("SearchNotes", func() {
+		hitTitles := func(hits []model.SearchHit) []string {
+			titles := []string{}
+			for _, hit := range hits {
+				titles = append(titles, hit.Title)
+			}
+
+			return titles
+		}
+
+		BeforeEach(func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "Weekly meeting", Description: "Prepare the agenda", Category: "work", Tags: []string{"team"}})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{Title: "Groceries", Description: "Buy milk before the weekly meeting", Category: "home"})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{Title: "Books", Description: "Read about meetings", Category: "home"})).To(Succeed())
+		})
+
+		It("should return the matching notes by relevance with their snippets", func() {
+			hits, next, err := dbInstance.SearchNotes("weekly meeting", nil, TagFilter{}, "", TimeRange{}, nil, Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(next).To(BeEmpty())
+			Expect(hitTitles(hits)).To(Equal([]string{"Weekly meeting", "Groceries"}))
+			Expect(hits[0].Score).To(BeNumerically(">", hits[1].Score))
+			Expect(hits[0].Snippets).To(HaveKeyWithValue("title", "<mark>Weekly meeting</mark>"))
+			Expect(hits[1].Snippets).To(HaveKeyWithValue("description", "Buy milk before the <mark>weekly meeting</mark>"))
+		})
+
+		It("should match phrases and prefixes", func() {
+			hits, _, err := dbInstance.SearchNotes(`"milk before"`, nil, TagFilter{}, "", TimeRange{}, nil, Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hitTitles(hits)).To(Equal([]string{"Groceries"}))
+
+			hits, _, err = dbInstance.SearchNotes("meet*", nil, TagFilter{}, "", TimeRange{}, []SortField{{Field: TitleField}}, Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hitTitles(hits)).To(Equal([]string{"Books", "Groceries", "Weekly meeting"}))
+		})
+
+		It("should filter the matching notes", func() {
+			hits, _, err := dbInstance.SearchNotes("meeting", nil, TagFilter{}, "home", TimeRange{}, nil, Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hitTitles(hits)).To(Equal([]string{"Groceries"}))
+
+			hits, _, err = dbInstance.SearchNotes("meeting", nil, TagFilter{Tags: []string{"team"}}, "", TimeRange{}, nil, Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hitTitles(hits)).To(Equal([]string{"Weekly meeting"}))
+
+			hits, _, err = dbInstance.SearchNotes("meeting", nil, TagFilter{}, "", TimeRange{Field: CreatedAtField, To: time.Now().Add(-time.Hour)}, nil, Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hits).To(BeEmpty())
+		})
+
+		It("should filter the matching notes with a structured filter", func() {
+			where, err := filter.Parse("NOT tag = team AND category in (home, work)", time.UTC)
+			Expect(err).NotTo(HaveOccurred())
+
+			hits, _, err := dbInstance.SearchNotes("meeting", where, TagFilter{}, "", TimeRange{}, nil, Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hitTitles(hits)).To(Equal([]string{"Groceries"}))
+		})
+
+		It("should find the updated notes by their new content", func() {
+			Expect(dbInstance.UpdateNote("Groceries", model.Note{Title: "Groceries", Description: "Buy bread"}, AnyVersion)).To(Succeed())
+
+			hits, _, err := dbInstance.SearchNotes("milk", nil, TagFilter{}, "", TimeRange{}, nil, Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hits).To(BeEmpty())
+
+			hits, _, err = dbInstance.SearchNotes("bread", nil, TagFilter{}, "", TimeRange{}, nil, Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hitTitles(hits)).To(Equal([]string{"Groceries"}))
+			Expect(hits[0].Version).To(Equal(int64(2)))
+		})
+
+		It("should not find the deleted notes until they are restored", func() {
+			Expect(dbInstance.DeleteNote("Books", AnyVersion)).To(Succeed())
+
+			hits, _, err := dbInstance.SearchNotes("books", nil, TagFilter{}, "", TimeRange{}, nil, Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hits).To(BeEmpty())
+
+			Expect(dbInstance.RestoreNote("Books")).To(Succeed())
+
+			hits, _, err = dbInstance.SearchNotes("books", nil, TagFilter{}, "", TimeRange{}, nil, Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hitTitles(hits)).To(Equal([]string{"Books"}))
+
+			Expect(dbInstance.DeleteNotes()).To(Succeed())
+
+			hits, _, err = dbInstance.SearchNotes("meeting", nil, TagFilter{}, "", TimeRange{}, nil, Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hits).To(BeEmpty())
+		})
+
+		It("should list the matching notes in pages", func() {
+			hits, next, err := dbInstance.SearchNotes("meet*", nil, TagFilter{}, "", TimeRange{}, nil, Page{Limit: 2})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hits).To(HaveLen(2))
+			Expect(next).NotTo(BeEmpty())
+
+			rest, next, err := dbInstance.SearchNotes("meet*", nil, TagFilter{}, "", TimeRange{}, nil, Page{Limit: 2, Cursor: next})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(next).To(BeEmpty())
+			Expect(hitTitles(append(hits, rest...))).To(ConsistOf("Weekly meeting", "Groceries", "Books"))
+		})
+
+		It("should return an error when the query is invalid", func() {
+			_, _, err := dbInstance.SearchNotes(`"unterminated`, nil, TagFilter{}, "", TimeRange{}, nil, Page{})
+			Expect(errors.Is(err, search.ErrInvalidQuery)).To(BeTrue())
+		})
+
+		It("should only sort by relevance in a search", func() {
+			_, _, err := dbInstance.GetNotesFiltered(nil, TagFilter{}, "", TimeRange{}, []SortField{{Field: RelevanceField}}, Page{}, nil)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("ApplyOperations", func() {
+		BeforeEach(func() {
+			Expect(dbInstance.AddNote(model.Note{ID: "id1", Title: "test1", Description: "first"})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{ID: "id2", Title: "test2", Description: "second"})).To(Succeed())
+		})
+
+		titles := func() []string {
+			notes, err := dbInstance.GetNotes()
+			Expect(err).NotTo(HaveOccurred())
+
+			titles := []string{}
+			for _, note := range notes {
+				titles = append(titles, note.Title)
+			}
+
+			return titles
+		}
+
+		It("should apply the operations in order", func() {
+			errs, err := dbInstance.ApplyOperations([]Operation{
+				{Type: OperationCreate, Note: model.Note{Title: "test3", Description: "third"}},
+				{Type: OperationUpdate, NoteTitle: "test3", Note: model.Note{Title: "test4", Description: "fourth"}, ExpectedVersion: 1},
+				{Type: OperationUpdate, NoteID: "id1", Note: model.Note{Title: "test1", Description: "changed"}, ExpectedVersion: AnyVersion},
+				{Type: OperationDelete, NoteID: "id2", ExpectedVersion: 1},
+			}, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(errs).To(Equal([]error{nil, nil, nil, nil}))
+
+			Expect(titles()).To(ConsistOf("test1", "test4"))
+
+			note, err := dbInstance.GetNote("test1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.Description).To(Equal("changed"))
+			Expect(note.Version).To(Equal(int64(2)))
+
+			hits, _, err := dbInstance.SearchNotes("fourth", nil, TagFilter{}, "", TimeRange{}, nil, Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hits).To(HaveLen(1))
+			Expect(hits[0].Title).To(Equal("test4"))
+
+			hits, _, err = dbInstance.SearchNotes("second", nil, TagFilter{}, "", TimeRange{}, nil, Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hits).To(BeEmpty())
+		})
+
+		It("should apply the other operations when one fails and the batch is not atomic", func() {
+			errs, err := dbInstance.ApplyOperations([]Operation{
+				{Type: OperationCreate, Note: model.Note{Title: "test1"}},
+				{Type: OperationDelete, NoteTitle: "missing", ExpectedVersion: AnyVersion},
+				{Type: OperationUpdate, NoteID: "id2", Note: model.Note{Title: "test2"}, ExpectedVersion: 5},
+				{Type: OperationCreate, Note: model.Note{Title: "test3"}},
+			}, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(errs).To(HaveLen(4))
+			Expect(mongo.IsDuplicateKeyError(errs[0])).To(BeTrue())
+			Expect(errors.Is(errs[1], mongo.ErrNoDocuments)).To(BeTrue())
+			Expect(errors.Is(errs[2], ErrVersionConflict)).To(BeTrue())
+			Expect(errs[3]).NotTo(HaveOccurred())
+
+			Expect(titles()).To(ConsistOf("test1", "test2", "test3"))
+		})
+
+		It("should apply none of the operations when one fails and the batch is atomic", func() {
+			errs, err := dbInstance.ApplyOperations([]Operation{
+				{Type: OperationCreate, Note: model.Note{Title: "test3", Description: "third"}},
+				{Type: OperationDelete, NoteID: "id1", ExpectedVersion: AnyVersion},
+				{Type: OperationUpdate, NoteID: "id2", Note: model.Note{Title: "test2"}, ExpectedVersion: 5},
+				{Type: OperationCreate, Note: model.Note{Title: "test5"}},
+			}, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(errs).To(HaveLen(4))
+			Expect(errs[0]).To(MatchError(ErrNotApplied))
+			Expect(errs[1]).To(MatchError(ErrNotApplied))
+			Expect(errors.Is(errs[2], ErrVersionConflict)).To(BeTrue())
+			Expect(errs[3]).To(MatchError(ErrNotApplied))
+
+			Expect(titles()).To(ConsistOf("test1", "test2"))
+
+			trash, err := dbInstance.GetTrash()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(trash).To(BeEmpty())
+
+			revisions, err := dbInstance.GetRevisions("test1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revisions).To(BeEmpty())
+
+			hits, _, err := dbInstance.SearchNotes("third", nil, TagFilter{}, "", TimeRange{}, nil, Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hits).To(BeEmpty())
+		})
+
+		It("should return an error for an unknown operation", func() {
+			errs, err := dbInstance.ApplyOperations([]Operation{{Type: "move"}}, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(errs[0]).To(HaveOccurred())
+		})
+	})
+
+	Describe("Concurrency", func() {
+		It("should keep the title unique when notes are added concurrently", func() {
+			var (
+				wg        sync.WaitGroup
+				mu        sync.Mutex
+				succeeded int
+			)
+
+			for i := 0; i < 50; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer GinkgoRecover()
+
+					if dbInstance.AddNote(model.Note{Title: "test"}) == nil {
+						mu.Lock()
+						succeeded++
+						mu.Unlock()
+					}
+
+					_, err := dbInstance.GetNotes()
+					Expect(err).NotTo(HaveOccurred())
+				}()
+			}
+
+			wg.Wait()
+			Expect(succeeded).To(Equal(1))
+		})
+	})
+
+}
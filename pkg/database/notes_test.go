@@ -1,18 +1,35 @@
 package database
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/notes-project/api/pkg/filter"
 	mockadapters "github.com/notes-project/api/pkg/mock/adapters"
 	"github.com/notes-project/api/pkg/model"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 )
 
+// testSession runs the transactions of the tests without a server, the changes of a transaction are made directly
+type testSession struct {
+	mongo.Session
+}
+
+func (s *testSession) WithTransaction(ctx context.Context, fn func(sessCtx mongo.SessionContext) (interface{}, error),
+	opts ...*options.TransactionOptions) (interface{}, error) {
+	return fn(mongo.NewSessionContext(ctx, s))
+}
+
+func (s *testSession) EndSession(ctx context.Context) {}
+
 var _ = Describe("DatabaseNotes", func() {
 
 	var (
@@ -20,6 +37,8 @@ var _ = Describe("DatabaseNotes", func() {
 
 		mockDbClient     *mockadapters.MockDbClient
 		mockDbCollection *mockadapters.MockDbCollection
+		mockDbRevisions  *mockadapters.MockDbCollection
+		mockDbCounters   *mockadapters.MockDbCollection
 
 		dbInstance *database
 	)
@@ -29,6 +48,8 @@ var _ = Describe("DatabaseNotes", func() {
 
 		mockDbClient = mockadapters.NewMockDbClient(ctrl)
 		mockDbCollection = mockadapters.NewMockDbCollection(ctrl)
+		mockDbRevisions = mockadapters.NewMockDbCollection(ctrl)
+		mockDbCounters = mockadapters.NewMockDbCollection(ctrl)
 
 		dbInstance = &database{
 			databaseConfiguration: databaseConfiguration{
@@ -39,9 +60,24 @@ var _ = Describe("DatabaseNotes", func() {
 			logger:     zap.L(),
 			client:     mockDbClient,
 			collection: mockDbCollection,
+			revisions:  mockDbRevisions,
+
+			revisionCounters: mockDbCounters,
 		}
+
+		// the changes of the notes are made in transactions along with their revisions
+		mockDbClient.EXPECT().StartSession().Return(&testSession{}, nil).AnyTimes()
 	})
 
+	// expectRevisionSaved expects the first revision of each of the notes to be saved
+	expectRevisionSaved := func(notes int) {
+		mockDbCounters.EXPECT().FindOneAndUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(
+			mongo.NewSingleResultFromDocument(revisionCounter{Number: 1}, nil, nil),
+		).Times(notes)
+		mockDbRevisions.EXPECT().InsertOne(gomock.Any(), gomock.Any()).Return(&mongo.InsertOneResult{}, nil).Times(notes)
+		mockDbRevisions.EXPECT().DeleteMany(gomock.Any(), gomock.Any()).Return(&mongo.DeleteResult{}, nil).Times(notes)
+	}
+
 	AfterEach(func() {
 		ctrl.Finish()
 	})
@@ -62,34 +98,509 @@ var _ = Describe("DatabaseNotes", func() {
 		})
 	})
 
+	Describe("AddNotes", func() {
+		It("should insert the notes without stopping at the first failure", func() {
+			mockDbCollection.EXPECT().InsertMany(gomock.Any(), gomock.Len(2), gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+					Expect(*opts[0].Ordered).To(BeFalse())
+
+					return &mongo.InsertManyResult{}, nil
+				},
+			)
+
+			errs, err := dbInstance.AddNotes([]model.Note{{Title: "test1"}, {Title: "test2"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(errs).To(Equal([]error{nil, nil}))
+		})
+
+		It("should return a duplicate key error for each duplicate note", func() {
+			mockDbCollection.EXPECT().InsertMany(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, mongo.BulkWriteException{
+				WriteErrors: []mongo.BulkWriteError{
+					{WriteError: mongo.WriteError{Index: 1, Code: duplicateKeyErrorCode}},
+				},
+			})
+
+			errs, err := dbInstance.AddNotes([]model.Note{{Title: "test1"}, {Title: "test1"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(errs).To(HaveLen(2))
+			Expect(errs[0]).NotTo(HaveOccurred())
+			Expect(mongo.IsDuplicateKeyError(errs[1])).To(BeTrue())
+		})
+
+		It("should return an error when the batch failed", func() {
+			mockDbCollection.EXPECT().InsertMany(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New(""))
+
+			_, err := dbInstance.AddNotes([]model.Note{{Title: "test"}})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should not insert anything when there are no notes", func() {
+			errs, err := dbInstance.AddNotes(nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(errs).To(BeEmpty())
+		})
+	})
+
+	Describe("ApplyOperations", func() {
+		It("should apply each operation on its own when the batch is not atomic", func() {
+			mockDbCollection.EXPECT().InsertOne(gomock.Any(), gomock.Any()).Return(nil, errors.New(""))
+			mockDbCollection.EXPECT().InsertOne(gomock.Any(), gomock.Any()).Return(nil, nil)
+
+			errs, err := dbInstance.ApplyOperations([]Operation{
+				{Type: OperationCreate, Note: model.Note{Title: "test1"}},
+				{Type: OperationCreate, Note: model.Note{Title: "test2"}},
+			}, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(errs).To(HaveLen(2))
+			Expect(errs[0]).To(HaveOccurred())
+			Expect(errs[1]).NotTo(HaveOccurred())
+		})
+
+		It("should return an error when the session of an atomic batch can't be started", func() {
+			failingClient := mockadapters.NewMockDbClient(ctrl)
+			failingClient.EXPECT().StartSession().Return(nil, errors.New(""))
+			dbInstance.client = failingClient
+
+			_, err := dbInstance.ApplyOperations([]Operation{{Type: OperationCreate, Note: model.Note{Title: "test"}}}, true)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
 	Describe("UpdateNote", func() {
 		It("should update a note from the database when error does not occur and note is in database", func() {
-			mockDbCollection.EXPECT().ReplaceOne(gomock.Any(), gomock.Any(), gomock.Any()).Return(&mongo.UpdateResult{
-				MatchedCount: 1,
-			}, nil)
+			mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				mongo.NewSingleResultFromDocument(model.Note{ID: "id", Title: "test"}, nil, nil),
+			)
+			expectRevisionSaved(1)
+
+			err := dbInstance.UpdateNote("test", model.Note{}, AnyVersion)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should save the previous version of the note as a revision", func() {
+			mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				mongo.NewSingleResultFromDocument(model.Note{ID: "id", Title: "test"}, nil, nil),
+			)
+			mockDbCounters.EXPECT().FindOneAndUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				mongo.NewSingleResultFromDocument(revisionCounter{NoteID: "id", Number: 3}, nil, nil),
+			)
+			mockDbRevisions.EXPECT().InsertOne(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, revision interface{}, _ ...interface{}) (*mongo.InsertOneResult, error) {
+					Expect(revision).To(BeAssignableToTypeOf(model.Revision{}))
+					Expect(revision.(model.Revision).NoteID).To(Equal("id"))
+					Expect(revision.(model.Revision).Number).To(Equal(int64(3)))
+					Expect(revision.(model.Revision).Note).To(Equal(model.Note{ID: "id", Title: "test"}))
+					return &mongo.InsertOneResult{}, nil
+				},
+			)
+			mockDbRevisions.EXPECT().DeleteMany(gomock.Any(), gomock.Any()).Return(&mongo.DeleteResult{}, nil)
 
-			err := dbInstance.UpdateNote("", model.Note{})
+			err := dbInstance.UpdateNote("test", model.Note{Title: "test", Description: "updated"}, AnyVersion)
 			Expect(err).NotTo(HaveOccurred())
 		})
 
+		It("should update the note and save its revision in the same transaction", func() {
+			mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, _, _ interface{}, _ ...*options.FindOneAndUpdateOptions) *mongo.SingleResult {
+					_, inSession := ctx.(mongo.SessionContext)
+					Expect(inSession).To(BeTrue())
+
+					return mongo.NewSingleResultFromDocument(model.Note{ID: "id", Title: "test"}, nil, nil)
+				},
+			)
+			mockDbCounters.EXPECT().FindOneAndUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				mongo.NewSingleResultFromDocument(revisionCounter{NoteID: "id", Number: 1}, nil, nil),
+			)
+			mockDbRevisions.EXPECT().InsertOne(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, _ interface{}, _ ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
+					_, inSession := ctx.(mongo.SessionContext)
+					Expect(inSession).To(BeTrue())
+
+					return nil, errors.New("")
+				},
+			)
+
+			// the failed revision fails the transaction, so the update is rolled back along with it
+			err := dbInstance.UpdateNote("test", model.Note{Title: "test"}, AnyVersion)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should start the revision counter from the latest revision saved without it", func() {
+			mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				mongo.NewSingleResultFromDocument(model.Note{ID: "id", Title: "test"}, nil, nil),
+			)
+			gomock.InOrder(
+				mockDbCounters.EXPECT().FindOneAndUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(
+					mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil),
+				),
+				mockDbRevisions.EXPECT().FindOne(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+					mongo.NewSingleResultFromDocument(model.Revision{NoteID: "id", Number: 2}, nil, nil),
+				),
+				mockDbCounters.EXPECT().FindOneAndUpdate(gomock.Any(), gomock.Any(),
+					bson.D{{Key: "$max", Value: bson.D{{Key: revisionCounterNumberKey, Value: int64(2)}}}}, gomock.Any(),
+				).Return(
+					mongo.NewSingleResultFromDocument(revisionCounter{NoteID: "id", Number: 2}, nil, nil),
+				),
+				mockDbCounters.EXPECT().FindOneAndUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(
+					mongo.NewSingleResultFromDocument(revisionCounter{NoteID: "id", Number: 3}, nil, nil),
+				),
+			)
+			mockDbRevisions.EXPECT().InsertOne(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, revision interface{}, _ ...interface{}) (*mongo.InsertOneResult, error) {
+					Expect(revision.(model.Revision).Number).To(Equal(int64(3)))
+					return &mongo.InsertOneResult{}, nil
+				},
+			)
+			mockDbRevisions.EXPECT().DeleteMany(gomock.Any(), gomock.Any()).Return(&mongo.DeleteResult{}, nil)
+
+			err := dbInstance.UpdateNote("test", model.Note{Title: "test", Description: "updated"}, AnyVersion)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should return an error when failed to save the revision", func() {
+			mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				mongo.NewSingleResultFromDocument(model.Note{ID: "id", Title: "test"}, nil, nil),
+			)
+			mockDbCounters.EXPECT().FindOneAndUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				mongo.NewSingleResultFromDocument(bson.D{}, errors.New(""), nil),
+			)
+
+			err := dbInstance.UpdateNote("test", model.Note{}, AnyVersion)
+			Expect(err).To(HaveOccurred())
+		})
+
 		It("should return an error when failed to update note in database", func() {
-			mockDbCollection.EXPECT().ReplaceOne(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New(""))
+			mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				mongo.NewSingleResultFromDocument(bson.D{}, errors.New(""), nil),
+			)
 
-			err := dbInstance.UpdateNote("", model.Note{})
+			err := dbInstance.UpdateNote("", model.Note{}, AnyVersion)
 			Expect(err).To(HaveOccurred())
 		})
 
 		It("should return an error when note to update is not in database", func() {
-			mockDbCollection.EXPECT().ReplaceOne(gomock.Any(), gomock.Any(), gomock.Any()).Return(&mongo.UpdateResult{
-				MatchedCount: 0,
-			}, nil)
+			mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil),
+			)
 
-			err := dbInstance.UpdateNote("", model.Note{})
+			err := dbInstance.UpdateNote("", model.Note{}, AnyVersion)
 			Expect(err).To(MatchError(mongo.ErrNoDocuments))
 		})
 	})
 
+	Describe("UpdateNoteByID", func() {
+		It("should update a note from the database when error does not occur and note is in database", func() {
+			mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), getIDFilter("id"), gomock.Any(), gomock.Any()).Return(
+				mongo.NewSingleResultFromDocument(model.Note{ID: "id", Title: "test"}, nil, nil),
+			)
+			expectRevisionSaved(1)
+
+			err := dbInstance.UpdateNoteByID("id", model.Note{}, AnyVersion)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should not replace the id of the note and increment its version", func() {
+			mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, _, update interface{}, _ ...*options.FindOneAndUpdateOptions) *mongo.SingleResult {
+					set := update.(bson.D)[0]
+					Expect(set.Key).To(Equal("$set"))
+					Expect(set.Value.(model.Note).ID).To(BeEmpty())
+					Expect(set.Value.(model.Note).Version).To(BeZero())
+					Expect(set.Value.(model.Note).CreatedAt).To(BeZero())
+					Expect(set.Value.(model.Note).UpdatedAt).NotTo(BeZero())
+					Expect(update.(bson.D)[1]).To(Equal(bson.E{Key: "$inc", Value: bson.D{{Key: noteVersionKey, Value: 1}}}))
+
+					return mongo.NewSingleResultFromDocument(model.Note{ID: "id", Title: "test"}, nil, nil)
+				},
+			)
+			expectRevisionSaved(1)
+
+			err := dbInstance.UpdateNoteByID("id", model.Note{ID: "other", Title: "test", Version: 5}, AnyVersion)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should only update the note with the expected version", func() {
+			filter := append(getIDFilter("id"), bson.E{Key: noteVersionKey, Value: int64(2)})
+
+			mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), filter, gomock.Any(), gomock.Any()).Return(
+				mongo.NewSingleResultFromDocument(model.Note{ID: "id", Title: "test", Version: 2}, nil, nil),
+			)
+			expectRevisionSaved(1)
+
+			err := dbInstance.UpdateNoteByID("id", model.Note{Title: "test"}, 2)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should return a version conflict when the note has another version", func() {
+			mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil),
+			)
+			mockDbCollection.EXPECT().FindOne(gomock.Any(), getIDFilter("id")).Return(
+				mongo.NewSingleResultFromDocument(model.Note{ID: "id", Title: "test", Version: 3}, nil, nil),
+			)
+
+			err := dbInstance.UpdateNoteByID("id", model.Note{Title: "test"}, 2)
+			Expect(err).To(MatchError(ErrVersionConflict))
+		})
+
+		It("should return an error when note with the expected version is not in database", func() {
+			mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil),
+			)
+			mockDbCollection.EXPECT().FindOne(gomock.Any(), gomock.Any()).Return(
+				mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil),
+			)
+
+			err := dbInstance.UpdateNoteByID("id", model.Note{Title: "test"}, 2)
+			Expect(err).To(MatchError(mongo.ErrNoDocuments))
+		})
+
+		It("should return an error when note to update is not in database", func() {
+			mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil),
+			)
+
+			err := dbInstance.UpdateNoteByID("", model.Note{}, AnyVersion)
+			Expect(err).To(MatchError(mongo.ErrNoDocuments))
+		})
+	})
+
+	Describe("UpsertNote", func() {
+		It("should add the note when no note was replaced", func() {
+			mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), getTitleFilter("test"), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, _, update interface{}, opts ...*options.FindOneAndUpdateOptions) *mongo.SingleResult {
+					Expect(*opts[0].Upsert).To(BeTrue())
+					Expect(update.(bson.D)[0].Value.(bson.D)[0]).To(Equal(bson.E{Key: noteTitleKey, Value: "test"}))
+					Expect(update.(bson.D)[1].Key).To(Equal("$setOnInsert"))
+
+					return mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil)
+				},
+			)
+
+			created, err := dbInstance.UpsertNote("test", model.Note{Title: "other"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created).To(BeTrue())
+		})
+
+		It("should replace the note when another upsert added it concurrently", func() {
+			duplicate := mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000}}}
+
+			gomock.InOrder(
+				mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(
+					mongo.NewSingleResultFromDocument(bson.D{}, duplicate, nil),
+				),
+				mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(
+					mongo.NewSingleResultFromDocument(model.Note{ID: "id", Title: "test"}, nil, nil),
+				),
+			)
+			expectRevisionSaved(1)
+
+			created, err := dbInstance.UpsertNote("test", model.Note{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created).To(BeFalse())
+		})
+	})
+
+	Describe("ChangeTags", func() {
+		It("should only update the note when its tags change and return the changed tags", func() {
+			mockDbCollection.EXPECT().FindOne(gomock.Any(), getTitleFilter("test"), gomock.Any()).Return(
+				mongo.NewSingleResultFromDocument(model.Note{ID: "id", Title: "test", Tags: []string{"a", "b"}}, nil, nil),
+			)
+			mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, filter, update interface{}, _ ...*options.FindOneAndUpdateOptions) *mongo.SingleResult {
+					changes := filter.(bson.D)[len(filter.(bson.D))-1]
+					Expect(changes.Key).To(Equal("$or"))
+					Expect(changes.Value).To(Equal(bson.A{
+						bson.D{{Key: noteTagsKey, Value: bson.D{{Key: "$not", Value: bson.D{{Key: "$all", Value: []string{"c"}}}}}}},
+						bson.D{{Key: noteTagsKey, Value: bson.D{{Key: "$in", Value: []string{"a"}}}}},
+					}))
+					Expect(update).To(BeAssignableToTypeOf(mongo.Pipeline{}))
+
+					return mongo.NewSingleResultFromDocument(model.Note{ID: "id", Title: "test", Tags: []string{"a", "b"}}, nil, nil)
+				},
+			)
+			expectRevisionSaved(1)
+
+			tags, err := dbInstance.ChangeTags("test", []string{"c", "c"}, []string{"a"}, AnyVersion)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tags).To(Equal([]string{"b", "c"}))
+		})
+
+		It("should return the current tags when the change is already applied", func() {
+			mockDbCollection.EXPECT().FindOne(gomock.Any(), getTitleFilter("test"), gomock.Any()).Return(
+				mongo.NewSingleResultFromDocument(model.Note{ID: "id", Title: "test", Tags: []string{"a"}, Version: 2}, nil, nil),
+			)
+			mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil),
+			)
+			mockDbCollection.EXPECT().FindOne(gomock.Any(), getIDFilter("id")).Return(
+				mongo.NewSingleResultFromDocument(model.Note{ID: "id", Title: "test", Tags: []string{"a"}, Version: 2}, nil, nil),
+			)
+
+			tags, err := dbInstance.ChangeTags("test", []string{"a"}, nil, 2)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tags).To(Equal([]string{"a"}))
+		})
+
+		It("should find the note by its title ignoring the case first, so the tags match their case", func() {
+			mockDbCollection.EXPECT().FindOne(gomock.Any(), getTitleFilter("TEST"), gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+					Expect(opts).To(HaveLen(1))
+					Expect(opts[0].Collation).To(Equal(&options.Collation{Locale: defaultMongoTitleLocale, Strength: caseInsensitiveStrength}))
+
+					return mongo.NewSingleResultFromDocument(model.Note{ID: "id", Title: "test", Tags: []string{"a"}}, nil, nil)
+				},
+			)
+			mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, filter, _ interface{}, opts ...*options.FindOneAndUpdateOptions) *mongo.SingleResult {
+					Expect(filter.(bson.D)[0]).To(Equal(getIDFilter("id")[0]))
+					Expect(opts[0].Collation).To(BeNil())
+
+					return mongo.NewSingleResultFromDocument(model.Note{ID: "id", Title: "test", Tags: []string{"a"}}, nil, nil)
+				},
+			)
+			expectRevisionSaved(1)
+
+			tags, err := dbInstance.ChangeTags("TEST", []string{"A"}, nil, AnyVersion)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tags).To(Equal([]string{"a", "A"}))
+		})
+	})
+
+	Describe("RenameValue", func() {
+		It("should rename the notes one at a time until no note has the value", func() {
+			renamedFilter := bson.D{getLiveFilter(), {Key: noteTagsKey, Value: "a"}}
+
+			mockDbCollection.EXPECT().CountDocuments(gomock.Any(), bson.D{getLiveFilter(), {Key: noteTagsKey, Value: "b"}}).Return(int64(1), nil)
+			gomock.InOrder(
+				mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), renamedFilter, gomock.Any(), gomock.Any()).Return(
+					mongo.NewSingleResultFromDocument(model.Note{ID: "id1", Title: "test1", Tags: []string{"a"}}, nil, nil),
+				),
+				mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), renamedFilter, gomock.Any(), gomock.Any()).Return(
+					mongo.NewSingleResultFromDocument(model.Note{ID: "id2", Title: "test2", Tags: []string{"a", "b"}}, nil, nil),
+				),
+				mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), renamedFilter, gomock.Any(), gomock.Any()).Return(
+					mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil),
+				),
+			)
+			expectRevisionSaved(2)
+
+			result, err := dbInstance.RenameValue(TagsField, "a", "b", false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(RenameResult{Changed: 2, Merged: true}))
+		})
+
+		It("should only count the notes in a dry run", func() {
+			mockDbCollection.EXPECT().CountDocuments(gomock.Any(), bson.D{getLiveFilter(), {Key: noteCategoryKey, Value: "house"}}).Return(int64(0), nil)
+			mockDbCollection.EXPECT().CountDocuments(gomock.Any(), bson.D{getLiveFilter(), {Key: noteCategoryKey, Value: "home"}}).Return(int64(3), nil)
+
+			result, err := dbInstance.RenameValue(CategoryField, "home", "house", true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(RenameResult{Changed: 3}))
+		})
+	})
+
+	Describe("PatchNote", func() {
+		It("should only set the patched fields and increment the version", func() {
+			title := "renamed"
+			tags := []string{"a"}
+
+			mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), getTitleFilter("test"), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, _, update interface{}, _ ...*options.FindOneAndUpdateOptions) *mongo.SingleResult {
+					set := update.(bson.D)[0]
+					Expect(set.Key).To(Equal("$set"))
+
+					fields := set.Value.(bson.D)
+					Expect(fields).To(HaveLen(3))
+					Expect(fields[0]).To(Equal(bson.E{Key: noteTitleKey, Value: "renamed"}))
+					Expect(fields[1]).To(Equal(bson.E{Key: noteTagsKey, Value: []string{"a"}}))
+					Expect(fields[2].Key).To(Equal(noteUpdatedAtKey))
+					Expect(update.(bson.D)[1]).To(Equal(bson.E{Key: "$inc", Value: bson.D{{Key: noteVersionKey, Value: 1}}}))
+
+					return mongo.NewSingleResultFromDocument(model.Note{ID: "id", Title: "test"}, nil, nil)
+				},
+			)
+			expectRevisionSaved(1)
+
+			err := dbInstance.PatchNote("test", NotePatch{Title: &title, Tags: &tags}, AnyVersion)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should return a version conflict when the note has another version", func() {
+			filter := append(getIDFilter("id"), bson.E{Key: noteVersionKey, Value: int64(2)})
+
+			mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), filter, gomock.Any(), gomock.Any()).Return(
+				mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil),
+			)
+			mockDbCollection.EXPECT().FindOne(gomock.Any(), getIDFilter("id")).Return(
+				mongo.NewSingleResultFromDocument(model.Note{ID: "id", Title: "test", Version: 3}, nil, nil),
+			)
+
+			err := dbInstance.PatchNoteByID("id", NotePatch{}, 2)
+			Expect(err).To(MatchError(ErrVersionConflict))
+		})
+	})
+
+	Describe("GetNoteByID", func() {
+		It("should return note when no error occurs", func() {
+			mockDbCollection.EXPECT().FindOne(gomock.Any(), getIDFilter("id")).Return(
+				mongo.NewSingleResultFromDocument(model.Note{
+					ID:    "id",
+					Title: "test",
+				}, nil, nil),
+			)
+
+			note, err := dbInstance.GetNoteByID("id")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.ID).To(Equal("id"))
+			Expect(note.Title).To(Equal("test"))
+		})
+
+		It("should return an error when failed to get note", func() {
+			mockDbCollection.EXPECT().FindOne(gomock.Any(), gomock.Any()).Return(
+				mongo.NewSingleResultFromDocument(bson.D{}, errors.New(""), nil),
+			)
+
+			_, err := dbInstance.GetNoteByID("")
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
 	Describe("GetNote", func() {
+		It("should match the title with the collation of the locale of the titles", func() {
+			dbInstance.titleLocale = "de-AT"
+
+			mockDbCollection.EXPECT().FindOne(gomock.Any(), getTitleFilter("STANDUP"), gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+					Expect(opts).To(HaveLen(1))
+					Expect(opts[0].Collation).To(Equal(&options.Collation{Locale: "de_AT", Strength: caseInsensitiveStrength}))
+
+					return mongo.NewSingleResultFromDocument(model.Note{Title: "Standup"}, nil, nil)
+				},
+			)
+
+			note, err := dbInstance.GetNote("STANDUP")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.Title).To(Equal("Standup"))
+		})
+
+		It("should only read the fields of the projection", func() {
+			mockDbCollection.EXPECT().FindOne(gomock.Any(), getIDFilter("id"), gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+					Expect(opts).To(HaveLen(1))
+					Expect(opts[0].Projection).To(Equal(bson.D{{Key: noteIDKey, Value: 1}, {Key: noteTagsKey, Value: 1}}))
+
+					return mongo.NewSingleResultFromDocument(model.Note{ID: "id", Tags: []string{"a"}}, nil, nil)
+				},
+			)
+
+			note, err := dbInstance.GetProjectedNoteByID("id", Projection{"tags"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(note.Tags).To(Equal([]string{"a"}))
+		})
+
 		It("should return note when no error occurs", func() {
 			mockDbCollection.EXPECT().FindOne(gomock.Any(), gomock.Any(), gomock.Any()).Return(
 				mongo.NewSingleResultFromDocument(model.Note{
@@ -106,7 +617,7 @@ var _ = Describe("DatabaseNotes", func() {
 
 		It("should return an error when failed to get note", func() {
 			mockDbCollection.EXPECT().FindOne(gomock.Any(), gomock.Any(), gomock.Any()).Return(
-				mongo.NewSingleResultFromDocument(nil, errors.New(""), nil),
+				mongo.NewSingleResultFromDocument(bson.D{}, errors.New(""), nil),
 			)
 
 			_, err := dbInstance.GetNote("")
@@ -116,7 +627,7 @@ var _ = Describe("DatabaseNotes", func() {
 
 		It("should return an error when failed to get note", func() {
 			mockDbCollection.EXPECT().FindOne(gomock.Any(), gomock.Any(), gomock.Any()).Return(
-				mongo.NewSingleResultFromDocument(nil, errors.New(""), nil),
+				mongo.NewSingleResultFromDocument(bson.D{}, errors.New(""), nil),
 			)
 
 			_, err := dbInstance.GetNote("")
@@ -147,9 +658,9 @@ var _ = Describe("DatabaseNotes", func() {
 		})
 
 		It("should return an error when failed to get notes", func() {
-			mockDbCollection.EXPECT().Find(gomock.Any(), gomock.Any()).Return(mongo.NewCursorFromDocuments([]interface{}{nil}, nil, nil))
+			mockDbCollection.EXPECT().Find(gomock.Any(), gomock.Any(), gomock.Any()).Return(mongo.NewCursorFromDocuments([]interface{}{nil}, nil, nil))
 
-			_, err := dbInstance.GetNotesFiltered(nil, "", "")
+			_, _, err := dbInstance.GetNotesFiltered(nil, TagFilter{}, "", TimeRange{}, nil, Page{}, nil)
 
 			Expect(err).To(HaveOccurred())
 		})
@@ -157,7 +668,7 @@ var _ = Describe("DatabaseNotes", func() {
 
 	Describe("GetNotesFiltered", func() {
 		It("should return notes when no error occurs", func() {
-			mockDbCollection.EXPECT().Find(gomock.Any(), gomock.Any()).Return(mongo.NewCursorFromDocuments(
+			mockDbCollection.EXPECT().Find(gomock.Any(), gomock.Any(), gomock.Any()).Return(mongo.NewCursorFromDocuments(
 				[]interface{}{
 					model.Note{
 						Title: "test1",
@@ -169,7 +680,7 @@ var _ = Describe("DatabaseNotes", func() {
 				nil, nil),
 			)
 
-			notes, err := dbInstance.GetNotesFiltered(nil, "", "")
+			notes, _, err := dbInstance.GetNotesFiltered(nil, TagFilter{}, "", TimeRange{}, nil, Page{}, nil)
 
 			Expect(err).NotTo(HaveOccurred())
 			Expect(notes).NotTo(BeEmpty())
@@ -183,55 +694,333 @@ var _ = Describe("DatabaseNotes", func() {
 
 			Expect(err).To(HaveOccurred())
 		})
+
+		It("should request one more note than the limit to return the cursor of the next page", func() {
+			mockDbCollection.EXPECT().Find(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+					Expect(*opts[0].Limit).To(Equal(int64(2)))
+					Expect(opts[0].Sort).To(Equal(bson.D{{Key: noteCreatedAtKey, Value: 1}, {Key: noteIDKey, Value: 1}}))
+
+					return mongo.NewCursorFromDocuments(
+						[]interface{}{
+							model.Note{ID: "id1", Title: "test1"},
+							model.Note{ID: "id2", Title: "test2"},
+						},
+						nil, nil)
+				},
+			)
+
+			notes, next, err := dbInstance.GetNotesFiltered(nil, TagFilter{}, "", TimeRange{}, nil, Page{Limit: 1}, nil)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(1))
+			Expect(next).To(Equal(encodeCursor(model.Note{ID: "id1"}, defaultSort)))
+		})
+
+		It("should only read the fields of the projection and the sorted fields", func() {
+			mockDbCollection.EXPECT().Find(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+					Expect(opts[0].Projection).To(Equal(bson.D{
+						{Key: noteIDKey, Value: 1},
+						{Key: noteTitleKey, Value: 1},
+						{Key: noteUpdatedAtKey, Value: 1},
+						{Key: noteCreatedAtKey, Value: 1},
+					}))
+
+					return mongo.NewCursorFromDocuments([]interface{}{}, nil, nil)
+				},
+			)
+
+			_, _, err := dbInstance.GetNotesFiltered(nil, TagFilter{}, "", TimeRange{}, nil, Page{}, Projection{"id", "title", "date", "updatedAt"})
+
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should compile the structured filter to the query", func() {
+			where, err := filter.Parse("category = work AND (tag in (a, b) OR NOT description exists) AND createdAt < 2024-01-01T00:00:00Z", time.UTC)
+			Expect(err).NotTo(HaveOccurred())
+
+			mockDbCollection.EXPECT().Find(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, query interface{}, _ ...*options.FindOptions) (*mongo.Cursor, error) {
+					Expect(query.(bson.D)[1]).To(Equal(bson.E{Key: "$and", Value: bson.A{
+						bson.D{{Key: "$and", Value: bson.A{
+							bson.D{{Key: noteCategoryKey, Value: bson.D{{Key: "$in", Value: bson.A{"work"}}}}},
+							bson.D{{Key: "$or", Value: bson.A{
+								bson.D{{Key: noteTagsKey, Value: bson.D{{Key: "$in", Value: bson.A{"a", "b"}}}}},
+								bson.D{{Key: "$nor", Value: bson.A{
+									bson.D{{Key: noteDescriptionKey, Value: bson.D{{Key: "$nin", Value: bson.A{"", nil}}}}},
+								}}},
+							}}},
+							bson.D{{Key: noteCreatedAtKey, Value: bson.D{{Key: "$lt", Value: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)}}}},
+						}}},
+					}}))
+
+					return mongo.NewCursorFromDocuments([]interface{}{}, nil, nil)
+				},
+			)
+
+			_, _, err = dbInstance.GetNotesFiltered(where, TagFilter{}, "", TimeRange{}, nil, Page{}, nil)
+
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should match the notes without a text field when it's compared to an empty text", func() {
+			Expect(getComparisonFilter(filter.Comparison{Field: filter.CategoryField, Operator: filter.Ne, Values: []string{""}})).To(Equal(
+				bson.E{Key: noteCategoryKey, Value: bson.D{{Key: "$nin", Value: bson.A{"", nil}}}},
+			))
+			Expect(getComparisonFilter(filter.Comparison{Field: filter.TagField, Operator: filter.Exists})).To(Equal(
+				bson.E{Key: noteTagsKey + ".0", Value: bson.D{{Key: "$exists", Value: true}}},
+			))
+		})
+
+		It("should return an error when the cursor is invalid", func() {
+			_, _, err := dbInstance.GetNotesFiltered(nil, TagFilter{}, "", TimeRange{}, nil, Page{Cursor: "invalid"}, nil)
+
+			Expect(err).To(MatchError(ErrInvalidCursor))
+		})
+	})
+
+	Describe("StreamNotes", func() {
+		It("should stream the notes of the cursor", func() {
+			mockDbCollection.EXPECT().Find(gomock.Any(), gomock.Any(), gomock.Any()).Return(mongo.NewCursorFromDocuments(
+				[]interface{}{
+					model.Note{Title: "test1"},
+					model.Note{Title: "test2"},
+				},
+				nil, nil),
+			)
+
+			titles := []string{}
+			err := dbInstance.StreamNotes(nil, TagFilter{}, "", TimeRange{}, nil, func(note model.Note) error {
+				titles = append(titles, note.Title)
+				return nil
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(titles).To(Equal([]string{"test1", "test2"}))
+		})
+
+		It("should return an error when failed to find notes", func() {
+			mockDbCollection.EXPECT().Find(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("find failed"))
+
+			err := dbInstance.StreamNotes(nil, TagFilter{}, "", TimeRange{}, nil, func(note model.Note) error {
+				return nil
+			})
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("CountValues", func() {
+		It("should group the distinct tags and request one more value than the limit", func() {
+			mockDbCollection.EXPECT().Aggregate(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, pipeline interface{}, _ ...*options.AggregateOptions) (*mongo.Cursor, error) {
+					stages := pipeline.(mongo.Pipeline)
+					Expect(stages[2]).To(Equal(bson.D{{Key: "$unwind", Value: "$" + noteTagsKey}}))
+					Expect(stages[len(stages)-2]).To(Equal(bson.D{{Key: "$sort", Value: bson.D{{Key: CountField, Value: -1}, {Key: ValueField, Value: 1}}}}))
+					Expect(stages[len(stages)-1]).To(Equal(bson.D{{Key: "$limit", Value: int64(2)}}))
+
+					return mongo.NewCursorFromDocuments(
+						[]interface{}{
+							bson.D{{Key: ValueField, Value: "b"}, {Key: CountField, Value: int32(2)}},
+							bson.D{{Key: ValueField, Value: "a"}, {Key: CountField, Value: int32(1)}},
+						},
+						nil, nil)
+				},
+			)
+
+			counts, next, err := dbInstance.CountValues(TagsField, TagFilter{}, "", TimeRange{}, nil, Page{Limit: 1})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(counts).To(Equal([]model.ValueCount{{Value: "b", Count: 2}}))
+			Expect(next).NotTo(BeEmpty())
+		})
+
+		It("should not unwind the category", func() {
+			mockDbCollection.EXPECT().Aggregate(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, pipeline interface{}, _ ...*options.AggregateOptions) (*mongo.Cursor, error) {
+					for _, stage := range pipeline.(mongo.Pipeline) {
+						Expect(stage[0].Key).NotTo(Equal("$unwind"))
+					}
+
+					return mongo.NewCursorFromDocuments([]interface{}{}, nil, nil)
+				},
+			)
+
+			counts, _, err := dbInstance.CountValues(CategoryField, TagFilter{}, "", TimeRange{}, nil, Page{})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(counts).To(BeEmpty())
+		})
+
+		It("should return an error when failed to aggregate the notes", func() {
+			mockDbCollection.EXPECT().Aggregate(gomock.Any(), gomock.Any()).Return(nil, errors.New("failed"))
+
+			_, _, err := dbInstance.CountValues(TagsField, TagFilter{}, "", TimeRange{}, nil, Page{})
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("getPageFilter", func() {
+		It("should return empty object for the first page", func() {
+			filter := getPageFilter(nil, defaultSort)
+			Expect(filter).To(Equal(bson.E{}))
+		})
+
+		It("should match the notes created later or created at the same time with a greater id", func() {
+			createdAt := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+			filter := getPageFilter(&pagePosition{Values: []interface{}{createdAt}, ID: "id"}, defaultSort)
+			Expect(filter).To(Equal(bson.E{
+				Key: "$or",
+				Value: bson.A{
+					bson.D{{Key: "createdAt", Value: bson.D{{Key: "$gt", Value: createdAt}}}},
+					bson.D{
+						{Key: "createdAt", Value: createdAt},
+						{Key: "$or", Value: bson.A{
+							bson.D{{Key: "_id", Value: bson.D{{Key: "$gt", Value: "id"}}}},
+							bson.D{{Key: "_id", Value: bson.D{{Key: "$type", Value: "objectId"}}}},
+						}},
+					},
+				},
+			}))
+		})
+
+		It("should match the notes after the position in the order of every sorted field", func() {
+			updatedAt := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+			sortBy := []SortField{{Field: UpdatedAtField, Descending: true}, {Field: TitleField}}
+
+			filter := getPageFilter(&pagePosition{Values: []interface{}{updatedAt, "test"}, ID: "id"}, sortBy)
+			Expect(filter).To(Equal(bson.E{
+				Key: "$or",
+				Value: bson.A{
+					bson.D{{Key: "updatedAt", Value: bson.D{{Key: "$lt", Value: updatedAt}}}},
+					bson.D{
+						{Key: "updatedAt", Value: updatedAt},
+						{Key: "title", Value: bson.D{{Key: "$gt", Value: "test"}}},
+					},
+					bson.D{
+						{Key: "updatedAt", Value: updatedAt},
+						{Key: "title", Value: "test"},
+						{Key: "$or", Value: bson.A{
+							bson.D{{Key: "_id", Value: bson.D{{Key: "$gt", Value: "id"}}}},
+							bson.D{{Key: "_id", Value: bson.D{{Key: "$type", Value: "objectId"}}}},
+						}},
+					},
+				},
+			}))
+		})
+
+		It("should only match the greater ObjectIDs after a note with an ObjectID", func() {
+			objectID := primitive.NewObjectID()
+
+			filter := getIDAfterFilter(objectID.Hex(), false)
+			Expect(filter).To(Equal(bson.E{Key: "_id", Value: bson.D{{Key: "$gt", Value: objectID}}}))
+		})
+
+		It("should only match the smaller string ids after a note with a string id in descending order", func() {
+			filter := getIDAfterFilter("id", true)
+			Expect(filter).To(Equal(bson.E{Key: "_id", Value: bson.D{{Key: "$lt", Value: "id"}}}))
+		})
+	})
+
+	Describe("getSortOption", func() {
+		It("should sort by the fields then by id in the direction of the last field", func() {
+			option := getSortOption([]SortField{{Field: CategoryField}, {Field: UpdatedAtField, Descending: true}})
+			Expect(option).To(Equal(bson.D{
+				{Key: "category", Value: 1},
+				{Key: "updatedAt", Value: -1},
+				{Key: "_id", Value: -1},
+			}))
+		})
 	})
 
 	Describe("DeleteNote", func() {
 		It("should return no error when no error occurs", func() {
-			mockDbCollection.EXPECT().DeleteOne(gomock.Any(), gomock.Any()).Return(
-				&mongo.DeleteResult{
-					DeletedCount: 1,
-				},
-				nil,
+			mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				mongo.NewSingleResultFromDocument(model.Note{ID: "id", Title: "test"}, nil, nil),
 			)
+			expectRevisionSaved(1)
 
-			err := dbInstance.DeleteNote("")
+			err := dbInstance.DeleteNote("test", AnyVersion)
 
 			Expect(err).NotTo(HaveOccurred())
 		})
 
 		It("should return error when failed to delete note", func() {
-			mockDbCollection.EXPECT().DeleteOne(gomock.Any(), gomock.Any()).Return(
-				&mongo.DeleteResult{},
-				errors.New(""),
+			mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				mongo.NewSingleResultFromDocument(bson.D{}, errors.New(""), nil),
 			)
 
-			err := dbInstance.DeleteNote("")
+			err := dbInstance.DeleteNote("", AnyVersion)
 
 			Expect(err).To(HaveOccurred())
 		})
 
 		It("should return error when no notes in database", func() {
-			mockDbCollection.EXPECT().DeleteOne(gomock.Any(), gomock.Any()).Return(
-				&mongo.DeleteResult{
-					DeletedCount: 0,
-				},
-				nil,
+			mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil),
 			)
 
-			err := dbInstance.DeleteNote("")
+			err := dbInstance.DeleteNote("", AnyVersion)
 
-			Expect(err).To(HaveOccurred())
+			Expect(err).To(MatchError(mongo.ErrNoDocuments))
+		})
+	})
+
+	Describe("DeleteNoteByID", func() {
+		It("should return a version conflict when the note has another version", func() {
+			filter := append(getIDFilter("id"), bson.E{Key: noteVersionKey, Value: int64(2)})
+
+			mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), filter, gomock.Any(), gomock.Any()).Return(
+				mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil),
+			)
+			mockDbCollection.EXPECT().FindOne(gomock.Any(), getIDFilter("id")).Return(
+				mongo.NewSingleResultFromDocument(model.Note{ID: "id", Title: "test", Version: 3}, nil, nil),
+			)
+
+			err := dbInstance.DeleteNoteByID("id", 2)
+			Expect(err).To(MatchError(ErrVersionConflict))
+		})
+
+		It("should return no error when no error occurs", func() {
+			mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), getIDFilter("id"), gomock.Any(), gomock.Any()).Return(
+				mongo.NewSingleResultFromDocument(model.Note{ID: "id", Title: "test"}, nil, nil),
+			)
+			expectRevisionSaved(1)
+
+			err := dbInstance.DeleteNoteByID("id", AnyVersion)
+
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should return error when no notes in database", func() {
+			mockDbCollection.EXPECT().FindOneAndUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil),
+			)
+
+			err := dbInstance.DeleteNoteByID("", AnyVersion)
+
+			Expect(err).To(MatchError(mongo.ErrNoDocuments))
 		})
 	})
 
 	Describe("DeleteNotes", func() {
+		expectNotesFound := func(notes ...interface{}) {
+			mockDbCollection.EXPECT().Find(gomock.Any(), bson.D{getLiveFilter()}, gomock.Any()).Return(mongo.NewCursorFromDocuments(notes, nil, nil))
+		}
+
 		It("should return no error when no error occurs", func() {
-			mockDbCollection.EXPECT().DeleteMany(gomock.Any(), gomock.Any()).Return(
-				&mongo.DeleteResult{
-					DeletedCount: 1,
+			expectNotesFound(model.Note{ID: "id1", Title: "test1"}, model.Note{ID: "id2", Title: "test2"})
+			mockDbCollection.EXPECT().UpdateMany(gomock.Any(), bson.D{getLiveFilter()}, gomock.Any()).Return(
+				&mongo.UpdateResult{
+					MatchedCount:  2,
+					ModifiedCount: 2,
 				},
 				nil,
 			)
+			expectRevisionSaved(2)
 
 			err := dbInstance.DeleteNotes()
 
@@ -239,8 +1028,10 @@ var _ = Describe("DatabaseNotes", func() {
 		})
 
 		It("should return error when failed to delete note", func() {
-			mockDbCollection.EXPECT().DeleteMany(gomock.Any(), gomock.Any()).Return(
-				&mongo.DeleteResult{},
+			expectNotesFound(model.Note{ID: "id1", Title: "test1"})
+			expectRevisionSaved(1)
+			mockDbCollection.EXPECT().UpdateMany(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				&mongo.UpdateResult{},
 				errors.New(""),
 			)
 
@@ -249,28 +1040,80 @@ var _ = Describe("DatabaseNotes", func() {
 			Expect(err).To(HaveOccurred())
 		})
 
-		It("should return error when no notes in database", func() {
-			mockDbCollection.EXPECT().DeleteMany(gomock.Any(), gomock.Any()).Return(
-				&mongo.DeleteResult{
-					DeletedCount: 0,
-				},
-				nil,
+		It("should return error when failed to save a revision", func() {
+			expectNotesFound(model.Note{ID: "id1", Title: "test1"})
+			mockDbCounters.EXPECT().FindOneAndUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				mongo.NewSingleResultFromDocument(bson.D{}, errors.New(""), nil),
 			)
 
 			err := dbInstance.DeleteNotes()
 
 			Expect(err).To(HaveOccurred())
 		})
+
+		It("should return error when no notes in database", func() {
+			expectNotesFound()
+			mockDbCollection.EXPECT().UpdateMany(gomock.Any(), gomock.Any(), gomock.Any()).Return(&mongo.UpdateResult{}, nil)
+
+			err := dbInstance.DeleteNotes()
+
+			Expect(err).To(MatchError(mongo.ErrNoDocuments))
+		})
+	})
+
+	Describe("getIDFilter", func() {
+		It("should match the id as a string", func() {
+			filter := getIDFilter("id")
+			Expect(filter).To(Equal(bson.D{
+				{
+					Key:   "_id",
+					Value: "id",
+				},
+				getLiveFilter(),
+			}))
+		})
+
+		It("should also match an ObjectID when the id is its hex representation", func() {
+			objectID := primitive.NewObjectID()
+
+			filter := getIDFilter(objectID.Hex())
+			Expect(filter).To(Equal(bson.D{
+				{
+					Key: "_id",
+					Value: bson.D{
+						{
+							Key:   "$in",
+							Value: bson.A{objectID.Hex(), objectID},
+						},
+					},
+				},
+				getLiveFilter(),
+			}))
+		})
+	})
+
+	Describe("getVersionFilter", func() {
+		It("should return the filter when any version is expected", func() {
+			Expect(getVersionFilter(getTitleFilter("test"), AnyVersion)).To(Equal(getTitleFilter("test")))
+		})
+
+		It("should match the notes without a version when the version 0 is expected", func() {
+			Expect(getVersionFilter(getTitleFilter("test"), 0)).To(Equal(bson.D{
+				{Key: noteTitleKey, Value: "test"},
+				getLiveFilter(),
+				{Key: noteVersionKey, Value: nil},
+			}))
+		})
 	})
 
 	Describe("getTagsFilter", func() {
 		It("should return an empty object when no tags provided", func() {
-			filter := getTagsFilter([]string{""})
+			filter := getTagsFilter(TagFilter{})
 			Expect(filter).To(Equal(bson.E{}))
 		})
 
 		It("should return not empty object when tags are provided", func() {
-			filter := getTagsFilter([]string{"test"})
+			filter := getTagsFilter(TagFilter{Tags: []string{"test"}})
 
 			Expect(filter).NotTo(Equal(bson.E{}))
 			Expect(filter).To(Equal(bson.E{
@@ -283,19 +1126,53 @@ var _ = Describe("DatabaseNotes", func() {
 				},
 			}))
 		})
+
+		It("should match any of the tags and none of the excluded tags", func() {
+			filter := getTagsFilter(TagFilter{Tags: []string{"a", "b"}, Any: true, Excluded: []string{"c"}})
+
+			Expect(filter).To(Equal(bson.E{
+				Key: "tags",
+				Value: bson.D{
+					{Key: "$in", Value: []string{"a", "b"}},
+					{Key: "$nin", Value: []string{"c"}},
+				},
+			}))
+		})
+
+		It("should match the notes without a first tag when untagged", func() {
+			filter := getTagsFilter(TagFilter{Untagged: true})
+
+			Expect(filter).To(Equal(bson.E{Key: "tags.0", Value: bson.D{{Key: "$exists", Value: false}}}))
+		})
 	})
 
-	Describe("getDateFilter", func() {
-		It("should return empty object when no date provided", func() {
-			filter := getDateFilter("")
+	Describe("getTimeRangeFilter", func() {
+		It("should return empty object when the range has no bounds", func() {
+			filter := getTimeRangeFilter(TimeRange{Field: UpdatedAtField})
 			Expect(filter).To(Equal(bson.E{}))
 		})
 
-		It("should return not empty object when date is provided", func() {
-			filter := getDateFilter("test")
+		It("should include the start and exclude the end of the range", func() {
+			from := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+			to := from.AddDate(0, 0, 1)
+
+			filter := getTimeRangeFilter(TimeRange{Field: UpdatedAtField, From: from, To: to})
 			Expect(filter).To(Equal(bson.E{
-				Key:   "date",
-				Value: "test",
+				Key: "updatedAt",
+				Value: bson.D{
+					{Key: "$gte", Value: from},
+					{Key: "$lt", Value: to},
+				},
+			}))
+		})
+
+		It("should leave the range open when a bound is missing", func() {
+			from := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+			filter := getTimeRangeFilter(TimeRange{Field: CreatedAtField, From: from})
+			Expect(filter).To(Equal(bson.E{
+				Key:   "createdAt",
+				Value: bson.D{{Key: "$gte", Value: from}},
 			}))
 		})
 	})
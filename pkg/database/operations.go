@@ -0,0 +1,96 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/notes-project/api/pkg/model"
+)
+
+// types of the operations applied by ApplyOperations
+const (
+	OperationCreate = "create"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+)
+
+// Operation is a single change of the notes applied by ApplyOperations
+type Operation struct {
+	Type string
+	// the updated or deleted note is found by its id, or by its title when the id is empty
+	NoteID    string
+	NoteTitle string
+	// the created note, or the fields of the updated note
+	Note model.Note
+	// only for the updates and deletes, AnyVersion skips the version check
+	ExpectedVersion int64
+}
+
+// ErrNotApplied is returned for the operations of an atomic batch that were rolled back or never applied
+// because another operation of the batch failed
+var ErrNotApplied = errors.New("operation was not applied because another operation of the batch failed")
+
+// noteWriter changes the notes one at a time, it's implemented by every driver and by their transactions
+type noteWriter interface {
+	AddNote(note model.Note) error
+	UpdateNote(noteTitle string, updatedNote model.Note, expectedVersion int64) error
+	UpdateNoteByID(noteID string, updatedNote model.Note, expectedVersion int64) error
+	DeleteNote(noteTitle string, expectedVersion int64) error
+	DeleteNoteByID(noteID string, expectedVersion int64) error
+}
+
+// applyOperation applies the operation with the same method as a single change
+func applyOperation(w noteWriter, operation Operation) error {
+	switch operation.Type {
+	case OperationCreate:
+		return w.AddNote(operation.Note)
+	case OperationUpdate:
+		if operation.NoteID != "" {
+			return w.UpdateNoteByID(operation.NoteID, operation.Note, operation.ExpectedVersion)
+		}
+
+		return w.UpdateNote(operation.NoteTitle, operation.Note, operation.ExpectedVersion)
+	case OperationDelete:
+		if operation.NoteID != "" {
+			return w.DeleteNoteByID(operation.NoteID, operation.ExpectedVersion)
+		}
+
+		return w.DeleteNote(operation.NoteTitle, operation.ExpectedVersion)
+	}
+
+	return fmt.Errorf("failed to apply operation '%s', the operation doesn't exist", operation.Type)
+}
+
+// applyEach applies every operation in order, independently of the failures of the previous ones
+func applyEach(w noteWriter, operations []Operation) []error {
+	errs := make([]error, 0, len(operations))
+	for _, operation := range operations {
+		errs = append(errs, applyOperation(w, operation))
+	}
+
+	return errs
+}
+
+// applyAll applies the operations in order until one fails, it returns the error of each operation,
+// where all the operations other than the failed one are not applied, and whether they were all applied
+func applyAll(w noteWriter, operations []Operation) ([]error, bool) {
+	errs := make([]error, len(operations))
+
+	for i, operation := range operations {
+		err := applyOperation(w, operation)
+		if err == nil {
+			continue
+		}
+
+		// the transaction is rolled back, so none of the other operations is applied
+		for j := range errs {
+			errs[j] = ErrNotApplied
+		}
+
+		errs[i] = err
+
+		return errs, false
+	}
+
+	return errs, true
+}
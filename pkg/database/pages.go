@@ -0,0 +1,326 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/notes-project/api/pkg/model"
+)
+
+// Page selects at most Limit notes listed after the position of the Cursor returned with the previous page,
+// a zero limit lists all the notes and an empty cursor starts from the first note.
+// The notes added while paging don't shift the pages, because the cursor holds the position of the last note.
+type Page struct {
+	Limit  int64
+	Cursor string
+}
+
+// SortField orders the listed notes by one of the fields that can be sorted, ascending unless Descending
+type SortField struct {
+	Field      string
+	Descending bool
+}
+
+// RelevanceField sorts the notes found by a search by the score of their match
+const RelevanceField = "relevance"
+
+// kinds of the values of the sorted fields, used to decode them from the cursors
+const (
+	stringSortValue = iota
+	timeSortValue
+	scoreSortValue
+	countSortValue
+)
+
+var (
+	// the notes are listed by creation time when no sort is provided
+	defaultSort = []SortField{{Field: CreatedAtField}}
+	// the notes found by a search are listed by relevance when no sort is provided
+	defaultSearchSort = []SortField{{Field: RelevanceField, Descending: true}}
+
+	// the fields of model.Note that can be sorted along with the kind of their values,
+	// the relevance can only be sorted in a search
+	sortFields = map[string]int{
+		TitleField:     stringSortValue,
+		CategoryField:  stringSortValue,
+		CreatedAtField: timeSortValue,
+		UpdatedAtField: timeSortValue,
+		RelevanceField: scoreSortValue,
+	}
+)
+
+// getSort returns the sort of the listed notes, the notes with equal values are ordered by id
+// in the direction of the last field, so a single field can be sorted with an index in both directions
+func getSort(sortBy []SortField) ([]SortField, error) {
+	if len(sortBy) == 0 {
+		return defaultSort, nil
+	}
+
+	for _, field := range sortBy {
+		if field.Field == RelevanceField {
+			return nil, fmt.Errorf("failed to sort notes by '%s', only the notes found by a search can be", field.Field)
+		}
+
+		if _, ok := sortFields[field.Field]; !ok {
+			return nil, fmt.Errorf("failed to sort notes by '%s', the field can't be sorted", field.Field)
+		}
+	}
+
+	return sortBy, nil
+}
+
+// getSearchSort returns the sort of the notes found by a search, which can also be sorted by relevance
+func getSearchSort(sortBy []SortField) ([]SortField, error) {
+	if len(sortBy) == 0 {
+		return defaultSearchSort, nil
+	}
+
+	for _, field := range sortBy {
+		if _, ok := sortFields[field.Field]; !ok {
+			return nil, fmt.Errorf("failed to sort notes by '%s', the field can't be sorted", field.Field)
+		}
+	}
+
+	return sortBy, nil
+}
+
+// idDescending returns true when the notes with equal values are ordered by descending id
+func idDescending(sortBy []SortField) bool {
+	return sortBy[len(sortBy)-1].Descending
+}
+
+// sortKey identifies the sort a cursor was returned with, so it can't be used with another sort
+func sortKey(sortBy []SortField) string {
+	fields := make([]string, 0, len(sortBy))
+	for _, field := range sortBy {
+		if field.Descending {
+			fields = append(fields, "-"+field.Field)
+		} else {
+			fields = append(fields, field.Field)
+		}
+	}
+
+	return strings.Join(fields, ",")
+}
+
+// sortValue returns the value of the sorted field of the note
+func sortValue(note model.Note, field string) interface{} {
+	switch field {
+	case TitleField:
+		return note.Title
+	case CategoryField:
+		return note.Category
+	case CreatedAtField:
+		return note.CreatedAt
+	case UpdatedAtField:
+		return note.UpdatedAt
+	}
+
+	return nil
+}
+
+// pagePosition is the position of a note in the listing order, encoded in the opaque cursors
+type pagePosition struct {
+	Values []interface{}
+	ID     string
+}
+
+// encodedPosition is the pagePosition encoded in a cursor, along with the sort it's valid for
+type encodedPosition struct {
+	Sort   string            `json:"s"`
+	Values []json.RawMessage `json:"v"`
+	ID     string            `json:"i"`
+}
+
+// notePosition returns the position of the note in the sort
+func notePosition(note model.Note, sortBy []SortField) pagePosition {
+	values := make([]interface{}, 0, len(sortBy))
+	for _, field := range sortBy {
+		values = append(values, sortValue(note, field.Field))
+	}
+
+	return pagePosition{Values: values, ID: note.ID}
+}
+
+// hitPosition returns the position of the note found by a search in the sort, which can include its score
+func hitPosition(hit model.SearchHit, sortBy []SortField) pagePosition {
+	position := notePosition(hit.Note, sortBy)
+	for i, field := range sortBy {
+		if field.Field == RelevanceField {
+			position.Values[i] = hit.Score
+		}
+	}
+
+	return position
+}
+
+// encodeCursor returns the cursor of the page that starts after the note
+func encodeCursor(note model.Note, sortBy []SortField) string {
+	return encodePosition(notePosition(note, sortBy), sortBy)
+}
+
+// encodePosition returns the cursor of the page that starts after the position
+func encodePosition(position pagePosition, sortBy []SortField) string {
+	encoded := encodedPosition{Sort: sortKey(sortBy), ID: position.ID}
+	for _, value := range position.Values {
+		data, _ := json.Marshal(value)
+		encoded.Values = append(encoded.Values, data)
+	}
+
+	data, _ := json.Marshal(encoded)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor returns the position after which the page starts, nil for the first page
+func decodeCursor(cursor string, sortBy []SortField) (*pagePosition, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	invalid := fmt.Errorf("failed to decode cursor '%s', error: %w", cursor, ErrInvalidCursor)
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, invalid
+	}
+
+	encoded := encodedPosition{}
+
+	err = json.Unmarshal(data, &encoded)
+	if err != nil || encoded.ID == "" || encoded.Sort != sortKey(sortBy) || len(encoded.Values) != len(sortBy) {
+		return nil, invalid
+	}
+
+	position := pagePosition{ID: encoded.ID}
+	for i, field := range sortBy {
+		var value interface{}
+
+		switch sortValueKind(field.Field) {
+		case timeSortValue:
+			t := time.Time{}
+			err = json.Unmarshal(encoded.Values[i], &t)
+			value = t
+		case scoreSortValue:
+			score := 0.0
+			err = json.Unmarshal(encoded.Values[i], &score)
+			value = score
+		case countSortValue:
+			count := int64(0)
+			err = json.Unmarshal(encoded.Values[i], &count)
+			value = count
+		default:
+			s := ""
+			err = json.Unmarshal(encoded.Values[i], &s)
+			value = s
+		}
+
+		if err != nil {
+			return nil, invalid
+		}
+
+		position.Values = append(position.Values, value)
+	}
+
+	return &position, nil
+}
+
+// sortValueKind returns the kind of the values of the sorted field of the notes or of the counted values
+func sortValueKind(field string) int {
+	kind, ok := sortFields[field]
+	if !ok {
+		return countSortFields[field]
+	}
+
+	return kind
+}
+
+// compareValues compares two values of the same sorted field
+func compareValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case time.Time:
+		b := b.(time.Time)
+
+		if a.Before(b) {
+			return -1
+		}
+
+		if a.After(b) {
+			return 1
+		}
+
+		return 0
+	case float64:
+		b := b.(float64)
+
+		if a < b {
+			return -1
+		}
+
+		if a > b {
+			return 1
+		}
+
+		return 0
+	case int64:
+		b := b.(int64)
+
+		if a < b {
+			return -1
+		}
+
+		if a > b {
+			return 1
+		}
+
+		return 0
+	case string:
+		return strings.Compare(a, b.(string))
+	}
+
+	return 0
+}
+
+// comparePositions returns a negative number when the position a is listed before b,
+// a positive number when it's listed after b and zero when they are the same,
+// the titles are compared with the collation
+func comparePositions(a, b pagePosition, sortBy []SortField, titles *titleCollation) int {
+	for i, field := range sortBy {
+		var result int
+		if field.Field == TitleField {
+			result = titles.compare(a.Values[i].(string), b.Values[i].(string))
+		} else {
+			result = compareValues(a.Values[i], b.Values[i])
+		}
+
+		if field.Descending {
+			result = -result
+		}
+
+		if result != 0 {
+			return result
+		}
+	}
+
+	result := strings.Compare(a.ID, b.ID)
+	if idDescending(sortBy) {
+		result = -result
+	}
+
+	return result
+}
+
+// cutPage returns the notes of the page and the cursor of the next page, the notes are listed in order
+// and one more note than the limit is requested to tell whether there is a next page
+func cutPage(notes []model.Note, sortBy []SortField, limit int64) ([]model.Note, string) {
+	if limit <= 0 || int64(len(notes)) <= limit {
+		return notes, ""
+	}
+
+	notes = notes[:limit]
+
+	return notes, encodeCursor(notes[limit-1], sortBy)
+}
@@ -0,0 +1,39 @@
+package database
+
+import (
+	"github.com/notes-project/api/pkg/model"
+)
+
+// NotePatch changes only the fields of a note that are set, the other fields keep their stored value
+type NotePatch struct {
+	Title       *string
+	Description *string
+	Category    *string
+	Tags        *[]string
+}
+
+// IsEmpty returns true when the patch doesn't change any field
+func (p NotePatch) IsEmpty() bool {
+	return p.Title == nil && p.Description == nil && p.Category == nil && p.Tags == nil
+}
+
+// apply returns the note with the fields of the patch
+func (p NotePatch) apply(note model.Note) model.Note {
+	if p.Title != nil {
+		note.Title = *p.Title
+	}
+
+	if p.Description != nil {
+		note.Description = *p.Description
+	}
+
+	if p.Category != nil {
+		note.Category = *p.Category
+	}
+
+	if p.Tags != nil {
+		note.Tags = append([]string(nil), *p.Tags...)
+	}
+
+	return note
+}
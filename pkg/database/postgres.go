@@ -0,0 +1,256 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/notes-project/api/pkg/model"
+	"go.uber.org/zap"
+	"golang.org/x/text/language"
+)
+
+/*
+	PostgreSQL implementation of the Database interface.
+
+	The notes are stored in a table named after the collection, with the tags in a text array.
+	The unique constraint on the title column plays the role of the unique index
+	created by setUniqueIndexes on MongoDB.
+*/
+
+type postgresDatabase struct {
+	databaseConfiguration
+	logger *zap.Logger
+
+	// populated automatically inside the Connect() method
+	db *sql.DB
+	// quoted name of the notes table
+	table string
+	// quoted name of the table with the previous versions of the notes
+	revisionsTable string
+	// quoted name of the table with the version of the schema of the tables
+	schemaTable string
+}
+
+const (
+	postgresDriverName = "postgres"
+
+	// error code returned by PostgreSQL when a unique constraint is violated
+	postgresUniqueViolationCode = "23505"
+
+	// root locale of the collation of the titles when the deployment sets no locale
+	postgresDefaultTitleLocale = "und"
+
+	// first version of PostgreSQL with the nondeterministic collations, as returned by server_version_num
+	postgresMinimumVersion = 120000
+)
+
+// ErrCollationNotSupported is returned by Connect when PostgreSQL can't compare the titles ignoring their case
+var ErrCollationNotSupported = errors.New("the case-insensitive collation of the titles requires PostgreSQL 12 or later built with ICU")
+
+func newPostgresDatabase(dbConfig databaseConfiguration, logger *zap.Logger) *postgresDatabase {
+	return &postgresDatabase{
+		databaseConfiguration: dbConfig,
+		logger:                logger,
+		table:                 pq.QuoteIdentifier(dbConfig.collectionName),
+		revisionsTable:        pq.QuoteIdentifier(dbConfig.collectionName + revisionsCollectionSuffix),
+		schemaTable:           pq.QuoteIdentifier(dbConfig.collectionName + postgresSchemaTableSuffix),
+	}
+}
+
+func (p *postgresDatabase) Connect() error {
+
+	if p.db != nil {
+		p.logger.Info("Database already started")
+		return nil
+	}
+
+	connectionString, err := postgresConnectionString(p.connectionUri, p.databaseName)
+	if err != nil {
+		return fmt.Errorf("failed to parse the connection uri, error: %w", err)
+	}
+
+	db, err := sql.Open(postgresDriverName, connectionString)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the database, error: %w", err)
+	}
+
+	err = db.PingContext(ctx)
+	if err != nil {
+		db.Close()
+		return fmt.Errorf("failed to verify database connection, error: %w", err)
+	}
+
+	p.db = db
+
+	err = p.createTable()
+	if err != nil {
+		p.db = nil
+		db.Close()
+		return err
+	}
+
+	p.logger.Info("Successfully connected to the database")
+
+	return nil
+}
+
+func (p *postgresDatabase) createTable() error {
+	err := p.checkCollationSupport()
+	if err != nil {
+		return err
+	}
+
+	err = p.migrate()
+	if err != nil {
+		return err
+	}
+
+	// the collation of the titles depends on the configuration, so it's set on every start,
+	// the statements only change the tables when the locale changed
+	for _, statement := range p.collationStatements() {
+		_, err := p.db.ExecContext(ctx, statement)
+		if err != nil {
+			return fmt.Errorf("failed to set the collation of the titles of table %s, error: %w", p.table, err)
+		}
+	}
+
+	p.logger.Info(fmt.Sprintf("Successfully set '%s' as a unique column of table %s", noteTitleKey, p.table))
+
+	return nil
+}
+
+// checkCollationSupport returns ErrCollationNotSupported when the server can't create the case-insensitive
+// collation of the titles, which needs PostgreSQL 12 or later built with ICU
+func (p *postgresDatabase) checkCollationSupport() error {
+	var version int
+	var icu bool
+
+	err := p.db.QueryRowContext(ctx, `SELECT current_setting('server_version_num')::int,
+		EXISTS (SELECT 1 FROM pg_collation WHERE collprovider = 'i')`).Scan(&version, &icu)
+	if err != nil {
+		return fmt.Errorf("failed to check the collations of the database, error: %w", err)
+	}
+
+	if version < postgresMinimumVersion || !icu {
+		return fmt.Errorf("found version %d with ICU %t, error: %w", version, icu, ErrCollationNotSupported)
+	}
+
+	return nil
+}
+
+// collationStatements creates the case-insensitive collation of the titles and sets it on the title columns,
+// so the unique index on the title matches the titles ignoring their case, like the other implementations
+func (p *postgresDatabase) collationStatements() []string {
+	return []string{
+		fmt.Sprintf(`CREATE COLLATION IF NOT EXISTS %s (provider = icu, locale = %s, deterministic = false)`,
+			p.titleCollationName(), pq.QuoteLiteral(postgresCaseInsensitiveLocale(p.collationLocale()))),
+		p.titleCollationStatement(p.collectionName, p.table),
+		// the revisions of a note are found by its title the same way as the note
+		p.titleCollationStatement(p.collectionName+revisionsCollectionSuffix, p.revisionsTable),
+	}
+}
+
+// titleCollationName returns the quoted name of the collation of the titles, which matches them ignoring the case
+// and orders them in the order of their locale
+func (p *postgresDatabase) titleCollationName() string {
+	return pq.QuoteIdentifier(p.titleCollationIdentifier())
+}
+
+// collationLocale returns the locale of the collation of the titles, the root locale when there is none
+func (p *postgresDatabase) collationLocale() string {
+	if p.titleLocale == "" {
+		return postgresDefaultTitleLocale
+	}
+
+	return p.titleLocale
+}
+
+// postgresCaseInsensitiveLocale returns the ICU locale of the collation that compares the base letters
+// and their accents, but not their case
+func postgresCaseInsensitiveLocale(locale string) string {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return locale
+	}
+
+	tag, err = tag.SetTypeForKey("ks", "level2")
+	if err != nil {
+		return locale
+	}
+
+	return tag.String()
+}
+
+func (p *postgresDatabase) titleCollationIdentifier() string {
+	return p.collectionName + "_title_" + p.collationLocale()
+}
+
+// titleCollationStatement sets the collation of the title column of the table, only when it changes
+// because every index on the title is rebuilt
+func (p *postgresDatabase) titleCollationStatement(tableName, table string) string {
+	collation := pq.QuoteLiteral(p.titleCollationIdentifier())
+
+	return fmt.Sprintf(`DO $$
+		BEGIN
+			IF (SELECT collation_name FROM information_schema.columns
+				WHERE table_schema = current_schema() AND table_name = %s AND column_name = 'title') IS DISTINCT FROM %s THEN
+				ALTER TABLE %s ALTER COLUMN title TYPE TEXT COLLATE %s;
+			END IF;
+		END
+	$$`, pq.QuoteLiteral(tableName), collation, table, p.titleCollationName())
+}
+
+func (p *postgresDatabase) indexName(suffix string) string {
+	return pq.QuoteIdentifier(p.collectionName + "_" + suffix)
+}
+
+func (p *postgresDatabase) IsReady() bool {
+	err := p.db.PingContext(ctx)
+	return err == nil
+}
+
+// postgresConnectionString sets the database name on the connection uri,
+// which can be either a URL or a list of key=value connection parameters
+func postgresConnectionString(connectionUri, databaseName string) (string, error) {
+	if databaseName == "" {
+		return connectionUri, nil
+	}
+
+	if !strings.HasPrefix(connectionUri, "postgres://") && !strings.HasPrefix(connectionUri, "postgresql://") {
+		return fmt.Sprintf("%s dbname=%s", connectionUri, quotePostgresParameter(databaseName)), nil
+	}
+
+	connectionUrl, err := url.Parse(connectionUri)
+	if err != nil {
+		return "", err
+	}
+
+	connectionUrl.Path = "/" + databaseName
+
+	return connectionUrl.String(), nil
+}
+
+func quotePostgresParameter(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+
+	return fmt.Sprintf("'%s'", value)
+}
+
+// postgresError converts the unique violations into the same errors the MongoDB implementation returns
+func postgresError(err error, note model.Note) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != postgresUniqueViolationCode {
+		return err
+	}
+
+	if strings.HasSuffix(pqErr.Constraint, "_id_key") {
+		return newDuplicateKeyError(noteIDKey, note.ID)
+	}
+
+	return newDuplicateKeyError(noteTitleKey, note.Title)
+}
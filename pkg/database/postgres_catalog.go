@@ -0,0 +1,92 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/notes-project/api/pkg/model"
+)
+
+var (
+	// columns of the fields of model.ValueCount that can be used in a SortField of the counted values
+	postgresCountColumns = map[string]string{
+		ValueField: "value",
+		CountField: "count",
+	}
+)
+
+func (p *postgresDatabase) CountValues(field string, tags TagFilter, category string, timeRange TimeRange, sortBy []SortField, page Page) ([]model.ValueCount, string, error) {
+	err := getCountedField(field)
+	if err != nil {
+		return []model.ValueCount{}, "", err
+	}
+
+	sortBy, err = getCountSort(sortBy)
+	if err != nil {
+		return []model.ValueCount{}, "", err
+	}
+
+	position, err := decodeCursor(page.Cursor, sortBy)
+	if err != nil {
+		return []model.ValueCount{}, "", err
+	}
+
+	conditions, args := postgresFilterConditions(tags, category, timeRange)
+
+	// a note is counted once for each of its distinct tags
+	values := fmt.Sprintf("SELECT id, category AS value FROM %s WHERE %s", p.table, strings.Join(conditions, " AND "))
+	if field == TagsField {
+		values = fmt.Sprintf("SELECT DISTINCT id, unnest(tags) AS value FROM %s WHERE %s", p.table, strings.Join(conditions, " AND "))
+	}
+
+	query := fmt.Sprintf("SELECT value, count FROM (SELECT value, COUNT(*) AS count FROM (%s) AS note_values WHERE value <> '' GROUP BY value) AS value_counts", values)
+
+	if position != nil {
+		var condition string
+		// the values are unique, so they order the values with equal counts
+		condition, args = postgresPositionCondition(position, sortBy, postgresCountColumns, "value", args)
+		query += " WHERE " + condition
+	}
+
+	query += " ORDER BY " + postgresOrder(sortBy, postgresCountColumns, "value")
+	if page.Limit > 0 {
+		// the extra value tells whether there is a next page
+		query += fmt.Sprintf(" LIMIT %d", page.Limit+1)
+	}
+
+	counts, err := p.queryCounts(query, args...)
+	if err != nil {
+		return []model.ValueCount{}, "", fmt.Errorf("failed to count the values of '%s' in collection, error: %w", field, err)
+	}
+
+	counts, next := cutCounts(counts, sortBy, page.Limit)
+
+	return counts, next, nil
+}
+
+func (p *postgresDatabase) queryCounts(query string, args ...interface{}) ([]model.ValueCount, error) {
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []model.ValueCount{}
+	for rows.Next() {
+		count := model.ValueCount{}
+
+		err = rows.Scan(&count.Value, &count.Count)
+		if err != nil {
+			return nil, err
+		}
+
+		counts = append(counts, count)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
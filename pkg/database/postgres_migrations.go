@@ -0,0 +1,141 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+const (
+	// suffix of the table with the version of the schema of the tables of a collection
+	postgresSchemaTableSuffix = "_schema"
+)
+
+// migrations returns the statements that upgrade the tables to each version of their schema, in order,
+// the tables of a version are upgraded by the statements at its index. The statements of a version
+// are never changed once released, a change of the schema is a new version appended to the list.
+// The tables created before the versions were recorded are upgraded from the first version, so every
+// statement can be executed on a table that already has it.
+func (p *postgresDatabase) migrations() [][]string {
+	return [][]string{
+		// the sequence column keeps the insertion order, the same way MongoDB returns the documents
+		{
+			fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+				sequence BIGSERIAL PRIMARY KEY,
+				title TEXT NOT NULL,
+				date TEXT NOT NULL DEFAULT '',
+				description TEXT NOT NULL DEFAULT '',
+				category TEXT NOT NULL DEFAULT '',
+				tags TEXT[]
+			)`, p.table),
+			fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s USING GIN (tags)`, p.indexName("tags_idx"), p.table),
+		},
+
+		// the notes created before the ids were introduced get a random id of the same length as model.NewNoteID
+		{
+			fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS id TEXT`, p.table),
+			fmt.Sprintf(`UPDATE %s SET id = md5(random()::text || clock_timestamp()::text || sequence::text) WHERE id IS NULL`, p.table),
+			fmt.Sprintf(`ALTER TABLE %s ALTER COLUMN id SET NOT NULL`, p.table),
+			fmt.Sprintf(`CREATE UNIQUE INDEX IF NOT EXISTS %s ON %s (id)`, p.indexName("id_key"), p.table),
+		},
+
+		// the notes created before the versions were introduced start from the first version
+		{
+			fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1`, p.table),
+		},
+
+		// the title is unique only among the notes that are not in the trash
+		{
+			fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ`, p.table),
+			fmt.Sprintf(`ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s`, p.table, p.indexName("title_key")),
+			fmt.Sprintf(`CREATE UNIQUE INDEX IF NOT EXISTS %s ON %s (title) WHERE deleted_at IS NULL`, p.indexName("title_live_key"), p.table),
+			fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s (deleted_at) WHERE deleted_at IS NOT NULL`, p.indexName("deleted_at_idx"), p.table),
+		},
+
+		// the notes created before the timestamps were introduced get them from their date,
+		// which was formatted in the local timezone of the server as constants.DateFormat
+		{
+			fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ`, p.table),
+			fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ`, p.table),
+			fmt.Sprintf(`UPDATE %s SET created_at = CASE
+				WHEN date ~ '^[0-9]{2}-[A-Za-z]{3}-[0-9]{4}$' THEN to_timestamp(date, 'DD-Mon-YYYY')
+				ELSE now()
+			END WHERE created_at IS NULL`, p.table),
+			fmt.Sprintf(`UPDATE %s SET updated_at = created_at WHERE updated_at IS NULL`, p.table),
+			fmt.Sprintf(`ALTER TABLE %s ALTER COLUMN created_at SET NOT NULL`, p.table),
+			fmt.Sprintf(`ALTER TABLE %s ALTER COLUMN updated_at SET NOT NULL`, p.table),
+		},
+
+		// every field that can be sorted is indexed along with the id, which orders the notes with equal values
+		{
+			fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s (created_at, id) WHERE deleted_at IS NULL`, p.indexName("created_at_idx"), p.table),
+			fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s (updated_at, id) WHERE deleted_at IS NULL`, p.indexName("updated_at_idx"), p.table),
+			fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s (title, id) WHERE deleted_at IS NULL`, p.indexName("title_idx"), p.table),
+			fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s (category, id) WHERE deleted_at IS NULL`, p.indexName("category_idx"), p.table),
+		},
+
+		p.revisionsSchemaStatements(),
+
+		// the notes are searched with a text index of the words of their title and description, which is an index
+		// of an expression instead of a generated column so the collation of the titles can still be changed
+		{
+			fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s USING GIN (%s) WHERE deleted_at IS NULL`, p.indexName("search_idx"), p.table, postgresSearchVector),
+		},
+	}
+}
+
+// migrate upgrades the tables to the last version of their schema, running only the statements
+// of the versions they don't have yet
+func (p *postgresDatabase) migrate() error {
+	_, err := p.db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (version INT NOT NULL)`, p.schemaTable))
+	if err != nil {
+		return fmt.Errorf("failed to create table %s, error: %w", p.schemaTable, err)
+	}
+
+	migrations := p.migrations()
+	from := 0
+
+	err = p.inTransaction(func(tx *sql.Tx) error {
+		// the instances started together wait for the one upgrading the tables, then find them up to date
+		_, err := tx.ExecContext(ctx, fmt.Sprintf(`LOCK TABLE %s IN EXCLUSIVE MODE`, p.schemaTable))
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRowContext(ctx, fmt.Sprintf(`SELECT version FROM %s`, p.schemaTable)).Scan(&from)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		if from >= len(migrations) {
+			return nil
+		}
+
+		for _, statements := range migrations[from:] {
+			for _, statement := range statements {
+				_, err = tx.ExecContext(ctx, statement)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s`, p.schemaTable))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (version) VALUES ($1)`, p.schemaTable), len(migrations))
+
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upgrade table %s from version %d, error: %w", p.table, from, err)
+	}
+
+	if from < len(migrations) {
+		p.logger.Info(fmt.Sprintf("Successfully upgraded table %s from version %d to %d", p.table, from, len(migrations)))
+	}
+
+	return nil
+}
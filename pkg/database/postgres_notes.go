@@ -0,0 +1,694 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/notes-project/api/pkg/filter"
+	"github.com/notes-project/api/pkg/model"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// the legacy date column is kept for the tables created before the timestamps were introduced, but not used
+	postgresNoteColumns = "id, title, description, category, tags, created_at, updated_at, version, deleted_at"
+	// the id and the creation time are immutable and the notes are moved to the trash only by the deletes,
+	// so they are not part of the updated columns
+	postgresNoteUpdateColumns = "title, description, category, tags, updated_at, version"
+
+	// the notes in the trash are excluded from every query of the notes
+	postgresLiveCondition = "deleted_at IS NULL"
+)
+
+var (
+	// columns of the fields that can be used in a TimeRange
+	postgresTimeColumns = map[string]string{
+		CreatedAtField: "created_at",
+		UpdatedAtField: "updated_at",
+	}
+
+	// columns of postgresNoteColumns after the id with the fields of a Projection they store,
+	// and the empty value read in their place when they are left out of the projection
+	postgresProjectionColumns = []struct {
+		field string
+		name  string
+		empty string
+	}{
+		{field: noteTitleKey, name: "title", empty: "''"},
+		{field: noteDescriptionKey, name: "description", empty: "''"},
+		{field: noteCategoryKey, name: "category", empty: "''"},
+		{field: noteTagsKey, name: "tags", empty: "NULL::TEXT[]"},
+		{field: noteCreatedAtKey, name: "created_at", empty: "'0001-01-01T00:00:00Z'::TIMESTAMPTZ"},
+		{field: noteUpdatedAtKey, name: "updated_at", empty: "'0001-01-01T00:00:00Z'::TIMESTAMPTZ"},
+		{field: noteVersionKey, name: "version", empty: "0"},
+		{field: noteDeletedAtKey, name: "deleted_at", empty: "NULL::TIMESTAMPTZ"},
+	}
+
+	// columns of the fields that can be used in a SortField
+	postgresSortColumns = map[string]string{
+		TitleField:     "title",
+		CategoryField:  "category",
+		CreatedAtField: "created_at",
+		UpdatedAtField: "updated_at",
+	}
+
+	// columns of the fields that can be used in a SortField when there is no locale, the titles are then
+	// ordered by the default collation instead of the case-insensitive collation of the column
+	postgresDefaultSortColumns = map[string]string{
+		TitleField:     `title COLLATE "default"`,
+		CategoryField:  "category",
+		CreatedAtField: "created_at",
+		UpdatedAtField: "updated_at",
+	}
+)
+
+func (p *postgresDatabase) AddNote(note model.Note) error {
+	return p.addNote(p.db, note)
+}
+
+// addNote inserts the note directly or in a transaction
+func (p *postgresDatabase) addNote(execer postgresExecer, note model.Note) error {
+	note = newNote(note)
+
+	_, err := execer.ExecContext(ctx,
+		fmt.Sprintf("INSERT INTO %s (%s) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)", p.table, postgresNoteColumns),
+		note.ID, note.Title, note.Description, note.Category, pq.Array(note.Tags), note.CreatedAt, note.UpdatedAt, note.Version, note.DeletedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add note %v to the collection, error: %w", note, postgresError(err, note))
+	}
+
+	p.logger.Info(fmt.Sprintf("Successfully added note '%s' to the collection", note.Title))
+
+	return nil
+}
+
+// AddNotes inserts every note on its own, so a duplicate doesn't roll back the other notes
+func (p *postgresDatabase) AddNotes(notes []model.Note) ([]error, error) {
+	errs := make([]error, 0, len(notes))
+	for _, note := range notes {
+		errs = append(errs, p.AddNote(note))
+	}
+
+	return errs, nil
+}
+
+func (p *postgresDatabase) UpdateNote(noteTitle string, updatedNote model.Note, expectedVersion int64) error {
+	return p.replaceNote("title", noteTitle, fmt.Sprintf("'%s'", noteTitle), updatedNote, expectedVersion)
+}
+
+func (p *postgresDatabase) UpdateNoteByID(noteID string, updatedNote model.Note, expectedVersion int64) error {
+	return p.replaceNote("id", noteID, fmt.Sprintf("with id '%s'", noteID), updatedNote, expectedVersion)
+}
+
+func (p *postgresDatabase) replaceNote(column, value, noteRef string, updatedNote model.Note, expectedVersion int64) error {
+	err := p.inTransaction(func(tx *sql.Tx) error {
+		return p.replaceNoteIn(tx, column, value, updatedNote, expectedVersion)
+	})
+
+	return postgresChangeError(err, "update", noteRef)
+}
+
+// replaceNoteIn updates the note in the transaction
+func (p *postgresDatabase) replaceNoteIn(tx *sql.Tx, column, value string, updatedNote model.Note, expectedVersion int64) error {
+	previous, err := p.lockNote(tx, column, value, expectedVersion)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		fmt.Sprintf("UPDATE %s SET (%s) = ($1, $2, $3, $4, $5, $6) WHERE id = $7", p.table, postgresNoteUpdateColumns),
+		updatedNote.Title, updatedNote.Description, updatedNote.Category, pq.Array(updatedNote.Tags),
+		updateTime(updatedNote), previous.Version+1, previous.ID,
+	)
+	if err != nil {
+		return postgresError(err, updatedNote)
+	}
+
+	return p.saveRevision(tx, previous)
+}
+
+func (p *postgresDatabase) UpsertNote(noteTitle string, note model.Note) (bool, error) {
+	added := newNote(model.Note{
+		Title:       noteTitle,
+		Description: note.Description,
+		Category:    note.Category,
+		Tags:        note.Tags,
+	})
+
+	created := false
+
+	err := p.inTransaction(func(tx *sql.Tx) error {
+		// the insert waits for a concurrent insert of the same title, then leaves the note it added to the update
+		result, err := tx.ExecContext(ctx,
+			fmt.Sprintf("INSERT INTO %s (%s) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) ON CONFLICT (title) WHERE %s DO NOTHING",
+				p.table, postgresNoteColumns, postgresLiveCondition),
+			added.ID, added.Title, added.Description, added.Category, pq.Array(added.Tags), added.CreatedAt, added.UpdatedAt, added.Version, added.DeletedAt,
+		)
+		if err != nil {
+			return postgresError(err, added)
+		}
+
+		inserted, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if inserted == 1 {
+			created = true
+			return nil
+		}
+
+		return p.replaceNoteIn(tx, "title", noteTitle, added, AnyVersion)
+	})
+	if err != nil {
+		return false, postgresChangeError(err, "upsert", fmt.Sprintf("'%s'", noteTitle))
+	}
+
+	return created, nil
+}
+
+func (p *postgresDatabase) PatchNote(noteTitle string, patch NotePatch, expectedVersion int64) error {
+	return p.patchNote("title", noteTitle, fmt.Sprintf("'%s'", noteTitle), patch, expectedVersion)
+}
+
+func (p *postgresDatabase) PatchNoteByID(noteID string, patch NotePatch, expectedVersion int64) error {
+	return p.patchNote("id", noteID, fmt.Sprintf("with id '%s'", noteID), patch, expectedVersion)
+}
+
+func (p *postgresDatabase) patchNote(column, value, noteRef string, patch NotePatch, expectedVersion int64) error {
+	err := p.inTransaction(func(tx *sql.Tx) error {
+		previous, err := p.lockNote(tx, column, value, expectedVersion)
+		if err != nil {
+			return err
+		}
+
+		// only the columns of the patched fields are updated
+		assignments := []string{}
+		args := []interface{}{}
+
+		assign := func(column string, value interface{}) {
+			args = append(args, value)
+			assignments = append(assignments, fmt.Sprintf("%s = $%d", column, len(args)))
+		}
+
+		if patch.Title != nil {
+			assign("title", *patch.Title)
+		}
+
+		if patch.Description != nil {
+			assign("description", *patch.Description)
+		}
+
+		if patch.Category != nil {
+			assign("category", *patch.Category)
+		}
+
+		if patch.Tags != nil {
+			assign("tags", pq.Array(*patch.Tags))
+		}
+
+		assign("updated_at", model.NewTimestamp())
+		assign("version", previous.Version+1)
+
+		_, err = tx.ExecContext(ctx,
+			fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d", p.table, strings.Join(assignments, ", "), len(args)+1),
+			append(args, previous.ID)...,
+		)
+		if err != nil {
+			return postgresError(err, patch.apply(previous))
+		}
+
+		return p.saveRevision(tx, previous)
+	})
+
+	return postgresChangeError(err, "update", noteRef)
+}
+
+func (p *postgresDatabase) ChangeTags(noteTitle string, add, remove []string, expectedVersion int64) ([]string, error) {
+	var tags []string
+
+	err := p.inTransaction(func(tx *sql.Tx) error {
+		previous, err := p.lockNote(tx, "title", noteTitle, expectedVersion)
+		if err != nil {
+			return err
+		}
+
+		var changed bool
+
+		tags, changed = changeTags(previous.Tags, add, remove)
+		if !changed {
+			return nil
+		}
+
+		_, err = tx.ExecContext(ctx,
+			fmt.Sprintf("UPDATE %s SET tags = $1, updated_at = $2, version = $3 WHERE id = $4", p.table),
+			pq.Array(tags), model.NewTimestamp(), previous.Version+1, previous.ID,
+		)
+		if err != nil {
+			return err
+		}
+
+		return p.saveRevision(tx, previous)
+	})
+	if err != nil {
+		return nil, postgresChangeError(err, "update the tags of", fmt.Sprintf("'%s'", noteTitle))
+	}
+
+	return tags, nil
+}
+
+// lockNote returns the note locked in the transaction, so the revisions of the note are numbered one at a time,
+// the lock of the row makes the version check and the change of the note atomic
+func (p *postgresDatabase) lockNote(tx *sql.Tx, column, value string, expectedVersion int64) (model.Note, error) {
+	row := tx.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT %s FROM %s WHERE %s = $1 AND %s FOR UPDATE", postgresNoteColumns, p.table, column, postgresLiveCondition),
+		value,
+	)
+
+	note, err := scanNote(row)
+	if err != nil {
+		return model.Note{}, err
+	}
+
+	if !matchesVersion(note, expectedVersion) {
+		return model.Note{}, ErrVersionConflict
+	}
+
+	return note, nil
+}
+
+// postgresChangeError returns the error of a change of a note, reported the same way as by the other implementations
+func postgresChangeError(err error, action, noteRef string) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return mongo.ErrNoDocuments
+	}
+	if err != nil {
+		return fmt.Errorf("failed to %s note %s, error: %w", action, noteRef, err)
+	}
+
+	return nil
+}
+
+func (p *postgresDatabase) GetNote(noteTitle string) (model.Note, error) {
+	return p.findNote("title", noteTitle, nil)
+}
+
+func (p *postgresDatabase) GetNoteByID(noteID string) (model.Note, error) {
+	return p.findNote("id", noteID, nil)
+}
+
+func (p *postgresDatabase) GetProjectedNote(noteTitle string, fields Projection) (model.Note, error) {
+	return p.findNote("title", noteTitle, fields)
+}
+
+func (p *postgresDatabase) GetProjectedNoteByID(noteID string, fields Projection) (model.Note, error) {
+	return p.findNote("id", noteID, fields)
+}
+
+func (p *postgresDatabase) findNote(column, value string, fields Projection) (model.Note, error) {
+	row := p.db.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT %s FROM %s WHERE %s = $1 AND %s", postgresProjectedColumns(fields), p.table, column, postgresLiveCondition),
+		value,
+	)
+
+	note, err := scanNote(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = mongo.ErrNoDocuments
+	}
+	if err != nil {
+		return model.Note{}, fmt.Errorf("failed to decode note into object, error: %w", err)
+	}
+
+	return note, nil
+}
+
+func (p *postgresDatabase) GetNotes() ([]model.Note, error) {
+	return p.findNotes([]string{postgresLiveCondition}, nil)
+}
+
+func (p *postgresDatabase) GetNotesFiltered(where filter.Expr, tags TagFilter, category string, timeRange TimeRange, sortBy []SortField, page Page, fields Projection) ([]model.Note, string, error) {
+	sortBy, err := getSort(sortBy)
+	if err != nil {
+		return []model.Note{}, "", err
+	}
+
+	position, err := decodeCursor(page.Cursor, sortBy)
+	if err != nil {
+		return []model.Note{}, "", err
+	}
+
+	conditions, args := postgresFilterConditions(tags, category, timeRange)
+
+	if where != nil {
+		var condition string
+		condition, args = postgresWhereCondition(where, args)
+		conditions = append(conditions, condition)
+	}
+
+	if position != nil {
+		var condition string
+		condition, args = p.pageCondition(position, sortBy, args)
+		conditions = append(conditions, condition)
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY %s",
+		postgresProjectedColumns(fields.withSort(sortBy)), p.table, strings.Join(conditions, " AND "), p.orderBy(sortBy))
+	if page.Limit > 0 {
+		// the extra note tells whether there is a next page
+		query += fmt.Sprintf(" LIMIT %d", page.Limit+1)
+	}
+
+	notes, err := p.queryNotes(query, args...)
+	if err != nil {
+		return []model.Note{}, "", fmt.Errorf("failed to get notes from collection, error: %w", err)
+	}
+
+	notes, next := cutPage(notes, sortBy, page.Limit)
+
+	return notes, next, nil
+}
+
+func (p *postgresDatabase) StreamNotes(where filter.Expr, tags TagFilter, category string, timeRange TimeRange, sortBy []SortField, each func(note model.Note) error) error {
+	sortBy, err := getSort(sortBy)
+	if err != nil {
+		return err
+	}
+
+	conditions, args := postgresFilterConditions(tags, category, timeRange)
+
+	if where != nil {
+		var condition string
+		condition, args = postgresWhereCondition(where, args)
+		conditions = append(conditions, condition)
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY %s",
+		postgresNoteColumns, p.table, strings.Join(conditions, " AND "), p.orderBy(sortBy))
+
+	err = p.eachNote(query, args, each)
+	if err != nil {
+		return fmt.Errorf("failed to stream notes from collection, error: %w", err)
+	}
+
+	return nil
+}
+
+// postgresFilterConditions mirrors the filters of GetNotesFiltered in getTagsFilter, getCategoryFilter
+// and getTimeRangeFilter, the conditions only match the notes that are not in the trash
+func postgresFilterConditions(tags TagFilter, category string, timeRange TimeRange) ([]string, []interface{}) {
+	var (
+		conditions = []string{postgresLiveCondition}
+		args       []interface{}
+	)
+
+	// same as in getTagsFilter, the tags are null or an empty array for the notes without tags
+	if tags.Untagged {
+		conditions = append(conditions, "COALESCE(cardinality(tags), 0) = 0")
+	}
+
+	if !tags.Untagged && len(tags.Tags) > 0 {
+		operator := "@>"
+		if tags.Any {
+			operator = "&&"
+		}
+
+		args = append(args, pq.Array(tags.Tags))
+		conditions = append(conditions, fmt.Sprintf("tags %s $%d", operator, len(args)))
+	}
+
+	if !tags.Untagged && len(tags.Excluded) > 0 {
+		args = append(args, pq.Array(tags.Excluded))
+		conditions = append(conditions, fmt.Sprintf("NOT COALESCE(tags && $%d, false)", len(args)))
+	}
+
+	if category != "" {
+		args = append(args, category)
+		conditions = append(conditions, fmt.Sprintf("category = $%d", len(args)))
+	}
+
+	column := postgresTimeColumns[timeRange.Field]
+
+	if column != "" && !timeRange.From.IsZero() {
+		args = append(args, timeRange.From)
+		conditions = append(conditions, fmt.Sprintf("%s >= $%d", column, len(args)))
+	}
+
+	if column != "" && !timeRange.To.IsZero() {
+		args = append(args, timeRange.To)
+		conditions = append(conditions, fmt.Sprintf("%s < $%d", column, len(args)))
+	}
+
+	return conditions, args
+}
+
+// sortColumns returns the columns of the fields that can be used in a SortField, only the locale orders the titles
+func (p *postgresDatabase) sortColumns() map[string]string {
+	if p.titleLocale == "" {
+		return postgresDefaultSortColumns
+	}
+
+	return postgresSortColumns
+}
+
+// orderBy mirrors the sort from getSortOption
+func (p *postgresDatabase) orderBy(sortBy []SortField) string {
+	return postgresOrder(sortBy, p.sortColumns(), "id")
+}
+
+// postgresOrder orders the rows by the columns of the sorted fields then by the id column
+func postgresOrder(sortBy []SortField, columns map[string]string, idColumn string) string {
+	order := make([]string, 0, len(sortBy)+1)
+	for _, field := range sortBy {
+		order = append(order, columns[field.Field]+postgresSortDirection(field.Descending))
+	}
+
+	return strings.Join(append(order, idColumn+postgresSortDirection(idDescending(sortBy))), ", ")
+}
+
+func postgresSortDirection(descending bool) string {
+	if descending {
+		return " DESC"
+	}
+
+	return ""
+}
+
+// pageCondition mirrors the filter from getPageFilter, the values of the position are appended to the args
+func (p *postgresDatabase) pageCondition(position *pagePosition, sortBy []SortField, args []interface{}) (string, []interface{}) {
+	return postgresPositionCondition(position, sortBy, p.sortColumns(), "id", args)
+}
+
+// postgresPositionCondition matches the rows after the position in the sort of the columns of the fields and the id column
+func postgresPositionCondition(position *pagePosition, sortBy []SortField, columns map[string]string, idColumn string, args []interface{}) (string, []interface{}) {
+	branches := []string{}
+	equal := []string{}
+
+	for i, field := range sortBy {
+		column := columns[field.Field]
+
+		operator := ">"
+		if field.Descending {
+			operator = "<"
+		}
+
+		args = append(args, position.Values[i])
+
+		branch := append(append([]string{}, equal...), fmt.Sprintf("%s %s $%d", column, operator, len(args)))
+		branches = append(branches, "("+strings.Join(branch, " AND ")+")")
+
+		equal = append(equal, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	operator := ">"
+	if idDescending(sortBy) {
+		operator = "<"
+	}
+
+	args = append(args, position.ID)
+	branches = append(branches, "("+strings.Join(append(equal, fmt.Sprintf("%s %s $%d", idColumn, operator, len(args))), " AND ")+")")
+
+	return "(" + strings.Join(branches, " OR ") + ")", args
+}
+
+// findNotes returns the notes that match all the conditions in insertion order
+func (p *postgresDatabase) findNotes(conditions []string, args []interface{}) ([]model.Note, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s", postgresNoteColumns, p.table)
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY sequence"
+
+	notes, err := p.queryNotes(query, args...)
+	if err != nil {
+		return []model.Note{}, fmt.Errorf("failed to get notes from collection, error: %w", err)
+	}
+
+	return notes, nil
+}
+
+func (p *postgresDatabase) queryNotes(query string, args ...interface{}) ([]model.Note, error) {
+	notes := []model.Note{}
+
+	err := p.eachNote(query, args, func(note model.Note) error {
+		notes = append(notes, note)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return notes, nil
+}
+
+// eachNote calls each with the notes of the rows of the query one by one as they are read
+func (p *postgresDatabase) eachNote(query string, args []interface{}, each func(note model.Note) error) error {
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		note, err := scanNote(rows)
+		if err != nil {
+			return err
+		}
+
+		err = each(note)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// rowScanner is implemented by both sql.Row and sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// postgresProjectedColumns returns the columns of postgresNoteColumns read by scanNote, with the empty value
+// of the ones left out of the projection in their place
+func postgresProjectedColumns(fields Projection) string {
+	if fields == nil {
+		return postgresNoteColumns
+	}
+
+	stored := fields.storedFields()
+
+	columns := []string{"id"}
+	for _, column := range postgresProjectionColumns {
+		if containsString(stored, column.field) {
+			columns = append(columns, column.name)
+			continue
+		}
+
+		columns = append(columns, fmt.Sprintf("%s AS %s", column.empty, column.name))
+	}
+
+	return strings.Join(columns, ", ")
+}
+
+func scanNote(row rowScanner) (model.Note, error) {
+	note := model.Note{}
+
+	err := row.Scan(&note.ID, &note.Title, &note.Description, &note.Category, pq.Array(&note.Tags),
+		&note.CreatedAt, &note.UpdatedAt, &note.Version, &note.DeletedAt,
+	)
+	if err != nil {
+		return model.Note{}, err
+	}
+
+	note.CreatedAt = note.CreatedAt.UTC()
+	note.UpdatedAt = note.UpdatedAt.UTC()
+
+	return note, nil
+}
+
+func (p *postgresDatabase) DeleteNote(noteTitle string, expectedVersion int64) error {
+	return p.deleteNote("title", noteTitle, fmt.Sprintf("'%s'", noteTitle), expectedVersion)
+}
+
+func (p *postgresDatabase) DeleteNoteByID(noteID string, expectedVersion int64) error {
+	return p.deleteNote("id", noteID, fmt.Sprintf("with id '%s'", noteID), expectedVersion)
+}
+
+func (p *postgresDatabase) deleteNote(column, value, noteRef string, expectedVersion int64) error {
+	err := p.inTransaction(func(tx *sql.Tx) error {
+		return p.deleteNoteIn(tx, column, value, expectedVersion)
+	})
+
+	return postgresChangeError(err, "delete", noteRef)
+}
+
+// deleteNoteIn moves the note to the trash in the transaction
+func (p *postgresDatabase) deleteNoteIn(tx *sql.Tx, column, value string, expectedVersion int64) error {
+	previous, err := p.lockNote(tx, column, value, expectedVersion)
+	if err != nil {
+		return err
+	}
+
+	// the note is moved to the trash
+	_, err = tx.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET deleted_at = $1 WHERE id = $2", p.table), time.Now().UTC(), previous.ID)
+	if err != nil {
+		return err
+	}
+
+	return p.saveRevision(tx, previous)
+}
+
+func (p *postgresDatabase) DeleteNotes() error {
+	err := p.inTransaction(func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx,
+			fmt.Sprintf("UPDATE %s SET deleted_at = $1 WHERE %s RETURNING %s", p.table, postgresLiveCondition, postgresNoteColumns),
+			time.Now().UTC(),
+		)
+		if err != nil {
+			return err
+		}
+
+		deleted := []model.Note{}
+		for rows.Next() {
+			note, err := scanNote(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+
+			deleted = append(deleted, note)
+		}
+
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return err
+		}
+
+		if len(deleted) == 0 {
+			return sql.ErrNoRows
+		}
+
+		for _, note := range deleted {
+			err = p.saveRevision(tx, note)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return mongo.ErrNoDocuments
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete notes from collection, error: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/notes-project/api/pkg/model"
+)
+
+// postgresExecer runs a statement directly or in a transaction
+type postgresExecer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func (p *postgresDatabase) ApplyOperations(operations []Operation, atomic bool) ([]error, error) {
+	if !atomic {
+		return applyEach(p, operations), nil
+	}
+
+	var errs []error
+
+	err := p.inTransaction(func(tx *sql.Tx) error {
+		var applied bool
+
+		errs, applied = applyAll(postgresTransaction{p: p, tx: tx}, operations)
+		if !applied {
+			return ErrNotApplied
+		}
+
+		return nil
+	})
+	if errors.Is(err, ErrNotApplied) {
+		return errs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply %d operations, error: %w", len(operations), err)
+	}
+
+	return errs, nil
+}
+
+// postgresTransaction changes the notes in the transaction of ApplyOperations
+type postgresTransaction struct {
+	p  *postgresDatabase
+	tx *sql.Tx
+}
+
+func (t postgresTransaction) AddNote(note model.Note) error {
+	return t.p.addNote(t.tx, note)
+}
+
+func (t postgresTransaction) UpdateNote(noteTitle string, updatedNote model.Note, expectedVersion int64) error {
+	return postgresChangeError(t.p.replaceNoteIn(t.tx, "title", noteTitle, updatedNote, expectedVersion), "update", fmt.Sprintf("'%s'", noteTitle))
+}
+
+func (t postgresTransaction) UpdateNoteByID(noteID string, updatedNote model.Note, expectedVersion int64) error {
+	return postgresChangeError(t.p.replaceNoteIn(t.tx, "id", noteID, updatedNote, expectedVersion), "update", fmt.Sprintf("with id '%s'", noteID))
+}
+
+func (t postgresTransaction) DeleteNote(noteTitle string, expectedVersion int64) error {
+	return postgresChangeError(t.p.deleteNoteIn(t.tx, "title", noteTitle, expectedVersion), "delete", fmt.Sprintf("'%s'", noteTitle))
+}
+
+func (t postgresTransaction) DeleteNoteByID(noteID string, expectedVersion int64) error {
+	return postgresChangeError(t.p.deleteNoteIn(t.tx, "id", noteID, expectedVersion), "delete", fmt.Sprintf("with id '%s'", noteID))
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/notes-project/api/pkg/model"
+)
+
+func (p *postgresDatabase) RenameValue(field, from, to string, dryRun bool) (RenameResult, error) {
+	err := getRename(field, from, to)
+	if err != nil {
+		return RenameResult{}, err
+	}
+
+	// same as in hasValue
+	condition := "$1 = ANY(tags)"
+	if field == CategoryField {
+		condition = "category = $1"
+	}
+
+	result := RenameResult{}
+
+	// all the notes are renamed in the transaction, which holds the locks of their rows
+	err = p.inTransaction(func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx,
+			fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE %s AND %s)", p.table, condition, postgresLiveCondition),
+			to,
+		).Scan(&result.Merged)
+		if err != nil {
+			return err
+		}
+
+		notes, err := p.lockNotes(tx, condition, from)
+		if err != nil {
+			return err
+		}
+
+		result.Changed = int64(len(notes))
+
+		if dryRun {
+			return nil
+		}
+
+		for _, previous := range notes {
+			note := renameValue(previous, field, from, to)
+
+			_, err = tx.ExecContext(ctx,
+				fmt.Sprintf("UPDATE %s SET category = $1, tags = $2, updated_at = $3, version = $4 WHERE id = $5", p.table),
+				note.Category, pq.Array(note.Tags), model.NewTimestamp(), previous.Version+1, previous.ID,
+			)
+			if err != nil {
+				return err
+			}
+
+			err = p.saveRevision(tx, previous)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return RenameResult{}, fmt.Errorf("failed to rename '%s' to '%s' in collection, error: %w", from, to, err)
+	}
+
+	return result, nil
+}
+
+// lockNotes returns the notes that match the condition locked in the transaction, like lockNote
+func (p *postgresDatabase) lockNotes(tx *sql.Tx, condition string, args ...interface{}) ([]model.Note, error) {
+	rows, err := tx.QueryContext(ctx,
+		fmt.Sprintf("SELECT %s FROM %s WHERE %s AND %s ORDER BY sequence FOR UPDATE", postgresNoteColumns, p.table, condition, postgresLiveCondition),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notes := []model.Note{}
+	for rows.Next() {
+		note, err := scanNote(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		notes = append(notes, note)
+	}
+
+	return notes, rows.Err()
+}
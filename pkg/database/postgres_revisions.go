@@ -0,0 +1,190 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/notes-project/api/pkg/model"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// the created_at column is the time of the revision, the times of the note are prefixed with note_,
+	// they are null for the revisions saved before the timestamps were introduced, which have a date instead
+	postgresRevisionColumns = "note_id, number, created_at, title, date, description, category, tags, version, note_created_at, note_updated_at"
+)
+
+// revisionsSchemaStatements creates the table with the previous versions of the notes
+func (p *postgresDatabase) revisionsSchemaStatements() []string {
+	return []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			note_id TEXT NOT NULL,
+			number BIGINT NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL,
+			title TEXT NOT NULL,
+			date TEXT NOT NULL DEFAULT '',
+			description TEXT NOT NULL DEFAULT '',
+			category TEXT NOT NULL DEFAULT '',
+			tags TEXT[],
+			PRIMARY KEY (note_id, number)
+		)`, p.revisionsTable),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s (title)`, p.indexName("revisions_title_idx"), p.revisionsTable),
+		fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 0`, p.revisionsTable),
+		fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS note_created_at TIMESTAMPTZ`, p.revisionsTable),
+		fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS note_updated_at TIMESTAMPTZ`, p.revisionsTable),
+	}
+}
+
+func (p *postgresDatabase) GetRevisions(noteTitle string) ([]model.Revision, error) {
+	noteID, err := p.findRevisionsNoteID(noteTitle)
+	if err != nil {
+		return []model.Revision{}, err
+	}
+
+	rows, err := p.db.QueryContext(ctx,
+		fmt.Sprintf("SELECT %s FROM %s WHERE note_id = $1 AND created_at >= $2 ORDER BY number", postgresRevisionColumns, p.revisionsTable),
+		noteID, p.revisionsRetention.oldestTime(time.Now()),
+	)
+	if err != nil {
+		return []model.Revision{}, fmt.Errorf("failed to get revisions of note '%s', error: %w", noteTitle, err)
+	}
+	defer rows.Close()
+
+	revisions := []model.Revision{}
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return []model.Revision{}, fmt.Errorf("failed to get revisions of note '%s', error: %w", noteTitle, err)
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return []model.Revision{}, fmt.Errorf("failed to get revisions of note '%s', error: %w", noteTitle, err)
+	}
+
+	return revisions, nil
+}
+
+func (p *postgresDatabase) GetRevision(noteTitle string, number int64) (model.Revision, error) {
+	noteID, err := p.findRevisionsNoteID(noteTitle)
+	if err != nil {
+		return model.Revision{}, err
+	}
+
+	row := p.db.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT %s FROM %s WHERE note_id = $1 AND number = $2 AND created_at >= $3", postgresRevisionColumns, p.revisionsTable),
+		noteID, number, p.revisionsRetention.oldestTime(time.Now()),
+	)
+
+	revision, err := scanRevision(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = mongo.ErrNoDocuments
+	}
+	if err != nil {
+		return model.Revision{}, fmt.Errorf("failed to decode revision %d of note '%s' into object, error: %w", number, noteTitle, err)
+	}
+
+	return revision, nil
+}
+
+// findRevisionsNoteID returns the id of the note with the title,
+// or of the most recently deleted note with the title when there is no such note
+func (p *postgresDatabase) findRevisionsNoteID(noteTitle string) (string, error) {
+	var noteID string
+
+	err := p.db.QueryRowContext(ctx,
+		fmt.Sprintf(`SELECT id FROM (
+			SELECT id, 1 AS priority, NULL::TIMESTAMPTZ AS created_at FROM %s WHERE title = $1
+			UNION ALL
+			SELECT note_id, 2, created_at FROM %s WHERE title = $1
+		) AS candidates ORDER BY priority, created_at DESC LIMIT 1`, p.table, p.revisionsTable),
+		noteTitle,
+	).Scan(&noteID)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = mongo.ErrNoDocuments
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to find revisions of note '%s', error: %w", noteTitle, err)
+	}
+
+	return noteID, nil
+}
+
+// saveRevision stores the previous version of an updated or deleted note
+// and removes the revisions of the note that exceed the retention,
+// it must be called in the transaction that changes the note, which holds the lock of its row
+func (p *postgresDatabase) saveRevision(tx *sql.Tx, note model.Note) error {
+	now := time.Now().UTC()
+
+	var number int64
+
+	err := tx.QueryRowContext(ctx,
+		fmt.Sprintf(`INSERT INTO %s (%s)
+			SELECT $1, COALESCE(MAX(number), 0) + 1, $2, $3, $4, $5, $6, $7, $8, $9, $10 FROM %s WHERE note_id = $1
+			RETURNING number`, p.revisionsTable, postgresRevisionColumns, p.revisionsTable),
+		note.ID, now, note.Title, note.Date, note.Description, note.Category, pq.Array(note.Tags), note.Version,
+		note.CreatedAt, note.UpdatedAt,
+	).Scan(&number)
+	if err != nil {
+		return fmt.Errorf("failed to save revision of note '%s', error: %w", note.Title, err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		fmt.Sprintf("DELETE FROM %s WHERE note_id = $1 AND (number < $2 OR created_at < $3)", p.revisionsTable),
+		note.ID, p.revisionsRetention.oldestNumber(number), p.revisionsRetention.oldestTime(now),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to remove expired revisions of note '%s', error: %w", note.Title, err)
+	}
+
+	return nil
+}
+
+// inTransaction runs the function in a transaction, which is committed when the function succeeds
+func (p *postgresDatabase) inTransaction(run func(tx *sql.Tx) error) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = run(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func scanRevision(row rowScanner) (model.Revision, error) {
+	revision := model.Revision{}
+	note := &revision.Note
+
+	var noteCreatedAt, noteUpdatedAt sql.NullTime
+
+	err := row.Scan(&revision.NoteID, &revision.Number, &revision.CreatedAt,
+		&note.Title, &note.Date, &note.Description, &note.Category, pq.Array(&note.Tags), &note.Version,
+		&noteCreatedAt, &noteUpdatedAt,
+	)
+	if err != nil {
+		return model.Revision{}, err
+	}
+
+	revision.Note.ID = revision.NoteID
+	revision.CreatedAt = revision.CreatedAt.UTC()
+
+	if noteCreatedAt.Valid {
+		note.CreatedAt = noteCreatedAt.Time.UTC()
+	}
+	if noteUpdatedAt.Valid {
+		note.UpdatedAt = noteUpdatedAt.Time.UTC()
+	}
+
+	return revision, nil
+}
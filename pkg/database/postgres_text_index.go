@@ -0,0 +1,71 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/notes-project/api/pkg/model"
+	"github.com/notes-project/api/pkg/search"
+)
+
+const (
+	// the words of the title and the description of a note, indexed as they are with the simple configuration,
+	// without the stop words and the stemming of a language. A query must use the same expression as the index.
+	postgresSearchVector = "(to_tsvector('simple', title) || to_tsvector('simple', description))"
+)
+
+// postgresTextIndex searches the notes with the text index of the table, which PostgreSQL keeps up to date with the notes
+type postgresTextIndex struct {
+	p *postgresDatabase
+}
+
+func (p *postgresDatabase) searchIndex() search.Index {
+	return postgresTextIndex{p: p}
+}
+
+// Search reads the notes that can match the query and matches them again with search.MatchNote,
+// because the words of the text index are split by the parser of PostgreSQL, which can split them
+// differently, such as the words with punctuation
+func (t postgresTextIndex) Search(query search.Query) ([]model.SearchHit, error) {
+	notes, err := t.p.findNotes([]string{
+		postgresLiveCondition,
+		fmt.Sprintf("%s @@ to_tsquery('simple', $1)", postgresSearchVector),
+	}, []interface{}{postgresTextQuery(query)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search notes in collection, error: %w", err)
+	}
+
+	hits := []model.SearchHit{}
+	for _, note := range notes {
+		hit, matched := search.MatchNote(note, query)
+		if matched {
+			hits = append(hits, hit)
+		}
+	}
+
+	search.SortHits(hits)
+
+	return hits, nil
+}
+
+// postgresTextQuery returns the text search query that requires every term, the words of a term follow each other
+// and the last one is a prefix when the term is. The words only have letters and digits, they are still quoted
+// so they are never read as the operators of the query.
+func postgresTextQuery(query search.Query) string {
+	terms := make([]string, 0, len(query.Terms))
+
+	for _, term := range query.Terms {
+		words := make([]string, 0, len(term.Words))
+		for _, word := range term.Words {
+			words = append(words, "'"+word+"'")
+		}
+
+		if term.Prefix {
+			words[len(words)-1] += ":*"
+		}
+
+		terms = append(terms, "("+strings.Join(words, " <-> ")+")")
+	}
+
+	return strings.Join(terms, " & ")
+}
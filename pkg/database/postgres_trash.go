@@ -0,0 +1,84 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/notes-project/api/pkg/model"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (p *postgresDatabase) GetTrash() ([]model.Note, error) {
+	notes, err := p.queryNotes(
+		fmt.Sprintf("SELECT %s FROM %s WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC, id", postgresNoteColumns, p.table),
+	)
+	if err != nil {
+		return []model.Note{}, fmt.Errorf("failed to get notes from trash, error: %w", err)
+	}
+
+	return notes, nil
+}
+
+func (p *postgresDatabase) RestoreNote(noteTitle string) error {
+	// when the trash has several notes with the title, the most recently deleted one is restored
+	result, err := p.db.ExecContext(ctx,
+		fmt.Sprintf(`UPDATE %s SET deleted_at = NULL WHERE id = (
+			SELECT id FROM %s WHERE title = $1 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC LIMIT 1
+		)`, p.table, p.table),
+		noteTitle,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to restore note '%s' from trash, error: %w", noteTitle, postgresError(err, model.Note{Title: noteTitle}))
+	}
+
+	restored, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to restore note '%s' from trash, error: %w", noteTitle, err)
+	}
+
+	if restored == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	p.logger.Info(fmt.Sprintf("Successfully restored note '%s' from trash", noteTitle))
+
+	return nil
+}
+
+func (p *postgresDatabase) PurgeNote(noteTitle string) error {
+	result, err := p.db.ExecContext(ctx,
+		fmt.Sprintf("DELETE FROM %s WHERE title = $1 AND deleted_at IS NOT NULL", p.table),
+		noteTitle,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to purge note '%s' from trash, error: %w", noteTitle, err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to purge note '%s' from trash, error: %w", noteTitle, err)
+	}
+
+	if purged == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (p *postgresDatabase) PurgeTrash(deletedBefore time.Time) (int64, error) {
+	result, err := p.db.ExecContext(ctx,
+		fmt.Sprintf("DELETE FROM %s WHERE deleted_at < $1", p.table),
+		deletedBefore,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge notes from trash, error: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge notes from trash, error: %w", err)
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/notes-project/api/pkg/filter"
+)
+
+var (
+	// columns of the fields of the notes compared by the filters, the titles are compared exactly
+	// like in the other implementations even when the column has a case-insensitive collation
+	postgresWhereColumns = map[string]string{
+		filter.TitleField:       `title COLLATE "default"`,
+		filter.DescriptionField: "description",
+		filter.CategoryField:    "category",
+		filter.CreatedAtField:   "created_at",
+		filter.UpdatedAtField:   "updated_at",
+	}
+)
+
+// postgresWhereCondition mirrors the filter from getWhereFilter, the values are appended to the args
+func postgresWhereCondition(where filter.Expr, args []interface{}) (string, []interface{}) {
+	switch e := where.(type) {
+	case filter.Conjunction:
+		return postgresWhereConditions(e.Exprs, " AND ", args)
+
+	case filter.Disjunction:
+		return postgresWhereConditions(e.Exprs, " OR ", args)
+
+	case filter.Negation:
+		var condition string
+		condition, args = postgresWhereCondition(e.Expr, args)
+
+		return fmt.Sprintf("NOT %s", condition), args
+
+	case filter.Comparison:
+		return postgresComparisonCondition(e, args)
+	}
+
+	return "TRUE", args
+}
+
+// postgresWhereConditions joins the conditions of the expressions with the operator in parentheses
+func postgresWhereConditions(exprs []filter.Expr, operator string, args []interface{}) (string, []interface{}) {
+	conditions := make([]string, 0, len(exprs))
+	for _, expr := range exprs {
+		var condition string
+		condition, args = postgresWhereCondition(expr, args)
+		conditions = append(conditions, condition)
+	}
+
+	return fmt.Sprintf("(%s)", strings.Join(conditions, operator)), args
+}
+
+// postgresComparisonCondition mirrors the filter from getComparisonFilter, every condition is true or false
+// so it can be negated, the tags being null for some notes
+func postgresComparisonCondition(comparison filter.Comparison, args []interface{}) (string, []interface{}) {
+	if comparison.Field == filter.TagField {
+		if comparison.Operator == filter.Exists {
+			return "(COALESCE(cardinality(tags), 0) > 0)", args
+		}
+
+		args = append(args, pq.Array(comparison.Values))
+		condition := fmt.Sprintf("COALESCE(tags && $%d, false)", len(args))
+
+		if comparison.Operator == filter.Ne {
+			return fmt.Sprintf("(NOT %s)", condition), args
+		}
+
+		return fmt.Sprintf("(%s)", condition), args
+	}
+
+	column := postgresWhereColumns[comparison.Field]
+
+	switch comparison.Operator {
+	case filter.Lt, filter.Le, filter.Gt, filter.Ge:
+		args = append(args, comparison.Time)
+		return fmt.Sprintf("(%s %s $%d)", column, comparison.Operator, len(args)), args
+
+	case filter.Exists:
+		return fmt.Sprintf("(%s <> '')", column), args
+
+	case filter.Ne:
+		args = append(args, pq.Array(comparison.Values))
+		return fmt.Sprintf("(%s <> ALL($%d))", column, len(args)), args
+	}
+
+	args = append(args, pq.Array(comparison.Values))
+
+	return fmt.Sprintf("(%s = ANY($%d))", column, len(args)), args
+}
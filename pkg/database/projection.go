@@ -0,0 +1,96 @@
+package database
+
+import (
+	"github.com/notes-project/api/pkg/model"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	// the fields of model.Note in a Projection that are not named like their keys
+	noteIDField   = "id"
+	noteDateField = "date"
+)
+
+// Projection is the fields of the notes read from the database, named as in the JSON of model.Note.
+// The id of the notes is always read, and a nil projection reads all the fields.
+type Projection []string
+
+// storedFields returns the keys of the fields read from the database, the legacy date is rendered
+// from the update time and the id is always read
+func (p Projection) storedFields() []string {
+	fields := []string{}
+	for _, field := range p {
+		switch field {
+		case noteIDField:
+			continue
+		case noteDateField:
+			field = noteUpdatedAtKey
+		}
+
+		if !containsString(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+// withSort returns the projection along with the fields the notes are sorted by, which the cursors of the pages are made of
+func (p Projection) withSort(sortBy []SortField) Projection {
+	if p == nil {
+		return nil
+	}
+
+	projection := append(Projection{}, p...)
+	for _, field := range sortBy {
+		projection = append(projection, field.Field)
+	}
+
+	return projection
+}
+
+// projectNote mirrors the projection from getProjectionOption, the fields left out have their zero value
+func projectNote(note model.Note, fields Projection) model.Note {
+	if fields == nil {
+		return note
+	}
+
+	projected := model.Note{ID: note.ID}
+	for _, field := range fields.storedFields() {
+		switch field {
+		case noteTitleKey:
+			projected.Title = note.Title
+		case noteDescriptionKey:
+			projected.Description = note.Description
+		case noteCategoryKey:
+			projected.Category = note.Category
+		case noteTagsKey:
+			projected.Tags = note.Tags
+		case noteCreatedAtKey:
+			projected.CreatedAt = note.CreatedAt
+		case noteUpdatedAtKey:
+			projected.UpdatedAt = note.UpdatedAt
+		case noteVersionKey:
+			projected.Version = note.Version
+		case noteDeletedAtKey:
+			projected.DeletedAt = note.DeletedAt
+		}
+	}
+
+	return projected
+}
+
+// getProjectionOption returns the projection of the MongoDB documents, nil to read all the fields
+func getProjectionOption(fields Projection) interface{} {
+	if fields == nil {
+		return nil
+	}
+
+	// the id is also listed so an empty projection doesn't read all the fields
+	projection := bson.D{{Key: noteIDKey, Value: 1}}
+	for _, field := range fields.storedFields() {
+		projection = append(projection, bson.E{Key: field, Value: 1})
+	}
+
+	return projection
+}
@@ -0,0 +1,166 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/notes-project/api/pkg/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RenameResult reports the notes changed by renaming a tag or a category
+type RenameResult struct {
+	// the number of notes that had the renamed value, which are changed unless it's a dry run
+	Changed int64
+	// true when some notes already had the new value, so the renamed value is merged into it
+	Merged bool
+}
+
+// getRename returns an error when the value of the field can't be renamed to the other one
+func getRename(field, from, to string) error {
+	err := getCountedField(field)
+	if err != nil {
+		return err
+	}
+
+	if from == "" || to == "" || from == to {
+		return fmt.Errorf("failed to rename '%s' to '%s', the values must be set and different", from, to)
+	}
+
+	return nil
+}
+
+// renameTags returns the tags with the renamed tag replaced in place, without the duplicates it creates
+func renameTags(tags []string, from, to string) []string {
+	renamed := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag == from {
+			tag = to
+		}
+
+		renamed = append(renamed, tag)
+	}
+
+	return uniqueTags(renamed)
+}
+
+// hasValue returns true when the field of the note has the value
+func hasValue(note model.Note, field, value string) bool {
+	if field == CategoryField {
+		return note.Category == value
+	}
+
+	return containsString(note.Tags, value)
+}
+
+// renameValue returns a copy of the note with the value of the field renamed
+func renameValue(note model.Note, field, from, to string) model.Note {
+	note = copyNote(note)
+
+	if field == CategoryField {
+		note.Category = to
+	} else {
+		note.Tags = renameTags(note.Tags, from, to)
+	}
+
+	return note
+}
+
+func (d *database) RenameValue(field, from, to string, dryRun bool) (RenameResult, error) {
+	err := getRename(field, from, to)
+	if err != nil {
+		return RenameResult{}, err
+	}
+
+	result := RenameResult{}
+
+	merged, err := d.collection.CountDocuments(d.context(), bson.D{getLiveFilter(), {Key: field, Value: to}})
+	if err != nil {
+		return RenameResult{}, fmt.Errorf("failed to rename '%s' to '%s' in collection, error: %w", from, to, err)
+	}
+
+	result.Merged = merged > 0
+
+	filter := bson.D{getLiveFilter(), {Key: field, Value: from}}
+
+	if dryRun {
+		result.Changed, err = d.collection.CountDocuments(d.context(), filter)
+		if err != nil {
+			return RenameResult{}, fmt.Errorf("failed to rename '%s' to '%s' in collection, error: %w", from, to, err)
+		}
+
+		return result, nil
+	}
+
+	// every note is renamed in a single atomic change that keeps its revision, until no note has the value,
+	// so the notes changed concurrently are renamed too and a rename that failed can be completed by trying it again
+	for {
+		err = d.inTransaction(func(transaction *database) error {
+			return transaction.renameNextNote(filter, field, from, to)
+		})
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return result, nil
+		}
+		if err != nil {
+			return RenameResult{}, fmt.Errorf("failed to rename '%s' to '%s' in collection after %d notes, error: %w", from, to, result.Changed, err)
+		}
+
+		result.Changed++
+	}
+}
+
+// renameNextNote renames the value of a note that has it, saved along with its revision,
+// it returns mongo.ErrNoDocuments when no note has the value anymore
+func (d *database) renameNextNote(filter bson.D, field, from, to string) error {
+	previous := model.Note{}
+
+	err := d.collection.FindOneAndUpdate(d.context(),
+		filter,
+		getRenameUpdate(field, from, to),
+		options.FindOneAndUpdate().SetReturnDocument(options.Before),
+	).Decode(&previous)
+	if err != nil {
+		return err
+	}
+
+	return d.saveRevision(d.context(), previous)
+}
+
+// getRenameUpdate renames the value of the field of a note, the tags are renamed in place and deduplicated
+// by the update from the stored ones, the literals keep the values starting with '$' as they are
+func getRenameUpdate(field, from, to string) mongo.Pipeline {
+	value := interface{}(bson.D{{Key: "$literal", Value: to}})
+
+	if field == TagsField {
+		renamed := bson.D{{Key: "$map", Value: bson.D{
+			{Key: "input", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$" + noteTagsKey, bson.A{}}}}},
+			{Key: "in", Value: bson.D{{Key: "$cond", Value: bson.A{
+				bson.D{{Key: "$eq", Value: bson.A{"$$this", bson.D{{Key: "$literal", Value: from}}}}},
+				bson.D{{Key: "$literal", Value: to}},
+				"$$this",
+			}}}},
+		}}}
+
+		value = bson.D{{Key: "$reduce", Value: bson.D{
+			{Key: "input", Value: renamed},
+			{Key: "initialValue", Value: bson.A{}},
+			{Key: "in", Value: bson.D{{Key: "$cond", Value: bson.A{
+				bson.D{{Key: "$in", Value: bson.A{"$$this", "$$value"}}},
+				"$$value",
+				bson.D{{Key: "$concatArrays", Value: bson.A{"$$value", bson.A{"$$this"}}}},
+			}}}},
+		}}}
+	}
+
+	return mongo.Pipeline{
+		{{Key: "$set", Value: bson.D{
+			{Key: field, Value: value},
+			{Key: noteUpdatedAtKey, Value: model.NewTimestamp()},
+			{Key: noteVersionKey, Value: bson.D{{Key: "$add", Value: bson.A{
+				bson.D{{Key: "$ifNull", Value: bson.A{"$" + noteVersionKey, 0}}}, 1,
+			}}}},
+		}}},
+	}
+}
@@ -0,0 +1,206 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/notes-project/api/pkg/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// fields of model.Revision
+	revisionNoteIDKey    = "noteId"
+	revisionNumberKey    = "number"
+	revisionCreatedAtKey = "createdAt"
+	revisionNoteTitleKey = "note.title"
+
+	// the latest revision number of a note, in the revision counter with the id of the note
+	revisionCounterNumberKey = "number"
+)
+
+// revisionCounter numbers the revisions of a note, it's incremented atomically for every saved revision
+type revisionCounter struct {
+	NoteID string `bson:"_id"`
+	Number int64  `bson:"number"`
+}
+
+func (d *database) GetRevisions(noteTitle string) ([]model.Revision, error) {
+	noteID, err := d.findRevisionsNoteID(noteTitle)
+	if err != nil {
+		return []model.Revision{}, err
+	}
+
+	cursor, err := d.revisions.Find(ctx, d.getRevisionsFilter(noteID),
+		options.Find().SetSort(bson.D{{Key: revisionNumberKey, Value: 1}}),
+	)
+	if err != nil {
+		return []model.Revision{}, fmt.Errorf("failed to get revisions of note '%s', error: %w", noteTitle, err)
+	}
+
+	revisions := []model.Revision{}
+	err = cursor.All(ctx, &revisions)
+	if err != nil {
+		return []model.Revision{}, fmt.Errorf("failed to get revisions of note '%s', error: %w", noteTitle, err)
+	}
+
+	return revisions, nil
+}
+
+func (d *database) GetRevision(noteTitle string, number int64) (model.Revision, error) {
+	noteID, err := d.findRevisionsNoteID(noteTitle)
+	if err != nil {
+		return model.Revision{}, err
+	}
+
+	filter := append(d.getRevisionsFilter(noteID), bson.E{Key: revisionNumberKey, Value: number})
+
+	revision := model.Revision{}
+
+	err = d.revisions.FindOne(ctx, filter).Decode(&revision)
+	if err != nil {
+		return model.Revision{}, fmt.Errorf("failed to decode revision %d of note '%s' into object, error: %w", number, noteTitle, err)
+	}
+
+	return revision, nil
+}
+
+// findRevisionsNoteID returns the id of the note with the title,
+// or of the most recently deleted note with the title when there is no such note
+func (d *database) findRevisionsNoteID(noteTitle string) (string, error) {
+	note, err := d.GetNote(noteTitle)
+	if err == nil {
+		return note.ID, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return "", err
+	}
+
+	revision := model.Revision{}
+
+	err = d.revisions.FindOne(ctx,
+		bson.D{{Key: revisionNoteTitleKey, Value: noteTitle}},
+		options.FindOne().SetSort(bson.D{{Key: revisionCreatedAtKey, Value: -1}}).SetCollation(d.titleCollationOption()),
+	).Decode(&revision)
+	if err != nil {
+		return "", fmt.Errorf("failed to find revisions of note '%s', error: %w", noteTitle, err)
+	}
+
+	return revision.NoteID, nil
+}
+
+// getRevisionsFilter matches the revisions of the note that are not older than the retention allows
+func (d *database) getRevisionsFilter(noteID string) bson.D {
+	filter := bson.D{{Key: revisionNoteIDKey, Value: noteID}}
+
+	oldestTime := d.revisionsRetention.oldestTime(time.Now())
+	if !oldestTime.IsZero() {
+		filter = append(filter, bson.E{
+			Key:   revisionCreatedAtKey,
+			Value: bson.D{{Key: "$gte", Value: oldestTime}},
+		})
+	}
+
+	return filter
+}
+
+// saveRevision stores the previous version of an updated or deleted note
+// and removes the revisions of the note that exceed the retention,
+// the context is the one of the change of the note, so in a transaction the revision is saved with the change
+func (d *database) saveRevision(ctx context.Context, note model.Note) error {
+	number, err := d.nextRevisionNumber(ctx, note.ID)
+	if err != nil {
+		return fmt.Errorf("failed to save revision of note '%s', error: %w", note.Title, err)
+	}
+
+	_, err = d.revisions.InsertOne(ctx, model.Revision{
+		NoteID:    note.ID,
+		Number:    number,
+		CreatedAt: time.Now().UTC(),
+		Note:      note,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save revision %d of note '%s', error: %w", number, note.Title, err)
+	}
+
+	return d.pruneRevisions(ctx, note.ID, number)
+}
+
+// nextRevisionNumber increments the revision counter of the note, so the revisions saved concurrently get different numbers
+func (d *database) nextRevisionNumber(ctx context.Context, noteID string) (int64, error) {
+	counter := revisionCounter{}
+
+	err := d.incrementRevisionCounter(ctx, noteID, &counter)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		err = d.startRevisionCounter(ctx, noteID)
+		if err != nil {
+			return 0, err
+		}
+
+		err = d.incrementRevisionCounter(ctx, noteID, &counter)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment the revision counter of note with id '%s', error: %w", noteID, err)
+	}
+
+	return counter.Number, nil
+}
+
+func (d *database) incrementRevisionCounter(ctx context.Context, noteID string, counter *revisionCounter) error {
+	return d.revisionCounters.FindOneAndUpdate(ctx,
+		bson.D{{Key: noteIDKey, Value: noteID}},
+		bson.D{{Key: "$inc", Value: bson.D{{Key: revisionCounterNumberKey, Value: 1}}}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(counter)
+}
+
+// startRevisionCounter adds the revision counter of the note, starting from its latest revision,
+// which is set when the revisions were saved before the counters were introduced
+func (d *database) startRevisionCounter(ctx context.Context, noteID string) error {
+	latest := model.Revision{}
+
+	err := d.revisions.FindOne(ctx,
+		bson.D{{Key: revisionNoteIDKey, Value: noteID}},
+		options.FindOne().SetSort(bson.D{{Key: revisionNumberKey, Value: -1}}),
+	).Decode(&latest)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return fmt.Errorf("failed to get the latest revision of note with id '%s', error: %w", noteID, err)
+	}
+
+	// the counter may have been started concurrently, so it's never moved back
+	err = d.revisionCounters.FindOneAndUpdate(ctx,
+		bson.D{{Key: noteIDKey, Value: noteID}},
+		bson.D{{Key: "$max", Value: bson.D{{Key: revisionCounterNumberKey, Value: latest.Number}}}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Err()
+	if err != nil {
+		return fmt.Errorf("failed to start the revision counter of note with id '%s', error: %w", noteID, err)
+	}
+
+	return nil
+}
+
+func (d *database) pruneRevisions(ctx context.Context, noteID string, latestNumber int64) error {
+	expired := bson.A{
+		bson.D{{Key: revisionNumberKey, Value: bson.D{{Key: "$lt", Value: d.revisionsRetention.oldestNumber(latestNumber)}}}},
+	}
+
+	oldestTime := d.revisionsRetention.oldestTime(time.Now())
+	if !oldestTime.IsZero() {
+		expired = append(expired, bson.D{{Key: revisionCreatedAtKey, Value: bson.D{{Key: "$lt", Value: oldestTime}}}})
+	}
+
+	_, err := d.revisions.DeleteMany(ctx, bson.D{
+		{Key: revisionNoteIDKey, Value: noteID},
+		{Key: "$or", Value: expired},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove expired revisions of note with id '%s', error: %w", noteID, err)
+	}
+
+	return nil
+}
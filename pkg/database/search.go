@@ -0,0 +1,110 @@
+package database
+
+import (
+	"sort"
+
+	"github.com/notes-project/api/pkg/filter"
+	"github.com/notes-project/api/pkg/model"
+	"github.com/notes-project/api/pkg/search"
+)
+
+/*
+	Full-text search of the notes stored by any Database implementation.
+
+	Every driver keeps the search index of its notes along with them, MongoDB
+	and PostgreSQL with a text index and the in-memory drivers with a
+	search.MemoryIndex, so the notes changed by other instances of the API
+	sharing the same database are found as soon as they are stored. The notes
+	found by the index are then filtered, sorted and listed in pages the same
+	way for every driver.
+*/
+
+type searchDatabase struct {
+	driver
+
+	// orders the notes found by a search when they are sorted by title
+	titles *titleCollation
+}
+
+func newSearchDatabase(d driver, titles *titleCollation) *searchDatabase {
+	return &searchDatabase{
+		driver: d,
+		titles: titles,
+	}
+}
+
+func (s *searchDatabase) SearchNotes(query string, where filter.Expr, tags TagFilter, category string, timeRange TimeRange, sortBy []SortField, page Page) ([]model.SearchHit, string, error) {
+	parsed, err := search.ParseQuery(query)
+	if err != nil {
+		return nil, "", err
+	}
+
+	sortBy, err = getSearchSort(sortBy)
+	if err != nil {
+		return nil, "", err
+	}
+
+	after, err := decodeCursor(page.Cursor, sortBy)
+	if err != nil {
+		return nil, "", err
+	}
+
+	indexed, err := s.driver.searchIndex().Search(parsed)
+	if err != nil {
+		return nil, "", err
+	}
+
+	hits := []model.SearchHit{}
+	positions := []pagePosition{}
+
+	for _, hit := range indexed {
+		if !matchesSearchFilters(hit, where, tags, category, timeRange) {
+			continue
+		}
+
+		position := hitPosition(hit, sortBy)
+		if after != nil && comparePositions(position, *after, sortBy, s.titles) <= 0 {
+			continue
+		}
+
+		hits = append(hits, hit)
+		positions = append(positions, position)
+	}
+
+	sort.Sort(hitsByPosition{hits: hits, positions: positions, sortBy: sortBy, titles: s.titles})
+
+	if page.Limit <= 0 || int64(len(hits)) <= page.Limit {
+		return hits, "", nil
+	}
+
+	return hits[:page.Limit], encodePosition(positions[page.Limit-1], sortBy), nil
+}
+
+// matchesSearchFilters returns true when the note of the hit matches the filters of the search
+func matchesSearchFilters(hit model.SearchHit, where filter.Expr, tags TagFilter, category string, timeRange TimeRange) bool {
+	return matchesWhere(hit.Note, where) &&
+		matchesTags(hit.Note, tags) &&
+		(category == "" || hit.Category == category) &&
+		matchesTimeRange(hit.Note, timeRange)
+}
+
+// hitsByPosition sorts the hits along with their positions
+type hitsByPosition struct {
+	hits      []model.SearchHit
+	positions []pagePosition
+	sortBy    []SortField
+	titles    *titleCollation
+}
+
+func (h hitsByPosition) Len() int {
+	return len(h.hits)
+}
+
+func (h hitsByPosition) Less(i, j int) bool {
+	return comparePositions(h.positions[i], h.positions[j], h.sortBy, h.titles) < 0
+}
+
+func (h hitsByPosition) Swap(i, j int) {
+	h.hits[i], h.hits[j] = h.hits[j], h.hits[i]
+	h.positions[i], h.positions[j] = h.positions[j], h.positions[i]
+}
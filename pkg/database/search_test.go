@@ -0,0 +1,124 @@
+package database
+
+import (
+	"github.com/notes-project/api/pkg/model"
+	"github.com/notes-project/api/pkg/search"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("SearchDatabase", func() {
+
+	var (
+		driver     *memoryDatabase
+		dbInstance *searchDatabase
+	)
+
+	BeforeEach(func() {
+		driver = newMemoryDatabase(revisionsRetention{}, nil, zap.L())
+		dbInstance = newSearchDatabase(driver, nil)
+
+		Expect(dbInstance.Connect()).To(Succeed())
+	})
+
+	Describe("SearchNotes", func() {
+		It("should find the notes as soon as they are stored", func() {
+			// another instance of the API changes the notes of the driver
+			Expect(driver.AddNote(model.Note{Title: "first meeting"})).To(Succeed())
+			Expect(driver.AddNote(model.Note{Title: "second meeting"})).To(Succeed())
+			Expect(driver.DeleteNote("first meeting", AnyVersion)).To(Succeed())
+
+			hits, next, err := dbInstance.SearchNotes("meeting", nil, TagFilter{}, "", TimeRange{}, nil, Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(next).To(BeEmpty())
+			Expect(hits).To(HaveLen(1))
+			Expect(hits[0].Title).To(Equal("second meeting"))
+
+			Expect(driver.RestoreNote("first meeting")).To(Succeed())
+
+			hits, _, err = dbInstance.SearchNotes("first", nil, TagFilter{}, "", TimeRange{}, nil, Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hits).To(HaveLen(1))
+		})
+
+		It("should return the current notes and filter them by their current values", func() {
+			Expect(dbInstance.AddNote(model.Note{Title: "first meeting", Tags: []string{"team"}})).To(Succeed())
+			Expect(dbInstance.AddNote(model.Note{Title: "second meeting", Tags: []string{"team"}})).To(Succeed())
+
+			Expect(driver.UpdateNote("first meeting", model.Note{Title: "first meeting", Description: "updated", Tags: []string{"team"}}, AnyVersion)).To(Succeed())
+			Expect(driver.UpdateNote("second meeting", model.Note{Title: "second meeting"}, AnyVersion)).To(Succeed())
+
+			hits, _, err := dbInstance.SearchNotes("meeting", nil, TagFilter{Tags: []string{"team"}}, "", TimeRange{}, nil, Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hits).To(HaveLen(1))
+			Expect(hits[0].Title).To(Equal("first meeting"))
+			Expect(hits[0].Description).To(Equal("updated"))
+			Expect(hits[0].Version).To(Equal(int64(2)))
+		})
+
+		It("should list the notes found in pages", func() {
+			for _, title := range []string{"a meeting", "b meeting", "c meeting"} {
+				Expect(dbInstance.AddNote(model.Note{Title: title})).To(Succeed())
+			}
+
+			sortBy := []SortField{{Field: TitleField}}
+
+			hits, next, err := dbInstance.SearchNotes("meeting", nil, TagFilter{}, "", TimeRange{}, sortBy, Page{Limit: 2})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hits).To(HaveLen(2))
+			Expect(hits[0].Title).To(Equal("a meeting"))
+			Expect(hits[1].Title).To(Equal("b meeting"))
+			Expect(next).NotTo(BeEmpty())
+
+			hits, next, err = dbInstance.SearchNotes("meeting", nil, TagFilter{}, "", TimeRange{}, sortBy, Page{Limit: 2, Cursor: next})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hits).To(HaveLen(1))
+			Expect(hits[0].Title).To(Equal("c meeting"))
+			Expect(next).To(BeEmpty())
+		})
+	})
+
+	Describe("getSearchFilter", func() {
+		It("should require every whole word with the text index and the prefixes with a regular expression", func() {
+			query, err := search.ParseQuery(`"weekly meeting" not* "e.g*"`)
+			Expect(err).NotTo(HaveOccurred())
+
+			prefixFilter := func(prefix string) bson.D {
+				pattern := primitive.Regex{Pattern: `(^|[^\p{L}\p{N}])` + prefix, Options: "i"}
+
+				return bson.D{{Key: "$or", Value: bson.A{
+					bson.D{{Key: noteTitleKey, Value: pattern}},
+					bson.D{{Key: noteDescriptionKey, Value: pattern}},
+				}}}
+			}
+
+			Expect(getSearchFilter(query)).To(Equal(bson.D{
+				getLiveFilter(),
+				{Key: "$text", Value: bson.D{{Key: "$search", Value: `"weekly" "meeting" "e"`}}},
+				{Key: "$and", Value: bson.A{prefixFilter("not"), prefixFilter("g")}},
+			}))
+		})
+
+		It("should not use the text index when every term is a prefix", func() {
+			query, err := search.ParseQuery("meet*")
+			Expect(err).NotTo(HaveOccurred())
+
+			filter := getSearchFilter(query)
+			Expect(filter).To(HaveLen(2))
+			Expect(filter[1].Key).To(Equal("$and"))
+		})
+	})
+
+	Describe("postgresTextQuery", func() {
+		It("should require every term with the words of a phrase following each other", func() {
+			query, err := search.ParseQuery(`meeting "weekly team" not*`)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(postgresTextQuery(query)).To(Equal(`('meeting') & ('weekly' <-> 'team') & ('not':*)`))
+		})
+	})
+
+})
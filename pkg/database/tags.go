@@ -0,0 +1,36 @@
+package database
+
+// changeTags returns the tags without the removed ones and with the added ones that are missing, in order,
+// along with whether they changed. The added tags are deduplicated, the tags that were already there are kept as is.
+func changeTags(tags, add, remove []string) ([]string, bool) {
+	changed := []string{}
+	for _, tag := range tags {
+		if !containsString(remove, tag) {
+			changed = append(changed, tag)
+		}
+	}
+
+	removed := len(changed) != len(tags)
+
+	added := false
+	for _, tag := range add {
+		if !containsString(changed, tag) {
+			changed = append(changed, tag)
+			added = true
+		}
+	}
+
+	return changed, removed || added
+}
+
+// uniqueTags returns the tags without duplicates, in order
+func uniqueTags(tags []string) []string {
+	unique := []string{}
+	for _, tag := range tags {
+		if !containsString(unique, tag) {
+			unique = append(unique, tag)
+		}
+	}
+
+	return unique
+}
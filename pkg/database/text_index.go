@@ -0,0 +1,118 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/notes-project/api/pkg/model"
+	"github.com/notes-project/api/pkg/search"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// textIndex searches the notes with the text index of the collection, which MongoDB keeps up to date with the notes
+type textIndex struct {
+	d *database
+}
+
+func (d *database) searchIndex() search.Index {
+	return textIndex{d: d}
+}
+
+// Search reads the notes that can match the query and matches them again with search.MatchNote,
+// because the text index matches the words ignoring their accents, and can't match the phrases
+// and the prefixes the same way
+func (t textIndex) Search(query search.Query) ([]model.SearchHit, error) {
+	cursor, err := t.d.collection.Find(ctx, getSearchFilter(query))
+	if err != nil {
+		return nil, fmt.Errorf("failed to search notes in collection, error: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	hits := []model.SearchHit{}
+
+	for cursor.Next(ctx) {
+		note := model.Note{}
+
+		err = cursor.Decode(&note)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode note into object, error: %w", err)
+		}
+
+		hit, matched := search.MatchNote(note, query)
+		if matched {
+			hits = append(hits, hit)
+		}
+	}
+
+	err = cursor.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to search notes in collection, error: %w", err)
+	}
+
+	search.SortHits(hits)
+
+	return hits, nil
+}
+
+// getSearchFilter returns the filter of the notes that have every word of the query. The whole words are found
+// with the text index, each of them quoted so that all of them are required, and the prefixes, which the text index
+// can't find, with a regular expression on the notes found by the whole words, or on all the notes when there are none
+func getSearchFilter(query search.Query) bson.D {
+	words := []string{}
+	prefixes := bson.A{}
+
+	for _, term := range query.Terms {
+		last := len(term.Words)
+		if term.Prefix {
+			last--
+			prefixes = append(prefixes, getPrefixFilter(term.Words[last]))
+		}
+
+		for _, word := range term.Words[:last] {
+			words = append(words, `"`+word+`"`)
+		}
+	}
+
+	filter := bson.D{getLiveFilter()}
+
+	if len(words) > 0 {
+		filter = append(filter, bson.E{
+			Key: "$text",
+			Value: bson.D{
+				{
+					Key:   "$search",
+					Value: strings.Join(words, " "),
+				},
+			},
+		})
+	}
+
+	if len(prefixes) > 0 {
+		filter = append(filter, bson.E{
+			Key:   "$and",
+			Value: prefixes,
+		})
+	}
+
+	return filter
+}
+
+// getPrefixFilter returns the filter of the notes whose title or description has a word that starts with the prefix
+func getPrefixFilter(prefix string) bson.D {
+	pattern := primitive.Regex{
+		Pattern: `(^|[^\p{L}\p{N}])` + regexp.QuoteMeta(prefix),
+		Options: "i",
+	}
+
+	return bson.D{
+		{
+			Key: "$or",
+			Value: bson.A{
+				bson.D{{Key: noteTitleKey, Value: pattern}},
+				bson.D{{Key: noteDescriptionKey, Value: pattern}},
+			},
+		},
+	}
+}
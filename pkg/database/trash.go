@@ -0,0 +1,125 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/notes-project/api/pkg/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (d *database) GetTrash() ([]model.Note, error) {
+	cursor, err := d.collection.Find(ctx, bson.D{getTrashFilter()},
+		options.Find().SetSort(bson.D{{Key: noteDeletedAtKey, Value: -1}, {Key: noteIDKey, Value: 1}}),
+	)
+	if err != nil {
+		return []model.Note{}, fmt.Errorf("failed to get notes from trash, error: %w", err)
+	}
+
+	notes := []model.Note{}
+	err = cursor.All(ctx, &notes)
+	if err != nil {
+		return []model.Note{}, fmt.Errorf("failed to get notes from trash, error: %w", err)
+	}
+
+	return notes, nil
+}
+
+func (d *database) RestoreNote(noteTitle string) error {
+	restored := model.Note{}
+
+	// when the trash has several notes with the title, the most recently deleted one is restored
+	err := d.collection.FindOneAndUpdate(ctx,
+		getTrashTitleFilter(noteTitle),
+		bson.D{{Key: "$unset", Value: bson.D{{Key: noteDeletedAtKey, Value: ""}}}},
+		options.FindOneAndUpdate().SetSort(bson.D{{Key: noteDeletedAtKey, Value: -1}}).SetCollation(d.titleCollationOption()),
+	).Decode(&restored)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return mongo.ErrNoDocuments
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restore note '%s' from trash, error: %w", noteTitle, err)
+	}
+
+	d.logger.Info(fmt.Sprintf("Successfully restored note '%s' from trash", noteTitle))
+
+	return nil
+}
+
+func (d *database) PurgeNote(noteTitle string) error {
+	deleteOptions := []*options.DeleteOptions{}
+	if collation := d.titleCollationOption(); collation != nil {
+		deleteOptions = append(deleteOptions, options.Delete().SetCollation(collation))
+	}
+
+	result, err := d.collection.DeleteMany(ctx, getTrashTitleFilter(noteTitle), deleteOptions...)
+	if err != nil {
+		return fmt.Errorf("failed to purge note '%s' from trash, error: %w", noteTitle, err)
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (d *database) PurgeTrash(deletedBefore time.Time) (int64, error) {
+	result, err := d.collection.DeleteMany(ctx, bson.D{
+		{
+			Key: noteDeletedAtKey,
+			Value: bson.D{
+				{
+					Key:   "$lt",
+					Value: deletedBefore,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge notes from trash, error: %w", err)
+	}
+
+	return result.DeletedCount, nil
+}
+
+// getTrashFilter matches only the notes in the trash
+func getTrashFilter() bson.E {
+	return bson.E{
+		Key: noteDeletedAtKey,
+		Value: bson.D{
+			{
+				Key:   "$exists",
+				Value: true,
+			},
+		},
+	}
+}
+
+func getTrashTitleFilter(noteTitle string) bson.D {
+	return bson.D{
+		{
+			Key:   noteTitleKey,
+			Value: noteTitle,
+		},
+		getTrashFilter(),
+	}
+}
+
+// getTrashUpdate moves the matched notes to the trash
+func getTrashUpdate(deletedAt time.Time) bson.D {
+	return bson.D{
+		{
+			Key: "$set",
+			Value: bson.D{
+				{
+					Key:   noteDeletedAtKey,
+					Value: deletedAt,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"github.com/notes-project/api/pkg/filter"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+/*
+	Compilation of the structured filters of the listed notes, parsed by filter.Parse,
+	to a MongoDB query. The other implementations mirror it in memory_where.go and postgres_where.go.
+*/
+
+var (
+	// keys of the fields of the notes compared by the filters
+	whereKeys = map[string]string{
+		filter.TitleField:       noteTitleKey,
+		filter.DescriptionField: noteDescriptionKey,
+		filter.CategoryField:    noteCategoryKey,
+		filter.TagField:         noteTagsKey,
+		filter.CreatedAtField:   noteCreatedAtKey,
+		filter.UpdatedAtField:   noteUpdatedAtKey,
+	}
+
+	// the query operators of the comparisons of the text fields and the tags with their values
+	whereSetOperators = map[filter.Operator]string{
+		filter.Eq: "$in",
+		filter.Ne: "$nin",
+		filter.In: "$in",
+	}
+
+	// the query operators of the comparisons of the time fields
+	whereTimeOperators = map[filter.Operator]string{
+		filter.Lt: "$lt",
+		filter.Le: "$lte",
+		filter.Gt: "$gt",
+		filter.Ge: "$gte",
+	}
+)
+
+// getWhereFilter matches the notes that match the parsed filter, every note when it's nil
+func getWhereFilter(where filter.Expr) bson.E {
+	if where == nil {
+		return bson.E{}
+	}
+
+	return bson.E{
+		Key:   "$and",
+		Value: bson.A{getExprFilter(where)},
+	}
+}
+
+func getExprFilter(expr filter.Expr) bson.D {
+	switch e := expr.(type) {
+	case filter.Conjunction:
+		return bson.D{{Key: "$and", Value: getExprFilters(e.Exprs)}}
+	case filter.Disjunction:
+		return bson.D{{Key: "$or", Value: getExprFilters(e.Exprs)}}
+	case filter.Negation:
+		return bson.D{{Key: "$nor", Value: bson.A{getExprFilter(e.Expr)}}}
+	case filter.Comparison:
+		return bson.D{getComparisonFilter(e)}
+	}
+
+	return bson.D{}
+}
+
+func getExprFilters(exprs []filter.Expr) bson.A {
+	filters := bson.A{}
+	for _, expr := range exprs {
+		filters = append(filters, getExprFilter(expr))
+	}
+
+	return filters
+}
+
+func getComparisonFilter(comparison filter.Comparison) bson.E {
+	key := whereKeys[comparison.Field]
+
+	if operator, ok := whereTimeOperators[comparison.Operator]; ok {
+		return bson.E{Key: key, Value: bson.D{{Key: operator, Value: comparison.Time}}}
+	}
+
+	// the notes have tags when their first tag exists, same as in getTagsFilter
+	if comparison.Field == filter.TagField && comparison.Operator == filter.Exists {
+		return bson.E{Key: key + ".0", Value: bson.D{{Key: "$exists", Value: true}}}
+	}
+
+	// the text fields are missing from some of the notes stored by the first versions of the API,
+	// they are compared as empty texts
+	if comparison.Operator == filter.Exists {
+		return bson.E{Key: key, Value: bson.D{{Key: "$nin", Value: bson.A{"", nil}}}}
+	}
+
+	values := bson.A{}
+	for _, value := range comparison.Values {
+		values = append(values, value)
+
+		if value == "" && comparison.Field != filter.TagField {
+			values = append(values, nil)
+		}
+	}
+
+	operator := whereSetOperators[comparison.Operator]
+
+	return bson.E{Key: key, Value: bson.D{{Key: operator, Value: values}}}
+}
@@ -0,0 +1,121 @@
+package diff
+
+/*
+	Line-based diff of two texts, computed with the Myers algorithm,
+	which finds the shortest sequence of added and removed lines.
+*/
+
+const (
+	Unchanged = "unchanged"
+	Added     = "added"
+	Removed   = "removed"
+)
+
+type Line struct {
+	Operation string `json:"operation"`
+	Text      string `json:"text"`
+}
+
+// Lines returns the lines of both texts in order, each marked as unchanged, added in b or removed from a
+func Lines(a, b []string) []Line {
+	trace := shortestEdit(a, b)
+
+	lines := []Line{}
+
+	// walks the edit graph back from the end of both texts
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		prevK := k - 1
+		if k == -d || (k != d && v.get(k-1) < v.get(k+1)) {
+			prevK = k + 1
+		}
+
+		prevX := v.get(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			lines = append(lines, Line{Operation: Unchanged, Text: a[x-1]})
+			x--
+			y--
+		}
+
+		if d > 0 {
+			if x == prevX {
+				lines = append(lines, Line{Operation: Added, Text: b[y-1]})
+			} else {
+				lines = append(lines, Line{Operation: Removed, Text: a[x-1]})
+			}
+		}
+
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+
+	return lines
+}
+
+// frontier holds the furthest x reached on each diagonal k = x - y in [-d, d]
+type frontier struct {
+	d int
+	x []int
+}
+
+func newFrontier(d int) frontier {
+	return frontier{d: d, x: make([]int, 2*d+3)}
+}
+
+func (f frontier) get(k int) int {
+	return f.x[k+f.d+1]
+}
+
+func (f frontier) set(k, x int) {
+	f.x[k+f.d+1] = x
+}
+
+// shortestEdit returns the frontier before every step of the search,
+// the last step being the one that reached the end of both texts
+func shortestEdit(a, b []string) []frontier {
+	n, m := len(a), len(b)
+
+	trace := []frontier{}
+
+	previous := newFrontier(0)
+	for d := 0; d <= n+m; d++ {
+		// the frontier of the previous step widened by one diagonal on each side
+		v := newFrontier(d)
+		for k := -previous.d - 1; k <= previous.d+1; k++ {
+			v.set(k, previous.get(k))
+		}
+		trace = append(trace, v)
+
+		next := newFrontier(d)
+		for k := -d; k <= d; k += 2 {
+			x := v.get(k-1) + 1
+			if k == -d || (k != d && v.get(k-1) < v.get(k+1)) {
+				x = v.get(k + 1)
+			}
+			y := x - k
+
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+
+			next.set(k, x)
+
+			if x >= n && y >= m {
+				return trace
+			}
+		}
+
+		previous = next
+	}
+
+	return trace
+}
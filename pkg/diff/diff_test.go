@@ -0,0 +1,78 @@
+package diff
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Diff", func() {
+
+	Describe("Lines", func() {
+		It("should return no lines when both texts are empty", func() {
+			Expect(Lines(nil, nil)).To(BeEmpty())
+		})
+
+		It("should mark all lines as unchanged when the texts are equal", func() {
+			Expect(Lines([]string{"a", "b"}, []string{"a", "b"})).To(Equal([]Line{
+				{Operation: Unchanged, Text: "a"},
+				{Operation: Unchanged, Text: "b"},
+			}))
+		})
+
+		It("should mark all lines as added when the first text is empty", func() {
+			Expect(Lines(nil, []string{"a", "b"})).To(Equal([]Line{
+				{Operation: Added, Text: "a"},
+				{Operation: Added, Text: "b"},
+			}))
+		})
+
+		It("should mark all lines as removed when the second text is empty", func() {
+			Expect(Lines([]string{"a", "b"}, nil)).To(Equal([]Line{
+				{Operation: Removed, Text: "a"},
+				{Operation: Removed, Text: "b"},
+			}))
+		})
+
+		It("should return the removed line before the added line when a line is changed", func() {
+			Expect(Lines([]string{"a", "b", "c"}, []string{"a", "x", "c"})).To(Equal([]Line{
+				{Operation: Unchanged, Text: "a"},
+				{Operation: Removed, Text: "b"},
+				{Operation: Added, Text: "x"},
+				{Operation: Unchanged, Text: "c"},
+			}))
+		})
+
+		It("should return the shortest diff", func() {
+			a := strings.Split("ABCABBA", "")
+			b := strings.Split("CBABAC", "")
+
+			lines := Lines(a, b)
+
+			changed := 0
+			for _, line := range lines {
+				if line.Operation != Unchanged {
+					changed++
+				}
+			}
+			Expect(changed).To(Equal(5))
+
+			Expect(text(lines, Added)).To(Equal(b))
+			Expect(text(lines, Removed)).To(Equal(a))
+		})
+	})
+
+})
+
+// text returns the lines of the first text when removed, or of the second one when added
+func text(lines []Line, operation string) []string {
+	result := []string{}
+	for _, line := range lines {
+		if line.Operation == Unchanged || line.Operation == operation {
+			result = append(result, line.Text)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,33 @@
+package mongo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Collection interface {
+	Find(collection *mongo.Collection, ctx context.Context, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error)
+	UpdateOne(collection *mongo.Collection, ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
+}
+
+type collection struct{}
+
+var collectionInstance Collection = collection{}
+
+func SetCollectionInstance(c Collection) {
+	collectionInstance = c
+}
+
+func GetCollectionInstance() Collection {
+	return collectionInstance
+}
+
+func (c collection) Find(collection *mongo.Collection, ctx context.Context, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+	return collection.Find(ctx, filter, opts...)
+}
+
+func (c collection) UpdateOne(collection *mongo.Collection, ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	return collection.UpdateOne(ctx, filter, update, opts...)
+}
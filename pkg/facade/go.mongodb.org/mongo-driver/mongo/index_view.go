@@ -3,12 +3,14 @@ package mongo
 import (
 	"context"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type IndexView interface {
 	CreateOne(indexView mongo.IndexView, ctx context.Context, model mongo.IndexModel, opts ...*options.CreateIndexesOptions) (string, error)
+	DropOne(indexView mongo.IndexView, ctx context.Context, name string, opts ...*options.DropIndexesOptions) (bson.Raw, error)
 }
 
 type indexView struct{}
@@ -26,3 +28,7 @@ func GetIndexViewInstace() IndexView {
 func (iv indexView) CreateOne(indexView mongo.IndexView, ctx context.Context, model mongo.IndexModel, opts ...*options.CreateIndexesOptions) (string, error) {
 	return indexView.CreateOne(ctx, model)
 }
+
+func (iv indexView) DropOne(indexView mongo.IndexView, ctx context.Context, name string, opts ...*options.DropIndexesOptions) (bson.Raw, error) {
+	return indexView.DropOne(ctx, name, opts...)
+}
@@ -0,0 +1,113 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+/*
+	Structured filter of the listed notes.
+
+	A filter is a text such as
+
+		category = work AND (tag = go OR tag = rust) AND NOT tag = archived
+
+	parsed into an expression tree by Parse, which every Database implementation compiles
+	to a query of its backend. The grammar, where the keywords are case-insensitive:
+
+		filter     = or
+		or         = and { OR and }
+		and        = not { AND not }
+		not        = NOT not | primary
+		primary    = "(" or ")" | comparison
+		comparison = field operator value | field IN "(" value { "," value } ")" | field EXISTS
+		operator   = "=" | "!=" | "<" | "<=" | ">" | ">="
+		value      = word | quoted
+
+	A word is a sequence of characters other than spaces, quotes, parentheses, commas and operators,
+	a quoted value is enclosed in double quotes with \" and \\ as escapes.
+*/
+
+// fields of the notes a filter can compare
+const (
+	TitleField       = "title"
+	DescriptionField = "description"
+	CategoryField    = "category"
+	TagField         = "tag"
+	CreatedAtField   = "createdAt"
+	UpdatedAtField   = "updatedAt"
+)
+
+// Operator compares a field of the notes to the values of a Comparison
+type Operator string
+
+const (
+	Eq Operator = "="
+	Ne Operator = "!="
+	Lt Operator = "<"
+	Le Operator = "<="
+	Gt Operator = ">"
+	Ge Operator = ">="
+	// In matches the notes whose field is any of the values
+	In Operator = "in"
+	// Exists matches the notes whose field is not empty
+	Exists Operator = "exists"
+)
+
+// Expr is a parsed filter, either a Conjunction, a Disjunction, a Negation or a Comparison
+type Expr interface {
+	expr()
+}
+
+// Conjunction matches the notes that match all of its expressions
+type Conjunction struct {
+	Exprs []Expr
+}
+
+// Disjunction matches the notes that match any of its expressions
+type Disjunction struct {
+	Exprs []Expr
+}
+
+// Negation matches the notes that don't match its expression
+type Negation struct {
+	Expr Expr
+}
+
+// Comparison matches the notes whose field compares to the values with the operator. The text fields
+// are compared to the Values: one value, several with In and none with Exists. The time fields are
+// only compared to the Time with the ordering operators.
+//
+// The tags are compared as a set: Eq matches the notes with the tag, Ne the notes without it,
+// In the notes with any of the tags and Exists the notes with at least one tag.
+type Comparison struct {
+	Field    string
+	Operator Operator
+	Values   []string
+	Time     time.Time
+}
+
+func (Conjunction) expr() {}
+func (Disjunction) expr() {}
+func (Negation) expr()    {}
+func (Comparison) expr()  {}
+
+// ErrInvalidFilter is returned when a filter doesn't follow the grammar
+var ErrInvalidFilter = errors.New("filter is invalid")
+
+// SyntaxError tells where a filter doesn't follow the grammar, it wraps ErrInvalidFilter
+type SyntaxError struct {
+	Filter string
+	// the position of the first character of the error in the filter, starting from 1
+	Position int
+	Message  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("failed to parse filter '%s' at position %d, %s, error: %s", e.Filter, e.Position, e.Message, ErrInvalidFilter)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return ErrInvalidFilter
+}
@@ -0,0 +1,486 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/notes-project/api/pkg/constants"
+)
+
+const (
+	// limit the work done for a single filter
+	maxComparisons = 32
+	maxValues      = 100
+	maxDepth       = 16
+)
+
+var (
+	// the fields accepted in a filter, tags is the same as tag
+	fields = map[string]string{
+		TitleField:       TitleField,
+		DescriptionField: DescriptionField,
+		CategoryField:    CategoryField,
+		TagField:         TagField,
+		"tags":           TagField,
+		CreatedAtField:   CreatedAtField,
+		UpdatedAtField:   UpdatedAtField,
+	}
+
+	timeFields = map[string]bool{
+		CreatedAtField: true,
+		UpdatedAtField: true,
+	}
+
+	operators = map[string]Operator{
+		string(Eq): Eq,
+		string(Ne): Ne,
+		string(Lt): Lt,
+		string(Le): Le,
+		string(Gt): Gt,
+		string(Ge): Ge,
+	}
+)
+
+// keywords of the grammar, matched case-insensitively
+const (
+	andKeyword    = "AND"
+	orKeyword     = "OR"
+	notKeyword    = "NOT"
+	inKeyword     = "IN"
+	existsKeyword = "EXISTS"
+)
+
+type tokenKind int
+
+const (
+	wordToken tokenKind = iota
+	quotedToken
+	operatorToken
+	leftParenToken
+	rightParenToken
+	commaToken
+	endToken
+)
+
+type token struct {
+	kind     tokenKind
+	text     string
+	position int
+}
+
+// describe returns the token as it's quoted in the errors
+func (t token) describe() string {
+	if t.kind == endToken {
+		return "the end of the filter"
+	}
+
+	if t.kind == quotedToken {
+		return fmt.Sprintf("\"%s\"", t.text)
+	}
+
+	return fmt.Sprintf("'%s'", t.text)
+}
+
+// isKeyword tells whether the token is the keyword, a quoted keyword is a value
+func (t token) isKeyword(keyword string) bool {
+	return t.kind == wordToken && strings.EqualFold(t.text, keyword)
+}
+
+// Parse parses a filter following the grammar documented in this package. The times are in RFC 3339,
+// or a day in the format of constants.DateFormat which stands for the start of the day in the location.
+// The error is a *SyntaxError that tells the position of the first character that doesn't follow the grammar.
+func Parse(text string, location *time.Location) (Expr, error) {
+	tokens, err := tokenize(text)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{
+		text:     text,
+		tokens:   tokens,
+		location: location,
+	}
+
+	if p.peek().kind == endToken {
+		return nil, p.errorAt(p.peek(), "it's empty")
+	}
+
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if next := p.peek(); next.kind != endToken {
+		return nil, p.errorAt(next, fmt.Sprintf("expected AND, OR or the end of the filter, found %s", next.describe()))
+	}
+
+	return expr, nil
+}
+
+// tokenize splits the filter into its tokens, ending with an endToken
+func tokenize(text string) ([]token, error) {
+	runes := []rune(text)
+	tokens := []token{}
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		position := i + 1
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '(':
+			tokens = append(tokens, token{kind: leftParenToken, text: "(", position: position})
+			i++
+
+		case r == ')':
+			tokens = append(tokens, token{kind: rightParenToken, text: ")", position: position})
+			i++
+
+		case r == ',':
+			tokens = append(tokens, token{kind: commaToken, text: ",", position: position})
+			i++
+
+		case isOperatorRune(r):
+			end := i + 1
+			if end < len(runes) && runes[end] == '=' {
+				end++
+			}
+
+			operator := string(runes[i:end])
+			if _, ok := operators[operator]; !ok {
+				return nil, &SyntaxError{Filter: text, Position: position, Message: fmt.Sprintf("unknown operator '%s'", operator)}
+			}
+
+			tokens = append(tokens, token{kind: operatorToken, text: operator, position: position})
+			i = end
+
+		case r == '"':
+			value, end, err := unquote(text, runes, i)
+			if err != nil {
+				return nil, err
+			}
+
+			tokens = append(tokens, token{kind: quotedToken, text: value, position: position})
+			i = end
+
+		default:
+			end := i
+			for end < len(runes) && isWordRune(runes[end]) {
+				end++
+			}
+
+			tokens = append(tokens, token{kind: wordToken, text: string(runes[i:end]), position: position})
+			i = end
+		}
+	}
+
+	return append(tokens, token{kind: endToken, position: len(runes) + 1}), nil
+}
+
+// unquote returns the value quoted from the start and the index after its closing quote
+func unquote(text string, runes []rune, start int) (string, int, error) {
+	value := strings.Builder{}
+
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '"':
+			return value.String(), i + 1, nil
+
+		case '\\':
+			if i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\') {
+				value.WriteRune(runes[i+1])
+				i++
+				continue
+			}
+
+			return "", 0, &SyntaxError{Filter: text, Position: i + 1, Message: "only \\\" and \\\\ can be escaped in a quoted value"}
+
+		default:
+			value.WriteRune(runes[i])
+		}
+	}
+
+	return "", 0, &SyntaxError{Filter: text, Position: start + 1, Message: "the quoted value is not terminated"}
+}
+
+func isOperatorRune(r rune) bool {
+	return r == '=' || r == '!' || r == '<' || r == '>'
+}
+
+func isWordRune(r rune) bool {
+	return !unicode.IsSpace(r) && !isOperatorRune(r) && !strings.ContainsRune("(),\"", r)
+}
+
+type parser struct {
+	text     string
+	tokens   []token
+	next     int
+	location *time.Location
+
+	depth       int
+	comparisons int
+	values      int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) consume() token {
+	t := p.tokens[p.next]
+	if t.kind != endToken {
+		p.next++
+	}
+
+	return t
+}
+
+func (p *parser) errorAt(t token, message string) error {
+	return &SyntaxError{Filter: p.text, Position: t.position, Message: message}
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	exprs, err := p.parseList(orKeyword, p.parseAnd)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+
+	return Disjunction{Exprs: exprs}, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	exprs, err := p.parseList(andKeyword, p.parseNot)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+
+	return Conjunction{Exprs: exprs}, nil
+}
+
+// parseList parses the expressions separated by the keyword
+func (p *parser) parseList(keyword string, parse func() (Expr, error)) ([]Expr, error) {
+	exprs := []Expr{}
+
+	for {
+		expr, err := parse()
+		if err != nil {
+			return nil, err
+		}
+
+		exprs = append(exprs, expr)
+
+		if !p.peek().isKeyword(keyword) {
+			return exprs, nil
+		}
+
+		p.consume()
+	}
+}
+
+func (p *parser) parseNot() (Expr, error) {
+	if !p.peek().isKeyword(notKeyword) {
+		return p.parsePrimary()
+	}
+
+	err := p.enter(p.consume())
+	if err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	expr, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	return Negation{Expr: expr}, nil
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	if p.peek().kind != leftParenToken {
+		return p.parseComparison()
+	}
+
+	open := p.consume()
+
+	err := p.enter(open)
+	if err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if next := p.consume(); next.kind != rightParenToken {
+		return nil, p.errorAt(next, fmt.Sprintf("expected ')' to close the '(' at position %d, found %s", open.position, next.describe()))
+	}
+
+	return expr, nil
+}
+
+// enter nests an expression in the one being parsed, leave must be called once it's parsed
+func (p *parser) enter(t token) error {
+	p.depth++
+	if p.depth > maxDepth {
+		return p.errorAt(t, fmt.Sprintf("the expressions are nested more than %d times", maxDepth))
+	}
+
+	return nil
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+func (p *parser) parseComparison() (Expr, error) {
+	name := p.consume()
+	if name.kind != wordToken {
+		return nil, p.errorAt(name, fmt.Sprintf("expected a field, found %s", name.describe()))
+	}
+
+	field, ok := fields[name.text]
+	if !ok {
+		return nil, p.errorAt(name, fmt.Sprintf("unknown field '%s', the fields are title, description, category, tag, createdAt and updatedAt", name.text))
+	}
+
+	p.comparisons++
+	if p.comparisons > maxComparisons {
+		return nil, p.errorAt(name, fmt.Sprintf("it has more than %d comparisons", maxComparisons))
+	}
+
+	comparison := Comparison{Field: field}
+
+	operator := p.consume()
+	switch {
+	case operator.kind == operatorToken:
+		comparison.Operator = operators[operator.text]
+
+	case operator.isKeyword(inKeyword):
+		comparison.Operator = In
+
+	case operator.isKeyword(existsKeyword):
+		comparison.Operator = Exists
+
+	default:
+		return nil, p.errorAt(operator, fmt.Sprintf("expected an operator, IN or EXISTS after the field '%s', found %s", name.text, operator.describe()))
+	}
+
+	if !allowsOperator(field, comparison.Operator) {
+		return nil, p.errorAt(operator, fmt.Sprintf("the field '%s' can't be compared with %s", name.text, operator.describe()))
+	}
+
+	switch comparison.Operator {
+	case Exists:
+		return comparison, nil
+
+	case In:
+		values, err := p.parseValues()
+		if err != nil {
+			return nil, err
+		}
+
+		comparison.Values = values
+
+		return comparison, nil
+	}
+
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	if !timeFields[field] {
+		comparison.Values = []string{value.text}
+		return comparison, nil
+	}
+
+	comparison.Time, err = parseTime(value.text, p.location)
+	if err != nil {
+		return nil, p.errorAt(value, fmt.Sprintf("the value of the field '%s' %s", name.text, err))
+	}
+
+	return comparison, nil
+}
+
+// allowsOperator tells whether the field can be compared with the operator, the text fields
+// only match values and the time fields are only ordered
+func allowsOperator(field string, operator Operator) bool {
+	switch operator {
+	case Lt, Le, Gt, Ge:
+		return timeFields[field]
+	}
+
+	return !timeFields[field]
+}
+
+// parseValues parses the values in parentheses separated by commas
+func (p *parser) parseValues() ([]string, error) {
+	open := p.consume()
+	if open.kind != leftParenToken {
+		return nil, p.errorAt(open, fmt.Sprintf("expected '(' before the values of IN, found %s", open.describe()))
+	}
+
+	values := []string{}
+
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value.text)
+
+		next := p.consume()
+		if next.kind == rightParenToken {
+			return values, nil
+		}
+
+		if next.kind != commaToken {
+			return nil, p.errorAt(next, fmt.Sprintf("expected ',' or ')' after the value, found %s", next.describe()))
+		}
+	}
+}
+
+func (p *parser) parseValue() (token, error) {
+	value := p.consume()
+	if value.kind != wordToken && value.kind != quotedToken {
+		return token{}, p.errorAt(value, fmt.Sprintf("expected a value, found %s", value.describe()))
+	}
+
+	p.values++
+	if p.values > maxValues {
+		return token{}, p.errorAt(value, fmt.Sprintf("it has more than %d values", maxValues))
+	}
+
+	return value, nil
+}
+
+// parseTime parses a time in RFC 3339 or the start of a day in the location
+func parseTime(value string, location *time.Location) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return t.UTC(), nil
+	}
+
+	t, err = time.ParseInLocation(constants.DateFormat, value, location)
+	if err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("must be a time in RFC 3339 or a day in the format '%s'", constants.DateFormat)
+}
@@ -0,0 +1,149 @@
+package filter
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Parse", func() {
+
+	// expectSyntaxError expects the filter to be rejected at the position with a message containing the text
+	expectSyntaxError := func(text string, position int, message string) {
+		_, err := Parse(text, time.UTC)
+		Expect(errors.Is(err, ErrInvalidFilter)).To(BeTrue())
+
+		var syntaxErr *SyntaxError
+		Expect(errors.As(err, &syntaxErr)).To(BeTrue())
+		Expect(syntaxErr.Position).To(Equal(position))
+		Expect(syntaxErr.Message).To(ContainSubstring(message))
+	}
+
+	It("should parse a comparison", func() {
+		expr, err := Parse("category = work", time.UTC)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(expr).To(Equal(Comparison{Field: CategoryField, Operator: Eq, Values: []string{"work"}}))
+	})
+
+	It("should parse AND before OR and the keywords in any case", func() {
+		expr, err := Parse("tag = go or tag = rust AND category != work", time.UTC)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(expr).To(Equal(Disjunction{Exprs: []Expr{
+			Comparison{Field: TagField, Operator: Eq, Values: []string{"go"}},
+			Conjunction{Exprs: []Expr{
+				Comparison{Field: TagField, Operator: Eq, Values: []string{"rust"}},
+				Comparison{Field: CategoryField, Operator: Ne, Values: []string{"work"}},
+			}},
+		}}))
+	})
+
+	It("should parse the parentheses and NOT", func() {
+		expr, err := Parse("category = work AND (tag = go OR tags = rust) AND NOT tag = archived", time.UTC)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(expr).To(Equal(Conjunction{Exprs: []Expr{
+			Comparison{Field: CategoryField, Operator: Eq, Values: []string{"work"}},
+			Disjunction{Exprs: []Expr{
+				Comparison{Field: TagField, Operator: Eq, Values: []string{"go"}},
+				Comparison{Field: TagField, Operator: Eq, Values: []string{"rust"}},
+			}},
+			Negation{Expr: Comparison{Field: TagField, Operator: Eq, Values: []string{"archived"}}},
+		}}))
+	})
+
+	It("should parse IN and EXISTS", func() {
+		expr, err := Parse(`tag IN (go, "rust lang") and not description exists`, time.UTC)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(expr).To(Equal(Conjunction{Exprs: []Expr{
+			Comparison{Field: TagField, Operator: In, Values: []string{"go", "rust lang"}},
+			Negation{Expr: Comparison{Field: DescriptionField, Operator: Exists}},
+		}}))
+	})
+
+	It("should parse the escapes of a quoted value and a quoted keyword as a value", func() {
+		expr, err := Parse(`title = "say \"hi\" \\ AND"`, time.UTC)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(expr).To(Equal(Comparison{Field: TitleField, Operator: Eq, Values: []string{`say "hi" \ AND`}}))
+	})
+
+	It("should parse the times in RFC 3339 and the days in the location", func() {
+		location := time.FixedZone("UTC+2", 2*60*60)
+
+		expr, err := Parse("createdAt >= 01-Mar-2024 AND updatedAt < 2024-03-02T10:00:00+01:00", location)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(expr).To(Equal(Conjunction{Exprs: []Expr{
+			Comparison{Field: CreatedAtField, Operator: Ge, Time: time.Date(2024, time.March, 1, 0, 0, 0, 0, location)},
+			Comparison{Field: UpdatedAtField, Operator: Lt, Time: time.Date(2024, time.March, 2, 9, 0, 0, 0, time.UTC)},
+		}}))
+	})
+
+	It("should fail when the filter is empty", func() {
+		expectSyntaxError("  ", 3, "empty")
+	})
+
+	It("should fail at the unknown field", func() {
+		expectSyntaxError("category = work AND color = red", 21, "unknown field 'color'")
+	})
+
+	It("should fail at the missing operator", func() {
+		expectSyntaxError("tag go", 5, "expected an operator")
+	})
+
+	It("should fail at the unknown operator", func() {
+		expectSyntaxError("tag == go", 5, "unknown operator '=='")
+	})
+
+	It("should fail at an operator the field can't be compared with", func() {
+		expectSyntaxError("tag < go", 5, "can't be compared")
+		expectSyntaxError("createdAt = 01-Mar-2024", 11, "can't be compared")
+	})
+
+	It("should fail at the invalid time", func() {
+		expectSyntaxError("createdAt > yesterday", 13, "must be a time")
+	})
+
+	It("should fail at the missing value", func() {
+		expectSyntaxError("category =", 11, "expected a value, found the end of the filter")
+	})
+
+	It("should fail at the unclosed parenthesis", func() {
+		expectSyntaxError("(tag = go OR tag = rust", 24, "expected ')' to close the '(' at position 1")
+	})
+
+	It("should fail at the unexpected token after the filter", func() {
+		expectSyntaxError("tag = go tag = rust", 10, "expected AND, OR or the end of the filter")
+	})
+
+	It("should fail at the unterminated quoted value", func() {
+		expectSyntaxError(`title = "meeting`, 9, "not terminated")
+	})
+
+	It("should fail at the invalid escape", func() {
+		expectSyntaxError(`title = "a\nb"`, 11, "can be escaped")
+	})
+
+	It("should count the position in characters", func() {
+		expectSyntaxError("title = café )", 14, "expected AND, OR")
+	})
+
+	It("should fail when the values of IN are not in parentheses", func() {
+		expectSyntaxError("tag in go", 8, "expected '('")
+		expectSyntaxError("tag in (go rust)", 12, "expected ',' or ')'")
+	})
+
+	It("should fail when there are too many comparisons", func() {
+		comparisons := make([]string, maxComparisons+1)
+		for i := range comparisons {
+			comparisons[i] = "tag = go"
+		}
+
+		expectSyntaxError(strings.Join(comparisons, " OR "), maxComparisons*12+1, "more than 32 comparisons")
+	})
+
+	It("should fail when the expressions are nested too deeply", func() {
+		expectSyntaxError(strings.Repeat("(", maxDepth+1)+"tag = go"+strings.Repeat(")", maxDepth+1), maxDepth+1, "nested more than")
+	})
+
+})
@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: .\pkg\facade\go.mongodb.org\mongo-driver\mongo\index_view.go
+// Source: pkg/facade/go.mongodb.org/mongo-driver/mongo/index_view.go
 
 // Package mock_mongo is a generated GoMock package.
 package mock_mongo
@@ -9,6 +9,7 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	bson "go.mongodb.org/mongo-driver/bson"
 	mongo "go.mongodb.org/mongo-driver/mongo"
 	options "go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -55,3 +56,23 @@ func (mr *MockIndexViewMockRecorder) CreateOne(indexView, ctx, model interface{}
 	varargs := append([]interface{}{indexView, ctx, model}, opts...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOne", reflect.TypeOf((*MockIndexView)(nil).CreateOne), varargs...)
 }
+
+// DropOne mocks base method.
+func (m *MockIndexView) DropOne(indexView mongo.IndexView, ctx context.Context, name string, opts ...*options.DropIndexesOptions) (bson.Raw, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{indexView, ctx, name}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DropOne", varargs...)
+	ret0, _ := ret[0].(bson.Raw)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DropOne indicates an expected call of DropOne.
+func (mr *MockIndexViewMockRecorder) DropOne(indexView, ctx, name interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{indexView, ctx, name}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DropOne", reflect.TypeOf((*MockIndexView)(nil).DropOne), varargs...)
+}
@@ -0,0 +1,8 @@
+package model
+
+// ValueCount is a distinct value of a field of the notes, such as a tag or a category,
+// along with the number of notes that have it
+type ValueCount struct {
+	Value string `json:"value" bson:"value"`
+	Count int64  `json:"count" bson:"count"`
+}
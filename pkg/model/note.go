@@ -1,9 +1,78 @@
 package model
 
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/notes-project/api/pkg/constants"
+)
+
 type Note struct {
-	Title       string   `json:"title" binding:"required"`
-	Date        string   `json:"date" binding:"-"`
+	// generated by the API, stored as the MongoDB document id
+	ID    string `json:"id" bson:"_id,omitempty" binding:"-"`
+	Title string `json:"title" binding:"required"`
+	// the day the note was last updated, in the format of constants.DateFormat, kept for the v1 clients.
+	// It's not stored anymore, it's rendered from UpdatedAt in the timezone of the client
+	Date        string   `json:"date" bson:"date,omitempty" binding:"-"`
 	Description string   `json:"description" binding:"required"`
 	Category    string   `json:"category" binding:"-"`
 	Tags        []string `json:"tags" binding:"-"`
+	// set by the database in UTC when the note is added and updated
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt,omitempty" binding:"-"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt,omitempty" binding:"-"`
+	// incremented by the database on every update, starting from 1
+	Version int64 `json:"version" bson:"version,omitempty" binding:"-"`
+	// set when the note is moved to the trash
+	DeletedAt *time.Time `json:"deletedAt,omitempty" bson:"deletedAt,omitempty" binding:"-"`
+}
+
+const (
+	noteIDLength = 16
+)
+
+// NewTimestamp returns the current time in UTC, truncated to milliseconds
+// because MongoDB stores the times with millisecond precision
+func NewTimestamp() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
+
+// SetDate renders the legacy date of the note from the time it was last updated.
+// The notes stored before the timestamps were introduced keep their date.
+func (n *Note) SetDate(location *time.Location) {
+	if n.UpdatedAt.IsZero() {
+		return
+	}
+
+	n.Date = n.UpdatedAt.In(location).Format(constants.DateFormat)
+}
+
+// NewNoteID returns a random identifier of 32 hexadecimal characters
+func NewNoteID() string {
+	id := make([]byte, noteIDLength)
+
+	// crypto/rand.Read never returns an error on the supported platforms
+	_, err := rand.Read(id)
+	if err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(id)
+}
+
+// NoteFields returns the names of the fields of a note in its JSON, in order
+func NoteFields() []string {
+	noteType := reflect.TypeOf(Note{})
+
+	fields := make([]string, 0, noteType.NumField())
+	for i := 0; i < noteType.NumField(); i++ {
+		name := strings.Split(noteType.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields = append(fields, name)
+		}
+	}
+
+	return fields
 }
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// Revision is a previous version of a note, saved when the note was updated or deleted
+type Revision struct {
+	NoteID string `json:"noteId" bson:"noteId"`
+	// starts from 1 and increases with every revision of the note
+	Number    int64     `json:"number" bson:"number"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	Note      Note      `json:"note" bson:"note"`
+}
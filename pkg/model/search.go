@@ -0,0 +1,9 @@
+package model
+
+// SearchHit is a note that matches a search, along with how well it matches
+// and the parts of its fields that match, keyed by the name of the field
+type SearchHit struct {
+	Note
+	Score    float64           `json:"score"`
+	Snippets map[string]string `json:"snippets"`
+}
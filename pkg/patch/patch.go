@@ -0,0 +1,427 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+/*
+	Patches of JSON documents, either as a JSON Merge Patch (RFC 7396), which is a partial document
+	merged into the patched one, or as a JSON Patch (RFC 6902), which is a list of operations applied
+	in order to the values found by their JSON Pointer (RFC 6901). A JSON Patch is applied as a whole:
+	when any of its operations fails the document is not patched at all.
+*/
+
+var (
+	// ErrInvalidPatch is wrapped by the errors of a patch that is malformed or can't be applied to the document
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrTestFailed is wrapped by the error of a JSON Patch whose test operation didn't match the document
+	ErrTestFailed = errors.New("patch test failed")
+)
+
+// operations of a JSON Patch
+const (
+	opAdd     = "add"
+	opRemove  = "remove"
+	opReplace = "replace"
+	opMove    = "move"
+	opCopy    = "copy"
+	opTest    = "test"
+)
+
+// the index of a JSON Pointer that refers to the position after the last element of an array
+const endOfArray = "-"
+
+type operation struct {
+	Op   string `json:"op"`
+	Path string `json:"path"`
+	From string `json:"from"`
+	// raw to tell a missing value from a null value
+	Value json.RawMessage `json:"value"`
+}
+
+// MergePatch applies the JSON Merge Patch to the document and returns the patched document
+func MergePatch(document, patch []byte) ([]byte, error) {
+	var target interface{}
+
+	err := json.Unmarshal(document, &target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the patched document, error: %w", err)
+	}
+
+	var merged interface{}
+
+	err = json.Unmarshal(patch, &merged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the merge patch, %s, error: %w", err, ErrInvalidPatch)
+	}
+
+	return json.Marshal(mergeValue(target, merged))
+}
+
+// mergeValue merges the patch into the target, the members of an object patch replace the ones of the target,
+// or remove them when they are null, and any other patch replaces the whole target
+func mergeValue(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+
+		targetObject[name] = mergeValue(targetObject[name], value)
+	}
+
+	return targetObject
+}
+
+// JSONPatch applies the operations of the JSON Patch in order to the document and returns the patched document
+func JSONPatch(document, patch []byte) ([]byte, error) {
+	var target interface{}
+
+	err := json.Unmarshal(document, &target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the patched document, error: %w", err)
+	}
+
+	operations := []operation{}
+
+	err = json.Unmarshal(patch, &operations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the JSON patch, it must be an array of operations, %s, error: %w", err, ErrInvalidPatch)
+	}
+
+	for i, op := range operations {
+		target, err = applyOperation(target, op)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply operation %d '%s' of the JSON patch, %w", i, op.Op, err)
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+// applyOperation returns the document changed by the operation, the containers of the document are changed in place
+func applyOperation(document interface{}, op operation) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case opAdd, opReplace, opTest:
+		value, err := operationValue(op)
+		if err != nil {
+			return nil, err
+		}
+
+		if op.Op == opAdd {
+			return add(document, path, value)
+		}
+
+		if op.Op == opReplace {
+			return replace(document, path, value)
+		}
+
+		current, err := get(document, path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !reflect.DeepEqual(current, value) {
+			return nil, fmt.Errorf("the value at '%s' is not %s, error: %w", op.Path, op.Value, ErrTestFailed)
+		}
+
+		return document, nil
+	case opRemove:
+		document, _, err = remove(document, path)
+		return document, err
+	case opMove, opCopy:
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+
+		if op.Op == opCopy {
+			value, err := get(document, from)
+			if err != nil {
+				return nil, err
+			}
+
+			return add(document, path, copyValue(value))
+		}
+
+		// a value can't be moved into itself
+		if len(path) > len(from) && reflect.DeepEqual(path[:len(from)], from) {
+			return nil, fmt.Errorf("'%s' can't be moved into its own child '%s', error: %w", op.From, op.Path, ErrInvalidPatch)
+		}
+
+		document, value, err := remove(document, from)
+		if err != nil {
+			return nil, err
+		}
+
+		return add(document, path, value)
+	}
+
+	return nil, fmt.Errorf("the operation must be one of add, remove, replace, move, copy or test, error: %w", ErrInvalidPatch)
+}
+
+func operationValue(op operation) (interface{}, error) {
+	if len(op.Value) == 0 {
+		return nil, fmt.Errorf("the operation requires a value, error: %w", ErrInvalidPatch)
+	}
+
+	var value interface{}
+
+	// the value was already decoded along with the patch, so it's valid JSON
+	err := json.Unmarshal(op.Value, &value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the value of the operation, %s, error: %w", err, ErrInvalidPatch)
+	}
+
+	return value, nil
+}
+
+// parsePointer returns the reference tokens of the JSON Pointer, which are empty for the whole document
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("path '%s' must be empty or start with '/', error: %w", pointer, ErrInvalidPatch)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		// '~' is only used to escape '~' as '~0' and '/' as '~1'
+		if strings.Count(token, "~") != strings.Count(token, "~0")+strings.Count(token, "~1") {
+			return nil, fmt.Errorf("path '%s' has an invalid escape sequence, error: %w", pointer, ErrInvalidPatch)
+		}
+
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+// formatPointer returns the JSON Pointer of the reference tokens, for the errors
+func formatPointer(tokens []string) string {
+	builder := strings.Builder{}
+	for _, token := range tokens {
+		builder.WriteString("/")
+		builder.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+
+	return builder.String()
+}
+
+// get returns the value at the path
+func get(document interface{}, path []string) (interface{}, error) {
+	value := document
+
+	for i, token := range path {
+		switch container := value.(type) {
+		case map[string]interface{}:
+			member, exist := container[token]
+			if !exist {
+				return nil, fmt.Errorf("path '%s' doesn't exist, error: %w", formatPointer(path[:i+1]), ErrInvalidPatch)
+			}
+
+			value = member
+		case []interface{}:
+			index, err := arrayIndex(token, len(container)-1, path[:i+1])
+			if err != nil {
+				return nil, err
+			}
+
+			value = container[index]
+		default:
+			return nil, fmt.Errorf("path '%s' doesn't exist, error: %w", formatPointer(path[:i+1]), ErrInvalidPatch)
+		}
+	}
+
+	return value, nil
+}
+
+// add adds the value at the path, it replaces the member of an object and inserts the element of an array
+func add(document interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return changeParent(document, path, func(parent interface{}) (interface{}, error) {
+		token := path[len(path)-1]
+
+		switch container := parent.(type) {
+		case map[string]interface{}:
+			container[token] = value
+			return container, nil
+		case []interface{}:
+			if token == endOfArray {
+				return append(container, value), nil
+			}
+
+			index, err := arrayIndex(token, len(container), path)
+			if err != nil {
+				return nil, err
+			}
+
+			container = append(container, nil)
+			copy(container[index+1:], container[index:])
+			container[index] = value
+
+			return container, nil
+		}
+
+		return nil, fmt.Errorf("path '%s' doesn't exist, error: %w", formatPointer(path), ErrInvalidPatch)
+	})
+}
+
+// replace replaces the existing value at the path
+func replace(document interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return changeParent(document, path, func(parent interface{}) (interface{}, error) {
+		token := path[len(path)-1]
+
+		switch container := parent.(type) {
+		case map[string]interface{}:
+			if _, exist := container[token]; !exist {
+				return nil, fmt.Errorf("path '%s' doesn't exist, error: %w", formatPointer(path), ErrInvalidPatch)
+			}
+
+			container[token] = value
+
+			return container, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(container)-1, path)
+			if err != nil {
+				return nil, err
+			}
+
+			container[index] = value
+
+			return container, nil
+		}
+
+		return nil, fmt.Errorf("path '%s' doesn't exist, error: %w", formatPointer(path), ErrInvalidPatch)
+	})
+}
+
+// remove removes the existing value at the path and returns it along with the changed document
+func remove(document interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("the whole document can't be removed, error: %w", ErrInvalidPatch)
+	}
+
+	var removed interface{}
+
+	document, err := changeParent(document, path, func(parent interface{}) (interface{}, error) {
+		token := path[len(path)-1]
+
+		switch container := parent.(type) {
+		case map[string]interface{}:
+			value, exist := container[token]
+			if !exist {
+				return nil, fmt.Errorf("path '%s' doesn't exist, error: %w", formatPointer(path), ErrInvalidPatch)
+			}
+
+			removed = value
+			delete(container, token)
+
+			return container, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(container)-1, path)
+			if err != nil {
+				return nil, err
+			}
+
+			removed = container[index]
+
+			return append(container[:index], container[index+1:]...), nil
+		}
+
+		return nil, fmt.Errorf("path '%s' doesn't exist, error: %w", formatPointer(path), ErrInvalidPatch)
+	})
+
+	return document, removed, err
+}
+
+// changeParent replaces the container of the last token of the path with the result of change,
+// the arrays are replaced since changing their length returns a new slice
+func changeParent(document interface{}, path []string, change func(parent interface{}) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return change(document)
+	}
+
+	child, err := get(document, path[:1])
+	if err != nil {
+		return nil, err
+	}
+
+	changed, err := changeParent(child, path[1:], change)
+	if err != nil {
+		return nil, err
+	}
+
+	switch container := document.(type) {
+	case map[string]interface{}:
+		container[path[0]] = changed
+	case []interface{}:
+		// the index was already checked by get
+		index, _ := strconv.Atoi(path[0])
+		container[index] = changed
+	}
+
+	return document, nil
+}
+
+// arrayIndex returns the index of the array from the token, which must be between 0 and max
+func arrayIndex(token string, max int, path []string) (int, error) {
+	index, err := strconv.Atoi(token)
+	// leading zeros are not allowed by RFC 6901
+	if err != nil || index < 0 || index > max || (len(token) > 1 && token[0] == '0') || token[0] == '+' {
+		return 0, fmt.Errorf("path '%s' doesn't exist, error: %w", formatPointer(path), ErrInvalidPatch)
+	}
+
+	return index, nil
+}
+
+// copyValue returns a deep copy of the decoded value, so the copy can be changed independently
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for name, member := range v {
+			copied[name] = copyValue(member)
+		}
+
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, 0, len(v))
+		for _, element := range v {
+			copied = append(copied, copyValue(element))
+		}
+
+		return copied
+	}
+
+	return value
+}
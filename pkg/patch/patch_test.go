@@ -0,0 +1,109 @@
+package patch
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Patch", func() {
+
+	document := []byte(`{"title":"a","category":"work","tags":["x","y"],"meta":{"a/b":1,"m~n":2}}`)
+
+	Describe("MergePatch", func() {
+		It("should replace, add and remove the members of the document", func() {
+			patched, err := MergePatch(document, []byte(`{"title":"b","category":null,"description":"new"}`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(patched).To(MatchJSON(`{"title":"b","description":"new","tags":["x","y"],"meta":{"a/b":1,"m~n":2}}`))
+		})
+
+		It("should merge the nested objects and replace the arrays", func() {
+			patched, err := MergePatch(document, []byte(`{"tags":["z"],"meta":{"a/b":null,"c":3}}`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(patched).To(MatchJSON(`{"title":"a","category":"work","tags":["z"],"meta":{"m~n":2,"c":3}}`))
+		})
+
+		It("should replace the whole document when the patch is not an object", func() {
+			patched, err := MergePatch(document, []byte(`["a"]`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(patched).To(MatchJSON(`["a"]`))
+		})
+
+		It("should fail when the patch is not JSON", func() {
+			_, err := MergePatch(document, []byte(`{"title":`))
+			Expect(err).To(MatchError(ErrInvalidPatch))
+		})
+	})
+
+	Describe("JSONPatch", func() {
+		It("should apply the operations in order", func() {
+			patched, err := JSONPatch(document, []byte(`[
+				{"op":"replace","path":"/title","value":"b"},
+				{"op":"add","path":"/tags/-","value":"z"},
+				{"op":"add","path":"/tags/0","value":"w"},
+				{"op":"remove","path":"/tags/2"},
+				{"op":"remove","path":"/category"},
+				{"op":"test","path":"/tags","value":["w","x","z"]}
+			]`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(patched).To(MatchJSON(`{"title":"b","tags":["w","x","z"],"meta":{"a/b":1,"m~n":2}}`))
+		})
+
+		It("should move and copy the values", func() {
+			patched, err := JSONPatch(document, []byte(`[
+				{"op":"copy","from":"/tags","path":"/labels"},
+				{"op":"add","path":"/labels/-","value":"copied"},
+				{"op":"move","from":"/category","path":"/kind"},
+				{"op":"move","from":"/tags/1","path":"/tags/0"}
+			]`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(patched).To(MatchJSON(`{"title":"a","kind":"work","tags":["y","x"],"labels":["x","y","copied"],"meta":{"a/b":1,"m~n":2}}`))
+		})
+
+		It("should unescape the tokens of the paths", func() {
+			patched, err := JSONPatch(document, []byte(`[
+				{"op":"replace","path":"/meta/a~1b","value":10},
+				{"op":"remove","path":"/meta/m~0n"}
+			]`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(patched).To(MatchJSON(`{"title":"a","category":"work","tags":["x","y"],"meta":{"a/b":10}}`))
+		})
+
+		It("should add a null value and fail without a value", func() {
+			patched, err := JSONPatch(document, []byte(`[{"op":"add","path":"/category","value":null}]`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(patched).To(MatchJSON(`{"title":"a","category":null,"tags":["x","y"],"meta":{"a/b":1,"m~n":2}}`))
+
+			_, err = JSONPatch(document, []byte(`[{"op":"add","path":"/category"}]`))
+			Expect(err).To(MatchError(ErrInvalidPatch))
+		})
+
+		It("should fail when the test doesn't match", func() {
+			_, err := JSONPatch(document, []byte(`[{"op":"test","path":"/title","value":"b"}]`))
+			Expect(err).To(MatchError(ErrTestFailed))
+		})
+
+		DescribeTable("should fail when the operation can't be applied",
+			func(patch string) {
+				_, err := JSONPatch(document, []byte(patch))
+				Expect(err).To(MatchError(ErrInvalidPatch))
+			},
+			Entry("not an array", `{"op":"add","path":"/a","value":1}`),
+			Entry("unknown operation", `[{"op":"merge","path":"/a","value":1}]`),
+			Entry("path without '/'", `[{"op":"add","path":"a","value":1}]`),
+			Entry("invalid escape", `[{"op":"remove","path":"/meta/a~2b"}]`),
+			Entry("missing member", `[{"op":"replace","path":"/description","value":"d"}]`),
+			Entry("missing parent", `[{"op":"add","path":"/missing/a","value":1}]`),
+			Entry("index out of range", `[{"op":"add","path":"/tags/3","value":"z"}]`),
+			Entry("index with leading zero", `[{"op":"remove","path":"/tags/01"}]`),
+			Entry("end of array removed", `[{"op":"remove","path":"/tags/-"}]`),
+			Entry("whole document removed", `[{"op":"remove","path":""}]`),
+			Entry("moved into its child", `[{"op":"move","from":"/meta","path":"/meta/child"}]`),
+		)
+
+		It("should not patch the document when an operation fails", func() {
+			_, err := JSONPatch(document, []byte(`[{"op":"remove","path":"/title"},{"op":"remove","path":"/title"}]`))
+			Expect(err).To(MatchError(ErrInvalidPatch))
+			Expect(document).To(MatchJSON(`{"title":"a","category":"work","tags":["x","y"],"meta":{"a/b":1,"m~n":2}}`))
+		})
+	})
+})
@@ -0,0 +1,107 @@
+package search
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/notes-project/api/pkg/model"
+)
+
+// MatchNote returns the hit of the note when it matches all the terms of the query. It's used by the indexes
+// that find the candidate notes with the text index of a database, which can match more notes than the query,
+// such as the words with other accents, so the candidates are matched again the same way as a MemoryIndex.
+// The score is BM25 without the frequency of the terms among the notes and the length of the fields,
+// which are only known to the database, so it only depends on the matches in the note.
+func MatchNote(note model.Note, query Query) (model.SearchHit, bool) {
+	doc := newDocument(note)
+
+	matches := make([]match, 0, len(query.Terms))
+	for _, term := range query.Terms {
+		termMatch, matched := matchDocument(doc, term)
+		if !matched {
+			return model.SearchHit{}, false
+		}
+
+		matches = append(matches, termMatch)
+	}
+
+	score := 0.0
+	for _, termMatch := range matches {
+		for field := 0; field < fieldsCount; field++ {
+			frequency := float64(len(termMatch.spans[field]))
+			score += fieldWeights[field] * frequency * (bm25K1 + 1) / (frequency + bm25K1)
+		}
+	}
+
+	return model.SearchHit{
+		Note:     note,
+		Score:    score,
+		Snippets: snippets(doc, matches),
+	}, true
+}
+
+// SortHits sorts the hits the same way as the hits returned by Index.Search, the best match first
+func SortHits(hits []model.SearchHit) {
+	sortHits(hits)
+}
+
+func sortHits(hits []model.SearchHit) {
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+
+		return hits[i].ID < hits[j].ID
+	})
+}
+
+// newDocument tokenizes the searched fields of the note
+func newDocument(note model.Note) document {
+	return document{
+		note: note,
+		tokens: [fieldsCount][]token{
+			tokenize(note.Title),
+			tokenize(note.Description),
+		},
+	}
+}
+
+// matchDocument returns where the term matches the fields of the document, and false when it doesn't match any
+func matchDocument(doc document, term Term) (match, bool) {
+	termMatch := match{}
+
+	for field := 0; field < fieldsCount; field++ {
+		for position := range doc.tokens[field] {
+			if hasWordsAt(doc.tokens[field], position, term) {
+				termMatch.spans[field] = append(termMatch.spans[field], span{start: position, end: position + len(term.Words)})
+			}
+		}
+	}
+
+	return termMatch, len(termMatch.spans[titleField]) > 0 || len(termMatch.spans[descriptionField]) > 0
+}
+
+// hasWordsAt returns true when the words of the term follow each other in the tokens from the position
+func hasWordsAt(tokens []token, position int, term Term) bool {
+	if position+len(term.Words) > len(tokens) {
+		return false
+	}
+
+	for i, word := range term.Words {
+		indexed := tokens[position+i].word
+
+		if i == len(term.Words)-1 && term.Prefix {
+			if !strings.HasPrefix(indexed, word) {
+				return false
+			}
+
+			continue
+		}
+
+		if indexed != word {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,46 @@
+package search
+
+import (
+	"github.com/notes-project/api/pkg/model"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MatchNote", func() {
+	note := model.Note{ID: "1", Title: "Weekly meeting", Description: "Prepare the meeting notes"}
+
+	match := func(text string) (model.SearchHit, bool) {
+		query, err := ParseQuery(text)
+		Expect(err).ToNot(HaveOccurred())
+
+		return MatchNote(note, query)
+	}
+
+	It("should match the note the same way as the memory index", func() {
+		for _, text := range []string{"meeting notes", `"meeting notes"`, "meet*", `"weekly meet*"`, "WEEKLY"} {
+			_, matched := match(text)
+			Expect(matched).To(BeTrue(), text)
+		}
+
+		for _, text := range []string{"meeting groceries", `"notes meeting"`, "meetings", "réunion"} {
+			_, matched := match(text)
+			Expect(matched).To(BeFalse(), text)
+		}
+	})
+
+	It("should highlight the matches in the snippets of the matched fields", func() {
+		hit, matched := match("notes")
+		Expect(matched).To(BeTrue())
+		Expect(hit.Note).To(Equal(note))
+		Expect(hit.Snippets).To(Equal(map[string]string{
+			DescriptionField: "Prepare the meeting <mark>notes</mark>",
+		}))
+	})
+
+	It("should score the matches in the title above the matches in the description", func() {
+		title, _ := match("weekly")
+		description, _ := match("notes")
+		Expect(title.Score).To(BeNumerically(">", description.Score))
+	})
+})
@@ -0,0 +1,277 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/notes-project/api/pkg/model"
+)
+
+const (
+	// BM25 parameters, k1 limits how much the repeated words raise the score
+	// and b how much the long fields lower it
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// the searched fields of a note, in the order of their weights
+const (
+	titleField = iota
+	descriptionField
+
+	fieldsCount
+)
+
+var (
+	fieldNames   = [fieldsCount]string{TitleField, DescriptionField}
+	fieldWeights = [fieldsCount]float64{2, 1}
+)
+
+type memoryIndex struct {
+	mu sync.RWMutex
+	// indexed notes keyed by their id
+	documents map[string]document
+	// positions of the words in the fields of the notes, keyed by the word then by the id of the note
+	postings map[string]map[string]*posting
+	// total number of words of each field of all the notes, for the average length of the fields
+	totalLengths [fieldsCount]int
+}
+
+// document is an indexed note along with the words of its fields
+type document struct {
+	note   model.Note
+	tokens [fieldsCount][]token
+}
+
+// posting has the positions of a word in each field of a note
+type posting struct {
+	positions [fieldsCount][]int
+}
+
+// match is where a term matches a note, as the ranges of the positions of the matched words in each field
+type match struct {
+	spans [fieldsCount][]span
+}
+
+// span is the range [start, end) of the positions of the matched words
+type span struct {
+	start int
+	end   int
+}
+
+func NewMemoryIndex() MemoryIndex {
+	return &memoryIndex{
+		documents: map[string]document{},
+		postings:  map[string]map[string]*posting{},
+	}
+}
+
+func (m *memoryIndex) Put(note model.Note) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(note.ID)
+	m.put(note)
+}
+
+func (m *memoryIndex) Remove(noteID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(noteID)
+}
+
+func (m *memoryIndex) Reset(notes []model.Note) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.documents = map[string]document{}
+	m.postings = map[string]map[string]*posting{}
+	m.totalLengths = [fieldsCount]int{}
+
+	for _, note := range notes {
+		m.remove(note.ID)
+		m.put(note)
+	}
+}
+
+// put must be called with the write lock held, after the note with the same id was removed
+func (m *memoryIndex) put(note model.Note) {
+	doc := newDocument(note)
+
+	for field, tokens := range doc.tokens {
+		m.totalLengths[field] += len(tokens)
+
+		for position, t := range tokens {
+			notes, exist := m.postings[t.word]
+			if !exist {
+				notes = map[string]*posting{}
+				m.postings[t.word] = notes
+			}
+
+			p, exist := notes[note.ID]
+			if !exist {
+				p = &posting{}
+				notes[note.ID] = p
+			}
+
+			p.positions[field] = append(p.positions[field], position)
+		}
+	}
+
+	m.documents[note.ID] = doc
+}
+
+// remove must be called with the write lock held
+func (m *memoryIndex) remove(noteID string) {
+	doc, exist := m.documents[noteID]
+	if !exist {
+		return
+	}
+
+	for field, tokens := range doc.tokens {
+		m.totalLengths[field] -= len(tokens)
+
+		for _, t := range tokens {
+			delete(m.postings[t.word], noteID)
+
+			if len(m.postings[t.word]) == 0 {
+				delete(m.postings, t.word)
+			}
+		}
+	}
+
+	delete(m.documents, noteID)
+}
+
+func (m *memoryIndex) Search(query Query) ([]model.SearchHit, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var matched map[string][]match
+	// the number of notes each term matches, the rarer terms weigh more
+	termNotes := make([]int, 0, len(query.Terms))
+
+	for _, term := range query.Terms {
+		termMatches := m.matchTerm(term)
+		termNotes = append(termNotes, len(termMatches))
+
+		// every term has to match
+		if matched == nil {
+			matched = map[string][]match{}
+			for noteID, termMatch := range termMatches {
+				matched[noteID] = []match{termMatch}
+			}
+
+			continue
+		}
+
+		for noteID := range matched {
+			termMatch, exist := termMatches[noteID]
+			if !exist {
+				delete(matched, noteID)
+				continue
+			}
+
+			matched[noteID] = append(matched[noteID], termMatch)
+		}
+	}
+
+	hits := make([]model.SearchHit, 0, len(matched))
+	for noteID, matches := range matched {
+		doc := m.documents[noteID]
+
+		hits = append(hits, model.SearchHit{
+			Note:     doc.note,
+			Score:    m.score(doc, matches, termNotes),
+			Snippets: snippets(doc, matches),
+		})
+	}
+
+	sortHits(hits)
+
+	return hits, nil
+}
+
+// matchTerm returns where the term matches the notes, keyed by the id of the note
+func (m *memoryIndex) matchTerm(term Term) map[string]match {
+	// the notes that have the first word are the only ones that can have the whole term
+	first := m.wordPostings(term.Words[0], term.Prefix && len(term.Words) == 1)
+
+	matches := map[string]match{}
+	for noteID, firstPostings := range first {
+		termMatch := match{}
+
+		for field := 0; field < fieldsCount; field++ {
+			for _, p := range firstPostings {
+				for _, position := range p.positions[field] {
+					if hasWordsAt(m.documents[noteID].tokens[field], position, term) {
+						termMatch.spans[field] = append(termMatch.spans[field], span{start: position, end: position + len(term.Words)})
+					}
+				}
+			}
+
+			sort.Slice(termMatch.spans[field], func(i, j int) bool {
+				return termMatch.spans[field][i].start < termMatch.spans[field][j].start
+			})
+		}
+
+		if len(termMatch.spans[titleField]) > 0 || len(termMatch.spans[descriptionField]) > 0 {
+			matches[noteID] = termMatch
+		}
+	}
+
+	return matches
+}
+
+// wordPostings returns the postings of the word in each note, or of all the words that start with it
+func (m *memoryIndex) wordPostings(word string, prefix bool) map[string][]*posting {
+	postings := map[string][]*posting{}
+
+	if !prefix {
+		for noteID, p := range m.postings[word] {
+			postings[noteID] = append(postings[noteID], p)
+		}
+
+		return postings
+	}
+
+	for indexed, notes := range m.postings {
+		if !strings.HasPrefix(indexed, word) {
+			continue
+		}
+
+		for noteID, p := range notes {
+			postings[noteID] = append(postings[noteID], p)
+		}
+	}
+
+	return postings
+}
+
+// score adds up the BM25 scores of every term in every field of the note, weighted by the field
+func (m *memoryIndex) score(doc document, matches []match, termNotes []int) float64 {
+	notesCount := float64(len(m.documents))
+
+	score := 0.0
+	for i, termMatch := range matches {
+		idf := math.Log(1 + (notesCount-float64(termNotes[i])+0.5)/(float64(termNotes[i])+0.5))
+
+		for field := 0; field < fieldsCount; field++ {
+			frequency := float64(len(termMatch.spans[field]))
+			if frequency == 0 {
+				continue
+			}
+
+			averageLength := float64(m.totalLengths[field]) / notesCount
+			length := float64(len(doc.tokens[field]))
+
+			score += fieldWeights[field] * idf * frequency * (bm25K1 + 1) /
+				(frequency + bm25K1*(1-bm25B+bm25B*length/averageLength))
+		}
+	}
+
+	return score
+}
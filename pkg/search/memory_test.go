@@ -0,0 +1,111 @@
+package search
+
+import (
+	"strings"
+
+	"github.com/notes-project/api/pkg/model"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Memory index", func() {
+	var index MemoryIndex
+
+	search := func(text string) []model.SearchHit {
+		query, err := ParseQuery(text)
+		Expect(err).ToNot(HaveOccurred())
+
+		hits, err := index.Search(query)
+		Expect(err).ToNot(HaveOccurred())
+
+		return hits
+	}
+
+	hitIDs := func(hits []model.SearchHit) []string {
+		ids := []string{}
+		for _, hit := range hits {
+			ids = append(ids, hit.ID)
+		}
+
+		return ids
+	}
+
+	BeforeEach(func() {
+		index = NewMemoryIndex()
+		index.Reset([]model.Note{
+			{ID: "1", Title: "Groceries", Description: "Buy milk, eggs and bread for the weekly meeting"},
+			{ID: "2", Title: "Weekly meeting", Description: "Prepare the meeting notes"},
+			{ID: "3", Title: "Books", Description: "Read about <b>meetings</b> & notes"},
+		})
+	})
+
+	It("should return the notes that match every term", func() {
+		Expect(hitIDs(search("meeting notes"))).To(ConsistOf("2"))
+	})
+
+	It("should rank the matches in the title before the matches in the description", func() {
+		Expect(hitIDs(search("weekly"))).To(Equal([]string{"2", "1"}))
+	})
+
+	It("should match a phrase only when the words follow each other", func() {
+		Expect(hitIDs(search(`"meeting notes"`))).To(ConsistOf("2"))
+		Expect(hitIDs(search(`"notes meeting"`))).To(BeEmpty())
+	})
+
+	It("should match the words that start with a prefix", func() {
+		Expect(hitIDs(search("meet*"))).To(ConsistOf("1", "2", "3"))
+	})
+
+	It("should match case-insensitively", func() {
+		Expect(hitIDs(search("GROCERIES"))).To(ConsistOf("1"))
+	})
+
+	It("should highlight the matches in the snippets of the matched fields", func() {
+		hits := search("meeting")
+		Expect(hits).To(HaveLen(2))
+
+		Expect(hits[0].Snippets).To(Equal(map[string]string{
+			TitleField:       "Weekly <mark>meeting</mark>",
+			DescriptionField: "Prepare the <mark>meeting</mark> notes",
+		}))
+		Expect(hits[1].Snippets).To(Equal(map[string]string{
+			DescriptionField: "Buy milk, eggs and bread for the weekly <mark>meeting</mark>",
+		}))
+	})
+
+	It("should escape the text of the snippets", func() {
+		hits := search("meetings notes")
+		Expect(hits).To(HaveLen(1))
+		Expect(hits[0].Snippets).To(Equal(map[string]string{
+			DescriptionField: "Read about &lt;b&gt;<mark>meetings</mark>&lt;/b&gt; &amp; <mark>notes</mark>",
+		}))
+	})
+
+	It("should cut the snippet of a long description around the first match", func() {
+		description := strings.Repeat("lorem ", 40) + "needle" + strings.Repeat(" ipsum", 40)
+		index.Put(model.Note{ID: "4", Title: "Long", Description: description})
+
+		hits := search("needle")
+		Expect(hits).To(HaveLen(1))
+
+		snippet := hits[0].Snippets[DescriptionField]
+		Expect(snippet).To(HavePrefix(snippetEllipsis + "lorem"))
+		Expect(snippet).To(ContainSubstring("lorem <mark>needle</mark> ipsum"))
+		Expect(snippet).To(HaveSuffix("ipsum" + snippetEllipsis))
+		Expect(strings.Count(snippet, "lorem")).To(Equal(snippetLeadingWords))
+	})
+
+	It("should replace the indexed note with the same id", func() {
+		index.Put(model.Note{ID: "1", Title: "Groceries", Description: "Buy apples"})
+
+		Expect(hitIDs(search("milk"))).To(BeEmpty())
+		Expect(hitIDs(search("apples"))).To(ConsistOf("1"))
+	})
+
+	It("should not return the removed notes", func() {
+		index.Remove("2")
+
+		Expect(hitIDs(search("weekly"))).To(ConsistOf("1"))
+	})
+})
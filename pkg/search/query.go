@@ -0,0 +1,82 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// limits the work done for a single search
+	maxQueryTerms = 32
+)
+
+// Query is a parsed search, a note matches it when it matches all of its terms
+type Query struct {
+	Terms []Term
+}
+
+// Term matches the notes whose title or description has the words in a row,
+// when Prefix is set the last word matches any word that starts with it
+type Term struct {
+	Words  []string
+	Prefix bool
+}
+
+// ParseQuery parses the words of a search separated by spaces. A phrase is quoted, such as "meeting notes",
+// and a word ending with '*' is a prefix, such as meet*. The words are compared case-insensitively,
+// ignoring the punctuation, so a word with punctuation such as e-mail is a phrase of its parts.
+func ParseQuery(text string) (Query, error) {
+	query := Query{}
+
+	rest := strings.TrimSpace(text)
+	for rest != "" {
+		var raw string
+
+		if strings.HasPrefix(rest, "\"") {
+			end := strings.Index(rest[1:], "\"")
+			if end < 0 {
+				return Query{}, fmt.Errorf("failed to parse search query '%s', the phrase is not terminated, error: %w", text, ErrInvalidQuery)
+			}
+
+			raw, rest = rest[1:end+1], rest[end+2:]
+
+			// a phrase can end with a prefix too, such as "meeting not*"
+			if strings.HasPrefix(rest, "*") {
+				raw, rest = raw+"*", rest[1:]
+			}
+		} else {
+			end := strings.IndexAny(rest, " \t\n\"")
+			if end < 0 {
+				end = len(rest)
+			}
+
+			raw, rest = rest[:end], rest[end:]
+		}
+
+		rest = strings.TrimSpace(rest)
+
+		term := Term{
+			Words: words(raw),
+		}
+
+		trimmed := strings.TrimSuffix(raw, "*")
+		term.Prefix = trimmed != raw && endsWithWordRune(trimmed)
+
+		// a term made only of punctuation doesn't match anything on its own
+		if len(term.Words) == 0 {
+			continue
+		}
+
+		query.Terms = append(query.Terms, term)
+	}
+
+	if len(query.Terms) == 0 {
+		return Query{}, fmt.Errorf("failed to parse search query '%s', it has no words, error: %w", text, ErrInvalidQuery)
+	}
+
+	if len(query.Terms) > maxQueryTerms {
+		return Query{}, fmt.Errorf("failed to parse search query '%s', it has more than %d terms, error: %w", text, maxQueryTerms, ErrInvalidQuery)
+	}
+
+	return query, nil
+}
@@ -0,0 +1,76 @@
+package search
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Query", func() {
+
+	Describe("ParseQuery", func() {
+		It("should parse every word as a term", func() {
+			query, err := ParseQuery("  Meeting  notes ")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(query.Terms).To(Equal([]Term{
+				{Words: []string{"meeting"}},
+				{Words: []string{"notes"}},
+			}))
+		})
+
+		It("should parse a quoted phrase as a single term", func() {
+			query, err := ParseQuery(`"meeting notes" monday`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(query.Terms).To(Equal([]Term{
+				{Words: []string{"meeting", "notes"}},
+				{Words: []string{"monday"}},
+			}))
+		})
+
+		It("should parse a word ending with a star as a prefix", func() {
+			query, err := ParseQuery(`meet* "weekly rep"*`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(query.Terms).To(Equal([]Term{
+				{Words: []string{"meet"}, Prefix: true},
+				{Words: []string{"weekly", "rep"}, Prefix: true},
+			}))
+		})
+
+		It("should split a word with punctuation into a phrase", func() {
+			query, err := ParseQuery("e-mail")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(query.Terms).To(Equal([]Term{
+				{Words: []string{"e", "mail"}},
+			}))
+		})
+
+		It("should skip the terms made only of punctuation", func() {
+			query, err := ParseQuery("notes - *")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(query.Terms).To(Equal([]Term{
+				{Words: []string{"notes"}},
+			}))
+		})
+
+		It("should fail when the phrase is not terminated", func() {
+			_, err := ParseQuery(`"meeting notes`)
+			Expect(errors.Is(err, ErrInvalidQuery)).To(BeTrue())
+		})
+
+		It("should fail when the query has no words", func() {
+			_, err := ParseQuery(" ?! ")
+			Expect(errors.Is(err, ErrInvalidQuery)).To(BeTrue())
+		})
+
+		It("should fail when the query has too many terms", func() {
+			text := ""
+			for i := 0; i <= maxQueryTerms; i++ {
+				text += "word "
+			}
+
+			_, err := ParseQuery(text)
+			Expect(errors.Is(err, ErrInvalidQuery)).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,45 @@
+package search
+
+import (
+	"errors"
+
+	"github.com/notes-project/api/pkg/model"
+)
+
+/*
+	Full-text search of the title and the description of the notes.
+
+	Every database keeps the index of its notes along with them, so the index is
+	always up to date with the changes of every instance of the API sharing the
+	database. The in-memory databases keep a MemoryIndex, which ranks the notes
+	with BM25, while the other databases find the candidate notes with their own
+	text index and rank them with MatchNote. Either way the words are matched the
+	same way, and the matches in the title weigh more than the matches in the
+	description.
+*/
+
+// Index finds the notes that match a search
+type Index interface {
+	// Search returns every note that matches all the terms of the query, the best match first
+	Search(query Query) ([]model.SearchHit, error)
+}
+
+// MemoryIndex is an Index of the notes kept in memory, it's updated every time a note is added, updated or deleted
+type MemoryIndex interface {
+	Index
+
+	// Put adds the note to the index, or replaces the indexed note with the same id
+	Put(note model.Note)
+	Remove(noteID string)
+	// Reset replaces all the indexed notes
+	Reset(notes []model.Note)
+}
+
+// ErrInvalidQuery is returned when a search query has no words or an unterminated phrase
+var ErrInvalidQuery = errors.New("search query is invalid")
+
+// names of the searched fields, used as the keys of the snippets of a hit
+const (
+	TitleField       = "title"
+	DescriptionField = "description"
+)
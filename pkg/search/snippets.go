@@ -0,0 +1,135 @@
+package search
+
+import (
+	"html"
+	"sort"
+	"strings"
+)
+
+const (
+	// the snippet of a long field starts a few words before the first match and has at most this many words
+	snippetLeadingWords = 8
+	snippetMaxWords     = 32
+
+	snippetEllipsis = "…"
+
+	// the matched words are highlighted with HTML, the rest of the snippet is escaped
+	highlightStart = "<mark>"
+	highlightEnd   = "</mark>"
+)
+
+// snippets returns the part of every field of the note that matches, with the matched words highlighted
+func snippets(doc document, matches []match) map[string]string {
+	snippets := map[string]string{}
+
+	for field := 0; field < fieldsCount; field++ {
+		spans := []span{}
+		for _, termMatch := range matches {
+			spans = append(spans, termMatch.spans[field]...)
+		}
+
+		if len(spans) == 0 {
+			continue
+		}
+
+		snippets[fieldNames[field]] = snippet(fieldText(doc, field), doc.tokens[field], mergeSpans(spans))
+	}
+
+	return snippets
+}
+
+func fieldText(doc document, field int) string {
+	if field == titleField {
+		return doc.note.Title
+	}
+
+	return doc.note.Description
+}
+
+// mergeSpans sorts the spans and merges the ones that overlap or follow each other
+func mergeSpans(spans []span) []span {
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i].start < spans[j].start
+	})
+
+	merged := []span{spans[0]}
+	for _, s := range spans[1:] {
+		last := &merged[len(merged)-1]
+
+		if s.start <= last.end {
+			if s.end > last.end {
+				last.end = s.end
+			}
+
+			continue
+		}
+
+		merged = append(merged, s)
+	}
+
+	return merged
+}
+
+// snippet cuts the text around the first span and highlights the spans in it
+func snippet(text string, tokens []token, spans []span) string {
+	first, last := 0, len(tokens)
+
+	if len(tokens) > snippetMaxWords {
+		first = spans[0].start - snippetLeadingWords
+		if first < 0 {
+			first = 0
+		}
+
+		last = first + snippetMaxWords
+		if last > len(tokens) {
+			last = len(tokens)
+			first = last - snippetMaxWords
+		}
+	}
+
+	builder := strings.Builder{}
+
+	// the whole text is kept when it's not cut, including the punctuation around the words
+	offset := 0
+	end := len(text)
+
+	if first > 0 {
+		builder.WriteString(snippetEllipsis)
+		offset = tokens[first].start
+	}
+
+	if last < len(tokens) {
+		end = tokens[last-1].end
+	}
+
+	for _, s := range spans {
+		if s.end <= first || s.start >= last {
+			continue
+		}
+
+		start := s.start
+		if start < first {
+			start = first
+		}
+
+		stop := s.end
+		if stop > last {
+			stop = last
+		}
+
+		builder.WriteString(html.EscapeString(text[offset:tokens[start].start]))
+		builder.WriteString(highlightStart)
+		builder.WriteString(html.EscapeString(text[tokens[start].start:tokens[stop-1].end]))
+		builder.WriteString(highlightEnd)
+
+		offset = tokens[stop-1].end
+	}
+
+	builder.WriteString(html.EscapeString(text[offset:end]))
+
+	if last < len(tokens) {
+		builder.WriteString(snippetEllipsis)
+	}
+
+	return builder.String()
+}
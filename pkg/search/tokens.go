@@ -0,0 +1,58 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// token is a word of a text, along with its byte offsets in the text
+type token struct {
+	word  string
+	start int
+	end   int
+}
+
+// tokenize splits the text into lower case words made of letters and digits
+func tokenize(text string) []token {
+	tokens := []token{}
+
+	start := -1
+	for i, r := range text {
+		isWordRune := unicode.IsLetter(r) || unicode.IsDigit(r)
+
+		if isWordRune && start < 0 {
+			start = i
+		}
+
+		if !isWordRune && start >= 0 {
+			tokens = append(tokens, token{word: strings.ToLower(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+
+	if start >= 0 {
+		tokens = append(tokens, token{word: strings.ToLower(text[start:]), start: start, end: len(text)})
+	}
+
+	return tokens
+}
+
+// words returns the words of the text in order
+func words(text string) []string {
+	tokens := tokenize(text)
+
+	words := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		words = append(words, t.word)
+	}
+
+	return words
+}
+
+// endsWithWordRune returns true when the last rune of the text is part of a word
+func endsWithWordRune(text string) bool {
+	r, _ := utf8.DecodeLastRuneInString(text)
+
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
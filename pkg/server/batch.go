@@ -0,0 +1,355 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/notes-project/api/pkg/database"
+	"github.com/notes-project/api/pkg/model"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// the custom method of the notes collection that adds several notes, as in POST /notes:batch
+	batchNotesMethod = "batch"
+
+	// limits the notes added or the operations applied by a single request
+	maxBatchSize = 1000
+)
+
+// statuses of the notes or the operations of a batch
+const (
+	batchStatusCreated   = "created"
+	batchStatusApplied   = "applied"
+	batchStatusDuplicate = "duplicate"
+	batchStatusNotFound  = "notFound"
+	batchStatusConflict  = "conflict"
+	batchStatusInvalid   = "invalid"
+	batchStatusFailed    = "failed"
+	// the operations of an atomic batch that were undone or never applied because another one failed
+	batchStatusRolledBack = "rolledBack"
+	batchStatusSkipped    = "skipped"
+)
+
+// batchResult is the result of adding the note or applying the operation at the index of the batch
+type batchResult struct {
+	Index  int         `json:"index"`
+	Op     string      `json:"op,omitempty"`
+	Status string      `json:"status"`
+	Note   *model.Note `json:"note,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// operationsBatch is a batch of operations applied in order, either all or none of them when it's atomic
+type operationsBatch struct {
+	Atomic     bool             `json:"atomic"`
+	Operations []batchOperation `json:"operations"`
+}
+
+// batchOperation creates, updates or deletes a note, the updated or deleted note is found by its id,
+// or by its title when the id is empty, and the version is the expected version of the note, any by default
+type batchOperation struct {
+	Op      string      `json:"op"`
+	ID      string      `json:"id"`
+	Title   string      `json:"title"`
+	Note    *model.Note `json:"note"`
+	Version *int64      `json:"version"`
+}
+
+// notesMethod calls the custom method of the notes collection, gin can't route a path with a literal colon
+// such as /notes:batch, so the name of the method is the value of the wildcard along with its colon. The wildcard
+// matches any path that starts with /notes, the paths other than the custom methods aren't routed.
+func (s server) notesMethod(c *gin.Context) {
+	switch c.Param("method") {
+	case ":" + batchNotesMethod:
+		s.addNotes(c)
+	default:
+		notRouted(c)
+	}
+}
+
+// notRouted responds like the router to a path that has no route
+func notRouted(c *gin.Context) {
+	c.Data(http.StatusNotFound, binding.MIMEPlain, []byte("404 page not found"))
+}
+
+// addNotes adds each note of the batch independently, so the invalid and duplicate notes don't prevent
+// the other ones from being added, and returns the result of every note in the order of the batch
+func (s server) addNotes(c *gin.Context) {
+	items := []json.RawMessage{}
+
+	err := c.MustBindWith(&items, binding.JSON)
+	if err != nil {
+		s.logger.Error(err.Error())
+		return
+	}
+
+	if len(items) == 0 || len(items) > maxBatchSize {
+		c.JSON(http.StatusBadRequest,
+			gin.H{
+				"error": fmt.Sprintf("batch must have from 1 to %d notes", maxBatchSize),
+			},
+		)
+
+		return
+	}
+
+	results := make([]batchResult, len(items))
+
+	notes := []model.Note{}
+	// the index in the batch of each note that is added
+	indexes := []int{}
+
+	for i, item := range items {
+		note := model.Note{}
+
+		err := json.Unmarshal(item, &note)
+		if err == nil {
+			err = binding.Validator.ValidateStruct(&note)
+		}
+
+		if err != nil {
+			results[i] = batchResult{Index: i, Status: batchStatusInvalid, Error: err.Error()}
+			continue
+		}
+
+		notes = append(notes, initNote(note))
+		indexes = append(indexes, i)
+	}
+
+	errs, err := s.db.AddNotes(notes)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to add a batch of notes to the database, err: %s", err))
+
+		c.JSON(http.StatusInternalServerError,
+			gin.H{
+				"error": err.Error(),
+			},
+		)
+
+		return
+	}
+
+	location := requestLocation(c)
+	created := 0
+
+	for i, note := range notes {
+		index := indexes[i]
+
+		switch {
+		case errs[i] == nil:
+			notes[i].SetDate(location)
+			results[index] = batchResult{Index: index, Status: batchStatusCreated, Note: &notes[i]}
+			created++
+		case mongo.IsDuplicateKeyError(errs[i]):
+			results[index] = batchResult{
+				Index:  index,
+				Status: batchStatusDuplicate,
+				Error:  fmt.Sprintf("note with key 'title' and value '%s' already exists", note.Title),
+			}
+		default:
+			s.logger.Error(fmt.Sprintf("Failed to add note '%s' of a batch to the database, err: %s", note.Title, errs[i]))
+
+			results[index] = batchResult{Index: index, Status: batchStatusFailed, Error: "failed to add the note"}
+		}
+	}
+
+	s.logger.Info(fmt.Sprintf("Added %d of the %d notes of a batch", created, len(items)))
+
+	c.JSON(http.StatusOK,
+		gin.H{
+			"results": results,
+		})
+}
+
+// applyOperations applies the operations of the batch in order and returns the result of every operation,
+// when the batch is atomic either all the operations are applied or none of them
+func (s server) applyOperations(c *gin.Context) {
+	batch := operationsBatch{}
+
+	err := c.MustBindWith(&batch, binding.JSON)
+	if err != nil {
+		s.logger.Error(err.Error())
+		return
+	}
+
+	if len(batch.Operations) == 0 || len(batch.Operations) > maxBatchSize {
+		c.JSON(http.StatusBadRequest,
+			gin.H{
+				"error": fmt.Sprintf("batch must have from 1 to %d operations", maxBatchSize),
+			},
+		)
+
+		return
+	}
+
+	results := make([]batchResult, len(batch.Operations))
+
+	operations := []database.Operation{}
+	// the index in the batch of each operation that is applied
+	indexes := []int{}
+
+	for i, requested := range batch.Operations {
+		operation, err := parseOperation(requested)
+		if err != nil {
+			results[i] = batchResult{Index: i, Op: requested.Op, Status: batchStatusInvalid, Error: err.Error()}
+			continue
+		}
+
+		operations = append(operations, operation)
+		indexes = append(indexes, i)
+	}
+
+	// an atomic batch with an invalid operation is not applied at all
+	if batch.Atomic && len(operations) < len(batch.Operations) {
+		for i, index := range indexes {
+			results[index] = batchResult{Index: index, Op: operations[i].Type, Status: batchStatusSkipped}
+		}
+
+		c.JSON(http.StatusOK,
+			gin.H{
+				"results": results,
+			})
+
+		return
+	}
+
+	errs, err := s.db.ApplyOperations(operations, batch.Atomic)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to apply a batch of operations to the database, err: %s", err))
+
+		c.JSON(http.StatusInternalServerError,
+			gin.H{
+				"error": err.Error(),
+			},
+		)
+
+		return
+	}
+
+	location := requestLocation(c)
+	// the operations of an atomic batch before the failed one are rolled back, the ones after it are skipped
+	failed := false
+	applied := 0
+
+	for i, operation := range operations {
+		index := indexes[i]
+		result := batchResult{Index: index, Op: operation.Type}
+
+		switch err := errs[i]; {
+		case err == nil:
+			result.Status = batchStatusApplied
+			if operation.Type == database.OperationCreate {
+				note := operation.Note
+				note.SetDate(location)
+				result.Note = &note
+			}
+			applied++
+		case errors.Is(err, database.ErrNotApplied):
+			result.Status = batchStatusRolledBack
+			if failed {
+				result.Status = batchStatusSkipped
+			}
+		case errors.Is(err, mongo.ErrNoDocuments):
+			failed = true
+			result.Status = batchStatusNotFound
+			result.Error = fmt.Sprintf("note %s does not exist", operationNoteRef(operation))
+		case errors.Is(err, database.ErrVersionConflict):
+			failed = true
+			result.Status = batchStatusConflict
+			result.Error = fmt.Sprintf("note %s does not have version %d", operationNoteRef(operation), operation.ExpectedVersion)
+		case mongo.IsDuplicateKeyError(err):
+			failed = true
+			result.Status = batchStatusDuplicate
+			result.Error = fmt.Sprintf("note with key 'title' and value '%s' already exists", operation.Note.Title)
+		default:
+			failed = true
+			s.logger.Error(fmt.Sprintf("Failed to apply the %s of note %s of a batch, err: %s", operation.Type, operationNoteRef(operation), err))
+
+			result.Status = batchStatusFailed
+			result.Error = fmt.Sprintf("failed to %s note %s", operation.Type, operationNoteRef(operation))
+		}
+
+		results[index] = result
+	}
+
+	s.logger.Info(fmt.Sprintf("Applied %d of the %d operations of a batch", applied, len(batch.Operations)))
+
+	c.JSON(http.StatusOK,
+		gin.H{
+			"results": results,
+		})
+}
+
+// parseOperation returns the operation of the database from the requested one, or an error when it's invalid
+func parseOperation(requested batchOperation) (database.Operation, error) {
+	operation := database.Operation{
+		Type:            requested.Op,
+		NoteID:          requested.ID,
+		NoteTitle:       requested.Title,
+		ExpectedVersion: database.AnyVersion,
+	}
+
+	if requested.Version != nil {
+		if *requested.Version < 0 {
+			return database.Operation{}, fmt.Errorf("version %d must not be negative", *requested.Version)
+		}
+
+		operation.ExpectedVersion = *requested.Version
+	}
+
+	switch requested.Op {
+	case database.OperationCreate, database.OperationUpdate:
+		if requested.Op == database.OperationUpdate && requested.ID == "" && requested.Title == "" {
+			return database.Operation{}, errors.New("the id or the title of the updated note is required")
+		}
+
+		if requested.Note == nil {
+			return database.Operation{}, fmt.Errorf("the note of the %s is required", requested.Op)
+		}
+
+		err := binding.Validator.ValidateStruct(requested.Note)
+		if err != nil {
+			return database.Operation{}, err
+		}
+
+		if requested.Op == database.OperationCreate {
+			operation.Note = initNote(*requested.Note)
+			return operation, nil
+		}
+
+		// same as a single update, the fields set by the API and the database can't be changed
+		operation.Note = *requested.Note
+		operation.Note.ID = ""
+		operation.Note.Version = 0
+		operation.Note.CreatedAt = time.Time{}
+		operation.Note.UpdatedAt = time.Time{}
+		operation.Note.Date = ""
+	case database.OperationDelete:
+		if requested.ID == "" && requested.Title == "" {
+			return database.Operation{}, errors.New("the id or the title of the deleted note is required")
+		}
+	default:
+		return database.Operation{}, fmt.Errorf("op '%s' must be one of 'create', 'update' or 'delete'", requested.Op)
+	}
+
+	return operation, nil
+}
+
+// operationNoteRef returns how the note of the operation is referred to in the messages
+func operationNoteRef(operation database.Operation) string {
+	if operation.Type == database.OperationCreate {
+		return fmt.Sprintf("'%s'", operation.Note.Title)
+	}
+
+	if operation.NoteID != "" {
+		return fmt.Sprintf("with id '%s'", operation.NoteID)
+	}
+
+	return fmt.Sprintf("'%s'", operation.NoteTitle)
+}
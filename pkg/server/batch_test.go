@@ -0,0 +1,60 @@
+package server
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Batch", func() {
+
+	It("should return the status of every operation", func() {
+		router := newTestRouter()
+		addTestNote(router, "a")
+		addTestNote(router, "b")
+
+		response := serve(router, http.MethodPost, "/api/v1/batch", `{"operations": [
+			{"op": "update", "title": "a", "version": 1, "note": {"title": "a", "description": "updated"}},
+			{"op": "update", "title": "b", "version": 2, "note": {"title": "b", "description": "updated"}},
+			{"op": "delete", "title": "missing"},
+			{"op": "create", "note": {"title": "a", "description": "duplicate"}},
+			{"op": "move", "title": "a"}
+		]}`)
+		Expect(response.Code).To(Equal(http.StatusOK))
+		Expect(response.Body.String()).To(MatchJSON(`{"results": [
+			{"index": 0, "op": "update", "status": "applied"},
+			{"index": 1, "op": "update", "status": "conflict", "error": "note 'b' does not have version 2"},
+			{"index": 2, "op": "delete", "status": "notFound", "error": "note 'missing' does not exist"},
+			{"index": 3, "op": "create", "status": "duplicate", "error": "note with key 'title' and value 'a' already exists"},
+			{"index": 4, "op": "move", "status": "invalid", "error": "op 'move' must be one of 'create', 'update' or 'delete'"}
+		]}`))
+	})
+
+	It("should roll back the operations of an atomic batch before the failed one and skip the ones after it", func() {
+		router := newTestRouter()
+		addTestNote(router, "a")
+
+		response := serve(router, http.MethodPost, "/api/v1/batch", `{"atomic": true, "operations": [
+			{"op": "create", "note": {"title": "b", "description": "new"}},
+			{"op": "delete", "title": "a", "version": 2},
+			{"op": "delete", "title": "b"}
+		]}`)
+		Expect(response.Code).To(Equal(http.StatusOK))
+		Expect(response.Body.String()).To(MatchJSON(`{"results": [
+			{"index": 0, "op": "create", "status": "rolledBack"},
+			{"index": 1, "op": "delete", "status": "conflict", "error": "note 'a' does not have version 2"},
+			{"index": 2, "op": "delete", "status": "skipped"}
+		]}`))
+
+		Expect(serve(router, http.MethodGet, "/api/v1/notes/a", "").Code).To(Equal(http.StatusOK))
+		Expect(serve(router, http.MethodGet, "/api/v1/notes/b", "").Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should return 400 when the batch has no operations", func() {
+		router := newTestRouter()
+
+		Expect(serve(router, http.MethodPost, "/api/v1/batch", `{"operations": []}`).Code).To(Equal(http.StatusBadRequest))
+	})
+
+})
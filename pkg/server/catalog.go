@@ -0,0 +1,220 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/notes-project/api/pkg/database"
+)
+
+// valueRename renames a tag or a category in all the notes
+type valueRename struct {
+	To string `json:"to" binding:"required"`
+}
+
+var (
+	// the fields of the counted values accepted by the sort query parameter of the catalogs
+	countSortableFields = map[string]string{
+		"value": database.ValueField,
+		"count": database.CountField,
+	}
+)
+
+func (s server) getTags(c *gin.Context) {
+	s.getCatalog(c, database.TagsField, "tags")
+}
+
+func (s server) getCategories(c *gin.Context) {
+	s.getCatalog(c, database.CategoryField, "categories")
+}
+
+// getCatalog lists the distinct values of the field of the notes along with the number of notes that have them,
+// the notes are filtered by the same query parameters as the listed notes
+func (s server) getCatalog(c *gin.Context, field, name string) {
+	tags, err := parseTagFilter(c.Request.URL.Query())
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("Invalid tags, err: %s", err))
+
+		c.JSON(http.StatusBadRequest,
+			gin.H{
+				"error": err.Error(),
+			},
+		)
+
+		return
+	}
+
+	category := c.Query("category")
+
+	timeRange, err := parseTimeRange(c.Request.URL.Query(), requestLocation(c), time.Now())
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("Invalid time range, err: %s", err))
+
+		c.JSON(http.StatusBadRequest,
+			gin.H{
+				"error": err.Error(),
+			},
+		)
+
+		return
+	}
+
+	sortBy, err := parseCountSort(c.Query(sortQueryParam))
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("Invalid sort, err: %s", err))
+
+		c.JSON(http.StatusBadRequest,
+			gin.H{
+				"error": err.Error(),
+			},
+		)
+
+		return
+	}
+
+	page, ok := s.parsePage(c)
+	if !ok {
+		return
+	}
+
+	counts, next, err := s.db.CountValues(field, tags, category, timeRange, sortBy, page)
+	if err != nil {
+		s.handleListingError(c, page, err)
+		return
+	}
+
+	response := gin.H{
+		name: counts,
+	}
+
+	if next != "" {
+		response["next"] = next
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// parseCountSort returns the sort of the counted values from the query parameter, like parseSort,
+// the values can be sorted by the value and the count, for example -count,value
+func parseCountSort(value string) ([]database.SortField, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	sortBy := []database.SortField{}
+	sorted := map[string]bool{}
+
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+
+		descending := strings.HasPrefix(name, "-")
+		name = strings.TrimPrefix(name, "-")
+
+		field, ok := countSortableFields[name]
+		if !ok {
+			return nil, fmt.Errorf("sort field '%s' must be one of 'value' or 'count'", name)
+		}
+
+		if sorted[field] {
+			return nil, fmt.Errorf("sort field '%s' is sorted more than once", name)
+		}
+
+		sorted[field] = true
+		sortBy = append(sortBy, database.SortField{Field: field, Descending: descending})
+	}
+
+	return sortBy, nil
+}
+
+func (s server) renameTag(c *gin.Context) {
+	s.renameValue(c, database.TagsField, "tag", c.Param("tag"))
+}
+
+func (s server) renameCategory(c *gin.Context) {
+	s.renameValue(c, database.CategoryField, "category", c.Param("category"))
+}
+
+// renameValue renames the value of the field in all the notes, or merges it into the new value when other notes have it
+func (s server) renameValue(c *gin.Context, field, name, from string) {
+	rename := valueRename{}
+
+	err := c.MustBindWith(&rename, binding.JSON)
+	if err != nil {
+		s.logger.Error(err.Error())
+		return
+	}
+
+	// a dry run only counts the notes that would change, it's a query parameter like the dry run of an import
+	dryRun, err := parseBoolParam(c.Request.URL.Query(), dryRunQueryParam)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("Invalid rename of %s '%s', err: %s", name, from, err))
+
+		c.JSON(http.StatusBadRequest,
+			gin.H{
+				"error": err.Error(),
+			},
+		)
+
+		return
+	}
+
+	if database.NormalizeValue(rename.To) == "" {
+		c.JSON(http.StatusBadRequest,
+			gin.H{
+				"error": fmt.Sprintf("%s '%s' can't be renamed to an empty %s", name, from, name),
+			},
+		)
+
+		return
+	}
+
+	if database.NormalizeValue(rename.To) == database.NormalizeValue(from) {
+		s.logger.Info(fmt.Sprintf("Invalid rename of %s '%s' to itself", name, from))
+
+		c.JSON(http.StatusBadRequest,
+			gin.H{
+				"error": fmt.Sprintf("%s '%s' can't be renamed to itself", name, from),
+			},
+		)
+
+		return
+	}
+
+	result, err := s.db.RenameValue(field, from, rename.To, dryRun)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to rename %s '%s' to '%s' in database, err: %s", name, from, rename.To, err))
+
+		c.JSON(http.StatusInternalServerError,
+			gin.H{
+				"error": fmt.Sprintf("failed to rename %s '%s'", name, from),
+			},
+		)
+
+		return
+	}
+
+	if result.Changed == 0 {
+		s.logger.Info(fmt.Sprintf("No note has %s '%s' in database", name, from))
+
+		c.JSON(http.StatusNotFound,
+			gin.H{
+				"error": fmt.Sprintf("no note has %s '%s'", name, from),
+			},
+		)
+
+		return
+	}
+
+	c.JSON(http.StatusOK,
+		gin.H{
+			"from":    from,
+			"to":      rename.To,
+			"changed": result.Changed,
+			"merged":  result.Merged,
+			"dryRun":  dryRun,
+		})
+}
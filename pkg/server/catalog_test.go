@@ -0,0 +1,86 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/notes-project/api/pkg/database"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Catalog", func() {
+
+	Describe("parseCountSort", func() {
+		It("should return no sort when the parameter is empty", func() {
+			sortBy, err := parseCountSort("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sortBy).To(BeNil())
+		})
+
+		It("should return the fields in order with their direction", func() {
+			sortBy, err := parseCountSort("-count, value")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sortBy).To(Equal([]database.SortField{
+				{Field: database.CountField, Descending: true},
+				{Field: database.ValueField},
+			}))
+		})
+
+		It("should return an error when a field is not allowed", func() {
+			for _, value := range []string{"title", "count,", "-", "value,-value"} {
+				_, err := parseCountSort(value)
+				Expect(err).To(HaveOccurred(), value)
+			}
+		})
+	})
+
+	Describe("renameValue", func() {
+		addTaggedNote := func() *gin.Engine {
+			router := newTestRouter()
+
+			response := serve(router, http.MethodPost, "/api/v1/notes", `{"title": "test", "description": "test", "tags": ["work"]}`)
+			Expect(response.Code).To(Equal(http.StatusOK))
+
+			return router
+		}
+
+		It("should only count the notes that would change with a dry run", func() {
+			router := addTaggedNote()
+
+			response := serve(router, http.MethodPost, "/api/v1/tags/work/rename?dryRun=true", `{"to": "job"}`)
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Body.String()).To(MatchJSON(`{"from": "work", "to": "job", "changed": 1, "merged": false, "dryRun": true}`))
+
+			response = serve(router, http.MethodGet, "/api/v1/notes/test", "")
+			Expect(response.Body.String()).To(ContainSubstring(`"tags":["work"]`))
+
+			response = serve(router, http.MethodPost, "/api/v1/tags/work/rename", `{"to": "job"}`)
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Body.String()).To(ContainSubstring(`"dryRun":false`))
+
+			response = serve(router, http.MethodGet, "/api/v1/notes/test", "")
+			Expect(response.Body.String()).To(ContainSubstring(`"tags":["job"]`))
+		})
+
+		It("should return 400 when the dry run is not a boolean", func() {
+			router := addTaggedNote()
+
+			response := serve(router, http.MethodPost, "/api/v1/tags/work/rename?dryRun=maybe", `{"to": "job"}`)
+			Expect(response.Code).To(Equal(http.StatusBadRequest))
+		})
+
+		It("should return 400 when the value is renamed to itself or to an empty value in another form", func() {
+			router := addTaggedNote()
+
+			for _, to := range []string{"work", " work", "work ", "  "} {
+				response := serve(router, http.MethodPost, "/api/v1/tags/work/rename", `{"to": "`+to+`"}`)
+				Expect(response.Code).To(Equal(http.StatusBadRequest), to)
+			}
+
+			response := serve(router, http.MethodPost, "/api/v1/categories/%20work/rename", `{"to": "work"}`)
+			Expect(response.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+})
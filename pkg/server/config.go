@@ -1,6 +1,10 @@
 package server
 
-import "github.com/notes-project/api/pkg/database"
+import (
+	"time"
+
+	"github.com/notes-project/api/pkg/database"
+)
 
 type serverConfiguration struct {
 	port string
@@ -9,14 +13,22 @@ type serverConfiguration struct {
 	tlsPort         string
 	tlsCertLocation string
 	tlsKeyLocation  string
+
+	// the notes in the trash are purged after the retention, zero keeps them until they are purged explicitly
+	trashRetention time.Duration
+
+	// the maximum number of notes listed in a single page
+	maxPageSize int64
 }
 
-func NewServerConfiguration(port, tlsPort, tlsCertLocation, tlsKeyLocation string, db database.Database) serverConfiguration {
+func NewServerConfiguration(port, tlsPort, tlsCertLocation, tlsKeyLocation string, db database.Database, trashRetention time.Duration, maxPageSize int64) serverConfiguration {
 	return serverConfiguration{
 		port:            port,
 		db:              db,
 		tlsPort:         tlsPort,
 		tlsCertLocation: tlsCertLocation,
 		tlsKeyLocation:  tlsKeyLocation,
+		trashRetention:  trashRetention,
+		maxPageSize:     maxPageSize,
 	}
 }
@@ -1,6 +1,8 @@
 package server
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -12,11 +14,13 @@ var _ = Describe("Config", func() {
 		testTlsPort         = "testTlsPort"
 		testTlsCertLocation = "testTlsCertLocation"
 		testTlsKeyLocation  = "testTlsKeyLocation"
+		testTrashRetention  = time.Hour
+		testMaxPageSize     = int64(10)
 	)
 
 	Describe("NewServerConfiguration", func() {
 		It("should return a new server configuration object", func() {
-			serverConfig := NewServerConfiguration(testPort, testTlsPort, testTlsCertLocation, testTlsKeyLocation, nil)
+			serverConfig := NewServerConfiguration(testPort, testTlsPort, testTlsCertLocation, testTlsKeyLocation, nil, testTrashRetention, testMaxPageSize)
 
 			Expect(serverConfig).To(Equal(
 				serverConfiguration{
@@ -24,6 +28,8 @@ var _ = Describe("Config", func() {
 					tlsPort:         testTlsPort,
 					tlsCertLocation: testTlsCertLocation,
 					tlsKeyLocation:  testTlsKeyLocation,
+					trashRetention:  testTrashRetention,
+					maxPageSize:     testMaxPageSize,
 				},
 			))
 		})
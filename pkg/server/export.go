@@ -0,0 +1,106 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/notes-project/api/pkg/model"
+	"github.com/notes-project/api/pkg/transfer"
+)
+
+const (
+	// query parameter of the format of the exported notes
+	formatQueryParam = "format"
+)
+
+// exportNotes streams the notes matching the same filters as the listing in the requested format,
+// the notes are written as they are read from the database so the export isn't paged
+func (s server) exportNotes(c *gin.Context) {
+	format := c.DefaultQuery(formatQueryParam, transfer.FormatNDJSON)
+
+	writer, err := transfer.NewWriter(format, c.Writer)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("Invalid export format, err: %s", err))
+
+		c.JSON(http.StatusBadRequest,
+			gin.H{
+				"error": fmt.Sprintf("format '%s' must be one of %s", format, strings.Join(transfer.Formats, ", ")),
+			},
+		)
+
+		return
+	}
+
+	filters, ok := s.parseListingFilters(c)
+	if !ok {
+		return
+	}
+
+	sortBy, err := parseSort(c.Query(sortQueryParam), false)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("Invalid sort, err: %s", err))
+
+		c.JSON(http.StatusBadRequest,
+			gin.H{
+				"error": err.Error(),
+			},
+		)
+
+		return
+	}
+
+	c.Header("Content-Type", transfer.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", transfer.FileName(format)))
+
+	location := requestLocation(c)
+	count := 0
+
+	err = s.db.StreamNotes(filters.where, filters.tags, filters.category, filters.timeRange, sortBy, func(note model.Note) error {
+		note.SetDate(location)
+		count++
+
+		// the export can take longer than the write timeout, so only a stalled write fails it
+		err := extendWriteDeadline(c)
+		if err != nil {
+			return fmt.Errorf("failed to extend the write deadline, error: %w", err)
+		}
+
+		return writer.Write(note)
+	})
+	if err == nil {
+		err = writer.Close()
+	}
+
+	if err != nil {
+		s.handleExportError(c, format, count, err)
+		return
+	}
+
+	s.logger.Info(fmt.Sprintf("Successfully exported %d notes to %s", count, format))
+}
+
+// handleExportError responds with the error when nothing was written yet, otherwise the export is cut short
+// as the status was already sent, and the client is left with an incomplete file
+func (s server) handleExportError(c *gin.Context, format string, count int, err error) {
+	if c.Writer.Written() {
+		s.logger.Error(fmt.Sprintf("Failed to export notes to %s after %d notes, err: %s", format, count, err))
+
+		c.Abort()
+
+		return
+	}
+
+	s.logger.Error(fmt.Sprintf("Failed to export notes to %s, err: %s", format, err))
+
+	// the headers of the export are replaced by the ones of the error
+	c.Header("Content-Disposition", "")
+	c.Header("Content-Type", "")
+
+	c.JSON(http.StatusInternalServerError,
+		gin.H{
+			"error": "failed to export notes",
+		},
+	)
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/notes-project/api/pkg/database"
+	"github.com/notes-project/api/pkg/model"
+)
+
+const (
+	// query parameter of the fields of the notes in the response
+	fieldsQueryParam = "fields"
+
+	// the version of the notes is read along with the projected fields to set the ETag of a single note
+	versionField = "version"
+)
+
+// parseFields returns the projection of the notes in the response from the query parameter, a list of fields
+// of model.Note separated by commas such as title,tags,updatedAt. It's nil when the parameter is empty,
+// so the notes have all their fields.
+func parseFields(value string) (database.Projection, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	noteFields := model.NoteFields()
+	fields := database.Projection{}
+
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+
+		if !containsField(noteFields, field) {
+			return nil, fmt.Errorf("field '%s' must be one of %s", field, strings.Join(noteFields, ", "))
+		}
+
+		if !containsField(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields, nil
+}
+
+// requestedFields returns the projection of the notes in the response, it responds with an error when it's invalid
+func (s server) requestedFields(c *gin.Context) (database.Projection, bool) {
+	fields, err := parseFields(c.Query(fieldsQueryParam))
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("Invalid fields, err: %s", err))
+
+		c.JSON(http.StatusBadRequest,
+			gin.H{
+				"error": err.Error(),
+			},
+		)
+
+		return nil, false
+	}
+
+	return fields, true
+}
+
+// withVersion returns the projection along with the version of the notes, nil when it reads all the fields
+func withVersion(fields database.Projection) database.Projection {
+	if fields == nil {
+		return nil
+	}
+
+	return append(append(database.Projection{}, fields...), versionField)
+}
+
+func containsField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// projectFields returns the note rendered in JSON without the fields left out of the projection,
+// the other fields, such as the score of a search hit, are kept. The note is returned as is without a projection.
+func projectFields(note interface{}, fields database.Projection) (interface{}, error) {
+	if fields == nil {
+		return note, nil
+	}
+
+	projected := map[string]json.RawMessage{}
+
+	err := renderJSON(note, &projected)
+	if err != nil {
+		return nil, err
+	}
+
+	deleteFields(projected, fields)
+
+	return projected, nil
+}
+
+// projectAllFields returns the list of notes rendered in JSON without the fields left out of the projection
+func projectAllFields(notes interface{}, fields database.Projection) (interface{}, error) {
+	if fields == nil {
+		return notes, nil
+	}
+
+	projected := []map[string]json.RawMessage{}
+
+	err := renderJSON(notes, &projected)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, note := range projected {
+		deleteFields(note, fields)
+	}
+
+	return projected, nil
+}
+
+// renderJSON decodes the value rendered in JSON into the rendered value
+func renderJSON(value interface{}, rendered interface{}) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to render the fields of the notes, error: %w", err)
+	}
+
+	err = json.Unmarshal(encoded, rendered)
+	if err != nil {
+		return fmt.Errorf("failed to render the fields of the notes, error: %w", err)
+	}
+
+	return nil
+}
+
+// deleteFields deletes the fields of model.Note left out of the projection from the rendered note
+func deleteFields(note map[string]json.RawMessage, fields database.Projection) {
+	for _, field := range model.NoteFields() {
+		if !containsField(fields, field) {
+			delete(note, field)
+		}
+	}
+}
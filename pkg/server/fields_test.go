@@ -0,0 +1,63 @@
+package server
+
+import (
+	"encoding/json"
+
+	"github.com/notes-project/api/pkg/database"
+	"github.com/notes-project/api/pkg/model"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Fields", func() {
+
+	Describe("parseFields", func() {
+		It("should return no projection when the parameter is empty", func() {
+			fields, err := parseFields(" ")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fields).To(BeNil())
+		})
+
+		It("should return the fields once in order", func() {
+			fields, err := parseFields("title, tags,updatedAt,title")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fields).To(Equal(database.Projection{"title", "tags", "updatedAt"}))
+		})
+
+		It("should return an error when a field is not a field of the notes", func() {
+			for _, value := range []string{"score", "title,", "Title", "_id"} {
+				_, err := parseFields(value)
+				Expect(err).To(HaveOccurred(), value)
+			}
+		})
+	})
+
+	Describe("projectFields", func() {
+		It("should keep only the fields of the projection", func() {
+			projected, err := projectFields(model.Note{ID: "id", Title: "test", Description: "long"}, database.Projection{"title"})
+			Expect(err).NotTo(HaveOccurred())
+
+			rendered, err := json.Marshal(projected)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered).To(MatchJSON(`{"title": "test"}`))
+		})
+
+		It("should keep the fields of the search hits that are not fields of the notes", func() {
+			projected, err := projectAllFields([]model.SearchHit{{Note: model.Note{Title: "test"}, Score: 1.5}}, database.Projection{"id"})
+			Expect(err).NotTo(HaveOccurred())
+
+			rendered, err := json.Marshal(projected)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered).To(MatchJSON(`[{"id": "", "score": 1.5, "snippets": null}]`))
+		})
+
+		It("should return the notes as they are without a projection", func() {
+			notes := []model.Note{{Title: "test"}}
+
+			projected, err := projectAllFields(notes, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(projected).To(Equal(notes))
+		})
+	})
+
+})
@@ -0,0 +1,410 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/notes-project/api/pkg/constants"
+	"github.com/notes-project/api/pkg/database"
+	"github.com/notes-project/api/pkg/filter"
+)
+
+const (
+	// query parameters of the tags of the listed notes
+	tagsQueryParam     = "tags"
+	tagModeQueryParam  = "tagMode"
+	untaggedQueryParam = "untagged"
+
+	// the listed notes have all the tags, or any of them
+	tagModeAll = "all"
+	tagModeAny = "any"
+
+	// prefix of the tags the listed notes must not have
+	excludedTagPrefix = "-"
+
+	// query parameters of the time range of the listed notes
+	dateQueryParam      = "date"
+	fromQueryParam      = "from"
+	toQueryParam        = "to"
+	sinceQueryParam     = "since"
+	timeFieldQueryParam = "timeField"
+
+	// day accepted by the from and to query parameters in addition to constants.DateFormat,
+	// it starts at midnight UTC whatever the location of the client
+	isoDateFormat = "2006-01-02"
+
+	// query parameters of the page of the listed notes
+	limitQueryParam  = "limit"
+	cursorQueryParam = "cursor"
+
+	// query parameter of the sort of the listed notes
+	sortQueryParam = "sort"
+
+	// sorts the notes by how well they match a search
+	relevanceSortField = "relevance"
+
+	// query parameter of the search of the listed notes
+	searchQueryParam = "q"
+
+	// query parameter of the structured filter of the listed notes, parsed by filter.Parse
+	filterQueryParam = "filter"
+)
+
+var (
+	// the time fields of the notes accepted by the timeField query parameter
+	timeFields = map[string]string{
+		"createdAt": database.CreatedAtField,
+		"updatedAt": database.UpdatedAtField,
+	}
+
+	// the fields of the notes accepted by the sort query parameter, the date is rendered from the update time
+	sortableFields = map[string]string{
+		"title":     database.TitleField,
+		"category":  database.CategoryField,
+		"date":      database.UpdatedAtField,
+		"createdAt": database.CreatedAtField,
+		"updatedAt": database.UpdatedAtField,
+	}
+
+	// units of the relative times accepted by the since query parameter
+	// in addition to the ones of time.ParseDuration
+	relativeTimeUnits = map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	}
+)
+
+// listingFilters are the filters of the listed and the exported notes
+type listingFilters struct {
+	where     filter.Expr
+	tags      database.TagFilter
+	category  string
+	timeRange database.TimeRange
+}
+
+// parseListingFilters returns the filters of the notes from the query parameters, it responds with an error when they are invalid
+func (s server) parseListingFilters(c *gin.Context) (listingFilters, bool) {
+	tags, err := parseTagFilter(c.Request.URL.Query())
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("Invalid tags, err: %s", err))
+
+		c.JSON(http.StatusBadRequest,
+			gin.H{
+				"error": err.Error(),
+			},
+		)
+
+		return listingFilters{}, false
+	}
+
+	category := c.Query("category")
+
+	where, err := parseWhere(c.Query(filterQueryParam), requestLocation(c))
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("Invalid filter, err: %s", err))
+
+		response := gin.H{
+			"error": err.Error(),
+		}
+
+		var syntaxErr *filter.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			response["error"] = fmt.Sprintf("filter is invalid at position %d, %s", syntaxErr.Position, syntaxErr.Message)
+			response["position"] = syntaxErr.Position
+		}
+
+		c.JSON(http.StatusBadRequest, response)
+
+		return listingFilters{}, false
+	}
+
+	timeRange, err := parseTimeRange(c.Request.URL.Query(), requestLocation(c), time.Now())
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("Invalid time range, err: %s", err))
+
+		c.JSON(http.StatusBadRequest,
+			gin.H{
+				"error": err.Error(),
+			},
+		)
+
+		return listingFilters{}, false
+	}
+
+	return listingFilters{
+		where:     where,
+		tags:      tags,
+		category:  category,
+		timeRange: timeRange,
+	}, true
+}
+
+// parseTagFilter returns the filter of the tags of the listed notes from the query parameters:
+//   - tags is a list of tags separated by commas, the ones prefixed with '-' are excluded
+//   - tagMode is all to list the notes with all the tags, the default, or any to list the notes with any of them
+//   - untagged lists only the notes without tags, it can't be combined with the tags
+func parseTagFilter(query url.Values) (database.TagFilter, error) {
+	filter := database.TagFilter{}
+
+	for _, tag := range strings.Split(query.Get(tagsQueryParam), ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+
+		if !strings.HasPrefix(tag, excludedTagPrefix) {
+			filter.Tags = append(filter.Tags, tag)
+			continue
+		}
+
+		tag = strings.TrimPrefix(tag, excludedTagPrefix)
+		if tag == "" {
+			return database.TagFilter{}, fmt.Errorf("excluded tag '%s' must not be empty", excludedTagPrefix)
+		}
+
+		filter.Excluded = append(filter.Excluded, tag)
+	}
+
+	for _, tag := range filter.Tags {
+		for _, excluded := range filter.Excluded {
+			if tag == excluded {
+				return database.TagFilter{}, fmt.Errorf("tag '%s' can't be both included and excluded", tag)
+			}
+		}
+	}
+
+	switch mode := query.Get(tagModeQueryParam); mode {
+	case "", tagModeAll:
+	case tagModeAny:
+		filter.Any = true
+	default:
+		return database.TagFilter{}, fmt.Errorf("tag mode '%s' must be '%s' or '%s'", mode, tagModeAll, tagModeAny)
+	}
+
+	if untagged := query.Get(untaggedQueryParam); untagged != "" {
+		value, err := strconv.ParseBool(untagged)
+		if err != nil {
+			return database.TagFilter{}, fmt.Errorf("untagged '%s' must be 'true' or 'false'", untagged)
+		}
+
+		filter.Untagged = value
+	}
+
+	if filter.Untagged && len(filter.Tags)+len(filter.Excluded) > 0 {
+		return database.TagFilter{}, fmt.Errorf("untagged can't be combined with the '%s' query parameter", tagsQueryParam)
+	}
+
+	return filter, nil
+}
+
+// parseTimeRange returns the time range of the listed notes from the query parameters:
+//   - date matches the day in the location of the client
+//   - from and to are the inclusive start and the exclusive end of the range,
+//     either a time in RFC 3339, a day in the location of the client or a day in UTC such as 2023-01-02
+//   - since starts the range at a time relative to now, such as 7d, 2w or 12h
+//   - timeField is the time of the notes the range applies to, updatedAt by default
+func parseTimeRange(query url.Values, location *time.Location, now time.Time) (database.TimeRange, error) {
+	timeRange := database.TimeRange{Field: database.UpdatedAtField}
+
+	timeField := query.Get(timeFieldQueryParam)
+	if timeField != "" {
+		field, ok := timeFields[timeField]
+		if !ok {
+			return database.TimeRange{}, fmt.Errorf("timeField '%s' must be one of 'createdAt' or 'updatedAt'", timeField)
+		}
+
+		timeRange.Field = field
+	}
+
+	date := query.Get(dateQueryParam)
+	from := query.Get(fromQueryParam)
+	to := query.Get(toQueryParam)
+	since := query.Get(sinceQueryParam)
+
+	if date != "" && (from != "" || to != "" || since != "") {
+		return database.TimeRange{}, fmt.Errorf("date can't be combined with from, to or since")
+	}
+
+	if from != "" && since != "" {
+		return database.TimeRange{}, fmt.Errorf("from can't be combined with since")
+	}
+
+	var err error
+
+	if date != "" {
+		timeRange.From, err = time.ParseInLocation(constants.DateFormat, date, location)
+		if err != nil {
+			return database.TimeRange{}, fmt.Errorf("date '%s' must be in the format '%s'", date, constants.DateFormat)
+		}
+
+		timeRange.To = timeRange.From.AddDate(0, 0, 1)
+
+		return timeRange, nil
+	}
+
+	if from != "" {
+		timeRange.From, err = parseTime(from, location)
+		if err != nil {
+			return database.TimeRange{}, fmt.Errorf("from '%s' %w", from, err)
+		}
+	}
+
+	if since != "" {
+		duration, err := parseRelativeTime(since)
+		if err != nil {
+			return database.TimeRange{}, fmt.Errorf("since '%s' %w", since, err)
+		}
+
+		timeRange.From = now.Add(-duration)
+	}
+
+	if to != "" {
+		timeRange.To, err = parseTime(to, location)
+		if err != nil {
+			return database.TimeRange{}, fmt.Errorf("to '%s' %w", to, err)
+		}
+	}
+
+	if !timeRange.From.IsZero() && !timeRange.To.IsZero() && !timeRange.From.Before(timeRange.To) {
+		return database.TimeRange{}, fmt.Errorf("the start of the time range must be before its end")
+	}
+
+	return timeRange, nil
+}
+
+// parseTime parses a time in RFC 3339, the start of a day in the format of constants.DateFormat in the location,
+// or the start of a day in the format of isoDateFormat in UTC
+func parseTime(value string, location *time.Location) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return t.UTC(), nil
+	}
+
+	t, err = time.Parse(isoDateFormat, value)
+	if err == nil {
+		return t, nil
+	}
+
+	t, err = time.ParseInLocation(constants.DateFormat, value, location)
+	if err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("must be a time in RFC 3339 or a day in the format '%s' or '%s'", constants.DateFormat, isoDateFormat)
+}
+
+// parseRelativeTime parses a positive duration, either a number of days or weeks such as 7d or 2w,
+// or a duration accepted by time.ParseDuration such as 12h
+func parseRelativeTime(value string) (time.Duration, error) {
+	invalid := fmt.Errorf("must be a positive duration such as 7d, 2w or 12h")
+
+	for suffix, unit := range relativeTimeUnits {
+		if !strings.HasSuffix(value, suffix) {
+			continue
+		}
+
+		count, err := strconv.ParseInt(strings.TrimSuffix(value, suffix), 10, 64)
+		if err != nil || count <= 0 || count > int64(math.MaxInt64/unit) {
+			return 0, invalid
+		}
+
+		return time.Duration(count) * unit, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return 0, invalid
+	}
+
+	return duration, nil
+}
+
+// parsePage returns the page of the listed notes from the query parameters, the limit is at most
+// the maximum page size which is also the default. When the limit is invalid the response is already written.
+func (s server) parsePage(c *gin.Context) (database.Page, bool) {
+	page := database.Page{
+		Limit:  s.maxPageSize,
+		Cursor: c.Query(cursorQueryParam),
+	}
+
+	limit := c.Query(limitQueryParam)
+	if limit == "" {
+		return page, true
+	}
+
+	value, err := strconv.ParseInt(limit, 10, 64)
+	if err != nil || value < 1 || value > s.maxPageSize {
+		s.logger.Info(fmt.Sprintf("Invalid limit '%s'", limit))
+
+		c.JSON(http.StatusBadRequest,
+			gin.H{
+				"error": fmt.Sprintf("limit '%s' must be an integer between 1 and %d", limit, s.maxPageSize),
+			},
+		)
+
+		return database.Page{}, false
+	}
+
+	page.Limit = value
+
+	return page, true
+}
+
+// parseWhere returns the structured filter of the listed notes from the query parameter, nil when it's missing.
+// The days it compares the times to start in the location of the client.
+func parseWhere(value string, location *time.Location) (filter.Expr, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	return filter.Parse(value, location)
+}
+
+// parseSort returns the sort of the listed notes from the query parameter, a list of fields separated by commas
+// in order of precedence, each one prefixed with '-' to sort it in descending order. For example -date,title.
+// The notes found by a search can also be sorted by relevance, -relevance lists the best matches first.
+func parseSort(value string, search bool) ([]database.SortField, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	sortBy := []database.SortField{}
+	sorted := map[string]bool{}
+
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+
+		descending := strings.HasPrefix(name, "-")
+		name = strings.TrimPrefix(name, "-")
+
+		field, ok := sortableFields[name]
+
+		if name == relevanceSortField {
+			if !search {
+				return nil, fmt.Errorf("sort by '%s' requires a search with the '%s' query parameter", relevanceSortField, searchQueryParam)
+			}
+
+			field, ok = database.RelevanceField, true
+		}
+
+		if !ok {
+			return nil, fmt.Errorf("sort field '%s' must be one of 'title', 'category', 'date', 'createdAt', 'updatedAt' or 'relevance'", name)
+		}
+
+		if sorted[field] {
+			return nil, fmt.Errorf("sort field '%s' is sorted more than once", name)
+		}
+
+		sorted[field] = true
+		sortBy = append(sortBy, database.SortField{Field: field, Descending: descending})
+	}
+
+	return sortBy, nil
+}
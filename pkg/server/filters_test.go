@@ -0,0 +1,203 @@
+package server
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/notes-project/api/pkg/database"
+	"github.com/notes-project/api/pkg/filter"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Filters", func() {
+
+	var (
+		now    = time.Date(2023, time.January, 10, 12, 0, 0, 0, time.UTC)
+		berlin *time.Location
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		berlin, err = time.LoadLocation("Europe/Berlin")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("parseTimeRange", func() {
+		It("should return an open range on the update time when no time is provided", func() {
+			timeRange, err := parseTimeRange(url.Values{}, time.UTC, now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(timeRange).To(Equal(database.TimeRange{Field: database.UpdatedAtField}))
+		})
+
+		It("should return the day of the date in the location", func() {
+			timeRange, err := parseTimeRange(url.Values{"date": {"02-Jan-2023"}}, berlin, now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(timeRange.From).To(BeTemporally("==", time.Date(2023, time.January, 1, 23, 0, 0, 0, time.UTC)))
+			Expect(timeRange.To).To(BeTemporally("==", time.Date(2023, time.January, 2, 23, 0, 0, 0, time.UTC)))
+		})
+
+		It("should return the range between from and to", func() {
+			timeRange, err := parseTimeRange(url.Values{
+				"from":      {"2023-01-02T10:00:00+02:00"},
+				"to":        {"05-Jan-2023"},
+				"timeField": {"createdAt"},
+			}, time.UTC, now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(timeRange).To(Equal(database.TimeRange{
+				Field: database.CreatedAtField,
+				From:  time.Date(2023, time.January, 2, 8, 0, 0, 0, time.UTC),
+				To:    time.Date(2023, time.January, 5, 0, 0, 0, 0, time.UTC),
+			}))
+		})
+
+		It("should return the range between the days in UTC without a time", func() {
+			timeRange, err := parseTimeRange(url.Values{
+				"from": {"2023-01-02"},
+				"to":   {"2023-01-05"},
+			}, berlin, now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(timeRange.From).To(Equal(time.Date(2023, time.January, 2, 0, 0, 0, 0, time.UTC)))
+			Expect(timeRange.To).To(Equal(time.Date(2023, time.January, 5, 0, 0, 0, 0, time.UTC)))
+		})
+
+		It("should return the range starting at the relative time", func() {
+			for since, from := range map[string]time.Time{
+				"7d":  time.Date(2023, time.January, 3, 12, 0, 0, 0, time.UTC),
+				"1w":  time.Date(2023, time.January, 3, 12, 0, 0, 0, time.UTC),
+				"12h": time.Date(2023, time.January, 10, 0, 0, 0, 0, time.UTC),
+			} {
+				timeRange, err := parseTimeRange(url.Values{"since": {since}}, time.UTC, now)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(timeRange.From).To(Equal(from))
+				Expect(timeRange.To).To(BeZero())
+			}
+		})
+
+		It("should return an error when a time is invalid", func() {
+			for _, query := range []url.Values{
+				{"date": {"2023-01-02"}},
+				{"from": {"yesterday"}},
+				{"to": {"32-Jan-2023"}},
+				{"to": {"2023-02-30"}},
+				{"since": {"0d"}},
+				{"since": {"-7d"}},
+				{"since": {"7y"}},
+				{"since": {"99999999999999w"}},
+				{"timeField": {"deletedAt"}},
+			} {
+				_, err := parseTimeRange(query, time.UTC, now)
+				Expect(err).To(HaveOccurred(), "%v", query)
+			}
+		})
+
+		It("should return an error when the times can't be combined", func() {
+			for _, query := range []url.Values{
+				{"date": {"02-Jan-2023"}, "from": {"01-Jan-2023"}},
+				{"date": {"02-Jan-2023"}, "since": {"7d"}},
+				{"from": {"01-Jan-2023"}, "since": {"7d"}},
+				{"from": {"02-Jan-2023"}, "to": {"02-Jan-2023"}},
+				{"from": {"03-Jan-2023"}, "to": {"02-Jan-2023"}},
+			} {
+				_, err := parseTimeRange(query, time.UTC, now)
+				Expect(err).To(HaveOccurred(), "%v", query)
+			}
+		})
+	})
+
+	Describe("parseTagFilter", func() {
+		It("should return an empty filter when no tags are provided", func() {
+			filter, err := parseTagFilter(url.Values{"tags": {""}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(filter).To(Equal(database.TagFilter{}))
+		})
+
+		It("should return the included and the excluded tags", func() {
+			filter, err := parseTagFilter(url.Values{"tags": {"work, -archived,home"}, "tagMode": {"any"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(filter).To(Equal(database.TagFilter{
+				Tags:     []string{"work", "home"},
+				Any:      true,
+				Excluded: []string{"archived"},
+			}))
+		})
+
+		It("should return the notes without tags when untagged", func() {
+			filter, err := parseTagFilter(url.Values{"untagged": {"true"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(filter).To(Equal(database.TagFilter{Untagged: true}))
+		})
+
+		It("should return an error when the filter is invalid", func() {
+			for _, query := range []url.Values{
+				{"tags": {"a,-"}},
+				{"tags": {"a,-a"}},
+				{"tagMode": {"none"}},
+				{"untagged": {"yes"}},
+				{"untagged": {"true"}, "tags": {"-a"}},
+			} {
+				_, err := parseTagFilter(query)
+				Expect(err).To(HaveOccurred(), query.Encode())
+			}
+		})
+	})
+
+	Describe("parseSort", func() {
+		It("should return no sort when the parameter is empty", func() {
+			sortBy, err := parseSort("", false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sortBy).To(BeNil())
+		})
+
+		It("should return the fields in order with their direction", func() {
+			sortBy, err := parseSort("-date, title,category", false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sortBy).To(Equal([]database.SortField{
+				{Field: database.UpdatedAtField, Descending: true},
+				{Field: database.TitleField},
+				{Field: database.CategoryField},
+			}))
+		})
+
+		It("should return an error when a field is not allowed", func() {
+			for _, value := range []string{"description", "title,", "-", "relevance", "date,-updatedAt"} {
+				_, err := parseSort(value, false)
+				Expect(err).To(HaveOccurred(), value)
+			}
+		})
+
+		It("should allow sorting by relevance in a search", func() {
+			sortBy, err := parseSort("-relevance,title", true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sortBy).To(Equal([]database.SortField{
+				{Field: database.RelevanceField, Descending: true},
+				{Field: database.TitleField},
+			}))
+
+			_, err = parseSort("relevance,-relevance", true)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("parseWhere", func() {
+		It("should return no filter when the parameter is empty", func() {
+			where, err := parseWhere("  ", time.UTC)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(where).To(BeNil())
+		})
+
+		It("should parse the days in the location of the client", func() {
+			location := time.FixedZone("UTC+1", 60*60)
+
+			where, err := parseWhere("updatedAt >= 10-Jan-2023", location)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(where).To(Equal(filter.Comparison{
+				Field:    filter.UpdatedAtField,
+				Operator: filter.Ge,
+				Time:     time.Date(2023, time.January, 10, 0, 0, 0, 0, location),
+			}))
+		})
+	})
+
+})
@@ -0,0 +1,424 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/notes-project/api/pkg/constants"
+	"github.com/notes-project/api/pkg/database"
+	"github.com/notes-project/api/pkg/model"
+	"github.com/notes-project/api/pkg/transfer"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// query parameters of the import, the format is the same as the one of the export
+	conflictQueryParam  = "conflict"
+	keepDatesQueryParam = "keepDates"
+	dryRunQueryParam    = "dryRun"
+
+	// what is done with an imported note whose title is already taken
+	conflictSkip      = "skip"
+	conflictOverwrite = "overwrite"
+	conflictRename    = "rename"
+
+	// limits the size of the imported file, a zip is held in memory while it's imported
+	maxImportSize = 32 << 20
+	// limits the numbered titles tried for a renamed note, such as "title (2)"
+	maxRenameAttempts = 100
+
+	// status of the imported notes that replaced an existing note, the other statuses are the ones of the batches
+	importStatusUpdated = "updated"
+)
+
+// importOptions are the options of an import from the query parameters
+type importOptions struct {
+	format   string
+	conflict string
+	// the notes keep the times of the file instead of being stamped with the time of the import
+	keepDates bool
+	// nothing is written, the summary reports what the import would do
+	dryRun bool
+}
+
+// importResult is the result of importing the note at the line of the file, or at the file of a zip
+type importResult struct {
+	Line  int    `json:"line,omitempty"`
+	File  string `json:"file,omitempty"`
+	Title string `json:"title,omitempty"`
+	// the title the note was added with when its title was already taken
+	RenamedTo string `json:"renamedTo,omitempty"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+// importSummary counts the notes of the import by status along with the result of every note in the order of the file
+type importSummary struct {
+	DryRun  bool           `json:"dryRun"`
+	Created int            `json:"created"`
+	Updated int            `json:"updated"`
+	Skipped int            `json:"skipped"`
+	Failed  int            `json:"failed"`
+	Results []importResult `json:"results"`
+}
+
+func (i *importSummary) add(result importResult) {
+	switch result.Status {
+	case batchStatusCreated:
+		i.Created++
+	case importStatusUpdated:
+		i.Updated++
+	case batchStatusSkipped:
+		i.Skipped++
+	default:
+		i.Failed++
+	}
+
+	i.Results = append(i.Results, result)
+}
+
+// noteImport imports the notes of a file one by one
+type noteImport struct {
+	db      database.Database
+	options importOptions
+	// the keys of the titles of the notes already imported from the file, which are not in the database during a dry run
+	titles map[string]bool
+}
+
+// importNotes adds the notes of an NDJSON, CSV or Markdown zip file in the formats of the export. The notes are
+// imported independently of each other in the order of the file, so the invalid notes don't prevent the other ones
+// from being imported, and the summary reports the result of every note along with its line in the file.
+func (s server) importNotes(c *gin.Context) {
+	options, err := parseImportOptions(c.Request.URL.Query())
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("Invalid import, err: %s", err))
+
+		c.JSON(http.StatusBadRequest,
+			gin.H{
+				"error": err.Error(),
+			},
+		)
+
+		return
+	}
+
+	summary := importSummary{DryRun: options.dryRun, Results: []importResult{}}
+
+	reader, err := transfer.NewReader(options.format, http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize))
+	if err != nil {
+		s.handleImportError(c, summary, err)
+		return
+	}
+
+	imported := &noteImport{db: s.db, options: options, titles: map[string]bool{}}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var recordErr *transfer.RecordError
+		if errors.As(err, &recordErr) {
+			summary.add(importResult{Line: recordErr.Line, File: recordErr.File, Status: batchStatusFailed, Error: recordErr.Err.Error()})
+			continue
+		}
+
+		if err != nil {
+			s.handleImportError(c, summary, err)
+			return
+		}
+
+		result, err := imported.importNote(record)
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("Failed to import note '%s' to the database, err: %s", record.Note.Title, err))
+		}
+
+		summary.add(result)
+	}
+
+	s.logger.Info(fmt.Sprintf("Imported %d notes from %s with %d created, %d updated, %d skipped and %d failed, dry run: %t",
+		len(summary.Results), options.format, summary.Created, summary.Updated, summary.Skipped, summary.Failed, options.dryRun))
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// handleImportError responds with the error of a file that can't be read any further,
+// along with the summary of the notes imported before
+func (s server) handleImportError(c *gin.Context, summary importSummary, err error) {
+	s.logger.Info(fmt.Sprintf("Failed to read the imported notes, err: %s", err))
+
+	status := http.StatusBadRequest
+	message := "failed to read the imported file"
+
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		status = http.StatusRequestEntityTooLarge
+		message = fmt.Sprintf("file must not be larger than %d bytes", maxImportSize)
+	case errors.Is(err, transfer.ErrInvalidFile):
+		message = err.Error()
+	}
+
+	c.JSON(status,
+		struct {
+			Error string `json:"error"`
+			importSummary
+		}{
+			Error:         message,
+			importSummary: summary,
+		},
+	)
+}
+
+// parseImportOptions returns the options of the import from the query parameters, the notes are read
+// from NDJSON and the notes whose title is taken are skipped by default
+func parseImportOptions(query url.Values) (importOptions, error) {
+	options := importOptions{
+		format:   transfer.FormatNDJSON,
+		conflict: conflictSkip,
+	}
+
+	if format := query.Get(formatQueryParam); format != "" {
+		if !containsField(transfer.Formats, format) {
+			return importOptions{}, fmt.Errorf("format '%s' must be one of %s", format, strings.Join(transfer.Formats, ", "))
+		}
+
+		options.format = format
+	}
+
+	if conflict := query.Get(conflictQueryParam); conflict != "" {
+		if conflict != conflictSkip && conflict != conflictOverwrite && conflict != conflictRename {
+			return importOptions{}, fmt.Errorf("conflict '%s' must be one of '%s', '%s' or '%s'", conflict, conflictSkip, conflictOverwrite, conflictRename)
+		}
+
+		options.conflict = conflict
+	}
+
+	var err error
+
+	options.keepDates, err = parseBoolParam(query, keepDatesQueryParam)
+	if err != nil {
+		return importOptions{}, err
+	}
+
+	options.dryRun, err = parseBoolParam(query, dryRunQueryParam)
+	if err != nil {
+		return importOptions{}, err
+	}
+
+	return options, nil
+}
+
+// parseBoolParam returns the boolean query parameter, false when it's missing
+func parseBoolParam(query url.Values, name string) (bool, error) {
+	value := query.Get(name)
+	if value == "" {
+		return false, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s '%s' must be 'true' or 'false'", name, value)
+	}
+
+	return parsed, nil
+}
+
+// importedNote returns the note added for the note of the file. It's initialized like a new note, except for its
+// times that are kept when keepDates is set: a note with only one of its times has both set to it, and a note
+// without its update time, such as a note exported before the times were added, is updated on its legacy date.
+func importedNote(source model.Note, keepDates bool) model.Note {
+	note := initNote(model.Note{
+		Title:       source.Title,
+		Description: source.Description,
+		Category:    source.Category,
+		Tags:        source.Tags,
+	})
+
+	if !keepDates {
+		return note
+	}
+
+	created, updated := source.CreatedAt, source.UpdatedAt
+	if updated.IsZero() {
+		updated = legacyDate(source.Date)
+	}
+
+	if created.IsZero() {
+		created = updated
+	}
+
+	if updated.IsZero() {
+		updated = created
+	}
+
+	if created.IsZero() {
+		return note
+	}
+
+	// truncated to milliseconds like the times stamped by the API
+	note.CreatedAt = created.UTC().Truncate(time.Millisecond)
+	note.UpdatedAt = updated.UTC().Truncate(time.Millisecond)
+
+	return note
+}
+
+// legacyDate returns the legacy date of a note at midnight UTC, the zero time when it's missing or invalid
+func legacyDate(date string) time.Time {
+	parsed, err := time.Parse(constants.DateFormat, date)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return parsed
+}
+
+// importNote imports the note of the record according to the options, the returned error is the one of the
+// database, which is left out of the result
+func (i *noteImport) importNote(record transfer.Record) (importResult, error) {
+	result := importResult{Line: record.Line, File: record.File, Title: record.Note.Title}
+
+	note := importedNote(record.Note, i.options.keepDates)
+
+	err := binding.Validator.ValidateStruct(&note)
+	if err != nil {
+		result.Status = batchStatusFailed
+		result.Error = err.Error()
+
+		return result, nil
+	}
+
+	exists, err := i.exists(note.Title)
+	if err != nil {
+		return failedImport(result, "failed to import the note"), err
+	}
+
+	if !exists {
+		return i.create(result, note)
+	}
+
+	switch i.options.conflict {
+	case conflictOverwrite:
+		return i.overwrite(result, note)
+	case conflictRename:
+		title, found, err := i.freeTitle(note.Title)
+		if err != nil {
+			return failedImport(result, "failed to import the note"), err
+		}
+
+		if !found {
+			return failedImport(result, fmt.Sprintf("no title from '%s (2)' to '%s (%d)' is free", note.Title, note.Title, maxRenameAttempts+1)), nil
+		}
+
+		note.Title = title
+		result.RenamedTo = title
+
+		return i.create(result, note)
+	}
+
+	result.Status = batchStatusSkipped
+
+	return result, nil
+}
+
+// create adds the note, unless it's a dry run
+func (i *noteImport) create(result importResult, note model.Note) (importResult, error) {
+	if !i.options.dryRun {
+		err := i.db.AddNote(note)
+		if err != nil {
+			// the title was taken by another request since it was checked
+			if mongo.IsDuplicateKeyError(err) {
+				return failedImport(result, fmt.Sprintf("note with key 'title' and value '%s' already exists", note.Title)), nil
+			}
+
+			return failedImport(result, "failed to add the note"), err
+		}
+	}
+
+	i.titles[database.TitleKey(note.Title)] = true
+	result.Status = batchStatusCreated
+
+	return result, nil
+}
+
+// overwrite updates the note that has the title with the fields of the imported note, like an update its
+// revision is saved and it keeps its id and creation time, and it keeps the update time of the file with keepDates
+func (i *noteImport) overwrite(result importResult, note model.Note) (importResult, error) {
+	if !i.options.dryRun {
+		// same as a single update, the fields set by the API and the database can't be changed
+		note.ID = ""
+		note.Version = 0
+		note.CreatedAt = time.Time{}
+		if !i.options.keepDates {
+			note.UpdatedAt = time.Time{}
+		}
+
+		err := i.db.UpdateNote(note.Title, note, database.AnyVersion)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return failedImport(result, fmt.Sprintf("note '%s' was deleted during the import", note.Title)), nil
+			}
+
+			return failedImport(result, "failed to update the note"), err
+		}
+	}
+
+	i.titles[database.TitleKey(note.Title)] = true
+	result.Status = importStatusUpdated
+
+	return result, nil
+}
+
+// exists returns whether a note has the title, either in the database or among the notes imported before
+func (i *noteImport) exists(title string) (bool, error) {
+	if i.titles[database.TitleKey(title)] {
+		return true, nil
+	}
+
+	// only the id is read
+	_, err := i.db.GetProjectedNote(title, database.Projection{})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// freeTitle returns the first title numbered from 2 such as "title (2)" that no note has
+func (i *noteImport) freeTitle(title string) (string, bool, error) {
+	for n := 2; n <= maxRenameAttempts+1; n++ {
+		candidate := fmt.Sprintf("%s (%d)", title, n)
+
+		exists, err := i.exists(candidate)
+		if err != nil {
+			return "", false, err
+		}
+
+		if !exists {
+			return candidate, true, nil
+		}
+	}
+
+	return "", false, nil
+}
+
+// failedImport returns the result of a note that failed to be imported with the message of the error
+func failedImport(result importResult, message string) importResult {
+	result.Status = batchStatusFailed
+	result.Error = message
+
+	return result
+}
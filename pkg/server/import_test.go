@@ -0,0 +1,130 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/notes-project/api/pkg/model"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Import", func() {
+
+	Describe("parseImportOptions", func() {
+		It("should skip the taken titles of NDJSON notes by default", func() {
+			options, err := parseImportOptions(url.Values{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(options).To(Equal(importOptions{format: "ndjson", conflict: conflictSkip}))
+		})
+
+		It("should return the requested options", func() {
+			options, err := parseImportOptions(url.Values{
+				"format":    {"markdown"},
+				"conflict":  {"rename"},
+				"keepDates": {"true"},
+				"dryRun":    {"1"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(options).To(Equal(importOptions{format: "markdown", conflict: conflictRename, keepDates: true, dryRun: true}))
+		})
+
+		It("should return an error when an option is invalid", func() {
+			for _, query := range []url.Values{
+				{"format": {"xml"}},
+				{"conflict": {"replace"}},
+				{"keepDates": {"yes"}},
+				{"dryRun": {"maybe"}},
+			} {
+				_, err := parseImportOptions(query)
+				Expect(err).To(HaveOccurred(), query.Encode())
+			}
+		})
+	})
+
+	Describe("importedNote", func() {
+		created := time.Date(2020, time.January, 1, 10, 0, 0, 123456789, time.UTC)
+		updated := created.AddDate(1, 0, 0)
+
+		source := model.Note{ID: "id", Title: "test", Description: "imported", Tags: []string{"a"}, Version: 7, CreatedAt: created, UpdatedAt: updated}
+
+		It("should initialize the note like a new note", func() {
+			note := importedNote(source, false)
+			Expect(note.ID).NotTo(Equal("id"))
+			Expect(note.Version).To(Equal(int64(1)))
+			Expect(note.Title).To(Equal("test"))
+			Expect(note.Tags).To(Equal([]string{"a"}))
+			Expect(note.CreatedAt).To(BeTemporally(">", updated))
+			Expect(note.UpdatedAt).To(Equal(note.CreatedAt))
+		})
+
+		It("should keep the times of the source truncated to milliseconds", func() {
+			note := importedNote(source, true)
+			Expect(note.CreatedAt).To(Equal(created.Truncate(time.Millisecond)))
+			Expect(note.UpdatedAt).To(Equal(updated.Truncate(time.Millisecond)))
+		})
+
+		It("should set both times to the only time of the source", func() {
+			note := importedNote(model.Note{Title: "test", UpdatedAt: updated}, true)
+			Expect(note.CreatedAt).To(Equal(updated.Truncate(time.Millisecond)))
+			Expect(note.UpdatedAt).To(Equal(note.CreatedAt))
+		})
+
+		It("should set both times to the legacy date of the source without its times", func() {
+			note := importedNote(model.Note{Title: "test", Date: "02-Mar-2021"}, true)
+			Expect(note.UpdatedAt).To(Equal(time.Date(2021, time.March, 2, 0, 0, 0, 0, time.UTC)))
+			Expect(note.CreatedAt).To(Equal(note.UpdatedAt))
+		})
+
+		It("should keep the creation time of the source along with its legacy date", func() {
+			note := importedNote(model.Note{Title: "test", Date: "02-Mar-2021", CreatedAt: created}, true)
+			Expect(note.CreatedAt).To(Equal(created.Truncate(time.Millisecond)))
+			Expect(note.UpdatedAt).To(Equal(time.Date(2021, time.March, 2, 0, 0, 0, 0, time.UTC)))
+		})
+	})
+
+	Describe("importNotes", func() {
+		// getTestNote returns the note with the title through the API
+		getTestNote := func(router *gin.Engine, title string) model.Note {
+			response := serve(router, http.MethodGet, "/api/v1/notes/"+title, "")
+			Expect(response.Code).To(Equal(http.StatusOK), response.Body.String())
+
+			note := struct {
+				Note model.Note `json:"note"`
+			}{}
+			Expect(json.Unmarshal(response.Body.Bytes(), &note)).To(Succeed())
+
+			return note.Note
+		}
+
+		It("should keep the creation time of the overwritten note and the update time of the file with keepDates", func() {
+			router := newTestRouter()
+			addTestNote(router, "overwritten")
+			before := getTestNote(router, "overwritten")
+
+			response := serve(router, http.MethodPost, "/api/v1/notes/import?conflict=overwrite&keepDates=true",
+				`{"title": "overwritten", "description": "imported", "createdAt": "2020-01-01T10:00:00Z", "updatedAt": "2021-01-01T10:00:00Z"}`)
+			Expect(response.Code).To(Equal(http.StatusOK), response.Body.String())
+			Expect(response.Body.String()).To(ContainSubstring(`"updated":1`))
+
+			after := getTestNote(router, "overwritten")
+			Expect(after.Description).To(Equal("imported"))
+			Expect(after.CreatedAt).To(Equal(before.CreatedAt))
+			Expect(after.UpdatedAt).To(Equal(time.Date(2021, time.January, 1, 10, 0, 0, 0, time.UTC)))
+		})
+
+		It("should find the titles imported before in another case during a dry run", func() {
+			router := newTestRouter()
+
+			response := serve(router, http.MethodPost, "/api/v1/notes/import?dryRun=true",
+				"{\"title\": \"Standup\", \"description\": \"test\"}\n{\"title\": \"standup\", \"description\": \"test\"}\n")
+			Expect(response.Code).To(Equal(http.StatusOK), response.Body.String())
+			Expect(response.Body.String()).To(ContainSubstring(`"created":1`))
+			Expect(response.Body.String()).To(ContainSubstring(`"skipped":1`))
+		})
+	})
+
+})
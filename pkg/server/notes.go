@@ -1,16 +1,19 @@
 package server
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
-	"github.com/notes-project/api/pkg/constants"
+	"github.com/notes-project/api/pkg/database"
 	"github.com/notes-project/api/pkg/model"
+	"github.com/notes-project/api/pkg/search"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -23,7 +26,7 @@ func (s server) addNote(c *gin.Context) {
 		return
 	}
 
-	note.Date = time.Now().Format(constants.DateFormat)
+	note = initNote(note)
 
 	err = s.db.AddNote(note)
 	if err != nil {
@@ -49,93 +52,286 @@ func (s server) addNote(c *gin.Context) {
 
 		return
 	}
+
+	note.SetDate(requestLocation(c))
+
+	c.JSON(http.StatusOK,
+		gin.H{
+			"note": note,
+		})
+}
+
+// initNote sets the fields of a new note that are populated by the API, so the added note can be returned as is
+func initNote(note model.Note) model.Note {
+	note.ID = model.NewNoteID()
+	note.CreatedAt = model.NewTimestamp()
+	note.UpdatedAt = note.CreatedAt
+	note.Version = 1
+	note.DeletedAt = nil
+
+	return note
 }
 
 func (s server) getNotes(c *gin.Context) {
-	var notes []model.Note
-	var err error
+	filters, ok := s.parseListingFilters(c)
+	if !ok {
+		return
+	}
 
-	tags := strings.Split(c.Query("tags"), ",")
-	category := c.Query("category")
-	date := c.Query("date")
+	query := c.Query(searchQueryParam)
 
-	notes, err = s.db.GetNotesFiltered(tags, category, date)
+	sortBy, err := parseSort(c.Query(sortQueryParam), query != "")
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("Invalid sort, err: %s", err))
 
+		c.JSON(http.StatusBadRequest,
+			gin.H{
+				"error": err.Error(),
+			},
+		)
+
+		return
+	}
+
+	page, ok := s.parsePage(c)
+	if !ok {
+		return
+	}
+
+	fields, ok := s.requestedFields(c)
+	if !ok {
+		return
+	}
+
+	if query != "" {
+		s.searchNotes(c, query, filters, sortBy, page, fields)
+		return
+	}
+
+	notes, next, err := s.db.GetNotesFiltered(filters.where, filters.tags, filters.category, filters.timeRange, sortBy, page, fields)
 	if err != nil {
-		s.logger.Error(fmt.Sprintf("Failed to get notes from database, err: %s", err))
+		s.handleListingError(c, page, err)
+		return
+	}
 
-		c.JSON(http.StatusInternalServerError,
+	renderDates(c, notes)
+
+	projected, err := projectAllFields(notes, fields)
+	if err != nil {
+		s.handleListingError(c, page, err)
+		return
+	}
+
+	response := gin.H{
+		"notes": projected,
+	}
+
+	if next != "" {
+		response["next"] = next
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// searchNotes lists the notes that match the search query along with their score and snippets,
+// the notes are searched in the index so the projection only applies to the response
+func (s server) searchNotes(c *gin.Context, query string, filters listingFilters, sortBy []database.SortField, page database.Page, fields database.Projection) {
+	hits, next, err := s.db.SearchNotes(query, filters.where, filters.tags, filters.category, filters.timeRange, sortBy, page)
+	if err != nil {
+		if errors.Is(err, search.ErrInvalidQuery) {
+			s.logger.Info(fmt.Sprintf("Invalid search query '%s', err: %s", query, err))
+
+			c.JSON(http.StatusBadRequest,
+				gin.H{
+					"error": fmt.Sprintf("search query '%s' is invalid, it must have words and every quoted phrase must be closed", query),
+				},
+			)
+
+			return
+		}
+
+		s.handleListingError(c, page, err)
+		return
+	}
+
+	location := requestLocation(c)
+	for i := range hits {
+		hits[i].SetDate(location)
+	}
+
+	projected, err := projectAllFields(hits, fields)
+	if err != nil {
+		s.handleListingError(c, page, err)
+		return
+	}
+
+	response := gin.H{
+		"notes": projected,
+	}
+
+	if next != "" {
+		response["next"] = next
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// handleListingError responds to a failure to list the notes
+func (s server) handleListingError(c *gin.Context, page database.Page, err error) {
+	if errors.Is(err, database.ErrInvalidCursor) {
+		s.logger.Info(fmt.Sprintf("Invalid cursor '%s'", page.Cursor))
+
+		c.JSON(http.StatusBadRequest,
 			gin.H{
-				"error": "failed to retrieve notes",
+				"error": fmt.Sprintf("cursor '%s' must be the next cursor returned with a previous page", page.Cursor),
 			},
 		)
 
 		return
 	}
 
-	c.JSON(http.StatusOK,
+	s.logger.Error(fmt.Sprintf("Failed to get notes from database, err: %s", err))
+
+	c.JSON(http.StatusInternalServerError,
 		gin.H{
-			"notes": notes,
-		})
+			"error": "failed to retrieve notes",
+		},
+	)
 }
 
 func (s server) getNoteByTitle(c *gin.Context) {
 	noteTtile := c.Param("title")
 
-	note, err := s.db.GetNote(noteTtile)
+	fields, ok := s.requestedFields(c)
+	if !ok {
+		return
+	}
+
+	note, err := s.db.GetProjectedNote(noteTtile, withVersion(fields))
+
+	s.writeNote(c, fmt.Sprintf("'%s'", noteTtile), note, err, fields)
+}
+
+func (s server) getNoteByID(c *gin.Context) {
+	noteID := c.Param("id")
+
+	fields, ok := s.requestedFields(c)
+	if !ok {
+		return
+	}
+
+	note, err := s.db.GetProjectedNoteByID(noteID, withVersion(fields))
+
+	s.writeNote(c, fmt.Sprintf("with id '%s'", noteID), note, err, fields)
+}
+
+// writeNote responds with the fields of the projection of the note retrieved from the database,
+// the note reference is either its quoted title or its id
+func (s server) writeNote(c *gin.Context, noteRef string, note model.Note, err error, fields database.Projection) {
 	if err != nil {
 
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			s.logger.Info(fmt.Sprintf("Note '%s' does not exist in database", noteTtile))
+			s.logger.Info(fmt.Sprintf("Note %s does not exist in database", noteRef))
 
 			c.JSON(http.StatusNotFound,
 				gin.H{
-					"error": fmt.Sprintf("note '%s' does not exist", noteTtile),
+					"error": fmt.Sprintf("note %s does not exist", noteRef),
 				},
 			)
 
 			return
 		}
 
-		s.logger.Error(fmt.Sprintf("Failed to get note '%s' from database, err: %s", noteTtile, err))
+		s.logger.Error(fmt.Sprintf("Failed to get note %s from database, err: %s", noteRef, err))
+
+		c.JSON(http.StatusInternalServerError,
+			gin.H{
+				"error": fmt.Sprintf("failed to retrieve note %s", noteRef),
+			},
+		)
+
+		return
+	}
+
+	note.SetDate(requestLocation(c))
+
+	projected, err := projectFields(note, fields)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to render note %s, err: %s", noteRef, err))
 
 		c.JSON(http.StatusInternalServerError,
 			gin.H{
-				"error": fmt.Sprintf("failed to retrieve note '%s'", noteTtile),
+				"error": fmt.Sprintf("failed to retrieve note %s", noteRef),
 			},
 		)
 
 		return
 	}
 
+	c.Header("ETag", noteETag(note.Version))
+
 	c.JSON(http.StatusOK,
 		gin.H{
-			"note": note,
+			"note": projected,
 		})
 }
 
 func (s server) deleteNoteByTitle(c *gin.Context) {
 	noteTtile := c.Param("title")
 
-	err := s.db.DeleteNote(noteTtile)
+	expectedVersion, ok := s.expectedVersion(c)
+	if !ok {
+		return
+	}
+
+	err := s.db.DeleteNote(noteTtile, expectedVersion)
+
+	s.writeDeleteResult(c, fmt.Sprintf("'%s'", noteTtile), err, func() (model.Note, error) {
+		return s.db.GetNote(noteTtile)
+	})
+}
+
+func (s server) deleteNoteByID(c *gin.Context) {
+	noteID := c.Param("id")
+
+	expectedVersion, ok := s.expectedVersion(c)
+	if !ok {
+		return
+	}
+
+	err := s.db.DeleteNoteByID(noteID, expectedVersion)
+
+	s.writeDeleteResult(c, fmt.Sprintf("with id '%s'", noteID), err, func() (model.Note, error) {
+		return s.db.GetNoteByID(noteID)
+	})
+}
+
+// writeDeleteResult responds with the result of the delete,
+// findNote gets the current version of the note when the expected version didn't match
+func (s server) writeDeleteResult(c *gin.Context, noteRef string, err error, findNote func() (model.Note, error)) {
 	if err != nil {
+		if errors.Is(err, database.ErrVersionConflict) {
+			s.writeVersionConflict(c, noteRef, findNote)
+			return
+		}
+
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			s.logger.Info(fmt.Sprintf("Note '%s' does not exist in database", noteTtile))
+			s.logger.Info(fmt.Sprintf("Note %s does not exist in database", noteRef))
 
 			c.JSON(http.StatusNoContent,
 				gin.H{
-					"info": fmt.Sprintf("note '%s' does not exist", noteTtile),
+					"info": fmt.Sprintf("note %s does not exist", noteRef),
 				},
 			)
 
 			return
 		}
 
-		s.logger.Error(fmt.Sprintf("Failed to delete note '%s' from database, err: %s", noteTtile, err))
+		s.logger.Error(fmt.Sprintf("Failed to delete note %s from database, err: %s", noteRef, err))
 
 		c.JSON(http.StatusInternalServerError,
 			gin.H{
-				"error": fmt.Sprintf("failed to retrieve note '%s'", noteTtile),
+				"error": fmt.Sprintf("failed to retrieve note %s", noteRef),
 			},
 		)
 
@@ -178,6 +374,37 @@ func (s server) deleteNotes(c *gin.Context) {
 func (s server) updateNoteByTitle(c *gin.Context) {
 	noteTtile := c.Param("title")
 
+	s.updateNote(c, fmt.Sprintf("'%s'", noteTtile),
+		func(note model.Note, expectedVersion int64) error {
+			return s.db.UpdateNote(noteTtile, note, expectedVersion)
+		},
+		func() (model.Note, error) {
+			return s.db.GetNote(noteTtile)
+		},
+	)
+}
+
+func (s server) updateNoteByID(c *gin.Context) {
+	noteID := c.Param("id")
+
+	s.updateNote(c, fmt.Sprintf("with id '%s'", noteID),
+		func(note model.Note, expectedVersion int64) error {
+			return s.db.UpdateNoteByID(noteID, note, expectedVersion)
+		},
+		func() (model.Note, error) {
+			return s.db.GetNoteByID(noteID)
+		},
+	)
+}
+
+// updateNote binds the note from the request and updates it,
+// findNote gets the current version of the note when the expected version didn't match
+func (s server) updateNote(c *gin.Context, noteRef string, update func(note model.Note, expectedVersion int64) error, findNote func() (model.Note, error)) {
+	expectedVersion, ok := s.expectedVersion(c)
+	if !ok {
+		return
+	}
+
 	note := model.Note{}
 
 	err := c.MustBindWith(&note, binding.JSON)
@@ -186,27 +413,49 @@ func (s server) updateNoteByTitle(c *gin.Context) {
 		return
 	}
 
-	note.Date = time.Now().Format(constants.DateFormat)
+	// the id is generated by the API, the version and the timestamps are set by the database, none can be changed
+	note.ID = ""
+	note.Version = 0
+	note.CreatedAt = time.Time{}
+	note.UpdatedAt = time.Time{}
+	note.Date = ""
 
-	err = s.db.UpdateNote(noteTtile, note)
+	err = update(note, expectedVersion)
 	if err != nil {
+		if errors.Is(err, database.ErrVersionConflict) {
+			s.writeVersionConflict(c, noteRef, findNote)
+			return
+		}
+
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			s.logger.Info(fmt.Sprintf("Note '%s' does not exist in database", noteTtile))
+			s.logger.Info(fmt.Sprintf("Note %s does not exist in database", noteRef))
 
 			c.JSON(http.StatusNotFound,
 				gin.H{
-					"error": fmt.Sprintf("note '%s' does not exist", noteTtile),
+					"error": fmt.Sprintf("note %s does not exist", noteRef),
 				},
 			)
 
 			return
 		}
 
-		s.logger.Error(fmt.Sprintf("Failed to update note '%s' from database, err: %s", noteTtile, err))
+		if mongo.IsDuplicateKeyError(err) {
+			s.logger.Info(fmt.Sprintf("Note '%s' already exists in database", note.Title))
+
+			c.JSON(http.StatusBadRequest,
+				gin.H{
+					"error": fmt.Sprintf("note with key 'title' and value '%s' already exists", note.Title),
+				},
+			)
+
+			return
+		}
+
+		s.logger.Error(fmt.Sprintf("Failed to update note %s from database, err: %s", noteRef, err))
 
 		c.JSON(http.StatusInternalServerError,
 			gin.H{
-				"error": fmt.Sprintf("failed to update note '%s'", noteTtile),
+				"error": fmt.Sprintf("failed to update note %s", noteRef),
 			},
 		)
 
@@ -215,3 +464,146 @@ func (s server) updateNoteByTitle(c *gin.Context) {
 
 	c.Status(http.StatusOK)
 }
+
+// upsertNote replaces the note with the title from the path or adds it when there is none,
+// the title from the path is the title of the note whatever the title in the body
+func (s server) upsertNote(c *gin.Context) {
+	noteTitle := c.Param("title")
+
+	expectedVersion, ok := s.expectedVersion(c)
+	if !ok {
+		return
+	}
+
+	note := model.Note{}
+
+	err := json.NewDecoder(c.Request.Body).Decode(&note)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("Invalid note '%s', err: %s", noteTitle, err))
+
+		c.JSON(http.StatusBadRequest,
+			gin.H{
+				"error": err.Error(),
+			},
+		)
+
+		return
+	}
+
+	note.Title = noteTitle
+
+	err = binding.Validator.ValidateStruct(note)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("Invalid note '%s', err: %s", noteTitle, err))
+
+		c.JSON(http.StatusBadRequest,
+			gin.H{
+				"error": err.Error(),
+			},
+		)
+
+		return
+	}
+
+	created := false
+
+	if expectedVersion == database.AnyVersion {
+		created, err = s.db.UpsertNote(noteTitle, note)
+	} else {
+		// a note that doesn't exist has no version, so with an expected version the note is only replaced, never added
+		note.ID = ""
+		note.Version = 0
+		note.CreatedAt = time.Time{}
+		note.UpdatedAt = time.Time{}
+		note.Date = ""
+
+		err = s.db.UpdateNote(noteTitle, note, expectedVersion)
+		if errors.Is(err, database.ErrVersionConflict) || errors.Is(err, mongo.ErrNoDocuments) {
+			s.writeVersionConflict(c, fmt.Sprintf("'%s'", noteTitle), func() (model.Note, error) {
+				return s.db.GetNote(noteTitle)
+			})
+
+			return
+		}
+	}
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to upsert note '%s' in database, err: %s", noteTitle, err))
+
+		c.JSON(http.StatusInternalServerError,
+			gin.H{
+				"error": fmt.Sprintf("failed to upsert note '%s'", noteTitle),
+			},
+		)
+
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+
+	// the note can be changed again in the meantime, the response is the latest version
+	stored, err := s.db.GetNote(noteTitle)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to get the upserted note '%s' from database, err: %s", noteTitle, err))
+		c.Status(status)
+
+		return
+	}
+
+	stored.SetDate(requestLocation(c))
+
+	c.Header("ETag", noteETag(stored.Version))
+	c.JSON(status,
+		gin.H{
+			"note": stored,
+		})
+}
+
+// noteETag returns the entity tag of the version of a note
+func noteETag(version int64) string {
+	return fmt.Sprintf("\"%d\"", version)
+}
+
+// expectedVersion returns the version of the note from the If-Match header, or database.AnyVersion when
+// the header is missing or matches any version. When the header is invalid the response is already written.
+func (s server) expectedVersion(c *gin.Context) (int64, bool) {
+	ifMatch := strings.TrimSpace(c.GetHeader("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		return database.AnyVersion, true
+	}
+
+	version, err := strconv.ParseInt(strings.TrimPrefix(strings.TrimSuffix(ifMatch, "\""), "\""), 10, 64)
+	if err != nil || version < 0 || !strings.HasPrefix(ifMatch, "\"") || !strings.HasSuffix(ifMatch, "\"") {
+		s.logger.Info(fmt.Sprintf("Invalid If-Match header '%s'", ifMatch))
+
+		c.JSON(http.StatusBadRequest,
+			gin.H{
+				"error": fmt.Sprintf("If-Match header '%s' must be '*' or a single entity tag returned in the ETag header", ifMatch),
+			},
+		)
+
+		return 0, false
+	}
+
+	return version, true
+}
+
+// writeVersionConflict responds with the current version of the note that was changed since the client read it
+func (s server) writeVersionConflict(c *gin.Context, noteRef string, findNote func() (model.Note, error)) {
+	s.logger.Info(fmt.Sprintf("Note %s does not match the expected version", noteRef))
+
+	response := gin.H{
+		"error": fmt.Sprintf("note %s was modified, its version does not match the If-Match header", noteRef),
+	}
+
+	// the note can be changed again in the meantime, so the version is only informative
+	current, err := findNote()
+	if err == nil {
+		c.Header("ETag", noteETag(current.Version))
+		response["version"] = current.Version
+	}
+
+	c.JSON(http.StatusPreconditionFailed, response)
+}
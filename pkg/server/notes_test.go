@@ -0,0 +1,126 @@
+package server
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Notes", func() {
+
+	Describe("If-Match", func() {
+		It("should return the version of the note in the ETag header", func() {
+			router := newTestRouter()
+			addTestNote(router, "test")
+
+			response := serve(router, http.MethodGet, "/api/v1/notes/test", "")
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Header().Get("ETag")).To(Equal(`"1"`))
+		})
+
+		It("should update the note when it still has the expected version", func() {
+			router := newTestRouter()
+			addTestNote(router, "test")
+
+			response := serve(router, http.MethodPost, "/api/v1/notes/test", `{"title": "test", "description": "updated"}`, "If-Match", `"1"`)
+			Expect(response.Code).To(Equal(http.StatusOK))
+
+			response = serve(router, http.MethodGet, "/api/v1/notes/test", "")
+			Expect(response.Header().Get("ETag")).To(Equal(`"2"`))
+		})
+
+		It("should return 412 with the current version when the note was updated since", func() {
+			router := newTestRouter()
+			id := addTestNote(router, "test")
+
+			Expect(serve(router, http.MethodPost, "/api/v1/notes/test", `{"title": "test", "description": "first"}`).Code).To(Equal(http.StatusOK))
+
+			response := serve(router, http.MethodPost, "/api/v1/notes/test", `{"title": "test", "description": "stale"}`, "If-Match", `"1"`)
+			Expect(response.Code).To(Equal(http.StatusPreconditionFailed))
+			Expect(response.Header().Get("ETag")).To(Equal(`"2"`))
+			Expect(response.Body.String()).To(MatchJSON(`{"error": "note 'test' was modified, its version does not match the If-Match header", "version": 2}`))
+
+			response = serve(router, http.MethodPost, "/api/v1/notes/id/"+id, `{"title": "test", "description": "stale"}`, "If-Match", `"1"`)
+			Expect(response.Code).To(Equal(http.StatusPreconditionFailed))
+
+			response = serve(router, http.MethodDelete, "/api/v1/notes/test", "", "If-Match", `"1"`)
+			Expect(response.Code).To(Equal(http.StatusPreconditionFailed))
+
+			response = serve(router, http.MethodDelete, "/api/v1/notes/id/"+id, "", "If-Match", `"1"`)
+			Expect(response.Code).To(Equal(http.StatusPreconditionFailed))
+
+			response = serve(router, http.MethodGet, "/api/v1/notes/test", "")
+			Expect(response.Body.String()).To(ContainSubstring(`"description":"first"`))
+		})
+
+		It("should change any version without the header or with a wildcard", func() {
+			router := newTestRouter()
+			addTestNote(router, "test")
+
+			Expect(serve(router, http.MethodPost, "/api/v1/notes/test", `{"title": "test", "description": "first"}`).Code).To(Equal(http.StatusOK))
+			Expect(serve(router, http.MethodPost, "/api/v1/notes/test", `{"title": "test", "description": "second"}`, "If-Match", "*").Code).To(Equal(http.StatusOK))
+			Expect(serve(router, http.MethodDelete, "/api/v1/notes/test", "", "If-Match", "*").Code).To(Equal(http.StatusOK))
+		})
+
+		It("should replace the note with PUT only when it still has the expected version", func() {
+			router := newTestRouter()
+			addTestNote(router, "test")
+
+			response := serve(router, http.MethodPut, "/api/v1/notes/test", `{"description": "replaced"}`, "If-Match", `"1"`)
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Header().Get("ETag")).To(Equal(`"2"`))
+
+			response = serve(router, http.MethodPut, "/api/v1/notes/test", `{"description": "stale"}`, "If-Match", `"1"`)
+			Expect(response.Code).To(Equal(http.StatusPreconditionFailed))
+			Expect(response.Header().Get("ETag")).To(Equal(`"2"`))
+			Expect(response.Body.String()).To(ContainSubstring(`"version":2`))
+
+			response = serve(router, http.MethodGet, "/api/v1/notes/test", "")
+			Expect(response.Body.String()).To(ContainSubstring(`"description":"replaced"`))
+		})
+
+		It("should not add the note with PUT when it has an expected version", func() {
+			router := newTestRouter()
+
+			response := serve(router, http.MethodPut, "/api/v1/notes/missing", `{"description": "new"}`, "If-Match", `"1"`)
+			Expect(response.Code).To(Equal(http.StatusPreconditionFailed))
+
+			Expect(serve(router, http.MethodGet, "/api/v1/notes/missing", "").Code).To(Equal(http.StatusNotFound))
+
+			response = serve(router, http.MethodPut, "/api/v1/notes/missing", `{"description": "new"}`, "If-Match", "*")
+			Expect(response.Code).To(Equal(http.StatusCreated))
+		})
+
+		It("should return 400 when the header is not a single entity tag", func() {
+			router := newTestRouter()
+			addTestNote(router, "test")
+
+			for _, ifMatch := range []string{"1", `"a"`, `"1", "2"`, `W/"1"`} {
+				response := serve(router, http.MethodPost, "/api/v1/notes/test", `{"title": "test", "description": "updated"}`, "If-Match", ifMatch)
+				Expect(response.Code).To(Equal(http.StatusBadRequest), ifMatch)
+			}
+		})
+
+		It("should return 404 when the updated note doesn't exist", func() {
+			router := newTestRouter()
+
+			response := serve(router, http.MethodPost, "/api/v1/notes/missing", `{"title": "missing", "description": "updated"}`, "If-Match", `"1"`)
+			Expect(response.Code).To(Equal(http.StatusNotFound))
+
+			response = serve(router, http.MethodPost, "/api/v1/notes/id/missing", `{"title": "missing", "description": "updated"}`)
+			Expect(response.Code).To(Equal(http.StatusNotFound))
+		})
+
+		It("should return 400 when the note is renamed to the title of another note", func() {
+			router := newTestRouter()
+			addTestNote(router, "first")
+			addTestNote(router, "second")
+
+			response := serve(router, http.MethodPost, "/api/v1/notes/second", `{"title": "first", "description": "updated"}`, "If-Match", `"1"`)
+			Expect(response.Code).To(Equal(http.StatusBadRequest))
+			Expect(response.Body.String()).To(ContainSubstring("already exists"))
+		})
+	})
+
+})
@@ -0,0 +1,286 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/notes-project/api/pkg/database"
+	"github.com/notes-project/api/pkg/model"
+	"github.com/notes-project/api/pkg/patch"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+
+	// the patch is applied to the note that was read, it's tried again when the note was changed in the meantime
+	maxPatchAttempts = 3
+)
+
+// patchableNote has the fields of a note that can be patched, the other fields are set by the API and the database
+type patchableNote struct {
+	Title       string   `json:"title" binding:"required"`
+	Description string   `json:"description" binding:"required"`
+	Category    string   `json:"category"`
+	Tags        []string `json:"tags"`
+}
+
+func (s server) patchNoteByTitle(c *gin.Context) {
+	noteTitle := c.Param("title")
+
+	s.patchNote(c, fmt.Sprintf("'%s'", noteTitle),
+		func(notePatch database.NotePatch, expectedVersion int64) error {
+			return s.db.PatchNote(noteTitle, notePatch, expectedVersion)
+		},
+		func() (model.Note, error) {
+			return s.db.GetNote(noteTitle)
+		},
+	)
+}
+
+func (s server) patchNoteByID(c *gin.Context) {
+	noteID := c.Param("id")
+
+	s.patchNote(c, fmt.Sprintf("with id '%s'", noteID),
+		func(notePatch database.NotePatch, expectedVersion int64) error {
+			return s.db.PatchNoteByID(noteID, notePatch, expectedVersion)
+		},
+		func() (model.Note, error) {
+			return s.db.GetNoteByID(noteID)
+		},
+	)
+}
+
+// patchNote applies the merge patch or the JSON patch from the request to the current note,
+// then updates only the fields it changed, as long as the note still has the version that was patched
+func (s server) patchNote(c *gin.Context, noteRef string, update func(notePatch database.NotePatch, expectedVersion int64) error, findNote func() (model.Note, error)) {
+	expectedVersion, ok := s.expectedVersion(c)
+	if !ok {
+		return
+	}
+
+	var apply func(document, patch []byte) ([]byte, error)
+
+	switch c.ContentType() {
+	case mergePatchContentType:
+		apply = patch.MergePatch
+	case jsonPatchContentType:
+		apply = patch.JSONPatch
+	default:
+		c.JSON(http.StatusUnsupportedMediaType,
+			gin.H{
+				"error": fmt.Sprintf("the patch must have the content type '%s' or '%s'", mergePatchContentType, jsonPatchContentType),
+			},
+		)
+
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to read the patch of note %s, err: %s", noteRef, err))
+
+		c.JSON(http.StatusBadRequest,
+			gin.H{
+				"error": "failed to read the patch",
+			},
+		)
+
+		return
+	}
+
+	for attempt := 1; ; attempt++ {
+		note, err := findNote()
+		if err != nil {
+			s.writePatchError(c, noteRef, "", err, findNote)
+			return
+		}
+
+		if expectedVersion != database.AnyVersion && note.Version != expectedVersion {
+			s.writeVersionConflict(c, noteRef, findNote)
+			return
+		}
+
+		notePatch, err := applyNotePatch(note, body, apply)
+		if err != nil {
+			s.logger.Info(fmt.Sprintf("Invalid patch of note %s, err: %s", noteRef, err))
+
+			status := http.StatusBadRequest
+			if errors.Is(err, patch.ErrTestFailed) {
+				status = http.StatusConflict
+			}
+
+			c.JSON(status,
+				gin.H{
+					"error": err.Error(),
+				},
+			)
+
+			return
+		}
+
+		// a patch that doesn't change anything doesn't create a new version
+		if !notePatch.IsEmpty() {
+			// the patch was applied to this version, so the note is only updated when it's still the current one
+			err = update(notePatch, note.Version)
+			if errors.Is(err, database.ErrVersionConflict) && expectedVersion == database.AnyVersion && attempt < maxPatchAttempts {
+				continue
+			}
+
+			if err != nil {
+				patchedTitle := note.Title
+				if notePatch.Title != nil {
+					patchedTitle = *notePatch.Title
+				}
+
+				s.writePatchError(c, noteRef, patchedTitle, err, findNote)
+
+				return
+			}
+
+			note, err = s.db.GetNoteByID(note.ID)
+			if err != nil {
+				s.logger.Error(fmt.Sprintf("Failed to get the patched note %s from database, err: %s", noteRef, err))
+
+				c.JSON(http.StatusInternalServerError,
+					gin.H{
+						"error": fmt.Sprintf("failed to retrieve note %s", noteRef),
+					},
+				)
+
+				return
+			}
+		}
+
+		note.SetDate(requestLocation(c))
+
+		c.Header("ETag", noteETag(note.Version))
+		c.JSON(http.StatusOK,
+			gin.H{
+				"note": note,
+			})
+
+		return
+	}
+}
+
+// applyNotePatch applies the patch to the fields of the note that can be patched and returns the fields it changed
+func applyNotePatch(note model.Note, body []byte, apply func(document, patch []byte) ([]byte, error)) (database.NotePatch, error) {
+	current := patchableNote{
+		Title:       note.Title,
+		Description: note.Description,
+		Category:    note.Category,
+		Tags:        note.Tags,
+	}
+
+	// the tags are an empty array rather than null, so the patch can add to them
+	if current.Tags == nil {
+		current.Tags = []string{}
+	}
+
+	document, err := json.Marshal(current)
+	if err != nil {
+		return database.NotePatch{}, err
+	}
+
+	patched, err := apply(document, body)
+	if err != nil {
+		return database.NotePatch{}, err
+	}
+
+	result := patchableNote{}
+
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+
+	err = decoder.Decode(&result)
+	if err != nil {
+		return database.NotePatch{}, fmt.Errorf("the patched note must be an object with only a title, description, category and tags, %s", err)
+	}
+
+	err = binding.Validator.ValidateStruct(result)
+	if err != nil {
+		return database.NotePatch{}, err
+	}
+
+	notePatch := database.NotePatch{}
+
+	if result.Title != current.Title {
+		notePatch.Title = &result.Title
+	}
+
+	if result.Description != current.Description {
+		notePatch.Description = &result.Description
+	}
+
+	if result.Category != current.Category {
+		notePatch.Category = &result.Category
+	}
+
+	if !equalTags(result.Tags, current.Tags) {
+		notePatch.Tags = &result.Tags
+	}
+
+	return notePatch, nil
+}
+
+func equalTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// writePatchError responds with the error of reading or updating the patched note
+func (s server) writePatchError(c *gin.Context, noteRef, patchedTitle string, err error, findNote func() (model.Note, error)) {
+	if errors.Is(err, database.ErrVersionConflict) {
+		s.writeVersionConflict(c, noteRef, findNote)
+		return
+	}
+
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		s.logger.Info(fmt.Sprintf("Note %s does not exist in database", noteRef))
+
+		c.JSON(http.StatusNotFound,
+			gin.H{
+				"error": fmt.Sprintf("note %s does not exist", noteRef),
+			},
+		)
+
+		return
+	}
+
+	if mongo.IsDuplicateKeyError(err) {
+		s.logger.Info(fmt.Sprintf("Note '%s' already exists in database", patchedTitle))
+
+		c.JSON(http.StatusBadRequest,
+			gin.H{
+				"error": fmt.Sprintf("note with key 'title' and value '%s' already exists", patchedTitle),
+			},
+		)
+
+		return
+	}
+
+	s.logger.Error(fmt.Sprintf("Failed to patch note %s from database, err: %s", noteRef, err))
+
+	c.JSON(http.StatusInternalServerError,
+		gin.H{
+			"error": fmt.Sprintf("failed to update note %s", noteRef),
+		},
+	)
+}
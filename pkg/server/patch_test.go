@@ -0,0 +1,82 @@
+package server
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Patch", func() {
+
+	It("should apply a merge patch to the note", func() {
+		router := newTestRouter()
+		addTestNote(router, "test")
+
+		response := serve(router, http.MethodPatch, "/api/v1/notes/test", `{"category": "work"}`,
+			"Content-Type", mergePatchContentType, "If-Match", `"1"`)
+		Expect(response.Code).To(Equal(http.StatusOK))
+		Expect(response.Header().Get("ETag")).To(Equal(`"2"`))
+		Expect(response.Body.String()).To(ContainSubstring(`"category":"work"`))
+	})
+
+	It("should apply a JSON patch to the note found by its id", func() {
+		router := newTestRouter()
+		id := addTestNote(router, "test")
+
+		response := serve(router, http.MethodPatch, "/api/v1/notes/id/"+id, `[{"op": "add", "path": "/tags/-", "value": "new"}]`,
+			"Content-Type", jsonPatchContentType)
+		Expect(response.Code).To(Equal(http.StatusOK))
+		Expect(response.Body.String()).To(ContainSubstring(`"tags":["new"]`))
+	})
+
+	It("should return 412 with the current version when the note doesn't have the expected version", func() {
+		router := newTestRouter()
+		id := addTestNote(router, "test")
+
+		for _, path := range []string{"/api/v1/notes/test", "/api/v1/notes/id/" + id} {
+			response := serve(router, http.MethodPatch, path, `{"category": "work"}`,
+				"Content-Type", mergePatchContentType, "If-Match", `"2"`)
+			Expect(response.Code).To(Equal(http.StatusPreconditionFailed), path)
+			Expect(response.Header().Get("ETag")).To(Equal(`"1"`), path)
+			Expect(response.Body.String()).To(ContainSubstring(`"version":1`), path)
+		}
+	})
+
+	It("should return 404 when the note doesn't exist", func() {
+		router := newTestRouter()
+
+		for _, path := range []string{"/api/v1/notes/missing", "/api/v1/notes/id/missing"} {
+			response := serve(router, http.MethodPatch, path, `{"category": "work"}`, "Content-Type", mergePatchContentType)
+			Expect(response.Code).To(Equal(http.StatusNotFound), path)
+		}
+	})
+
+	It("should return 409 when a test operation of the JSON patch fails", func() {
+		router := newTestRouter()
+		addTestNote(router, "test")
+
+		response := serve(router, http.MethodPatch, "/api/v1/notes/test", `[{"op": "test", "path": "/category", "value": "work"}]`,
+			"Content-Type", jsonPatchContentType)
+		Expect(response.Code).To(Equal(http.StatusConflict))
+	})
+
+	It("should return 400 when the note is renamed to the title of another note", func() {
+		router := newTestRouter()
+		addTestNote(router, "first")
+		addTestNote(router, "second")
+
+		response := serve(router, http.MethodPatch, "/api/v1/notes/second", `{"title": "first"}`, "Content-Type", mergePatchContentType)
+		Expect(response.Code).To(Equal(http.StatusBadRequest))
+		Expect(response.Body.String()).To(ContainSubstring("already exists"))
+	})
+
+	It("should return 415 when the patch has another content type", func() {
+		router := newTestRouter()
+		addTestNote(router, "test")
+
+		response := serve(router, http.MethodPatch, "/api/v1/notes/test", `{"category": "work"}`, "Content-Type", "application/json")
+		Expect(response.Code).To(Equal(http.StatusUnsupportedMediaType))
+	})
+
+})
@@ -0,0 +1,233 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/notes-project/api/pkg/database"
+	"github.com/notes-project/api/pkg/diff"
+	"github.com/notes-project/api/pkg/model"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (s server) getRevisions(c *gin.Context) {
+	noteTtile := c.Param("title")
+
+	revisions, err := s.db.GetRevisions(noteTtile)
+	if err != nil {
+		s.writeRevisionError(c, fmt.Sprintf("revisions of note '%s'", noteTtile), err)
+		return
+	}
+
+	for i := range revisions {
+		revisions[i].Note.SetDate(requestLocation(c))
+	}
+
+	c.JSON(http.StatusOK,
+		gin.H{
+			"revisions": revisions,
+		})
+}
+
+func (s server) getRevision(c *gin.Context) {
+	noteTtile := c.Param("title")
+
+	revision, ok := s.findRevision(c, noteTtile, c.Param("number"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK,
+		gin.H{
+			"revision": revision,
+		})
+}
+
+func (s server) getRevisionsDiff(c *gin.Context) {
+	noteTtile := c.Param("title")
+
+	from, ok := s.findRevision(c, noteTtile, c.Param("number"))
+	if !ok {
+		return
+	}
+
+	to, ok := s.findRevision(c, noteTtile, c.Param("otherNumber"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK,
+		gin.H{
+			"from": from.Number,
+			"to":   to.Number,
+			"diff": diff.Lines(noteLines(from.Note), noteLines(to.Note)),
+		})
+}
+
+func (s server) restoreRevision(c *gin.Context) {
+	noteTtile := c.Param("title")
+
+	revision, ok := s.findRevision(c, noteTtile, c.Param("number"))
+	if !ok {
+		return
+	}
+
+	// the restored note is updated now, it keeps the creation time of the note
+	note := revision.Note
+	note.ID = ""
+	note.Version = 0
+	note.UpdatedAt = time.Time{}
+	note.Date = ""
+
+	// the note keeps its id, so the restored version continues the same history
+	err := s.db.UpdateNoteByID(revision.NoteID, note, database.AnyVersion)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// the note in the trash has the same id, so it has to be restored from the trash first
+		trashed, trashErr := s.isInTrash(revision.NoteID)
+		if trashErr == nil && trashed {
+			s.logger.Info(fmt.Sprintf("Note with id '%s' is in the trash", revision.NoteID))
+
+			c.JSON(http.StatusBadRequest,
+				gin.H{
+					"error": fmt.Sprintf("note '%s' is in the trash, restore it from the trash first", noteTtile),
+				},
+			)
+
+			return
+		}
+
+		note.ID = revision.NoteID
+		note.Version, err = s.nextVersion(noteTtile)
+		if err == nil {
+			err = s.db.AddNote(note)
+		}
+	}
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			s.logger.Info(fmt.Sprintf("Note '%s' already exists in database", note.Title))
+
+			c.JSON(http.StatusBadRequest,
+				gin.H{
+					"error": fmt.Sprintf("note with key 'title' and value '%s' already exists", note.Title),
+				},
+			)
+
+			return
+		}
+
+		s.logger.Error(fmt.Sprintf("Failed to restore revision %d of note '%s', err: %s", revision.Number, noteTtile, err))
+
+		c.JSON(http.StatusInternalServerError,
+			gin.H{
+				"error": fmt.Sprintf("failed to restore revision %d of note '%s'", revision.Number, noteTtile),
+			},
+		)
+
+		return
+	}
+
+	note, err = s.db.GetNoteByID(revision.NoteID)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to get restored note with id '%s', err: %s", revision.NoteID, err))
+
+		c.JSON(http.StatusInternalServerError,
+			gin.H{
+				"error": fmt.Sprintf("failed to retrieve restored note with id '%s'", revision.NoteID),
+			},
+		)
+
+		return
+	}
+
+	note.SetDate(requestLocation(c))
+
+	c.Header("ETag", noteETag(note.Version))
+
+	c.JSON(http.StatusOK,
+		gin.H{
+			"note": note,
+		})
+}
+
+// nextVersion returns the version of a deleted note when it's restored, which follows the versions it had,
+// so an entity tag of the deleted note never matches the restored one
+func (s server) nextVersion(noteTitle string) (int64, error) {
+	revisions, err := s.db.GetRevisions(noteTitle)
+	if err != nil {
+		return 0, err
+	}
+
+	version := int64(0)
+	for _, revision := range revisions {
+		if revision.Note.Version > version {
+			version = revision.Note.Version
+		}
+	}
+
+	return version + 1, nil
+}
+
+// findRevision gets the revision with the number from the path parameter,
+// when it fails the response is already written
+func (s server) findRevision(c *gin.Context, noteTitle, number string) (model.Revision, bool) {
+	revisionNumber, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || revisionNumber < 1 {
+		c.JSON(http.StatusBadRequest,
+			gin.H{
+				"error": fmt.Sprintf("revision number '%s' is not a positive integer", number),
+			},
+		)
+
+		return model.Revision{}, false
+	}
+
+	revision, err := s.db.GetRevision(noteTitle, revisionNumber)
+	if err != nil {
+		s.writeRevisionError(c, fmt.Sprintf("revision %d of note '%s'", revisionNumber, noteTitle), err)
+		return model.Revision{}, false
+	}
+
+	revision.Note.SetDate(requestLocation(c))
+
+	return revision, true
+}
+
+func (s server) writeRevisionError(c *gin.Context, revisionRef string, err error) {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		s.logger.Info(fmt.Sprintf("Could not find %s in database", revisionRef))
+
+		c.JSON(http.StatusNotFound,
+			gin.H{
+				"error": fmt.Sprintf("%s does not exist", revisionRef),
+			},
+		)
+
+		return
+	}
+
+	s.logger.Error(fmt.Sprintf("Failed to get %s from database, err: %s", revisionRef, err))
+
+	c.JSON(http.StatusInternalServerError,
+		gin.H{
+			"error": fmt.Sprintf("failed to retrieve %s", revisionRef),
+		},
+	)
+}
+
+// noteLines renders the note as the lines compared by the diff of two revisions
+func noteLines(note model.Note) []string {
+	lines := []string{
+		fmt.Sprintf("title: %s", note.Title),
+		fmt.Sprintf("category: %s", note.Category),
+		fmt.Sprintf("tags: %s", strings.Join(note.Tags, ", ")),
+		fmt.Sprintf("date: %s", note.Date),
+		"",
+	}
+
+	return append(lines, strings.Split(note.Description, "\n")...)
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Revisions", func() {
+
+	It("should list the revisions of an updated note", func() {
+		router := newTestRouter()
+		addTestNote(router, "test")
+
+		Expect(serve(router, http.MethodPost, "/api/v1/notes/test", `{"title": "test", "description": "updated"}`).Code).To(Equal(http.StatusOK))
+
+		response := serve(router, http.MethodGet, "/api/v1/notes/test/revisions", "")
+		Expect(response.Code).To(Equal(http.StatusOK))
+		Expect(response.Body.String()).To(ContainSubstring(`"description":"test"`))
+
+		response = serve(router, http.MethodGet, "/api/v1/notes/test/revisions/1", "")
+		Expect(response.Code).To(Equal(http.StatusOK))
+		Expect(response.Body.String()).To(ContainSubstring(`"number":1`))
+	})
+
+	It("should return 404 when the note or the revision doesn't exist", func() {
+		router := newTestRouter()
+		addTestNote(router, "test")
+
+		Expect(serve(router, http.MethodPost, "/api/v1/notes/test", `{"title": "test", "description": "updated"}`).Code).To(Equal(http.StatusOK))
+
+		for _, path := range []string{
+			"/api/v1/notes/never-added/revisions/1",
+			"/api/v1/notes/test/revisions/9",
+			"/api/v1/notes/test/revisions/1/diff/9",
+			"/api/v1/notes/test/revisions/9/diff/1",
+		} {
+			Expect(serve(router, http.MethodGet, path, "").Code).To(Equal(http.StatusNotFound), path)
+		}
+
+		Expect(serve(router, http.MethodPost, "/api/v1/notes/test/revisions/9/restore", "").Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should return 400 when the revision number is not a positive integer", func() {
+		router := newTestRouter()
+		addTestNote(router, "test")
+
+		for _, number := range []string{"0", "-1", "first"} {
+			Expect(serve(router, http.MethodGet, "/api/v1/notes/test/revisions/"+number, "").Code).To(Equal(http.StatusBadRequest), number)
+		}
+	})
+
+	It("should restore a revision with a new version of the note", func() {
+		router := newTestRouter()
+		addTestNote(router, "test")
+
+		Expect(serve(router, http.MethodPost, "/api/v1/notes/test", `{"title": "test", "description": "updated"}`).Code).To(Equal(http.StatusOK))
+
+		response := serve(router, http.MethodPost, "/api/v1/notes/test/revisions/1/restore", "")
+		Expect(response.Code).To(Equal(http.StatusOK))
+		Expect(response.Header().Get("ETag")).To(Equal(`"3"`))
+		Expect(response.Body.String()).To(ContainSubstring(`"description":"test"`))
+	})
+
+	It("should return 400 when the restored title was taken by another note", func() {
+		router := newTestRouter()
+		addTestNote(router, "test")
+
+		Expect(serve(router, http.MethodPost, "/api/v1/notes/test", `{"title": "renamed", "description": "test"}`).Code).To(Equal(http.StatusOK))
+		addTestNote(router, "test")
+
+		response := serve(router, http.MethodPost, "/api/v1/notes/renamed/revisions/1/restore", "")
+		Expect(response.Code).To(Equal(http.StatusBadRequest))
+		Expect(response.Body.String()).To(ContainSubstring("already exists"))
+	})
+
+	It("should return 400 when the note of the restored revision is in the trash", func() {
+		router := newTestRouter()
+		addTestNote(router, "test")
+
+		Expect(serve(router, http.MethodPost, "/api/v1/notes/test", `{"title": "test", "description": "updated"}`).Code).To(Equal(http.StatusOK))
+		Expect(serve(router, http.MethodDelete, "/api/v1/notes/test", "").Code).To(Equal(http.StatusOK))
+
+		response := serve(router, http.MethodPost, "/api/v1/notes/test/revisions/1/restore", "")
+		Expect(response.Code).To(Equal(http.StatusBadRequest))
+		Expect(response.Body.String()).To(ContainSubstring("in the trash"))
+	})
+
+})
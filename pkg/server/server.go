@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -14,6 +15,14 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	// limits the time to write a response, a streamed response extends it before each of its writes
+	writeTimeout = 10 * time.Second
+)
+
+// connContextKey is the key of the connection of a request in its context
+type connContextKey struct{}
+
 type Server interface {
 	Start() error
 }
@@ -36,6 +45,10 @@ func (s server) Start() error {
 	s.startMainServers()
 	s.serveHealthProbes()
 
+	if s.trashRetention > 0 {
+		go s.purgeTrashPeriodically()
+	}
+
 	go s.handleGracefulShutdown()
 
 	select {
@@ -73,22 +86,63 @@ func (s server) handleGracefulShutdown() {
 }
 
 func (s server) startMainServers() {
+	defaultRouter := s.router()
+
+	s.serveHttp(defaultRouter)
+	s.serveHttps(defaultRouter)
+}
+
+// router returns the router of the API. The static routes of the notes, such as /notes/export or /notes/id/:id,
+// take precedence over the routes of a single note by its title only for their exact path and method, so a note
+// titled id is still reached at /notes/id, a note titled export with any method other than GET, and a note
+// titled import with any method other than POST.
+func (s server) router() *gin.Engine {
 	defaultRouter := gin.Default()
 	defaultRouter.SetTrustedProxies(nil)
 
-	v1 := defaultRouter.Group("/api/v1")
+	v1 := defaultRouter.Group("/api/v1", s.resolveTimezone)
 	{
 		v1.GET("/notes", s.getNotes)
 		v1.POST("/notes", s.addNote)
 		v1.DELETE("/notes", s.deleteNotes)
+		v1.POST("/notes:method", s.notesMethod)
+		v1.GET("/notes/export", s.exportNotes)
+		v1.POST("/notes/import", s.importNotes)
 
 		v1.GET("/notes/:title", s.getNoteByTitle)
 		v1.POST("/notes/:title", s.updateNoteByTitle)
+		v1.PUT("/notes/:title", s.upsertNote)
+		v1.PATCH("/notes/:title", s.patchNoteByTitle)
 		v1.DELETE("/notes/:title", s.deleteNoteByTitle)
+
+		v1.POST("/notes/:title/tags", s.changeTagsInBulk)
+		v1.PUT("/notes/:title/tags/:tag", s.addTag)
+		v1.DELETE("/notes/:title/tags/:tag", s.removeTag)
+
+		v1.GET("/notes/:title/revisions", s.getRevisions)
+		v1.GET("/notes/:title/revisions/:number", s.getRevision)
+		v1.GET("/notes/:title/revisions/:number/diff/:otherNumber", s.getRevisionsDiff)
+		v1.POST("/notes/:title/revisions/:number/restore", s.restoreRevision)
+
+		v1.GET("/notes/id/:id", s.getNoteByID)
+		v1.POST("/notes/id/:id", s.updateNoteByID)
+		v1.PATCH("/notes/id/:id", s.patchNoteByID)
+		v1.DELETE("/notes/id/:id", s.deleteNoteByID)
+
+		v1.POST("/batch", s.applyOperations)
+
+		v1.GET("/tags", s.getTags)
+		v1.POST("/tags/:tag/rename", s.renameTag)
+		v1.GET("/categories", s.getCategories)
+		v1.POST("/categories/:category/rename", s.renameCategory)
+
+		v1.GET("/trash", s.getTrash)
+		v1.DELETE("/trash", s.purgeTrash)
+		v1.POST("/trash/:title/restore", s.restoreTrashedNote)
+		v1.DELETE("/trash/:title", s.purgeTrashedNote)
 	}
 
-	s.serveHttp(defaultRouter)
-	s.serveHttps(defaultRouter)
+	return defaultRouter
 }
 
 func (s server) serveHttp(router *gin.Engine) {
@@ -96,7 +150,8 @@ func (s server) serveHttp(router *gin.Engine) {
 		Addr:         fmt.Sprintf(":%s", s.port),
 		Handler:      router,
 		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
+		WriteTimeout: writeTimeout,
+		ConnContext:  withConn,
 	}
 
 	*s.servers = append(*s.servers, httpServer)
@@ -117,7 +172,8 @@ func (s server) serveHttps(router *gin.Engine) {
 		Addr:         fmt.Sprintf(":%s", s.tlsPort),
 		Handler:      router,
 		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
+		WriteTimeout: writeTimeout,
+		ConnContext:  withConn,
 	}
 
 	if len(s.tlsPort) > 0 && s.tlsCertLocation != "" && s.tlsKeyLocation != "" {
@@ -135,3 +191,19 @@ func (s server) serveHttps(router *gin.Engine) {
 		}()
 	}
 }
+
+// withConn keeps the connection in the context of its requests, so a streamed response can extend its write deadline
+func withConn(ctx context.Context, conn net.Conn) context.Context {
+	return context.WithValue(ctx, connContextKey{}, conn)
+}
+
+// extendWriteDeadline gives the response of the request another write timeout from now, the responses
+// of the requests served without a connection, such as in the tests, have no deadline to extend
+func extendWriteDeadline(c *gin.Context) error {
+	conn, ok := c.Request.Context().Value(connContextKey{}).(net.Conn)
+	if !ok {
+		return nil
+	}
+
+	return conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+}
@@ -1,13 +1,90 @@
 package server
 
 import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/gin-gonic/gin"
+	"github.com/notes-project/api/pkg/constants"
+	"github.com/notes-project/api/pkg/database"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
 )
 
 func TestServer(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Server Suite")
 }
+
+var (
+	// purges all the notes in the trash
+	farFuture = time.Now().AddDate(100, 0, 0)
+
+	// the in-memory database of the handler tests, the database factory returns a single instance
+	// so the notes are deleted before every test
+	testDatabase database.Database
+)
+
+var _ = BeforeSuite(func() {
+	gin.SetMode(gin.TestMode)
+
+	dbConfig := database.NewDatabaseConfiguration(constants.MemoryDriver, "", "", "notes", database.NewRevisionsRetention(0, 0), "")
+	testDatabase = database.NewDatabaseFactory().NewDatabase(dbConfig)
+
+	Expect(testDatabase.Connect()).To(Succeed())
+})
+
+// newTestRouter returns the router of a server on the empty test database,
+// the revisions of the deleted notes are kept so the notes of other tests may have revisions
+func newTestRouter() *gin.Engine {
+	// there may be no notes to delete
+	err := testDatabase.DeleteNotes()
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	_, err = testDatabase.PurgeTrash(farFuture)
+	Expect(err).NotTo(HaveOccurred())
+
+	s := server{
+		serverConfiguration: NewServerConfiguration("", "", "", "", testDatabase, 0, 100),
+		logger:              zap.NewNop(),
+	}
+
+	return s.router()
+}
+
+// serve returns the response of the router to the request with the body and the headers, in pairs of name and value
+func serve(router *gin.Engine, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	for i := 0; i+1 < len(headers); i += 2 {
+		request.Header.Set(headers[i], headers[i+1])
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+// addTestNote adds a note with the title through the API and returns its id
+func addTestNote(router *gin.Engine, title string) string {
+	response := serve(router, http.MethodPost, "/api/v1/notes", `{"title": "`+title+`", "description": "test"}`)
+	Expect(response.Code).To(Equal(http.StatusOK), response.Body.String())
+
+	note := struct {
+		Note struct {
+			ID string `json:"id"`
+		} `json:"note"`
+	}{}
+	Expect(json.Unmarshal(response.Body.Bytes(), &note)).To(Succeed())
+
+	return note.Note.ID
+}
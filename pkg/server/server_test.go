@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Server", func() {
+
+	Describe("router", func() {
+		It("should reach a note by its id", func() {
+			router := newTestRouter()
+			id := addTestNote(router, "test")
+
+			response := serve(router, http.MethodGet, "/api/v1/notes/id/"+id, "")
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Body.String()).To(ContainSubstring(`"title":"test"`))
+		})
+
+		It("should reach the notes titled like the other routes of the notes by their title", func() {
+			router := newTestRouter()
+			addTestNote(router, "id")
+
+			response := serve(router, http.MethodGet, "/api/v1/notes/id", "")
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Body.String()).To(ContainSubstring(`"title":"id"`))
+
+			response = serve(router, http.MethodPost, "/api/v1/notes/id", `{"title": "id", "description": "updated"}`)
+			Expect(response.Code).To(Equal(http.StatusOK))
+
+			response = serve(router, http.MethodDelete, "/api/v1/notes/id", "")
+			Expect(response.Code).To(Equal(http.StatusOK))
+		})
+
+		It("should export the notes instead of reading the note titled export", func() {
+			router := newTestRouter()
+			id := addTestNote(router, "export")
+
+			response := serve(router, http.MethodGet, "/api/v1/notes/export?format=csv", "")
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Header().Get("Content-Type")).To(ContainSubstring("text/csv"))
+
+			response = serve(router, http.MethodGet, "/api/v1/notes/id/"+id, "")
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Body.String()).To(ContainSubstring(`"title":"export"`))
+
+			response = serve(router, http.MethodPost, "/api/v1/notes/export", `{"title": "export", "description": "updated"}`)
+			Expect(response.Code).To(Equal(http.StatusOK))
+
+			response = serve(router, http.MethodDelete, "/api/v1/notes/export", "")
+			Expect(response.Code).To(Equal(http.StatusOK))
+		})
+
+		It("should import the notes instead of updating the note titled import", func() {
+			router := newTestRouter()
+			addTestNote(router, "import")
+
+			response := serve(router, http.MethodPost, "/api/v1/notes/import?dryRun=true", `{"title": "other", "description": "imported"}`)
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Body.String()).To(ContainSubstring(`"created":1`))
+
+			response = serve(router, http.MethodGet, "/api/v1/notes/import", "")
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Body.String()).To(ContainSubstring(`"title":"import"`))
+
+			response = serve(router, http.MethodPut, "/api/v1/notes/import", `{"title": "import", "description": "updated"}`)
+			Expect(response.Code).To(Equal(http.StatusOK))
+
+			response = serve(router, http.MethodDelete, "/api/v1/notes/import", "")
+			Expect(response.Code).To(Equal(http.StatusOK))
+		})
+
+		It("should not route the paths starting with the notes other than their custom methods", func() {
+			router := newTestRouter()
+			notRoutedResponse := serve(router, http.MethodPost, "/api/v1/missing", "")
+
+			for _, path := range []string{"/api/v1/notes:missing", "/api/v1/notes:import", "/api/v1/notes:batchx", "/api/v1/notesbatch"} {
+				response := serve(router, http.MethodPost, path, "[]")
+				Expect(response.Code).To(Equal(http.StatusNotFound), path)
+				Expect(response.Body.String()).To(Equal(notRoutedResponse.Body.String()), path)
+				Expect(response.Header().Get("Content-Type")).To(Equal(notRoutedResponse.Header().Get("Content-Type")), path)
+			}
+
+			for _, path := range []string{"/api/v1/notes:export", "/api/v1/notes:import"} {
+				response := serve(router, http.MethodGet, path, "")
+				Expect(response.Code).To(Equal(http.StatusNotFound), path)
+			}
+		})
+	})
+
+	Describe("extendWriteDeadline", func() {
+		It("should set the write deadline of the connection of the request", func() {
+			conn, other := net.Pipe()
+			defer other.Close()
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/notes/export", nil).WithContext(withConn(context.Background(), conn))
+
+			Expect(extendWriteDeadline(c)).To(Succeed())
+
+			Expect(conn.Close()).To(Succeed())
+			Expect(extendWriteDeadline(c)).NotTo(Succeed())
+		})
+
+		It("should do nothing without a connection", func() {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/notes/export", nil)
+
+			Expect(extendWriteDeadline(c)).To(Succeed())
+		})
+	})
+
+})
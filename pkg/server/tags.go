@@ -0,0 +1,130 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/notes-project/api/pkg/database"
+	"github.com/notes-project/api/pkg/model"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// limits the tags added and removed by a single request
+	maxChangedTags = 100
+)
+
+// tagsChange adds and removes tags of a note in a single change
+type tagsChange struct {
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"`
+}
+
+func (s server) addTag(c *gin.Context) {
+	s.changeTags(c, []string{c.Param("tag")}, nil)
+}
+
+func (s server) removeTag(c *gin.Context) {
+	s.changeTags(c, nil, []string{c.Param("tag")})
+}
+
+func (s server) changeTagsInBulk(c *gin.Context) {
+	change := tagsChange{}
+
+	err := c.MustBindWith(&change, binding.JSON)
+	if err != nil {
+		s.logger.Error(err.Error())
+		return
+	}
+
+	s.changeTags(c, change.Add, change.Remove)
+}
+
+// changeTags adds the tags that the note doesn't have and removes the others, without changing the rest of the note
+func (s server) changeTags(c *gin.Context, add, remove []string) {
+	noteTitle := c.Param("title")
+	noteRef := fmt.Sprintf("'%s'", noteTitle)
+
+	expectedVersion, ok := s.expectedVersion(c)
+	if !ok {
+		return
+	}
+
+	err := validateTagsChange(add, remove)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("Invalid change of the tags of note %s, err: %s", noteRef, err))
+
+		c.JSON(http.StatusBadRequest,
+			gin.H{
+				"error": err.Error(),
+			},
+		)
+
+		return
+	}
+
+	tags, err := s.db.ChangeTags(noteTitle, add, remove, expectedVersion)
+	if err != nil {
+		if errors.Is(err, database.ErrVersionConflict) {
+			s.writeVersionConflict(c, noteRef, func() (model.Note, error) {
+				return s.db.GetNote(noteTitle)
+			})
+
+			return
+		}
+
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			s.logger.Info(fmt.Sprintf("Note %s does not exist in database", noteRef))
+
+			c.JSON(http.StatusNotFound,
+				gin.H{
+					"error": fmt.Sprintf("note %s does not exist", noteRef),
+				},
+			)
+
+			return
+		}
+
+		s.logger.Error(fmt.Sprintf("Failed to change the tags of note %s in database, err: %s", noteRef, err))
+
+		c.JSON(http.StatusInternalServerError,
+			gin.H{
+				"error": fmt.Sprintf("failed to change the tags of note %s", noteRef),
+			},
+		)
+
+		return
+	}
+
+	c.JSON(http.StatusOK,
+		gin.H{
+			"tags": tags,
+		})
+}
+
+// validateTagsChange returns an error when the change has no tags or too many, an empty tag,
+// or a tag that is both added and removed
+func validateTagsChange(add, remove []string) error {
+	if len(add)+len(remove) == 0 || len(add)+len(remove) > maxChangedTags {
+		return fmt.Errorf("from 1 to %d tags must be added or removed", maxChangedTags)
+	}
+
+	for _, tag := range append(append([]string{}, add...), remove...) {
+		if tag == "" {
+			return errors.New("tags must not be empty")
+		}
+	}
+
+	for _, added := range add {
+		for _, removed := range remove {
+			if added == removed {
+				return fmt.Errorf("tag '%s' can't be both added and removed", added)
+			}
+		}
+	}
+
+	return nil
+}
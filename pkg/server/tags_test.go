@@ -0,0 +1,29 @@
+package server
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tags", func() {
+
+	Describe("validateTagsChange", func() {
+		It("should accept tags that are added and removed", func() {
+			Expect(validateTagsChange([]string{"a", "b"}, []string{"c"})).To(Succeed())
+			Expect(validateTagsChange(nil, []string{"c"})).To(Succeed())
+		})
+
+		It("should reject a change without tags or with too many tags", func() {
+			Expect(validateTagsChange(nil, nil)).NotTo(Succeed())
+			Expect(validateTagsChange(make([]string, maxChangedTags), []string{"a"})).NotTo(Succeed())
+		})
+
+		It("should reject an empty tag", func() {
+			Expect(validateTagsChange([]string{"a"}, []string{""})).To(MatchError("tags must not be empty"))
+		})
+
+		It("should reject a tag that is both added and removed", func() {
+			Expect(validateTagsChange([]string{"a", "b"}, []string{"b"})).To(MatchError("tag 'b' can't be both added and removed"))
+		})
+	})
+})
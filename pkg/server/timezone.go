@@ -0,0 +1,66 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/notes-project/api/pkg/model"
+)
+
+const (
+	// the timezone of the client is read from the query parameter, or from the header when the parameter is missing
+	timezoneQueryParam = "timezone"
+	timezoneHeader     = "X-Timezone"
+
+	// key of the location of the client in the gin context
+	locationContextKey = "location"
+)
+
+// resolveTimezone loads the IANA timezone of the client, which is used to render the dates of the notes
+// and to filter them by day. When the timezone is invalid the request is aborted.
+func (s server) resolveTimezone(c *gin.Context) {
+	timezone := c.Query(timezoneQueryParam)
+	if timezone == "" {
+		timezone = c.GetHeader(timezoneHeader)
+	}
+
+	if timezone == "" {
+		return
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("Invalid timezone '%s'", timezone))
+
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			gin.H{
+				"error": fmt.Sprintf("timezone '%s' is not a valid IANA timezone", timezone),
+			},
+		)
+
+		return
+	}
+
+	c.Set(locationContextKey, location)
+}
+
+// requestLocation returns the location of the client, UTC when it didn't send a timezone
+func requestLocation(c *gin.Context) *time.Location {
+	location, ok := c.Get(locationContextKey)
+	if !ok {
+		return time.UTC
+	}
+
+	return location.(*time.Location)
+}
+
+// renderDates sets the dates of the notes in the location of the client
+func renderDates(c *gin.Context, notes []model.Note) {
+	location := requestLocation(c)
+
+	for i := range notes {
+		notes[i].SetDate(location)
+	}
+}
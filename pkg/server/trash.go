@@ -0,0 +1,173 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// the trash is purged at least this often, or more often when the retention is shorter
+	trashPurgeMaxInterval = time.Hour
+)
+
+func (s server) getTrash(c *gin.Context) {
+	notes, err := s.db.GetTrash()
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to get notes from trash, err: %s", err))
+
+		c.JSON(http.StatusInternalServerError,
+			gin.H{
+				"error": "failed to retrieve notes from trash",
+			},
+		)
+
+		return
+	}
+
+	renderDates(c, notes)
+
+	c.JSON(http.StatusOK,
+		gin.H{
+			"notes": notes,
+		})
+}
+
+func (s server) restoreTrashedNote(c *gin.Context) {
+	noteTtile := c.Param("title")
+
+	err := s.db.RestoreNote(noteTtile)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			s.logger.Info(fmt.Sprintf("Note '%s' is not in the trash", noteTtile))
+
+			c.JSON(http.StatusNotFound,
+				gin.H{
+					"error": fmt.Sprintf("note '%s' is not in the trash", noteTtile),
+				},
+			)
+
+			return
+		}
+
+		if mongo.IsDuplicateKeyError(err) {
+			s.logger.Info(fmt.Sprintf("Note '%s' already exists in database", noteTtile))
+
+			c.JSON(http.StatusBadRequest,
+				gin.H{
+					"error": fmt.Sprintf("note with key 'title' and value '%s' already exists", noteTtile),
+				},
+			)
+
+			return
+		}
+
+		s.logger.Error(fmt.Sprintf("Failed to restore note '%s' from trash, err: %s", noteTtile, err))
+
+		c.JSON(http.StatusInternalServerError,
+			gin.H{
+				"error": fmt.Sprintf("failed to restore note '%s' from trash", noteTtile),
+			},
+		)
+
+		return
+	}
+
+	note, err := s.db.GetNote(noteTtile)
+
+	s.writeNote(c, fmt.Sprintf("'%s'", noteTtile), note, err, nil)
+}
+
+func (s server) purgeTrashedNote(c *gin.Context) {
+	noteTtile := c.Param("title")
+
+	err := s.db.PurgeNote(noteTtile)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			s.logger.Info(fmt.Sprintf("Note '%s' is not in the trash", noteTtile))
+
+			c.JSON(http.StatusNoContent,
+				gin.H{
+					"info": fmt.Sprintf("note '%s' is not in the trash", noteTtile),
+				},
+			)
+
+			return
+		}
+
+		s.logger.Error(fmt.Sprintf("Failed to purge note '%s' from trash, err: %s", noteTtile, err))
+
+		c.JSON(http.StatusInternalServerError,
+			gin.H{
+				"error": fmt.Sprintf("failed to purge note '%s' from trash", noteTtile),
+			},
+		)
+
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (s server) purgeTrash(c *gin.Context) {
+	purged, err := s.db.PurgeTrash(time.Now())
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to purge trash, err: %s", err))
+
+		c.JSON(http.StatusInternalServerError,
+			gin.H{
+				"error": "failed to purge trash",
+			},
+		)
+
+		return
+	}
+
+	c.JSON(http.StatusOK,
+		gin.H{
+			"purged": purged,
+		})
+}
+
+// purgeTrashPeriodically permanently deletes the notes that have been in the trash for longer than the retention
+func (s server) purgeTrashPeriodically() {
+	interval := s.trashRetention
+	if interval > trashPurgeMaxInterval {
+		interval = trashPurgeMaxInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		purged, err := s.db.PurgeTrash(time.Now().Add(-s.trashRetention))
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("Failed to purge trash, err: %s", err))
+			continue
+		}
+
+		if purged > 0 {
+			s.logger.Info(fmt.Sprintf("Successfully purged %d notes from trash", purged))
+		}
+	}
+}
+
+// isInTrash returns true when the note with the id is in the trash
+func (s server) isInTrash(noteID string) (bool, error) {
+	notes, err := s.db.GetTrash()
+	if err != nil {
+		return false, err
+	}
+
+	for _, note := range notes {
+		if note.ID == noteID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
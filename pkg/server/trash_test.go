@@ -0,0 +1,62 @@
+package server
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Trash", func() {
+
+	It("should list the deleted notes and restore them", func() {
+		router := newTestRouter()
+		id := addTestNote(router, "test")
+
+		Expect(serve(router, http.MethodDelete, "/api/v1/notes/test", "").Code).To(Equal(http.StatusOK))
+		Expect(serve(router, http.MethodGet, "/api/v1/notes/test", "").Code).To(Equal(http.StatusNotFound))
+
+		response := serve(router, http.MethodGet, "/api/v1/trash", "")
+		Expect(response.Code).To(Equal(http.StatusOK))
+		Expect(response.Body.String()).To(ContainSubstring(`"id":"` + id + `"`))
+
+		response = serve(router, http.MethodPost, "/api/v1/trash/test/restore", "")
+		Expect(response.Code).To(Equal(http.StatusOK))
+		Expect(response.Body.String()).To(ContainSubstring(`"id":"` + id + `"`))
+
+		Expect(serve(router, http.MethodGet, "/api/v1/notes/test", "").Code).To(Equal(http.StatusOK))
+	})
+
+	It("should return 404 when the restored note is not in the trash", func() {
+		router := newTestRouter()
+		addTestNote(router, "test")
+
+		response := serve(router, http.MethodPost, "/api/v1/trash/test/restore", "")
+		Expect(response.Code).To(Equal(http.StatusNotFound))
+		Expect(response.Body.String()).To(MatchJSON(`{"error": "note 'test' is not in the trash"}`))
+	})
+
+	It("should return 400 when the title of the restored note was taken by another note", func() {
+		router := newTestRouter()
+		addTestNote(router, "test")
+
+		Expect(serve(router, http.MethodDelete, "/api/v1/notes/test", "").Code).To(Equal(http.StatusOK))
+		addTestNote(router, "test")
+
+		response := serve(router, http.MethodPost, "/api/v1/trash/test/restore", "")
+		Expect(response.Code).To(Equal(http.StatusBadRequest))
+		Expect(response.Body.String()).To(ContainSubstring("already exists"))
+	})
+
+	It("should purge the note from the trash", func() {
+		router := newTestRouter()
+		addTestNote(router, "test")
+
+		Expect(serve(router, http.MethodDelete, "/api/v1/notes/test", "").Code).To(Equal(http.StatusOK))
+
+		Expect(serve(router, http.MethodDelete, "/api/v1/trash/test", "").Code).To(Equal(http.StatusOK))
+		Expect(serve(router, http.MethodDelete, "/api/v1/trash/test", "").Code).To(Equal(http.StatusNoContent))
+		Expect(serve(router, http.MethodPost, "/api/v1/trash/test/restore", "").Code).To(Equal(http.StatusNotFound))
+	})
+
+})
@@ -0,0 +1,281 @@
+package transfer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/notes-project/api/pkg/model"
+)
+
+const (
+	// separates the tags of a note in the tags column
+	csvTagSeparator = ","
+
+	// prefixes the cells a spreadsheet would run as a formula
+	csvFormulaEscape = "'"
+	// the first characters of the cells a spreadsheet runs as a formula
+	csvFormulaCharacters = "=+-@"
+)
+
+// the columns of the rows of the notes, in order
+var csvHeader = []string{"id", "title", "description", "category", "tags", "createdAt", "updatedAt", "version"}
+
+type csvWriter struct {
+	writer *csv.Writer
+	// the header is written along with the first row, so an export that fails before is empty
+	headerWritten bool
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{writer: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) Write(note model.Note) error {
+	if !c.headerWritten {
+		err := c.writer.Write(csvHeader)
+		if err != nil {
+			return fmt.Errorf("failed to export the header of the notes, error: %w", err)
+		}
+
+		c.headerWritten = true
+	}
+
+	err := c.writer.Write([]string{
+		note.ID,
+		escapeFormula(note.Title),
+		escapeFormula(note.Description),
+		escapeFormula(note.Category),
+		escapeFormula(strings.Join(note.Tags, csvTagSeparator)),
+		formatTime(note.CreatedAt),
+		formatTime(note.UpdatedAt),
+		strconv.FormatInt(note.Version, 10),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export note '%s', error: %w", note.Title, err)
+	}
+
+	return nil
+}
+
+// Close writes the header when there were no notes and flushes the rows
+func (c *csvWriter) Close() error {
+	if !c.headerWritten {
+		err := c.writer.Write(csvHeader)
+		if err != nil {
+			return fmt.Errorf("failed to export the header of the notes, error: %w", err)
+		}
+	}
+
+	c.writer.Flush()
+
+	err := c.writer.Error()
+	if err != nil {
+		return fmt.Errorf("failed to export the notes, error: %w", err)
+	}
+
+	return nil
+}
+
+// escapeFormula prefixes the text a spreadsheet would run as a formula with a quote, along with the text
+// already prefixed with quotes, so unescapeFormula returns the text as it was
+func escapeFormula(text string) string {
+	if isFormula(strings.TrimLeft(text, csvFormulaEscape)) {
+		return csvFormulaEscape + text
+	}
+
+	return text
+}
+
+// unescapeFormula removes the quote escapeFormula prefixed the text with
+func unescapeFormula(text string) string {
+	if strings.HasPrefix(text, csvFormulaEscape) && isFormula(strings.TrimLeft(text, csvFormulaEscape)) {
+		return strings.TrimPrefix(text, csvFormulaEscape)
+	}
+
+	return text
+}
+
+func isFormula(text string) bool {
+	return text != "" && strings.ContainsRune(csvFormulaCharacters, rune(text[0]))
+}
+
+// formatTime returns the time in RFC 3339 in UTC, empty for the zero time
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+type csvReader struct {
+	reader *csv.Reader
+	// the index of each column of the header by name, nil until the header is read
+	columns map[string]int
+}
+
+func newCSVReader(r io.Reader) *csvReader {
+	reader := csv.NewReader(r)
+	// the rows are checked against the header instead
+	reader.FieldsPerRecord = -1
+
+	return &csvReader{reader: reader}
+}
+
+// Read returns the note of the next row, the header is read first. The columns can be in any order
+// and only the title is required, the missing columns are left empty.
+func (c *csvReader) Read() (Record, error) {
+	if c.columns == nil {
+		err := c.readHeader()
+		if err != nil {
+			return Record{}, err
+		}
+	}
+
+	row, err := c.reader.Read()
+	if err != nil {
+		return Record{}, c.readError(err)
+	}
+
+	line, _ := c.reader.FieldPos(0)
+
+	if len(row) != len(c.columns) {
+		return Record{}, &RecordError{
+			Line: line,
+			Err:  fmt.Errorf("row has %d columns instead of the %d of the header", len(row), len(c.columns)),
+		}
+	}
+
+	note, err := c.parseNote(row)
+	if err != nil {
+		return Record{}, &RecordError{Line: line, Err: err}
+	}
+
+	return Record{Note: note, Line: line}, nil
+}
+
+// readHeader reads the names of the columns, which must be columns of the exported notes
+func (c *csvReader) readHeader() error {
+	header, err := c.reader.Read()
+	if err != nil {
+		return c.readError(err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		// spreadsheets may start the files with a byte order mark
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+
+		name = strings.TrimSpace(name)
+
+		if !containsColumn(csvHeader, name) {
+			return fmt.Errorf("failed to import the notes, column '%s' must be one of %s, error: %w", name, strings.Join(csvHeader, ", "), ErrInvalidFile)
+		}
+
+		if _, ok := columns[name]; ok {
+			return fmt.Errorf("failed to import the notes, column '%s' is repeated, error: %w", name, ErrInvalidFile)
+		}
+
+		columns[name] = i
+	}
+
+	if _, ok := columns["title"]; !ok {
+		return fmt.Errorf("failed to import the notes, the header has no column 'title', error: %w", ErrInvalidFile)
+	}
+
+	c.columns = columns
+
+	return nil
+}
+
+// readError returns the error of the reader, the rows after a malformed one can't be told apart so the file is invalid
+func (c *csvReader) readError(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.EOF
+	}
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return fmt.Errorf("failed to import the notes at line %d, %s, error: %w", parseErr.StartLine, parseErr.Err, ErrInvalidFile)
+	}
+
+	return fmt.Errorf("failed to import the notes, error: %w", err)
+}
+
+// parseNote returns the note of the row, in the format written by the csvWriter
+func (c *csvReader) parseNote(row []string) (model.Note, error) {
+	value := func(column string) string {
+		i, ok := c.columns[column]
+		if !ok {
+			return ""
+		}
+
+		return row[i]
+	}
+
+	note := model.Note{
+		ID:          value("id"),
+		Title:       unescapeFormula(value("title")),
+		Description: unescapeFormula(value("description")),
+		Category:    unescapeFormula(value("category")),
+	}
+
+	for _, tag := range strings.Split(unescapeFormula(value("tags")), csvTagSeparator) {
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			note.Tags = append(note.Tags, tag)
+		}
+	}
+
+	var err error
+
+	note.CreatedAt, err = parseTime("createdAt", value("createdAt"))
+	if err != nil {
+		return model.Note{}, err
+	}
+
+	note.UpdatedAt, err = parseTime("updatedAt", value("updatedAt"))
+	if err != nil {
+		return model.Note{}, err
+	}
+
+	if version := value("version"); version != "" {
+		note.Version, err = strconv.ParseInt(version, 10, 64)
+		if err != nil {
+			return model.Note{}, fmt.Errorf("column 'version' must be a number, found '%s'", version)
+		}
+	}
+
+	return note, nil
+}
+
+// parseTime returns the time of the column in RFC 3339, the zero time when it's empty
+func parseTime(column, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("column '%s' must be a time in RFC 3339, found '%s'", column, value)
+	}
+
+	return t.UTC(), nil
+}
+
+func containsColumn(columns []string, column string) bool {
+	for _, c := range columns {
+		if c == column {
+			return true
+		}
+	}
+
+	return false
+}
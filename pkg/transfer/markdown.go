@@ -0,0 +1,269 @@
+package transfer
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/notes-project/api/pkg/model"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// delimits the YAML front matter at the start of a Markdown file
+	frontMatterDelimiter = "---"
+
+	markdownExtension = ".md"
+	// the file names made from the titles are cut to stay below the limits of the file systems
+	maxFileNameLength = 100
+	// the file name of the notes whose title has no letter or digit
+	defaultFileName = "note"
+	// limits the decompressed size of each imported file
+	maxMarkdownFileSize = 16 << 20
+)
+
+// markdownFrontMatter is the metadata of a note at the start of its Markdown file
+type markdownFrontMatter struct {
+	Title string `yaml:"title"`
+	// the time the note was last updated, the date of the note in the API
+	Date      time.Time `yaml:"date,omitempty"`
+	CreatedAt time.Time `yaml:"createdAt,omitempty"`
+	Category  string    `yaml:"category,omitempty"`
+	Tags      []string  `yaml:"tags,omitempty"`
+}
+
+type markdownWriter struct {
+	archive *zip.Writer
+	// the names of the files already in the archive
+	names map[string]bool
+}
+
+func newMarkdownWriter(w io.Writer) *markdownWriter {
+	return &markdownWriter{
+		archive: zip.NewWriter(w),
+		names:   map[string]bool{},
+	}
+}
+
+// Write adds the Markdown file of the note to the archive, it's compressed as it's written
+func (m *markdownWriter) Write(note model.Note) error {
+	content, err := renderMarkdown(note)
+	if err != nil {
+		return fmt.Errorf("failed to export note '%s', error: %w", note.Title, err)
+	}
+
+	file, err := m.archive.CreateHeader(&zip.FileHeader{
+		Name:     m.fileName(note.Title),
+		Method:   zip.Deflate,
+		Modified: note.UpdatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export note '%s', error: %w", note.Title, err)
+	}
+
+	_, err = file.Write(content)
+	if err != nil {
+		return fmt.Errorf("failed to export note '%s', error: %w", note.Title, err)
+	}
+
+	return nil
+}
+
+// Close writes the directory of the archive
+func (m *markdownWriter) Close() error {
+	err := m.archive.Close()
+	if err != nil {
+		return fmt.Errorf("failed to export the notes, error: %w", err)
+	}
+
+	return nil
+}
+
+// fileName returns a name of file made from the title that is not in the archive yet,
+// the titles that only differ by the characters left out of the names are numbered
+func (m *markdownWriter) fileName(title string) string {
+	base := fileNameBase(title)
+
+	name := base + markdownExtension
+	for i := 2; m.names[name]; i++ {
+		name = fmt.Sprintf("%s-%d%s", base, i, markdownExtension)
+	}
+
+	m.names[name] = true
+
+	return name
+}
+
+// fileNameBase keeps the letters, the digits, '-' and '_' of the title, the other characters are replaced by '-'
+func fileNameBase(title string) string {
+	base := []rune{}
+	for _, r := range title {
+		if len(base) == maxFileNameLength {
+			break
+		}
+
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' {
+			base = append(base, r)
+			continue
+		}
+
+		if len(base) > 0 && base[len(base)-1] != '-' {
+			base = append(base, '-')
+		}
+	}
+
+	name := strings.Trim(string(base), "-")
+	if name == "" {
+		return defaultFileName
+	}
+
+	return name
+}
+
+// renderMarkdown returns the Markdown file of the note: its front matter, a blank line then its description
+func renderMarkdown(note model.Note) ([]byte, error) {
+	frontMatter, err := yaml.Marshal(markdownFrontMatter{
+		Title:     note.Title,
+		Date:      note.UpdatedAt.UTC(),
+		CreatedAt: note.CreatedAt.UTC(),
+		Category:  note.Category,
+		Tags:      note.Tags,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	content := bytes.Buffer{}
+	content.WriteString(frontMatterDelimiter + "\n")
+	content.Write(frontMatter)
+	content.WriteString(frontMatterDelimiter + "\n\n")
+	content.WriteString(note.Description)
+	content.WriteString("\n")
+
+	return content.Bytes(), nil
+}
+
+type markdownReader struct {
+	files []*zip.File
+	// the index of the next file
+	next int
+}
+
+func newMarkdownReader(r io.Reader) (*markdownReader, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import the notes, error: %w", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to import the notes, %s, error: %w", err, ErrInvalidFile)
+	}
+
+	files := []*zip.File{}
+	for _, file := range archive.File {
+		// the other files, such as the metadata some archivers add, are left out
+		if file.FileInfo().IsDir() || !strings.EqualFold(path.Ext(file.Name), markdownExtension) {
+			continue
+		}
+
+		files = append(files, file)
+	}
+
+	return &markdownReader{files: files}, nil
+}
+
+// Read returns the note of the next Markdown file of the archive, in the order of the archive
+func (m *markdownReader) Read() (Record, error) {
+	if m.next == len(m.files) {
+		return Record{}, io.EOF
+	}
+
+	file := m.files[m.next]
+	m.next++
+
+	note, err := readMarkdown(file)
+	if err != nil {
+		return Record{}, &RecordError{File: file.Name, Err: err}
+	}
+
+	return Record{Note: note, File: file.Name}, nil
+}
+
+// readMarkdown returns the note of the file, the title is the name of the file when the front matter has none
+func readMarkdown(file *zip.File) (model.Note, error) {
+	content, err := file.Open()
+	if err != nil {
+		return model.Note{}, err
+	}
+	defer content.Close()
+
+	// the size in the header of the file can't be trusted, the content is limited as it's decompressed
+	data, err := io.ReadAll(io.LimitReader(content, maxMarkdownFileSize+1))
+	if err != nil {
+		return model.Note{}, err
+	}
+
+	if len(data) > maxMarkdownFileSize {
+		return model.Note{}, fmt.Errorf("file is larger than %d bytes", maxMarkdownFileSize)
+	}
+
+	note, err := parseMarkdown(strings.ReplaceAll(string(data), "\r\n", "\n"))
+	if err != nil {
+		return model.Note{}, err
+	}
+
+	if note.Title == "" {
+		note.Title = strings.TrimSuffix(path.Base(file.Name), path.Ext(file.Name))
+	}
+
+	return note, nil
+}
+
+// parseMarkdown returns the note of the Markdown file written by renderMarkdown,
+// the whole file is the description when it has no front matter
+func parseMarkdown(content string) (model.Note, error) {
+	content = strings.TrimSuffix(content, "\n")
+
+	lines := strings.Split(content, "\n")
+	if lines[0] != frontMatterDelimiter {
+		return model.Note{Description: content}, nil
+	}
+
+	end := 1
+	for end < len(lines) && lines[end] != frontMatterDelimiter {
+		end++
+	}
+
+	if end == len(lines) {
+		return model.Note{}, errors.New("front matter is not closed by '---'")
+	}
+
+	frontMatter := markdownFrontMatter{}
+
+	err := yaml.Unmarshal([]byte(strings.Join(lines[1:end], "\n")), &frontMatter)
+	if err != nil {
+		return model.Note{}, fmt.Errorf("front matter is invalid, %w", err)
+	}
+
+	// the blank line after the front matter is not part of the description
+	description := lines[end+1:]
+	if len(description) > 0 && description[0] == "" {
+		description = description[1:]
+	}
+
+	return model.Note{
+		Title:       frontMatter.Title,
+		Description: strings.Join(description, "\n"),
+		Category:    frontMatter.Category,
+		Tags:        frontMatter.Tags,
+		CreatedAt:   frontMatter.CreatedAt.UTC(),
+		UpdatedAt:   frontMatter.Date.UTC(),
+	}, nil
+}
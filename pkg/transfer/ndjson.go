@@ -0,0 +1,78 @@
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/notes-project/api/pkg/model"
+)
+
+type ndjsonWriter struct {
+	encoder *json.Encoder
+}
+
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	encoder := json.NewEncoder(w)
+	// the descriptions are exported as they are, not escaped to be embedded in HTML
+	encoder.SetEscapeHTML(false)
+
+	return &ndjsonWriter{encoder: encoder}
+}
+
+// Write writes the note on its own line, the encoder ends every value with a newline
+func (n *ndjsonWriter) Write(note model.Note) error {
+	err := n.encoder.Encode(note)
+	if err != nil {
+		return fmt.Errorf("failed to export note '%s', error: %w", note.Title, err)
+	}
+
+	return nil
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
+
+type ndjsonReader struct {
+	reader *bufio.Reader
+	// the number of lines read so far
+	line int
+}
+
+func newNDJSONReader(r io.Reader) *ndjsonReader {
+	return &ndjsonReader{reader: bufio.NewReader(r)}
+}
+
+// Read decodes the next line that isn't blank, the lines are not limited in length unlike with a bufio.Scanner
+func (n *ndjsonReader) Read() (Record, error) {
+	for {
+		data, err := n.reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return Record{}, fmt.Errorf("failed to import the notes, error: %w", err)
+		}
+
+		// the last line doesn't have to end with a newline
+		if len(data) == 0 && errors.Is(err, io.EOF) {
+			return Record{}, io.EOF
+		}
+
+		n.line++
+
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+
+		note := model.Note{}
+
+		decodeErr := json.Unmarshal(data, &note)
+		if decodeErr != nil {
+			return Record{}, &RecordError{Line: n.line, Err: decodeErr}
+		}
+
+		return Record{Note: note, Line: n.line}, nil
+	}
+}
@@ -0,0 +1,133 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/notes-project/api/pkg/model"
+)
+
+/*
+	Formats the notes are exported to and imported from.
+
+	The notes are written one by one as they are read from the database, so an export
+	of any size is streamed without holding all the notes in memory:
+	  - ndjson writes every note in JSON on its own line, as the API renders them
+	  - csv writes a header then a row per note, the tags separated by commas in a single column
+	  - markdown writes a zip of one Markdown file per note, with the description as its content
+	    and the title, the dates, the category and the tags in a YAML front matter
+
+	The same formats are read back one note at a time, along with the line of each note in the file,
+	or the name of its file in the zip, so the notes that can't be read are reported without
+	preventing the other ones from being imported.
+*/
+
+const (
+	FormatNDJSON   = "ndjson"
+	FormatCSV      = "csv"
+	FormatMarkdown = "markdown"
+)
+
+// Formats lists the supported formats
+var Formats = []string{FormatNDJSON, FormatCSV, FormatMarkdown}
+
+// ErrInvalidFormat is returned for a format that is not one of Formats
+var ErrInvalidFormat = errors.New("format is invalid")
+
+// ErrInvalidFile is returned when the imported file can't be read at all, such as a CSV file without a header
+var ErrInvalidFile = errors.New("file is invalid")
+
+// Writer writes the exported notes one by one
+type Writer interface {
+	Write(note model.Note) error
+	// Close writes the end of the export, which is incomplete until it's closed. It doesn't close the underlying writer.
+	Close() error
+}
+
+// Record is a note read from an imported file
+type Record struct {
+	Note model.Note
+	// the line the note starts at in the file, 0 for the notes of a zip
+	Line int
+	// the name of the file of the note in a zip, empty otherwise
+	File string
+}
+
+// RecordError is returned for a note of the file that can't be read, the next notes can still be read
+type RecordError struct {
+	Line int
+	File string
+	Err  error
+}
+
+func (e *RecordError) Error() string {
+	if e.File != "" {
+		return fmt.Sprintf("failed to import the note of file '%s', error: %s", e.File, e.Err)
+	}
+
+	return fmt.Sprintf("failed to import the note at line %d, error: %s", e.Line, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// Reader reads the imported notes one by one
+type Reader interface {
+	// Read returns the next note of the file, or io.EOF after the last one. A *RecordError is returned
+	// for a note that can't be read, any other error means the rest of the file can't be read.
+	Read() (Record, error)
+}
+
+// NewReader returns the reader of the notes in the format from r, the notes of a zip are only read
+// once the whole archive is in memory as its directory is at its end
+func NewReader(format string, r io.Reader) (Reader, error) {
+	switch format {
+	case FormatNDJSON:
+		return newNDJSONReader(r), nil
+	case FormatCSV:
+		return newCSVReader(r), nil
+	case FormatMarkdown:
+		return newMarkdownReader(r)
+	}
+
+	return nil, fmt.Errorf("failed to import notes from format '%s', error: %w", format, ErrInvalidFormat)
+}
+
+// NewWriter returns the writer of the notes in the format to w
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatNDJSON:
+		return newNDJSONWriter(w), nil
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatMarkdown:
+		return newMarkdownWriter(w), nil
+	}
+
+	return nil, fmt.Errorf("failed to export notes to format '%s', error: %w", format, ErrInvalidFormat)
+}
+
+// ContentType returns the media type of an export in the format
+func ContentType(format string) string {
+	switch format {
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatMarkdown:
+		return "application/zip"
+	}
+
+	return "application/octet-stream"
+}
+
+// FileName returns the name of the file of an export in the format
+func FileName(format string) string {
+	if format == FormatMarkdown {
+		return "notes.zip"
+	}
+
+	return "notes." + format
+}
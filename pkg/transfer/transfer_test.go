@@ -0,0 +1,274 @@
+package transfer
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/notes-project/api/pkg/model"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Transfer", func() {
+	created := time.Date(2023, time.January, 1, 10, 0, 0, 0, time.UTC)
+	updated := created.Add(time.Hour)
+
+	notes := []model.Note{
+		{ID: "id1", Title: "Weekly meeting", Description: "Prepare the \"agenda\", then\nsend it", Category: "work", Tags: []string{"team", "todo"}, CreatedAt: created, UpdatedAt: updated, Version: 2},
+		{ID: "id2", Title: "Weekly/meeting", Description: "<b>bold</b>", CreatedAt: created, UpdatedAt: created, Version: 1},
+	}
+
+	// export writes the notes in the format
+	export := func(format string, notes ...model.Note) []byte {
+		buffer := &bytes.Buffer{}
+
+		writer, err := NewWriter(format, buffer)
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, note := range notes {
+			Expect(writer.Write(note)).To(Succeed())
+		}
+		Expect(writer.Close()).To(Succeed())
+
+		return buffer.Bytes()
+	}
+
+	It("should return an error when the format is not supported", func() {
+		_, err := NewWriter("xml", &bytes.Buffer{})
+		Expect(err).To(MatchError(ErrInvalidFormat))
+	})
+
+	Describe("NDJSON", func() {
+		It("should write every note on its own line", func() {
+			lines := bytes.Split(bytes.TrimSuffix(export(FormatNDJSON, notes...), []byte("\n")), []byte("\n"))
+			Expect(lines).To(HaveLen(2))
+			Expect(lines[0]).To(MatchJSON(`{
+				"id": "id1", "title": "Weekly meeting", "date": "", "description": "Prepare the \"agenda\", then\nsend it",
+				"category": "work", "tags": ["team", "todo"], "createdAt": "2023-01-01T10:00:00Z", "updatedAt": "2023-01-01T11:00:00Z", "version": 2
+			}`))
+			Expect(string(lines[1])).To(ContainSubstring(`"description":"<b>bold</b>"`))
+		})
+
+		It("should write nothing without notes", func() {
+			Expect(export(FormatNDJSON)).To(BeEmpty())
+		})
+	})
+
+	Describe("CSV", func() {
+		It("should write the header then a row per note", func() {
+			Expect(string(export(FormatCSV, notes...))).To(Equal(
+				"id,title,description,category,tags,createdAt,updatedAt,version\n" +
+					"id1,Weekly meeting,\"Prepare the \"\"agenda\"\", then\nsend it\",work,\"team,todo\",2023-01-01T10:00:00Z,2023-01-01T11:00:00Z,2\n" +
+					"id2,Weekly/meeting,<b>bold</b>,,,2023-01-01T10:00:00Z,2023-01-01T10:00:00Z,1\n",
+			))
+		})
+
+		It("should write the header without notes", func() {
+			Expect(string(export(FormatCSV))).To(Equal("id,title,description,category,tags,createdAt,updatedAt,version\n"))
+		})
+
+		It("should prefix the cells a spreadsheet would run as a formula with a quote", func() {
+			Expect(string(export(FormatCSV, model.Note{ID: "id", Title: "=SUM(A1)", Description: "+1", Category: "-work", Tags: []string{"@team", "=todo"}, Version: 1}))).To(Equal(
+				"id,title,description,category,tags,createdAt,updatedAt,version\n" +
+					"id,'=SUM(A1),'+1,'-work,\"'@team,=todo\",,,1\n",
+			))
+			Expect(string(export(FormatCSV, model.Note{ID: "id", Title: "'=quoted", Description: "'quoted", Version: 1}))).To(ContainSubstring("id,''=quoted,'quoted,"))
+		})
+	})
+
+	Describe("Markdown", func() {
+		// files returns the content of the files in the archive by name
+		files := func(archive []byte) map[string]string {
+			reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+			Expect(err).NotTo(HaveOccurred())
+
+			files := map[string]string{}
+			for _, file := range reader.File {
+				content, err := file.Open()
+				Expect(err).NotTo(HaveOccurred())
+
+				data, err := io.ReadAll(content)
+				Expect(err).NotTo(HaveOccurred())
+
+				files[file.Name] = string(data)
+			}
+
+			return files
+		}
+
+		It("should write a file per note with its front matter", func() {
+			exported := files(export(FormatMarkdown, notes...))
+			Expect(exported).To(HaveLen(2))
+			Expect(exported).To(HaveKeyWithValue("Weekly-meeting.md",
+				"---\n"+
+					"title: Weekly meeting\n"+
+					"date: 2023-01-01T11:00:00Z\n"+
+					"createdAt: 2023-01-01T10:00:00Z\n"+
+					"category: work\n"+
+					"tags:\n"+
+					"    - team\n"+
+					"    - todo\n"+
+					"---\n\n"+
+					"Prepare the \"agenda\", then\nsend it\n",
+			))
+		})
+
+		It("should give a distinct name to the notes whose titles make the same file name", func() {
+			exported := files(export(FormatMarkdown, notes...))
+			Expect(exported).To(HaveKey("Weekly-meeting-2.md"))
+		})
+
+		It("should name the file of a note whose title has no letter or digit", func() {
+			exported := files(export(FormatMarkdown, model.Note{Title: "???"}))
+			Expect(exported).To(HaveKey("note.md"))
+		})
+
+		It("should write an empty archive without notes", func() {
+			Expect(files(export(FormatMarkdown))).To(BeEmpty())
+		})
+	})
+
+	Describe("Reader", func() {
+		// read returns the records of the file in the format along with the errors of the records that can't be read
+		read := func(format string, data []byte) ([]Record, []*RecordError) {
+			reader, err := NewReader(format, bytes.NewReader(data))
+			Expect(err).NotTo(HaveOccurred())
+
+			records := []Record{}
+			recordErrs := []*RecordError{}
+
+			for {
+				record, err := reader.Read()
+				if errors.Is(err, io.EOF) {
+					return records, recordErrs
+				}
+
+				var recordErr *RecordError
+				if errors.As(err, &recordErr) {
+					recordErrs = append(recordErrs, recordErr)
+					continue
+				}
+
+				Expect(err).NotTo(HaveOccurred())
+				records = append(records, record)
+			}
+		}
+
+		// readNotes returns the notes of the records
+		readNotes := func(records []Record) []model.Note {
+			notes := []model.Note{}
+			for _, record := range records {
+				notes = append(notes, record.Note)
+			}
+
+			return notes
+		}
+
+		It("should return an error when the format is not supported", func() {
+			_, err := NewReader("xml", &bytes.Buffer{})
+			Expect(err).To(MatchError(ErrInvalidFormat))
+		})
+
+		It("should read back the notes exported to NDJSON and CSV", func() {
+			for _, format := range []string{FormatNDJSON, FormatCSV} {
+				records, recordErrs := read(format, export(format, notes...))
+				Expect(recordErrs).To(BeEmpty(), format)
+				Expect(readNotes(records)).To(Equal(notes), format)
+			}
+		})
+
+		It("should read back the CSV cells prefixed with a quote as they were exported", func() {
+			escaped := []model.Note{
+				{ID: "id", Title: "=SUM(A1)", Description: "'+1", Category: "'work", Tags: []string{"-team", "@todo"}, Version: 1},
+			}
+
+			records, recordErrs := read(FormatCSV, export(FormatCSV, escaped...))
+			Expect(recordErrs).To(BeEmpty())
+			Expect(readNotes(records)).To(Equal(escaped))
+		})
+
+		It("should read back the notes exported to Markdown with their file names", func() {
+			records, recordErrs := read(FormatMarkdown, export(FormatMarkdown, notes...))
+			Expect(recordErrs).To(BeEmpty())
+			Expect(records).To(HaveLen(2))
+			Expect(records[0].File).To(Equal("Weekly-meeting.md"))
+			Expect(records[0].Note).To(Equal(model.Note{
+				Title:       "Weekly meeting",
+				Description: "Prepare the \"agenda\", then\nsend it",
+				Category:    "work",
+				Tags:        []string{"team", "todo"},
+				CreatedAt:   created,
+				UpdatedAt:   updated,
+			}))
+		})
+
+		It("should return the line of each NDJSON note and skip the blank lines", func() {
+			records, recordErrs := read(FormatNDJSON, []byte("{\"title\": \"a\"}\n\n  \nnot json\n{\"title\": \"b\"}"))
+			Expect(records).To(HaveLen(2))
+			Expect(records[0].Line).To(Equal(1))
+			Expect(records[1].Line).To(Equal(5))
+			Expect(records[1].Note.Title).To(Equal("b"))
+			Expect(recordErrs).To(HaveLen(1))
+			Expect(recordErrs[0].Line).To(Equal(4))
+		})
+
+		It("should read the CSV columns in any order and report the invalid rows with their line", func() {
+			records, recordErrs := read(FormatCSV, []byte("\ufefftags, title\n\"a, b\",first\n\"multi\nline\",second\nonly\nc,third,extra\n"))
+			Expect(readNotes(records)).To(Equal([]model.Note{
+				{Title: "first", Tags: []string{"a", "b"}},
+				{Title: "second", Tags: []string{"multi\nline"}},
+			}))
+			Expect(records[1].Line).To(Equal(3))
+			Expect(recordErrs).To(HaveLen(2))
+			Expect(recordErrs[0].Line).To(Equal(5))
+			Expect(recordErrs[1].Line).To(Equal(6))
+		})
+
+		It("should report the CSV rows with an invalid time", func() {
+			_, recordErrs := read(FormatCSV, []byte("title,createdAt\nfirst,yesterday\n"))
+			Expect(recordErrs).To(HaveLen(1))
+			Expect(recordErrs[0].Err).To(MatchError(ContainSubstring("column 'createdAt'")))
+		})
+
+		It("should return an error when the CSV header is invalid", func() {
+			for _, header := range []string{"title,name\n", "description\n", "title,title\n"} {
+				reader, err := NewReader(FormatCSV, bytes.NewBufferString(header+"a,b\n"))
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = reader.Read()
+				Expect(err).To(MatchError(ErrInvalidFile), header)
+			}
+		})
+
+		It("should read the Markdown files without front matter and leave out the other files", func() {
+			archive := &bytes.Buffer{}
+			writer := zip.NewWriter(archive)
+			for name, content := range map[string]string{
+				"notes/Plain.MD":    "Just text\n",
+				"notes/broken.md":   "---\ntitle: [\n---\n",
+				"notes/unclosed.md": "---\ntitle: open\n",
+				"README.txt":        "not a note",
+			} {
+				file, err := writer.Create(name)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = file.Write([]byte(content))
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(writer.Close()).To(Succeed())
+
+			records, recordErrs := read(FormatMarkdown, archive.Bytes())
+			Expect(records).To(HaveLen(1))
+			Expect(records[0].Note).To(Equal(model.Note{Title: "Plain", Description: "Just text"}))
+			Expect(recordErrs).To(HaveLen(2))
+		})
+
+		It("should return an error when the Markdown archive is not a zip", func() {
+			_, err := NewReader(FormatMarkdown, bytes.NewBufferString("not a zip"))
+			Expect(err).To(MatchError(ErrInvalidFile))
+		})
+	})
+
+})
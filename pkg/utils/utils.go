@@ -3,44 +3,119 @@ package utils
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"time"
+
+	"github.com/notes-project/api/pkg/constants"
+	"golang.org/x/text/language"
 )
 
 const (
+	DATABASE_DRIVER     = "DATABASE_DRIVER"
 	DATABASE_URI        = "DATABASE_URI"
 	DATABASE_NAME       = "DATABASE_NAME"
 	DATABASE_COLLECTION = "DATABASE_COLLECTION"
 
 	SERVER_PORT     = "SERVER_PORT"
 	SERVER_TLS_PORT = "SERVER_TLS_PORT"
+
+	REVISIONS_MAX_COUNT = "REVISIONS_MAX_COUNT"
+	REVISIONS_MAX_AGE   = "REVISIONS_MAX_AGE"
+
+	TRASH_RETENTION = "TRASH_RETENTION"
+
+	MAX_PAGE_SIZE = "MAX_PAGE_SIZE"
+
+	TITLE_LOCALE = "TITLE_LOCALE"
 )
 
 const (
-	envVarIsEmptyErrMsg = "env var %s is empty"
+	defaultTrashRetention = 30 * 24 * time.Hour
+	defaultMaxPageSize    = 100
+)
+
+const (
+	envVarIsEmptyErrMsg   = "env var %s is empty"
+	envVarIsInvalidErrMsg = "env var %s has unsupported value '%s'"
 )
 
 type Config struct {
+	DatabaseDriver     string
 	DatabaseUri        string
 	DatabaseName       string
 	DatabaseCollection string
 
 	ServerPort    string
 	ServerTlsPort string
+
+	// zero values mean the revisions are kept forever
+	RevisionsMaxCount int64
+	RevisionsMaxAge   time.Duration
+
+	// zero means the notes are kept in the trash until they are purged explicitly
+	TrashRetention time.Duration
+
+	// the maximum number of notes listed in a single page, also the size of the pages when the client doesn't set it
+	MaxPageSize int64
+
+	// the BCP 47 locale of the titles, which are then unique and found ignoring the case and sorted
+	// in the order of the locale, empty means they are unique and sorted byte by byte
+	TitleLocale string
 }
 
 func GetEnvConfig() (Config, error) {
-	dbUri, exist := os.LookupEnv(DATABASE_URI)
-	if !exist {
-		return Config{}, fmt.Errorf(envVarIsEmptyErrMsg, DATABASE_URI)
+	dbDriver := os.Getenv(DATABASE_DRIVER)
+	if dbDriver == "" {
+		dbDriver = constants.MongoDriver
 	}
 
-	dbName, exist := os.LookupEnv(DATABASE_NAME)
-	if !exist {
-		return Config{}, fmt.Errorf(envVarIsEmptyErrMsg, DATABASE_NAME)
+	config := Config{
+		DatabaseDriver: dbDriver,
 	}
 
-	dbCollection, exist := os.LookupEnv(DATABASE_COLLECTION)
-	if !exist {
-		return Config{}, fmt.Errorf(envVarIsEmptyErrMsg, DATABASE_COLLECTION)
+	switch dbDriver {
+	case constants.MongoDriver:
+		dbUri, exist := os.LookupEnv(DATABASE_URI)
+		if !exist {
+			return Config{}, fmt.Errorf(envVarIsEmptyErrMsg, DATABASE_URI)
+		}
+
+		dbName, exist := os.LookupEnv(DATABASE_NAME)
+		if !exist {
+			return Config{}, fmt.Errorf(envVarIsEmptyErrMsg, DATABASE_NAME)
+		}
+
+		dbCollection, exist := os.LookupEnv(DATABASE_COLLECTION)
+		if !exist {
+			return Config{}, fmt.Errorf(envVarIsEmptyErrMsg, DATABASE_COLLECTION)
+		}
+
+		config.DatabaseUri = dbUri
+		config.DatabaseName = dbName
+		config.DatabaseCollection = dbCollection
+	case constants.FileDriver, constants.PostgresDriver:
+		// for the file driver the uri is the path of the data directory and the collection is the name of the data files,
+		// for the postgres driver the name is optional because the database can be part of the uri
+		dbUri, exist := os.LookupEnv(DATABASE_URI)
+		if !exist {
+			return Config{}, fmt.Errorf(envVarIsEmptyErrMsg, DATABASE_URI)
+		}
+
+		dbCollection, exist := os.LookupEnv(DATABASE_COLLECTION)
+		if !exist {
+			return Config{}, fmt.Errorf(envVarIsEmptyErrMsg, DATABASE_COLLECTION)
+		}
+
+		config.DatabaseUri = dbUri
+		config.DatabaseName = os.Getenv(DATABASE_NAME)
+		config.DatabaseCollection = dbCollection
+	case constants.MemoryDriver:
+		// the in-memory database doesn't connect anywhere, so the rest of the database configuration is optional
+		config.DatabaseUri = os.Getenv(DATABASE_URI)
+		config.DatabaseName = os.Getenv(DATABASE_NAME)
+		config.DatabaseCollection = os.Getenv(DATABASE_COLLECTION)
+	default:
+		return Config{}, fmt.Errorf(envVarIsInvalidErrMsg, DATABASE_DRIVER, dbDriver)
 	}
 
 	serverPort, exist := os.LookupEnv(SERVER_PORT)
@@ -48,13 +123,55 @@ func GetEnvConfig() (Config, error) {
 		return Config{}, fmt.Errorf(envVarIsEmptyErrMsg, SERVER_PORT)
 	}
 
-	serverTlsPort := os.Getenv(SERVER_TLS_PORT)
+	config.ServerPort = serverPort
+	config.ServerTlsPort = os.Getenv(SERVER_TLS_PORT)
+
+	if maxCount := os.Getenv(REVISIONS_MAX_COUNT); maxCount != "" {
+		value, err := strconv.ParseInt(maxCount, 10, 64)
+		if err != nil || value < 0 {
+			return Config{}, fmt.Errorf(envVarIsInvalidErrMsg, REVISIONS_MAX_COUNT, maxCount)
+		}
+
+		config.RevisionsMaxCount = value
+	}
+
+	if maxAge := os.Getenv(REVISIONS_MAX_AGE); maxAge != "" {
+		value, err := time.ParseDuration(maxAge)
+		if err != nil || value < 0 {
+			return Config{}, fmt.Errorf(envVarIsInvalidErrMsg, REVISIONS_MAX_AGE, maxAge)
+		}
+
+		config.RevisionsMaxAge = value
+	}
+
+	config.TrashRetention = defaultTrashRetention
+	if retention := os.Getenv(TRASH_RETENTION); retention != "" {
+		value, err := time.ParseDuration(retention)
+		if err != nil || value < 0 {
+			return Config{}, fmt.Errorf(envVarIsInvalidErrMsg, TRASH_RETENTION, retention)
+		}
+
+		config.TrashRetention = value
+	}
+
+	config.MaxPageSize = defaultMaxPageSize
+	if maxPageSize := os.Getenv(MAX_PAGE_SIZE); maxPageSize != "" {
+		value, err := strconv.ParseInt(maxPageSize, 10, 64)
+		if err != nil || value < 1 {
+			return Config{}, fmt.Errorf(envVarIsInvalidErrMsg, MAX_PAGE_SIZE, maxPageSize)
+		}
+
+		config.MaxPageSize = value
+	}
+
+	if locale := os.Getenv(TITLE_LOCALE); locale != "" {
+		tag, err := language.Parse(locale)
+		if err != nil {
+			return Config{}, fmt.Errorf(envVarIsInvalidErrMsg, TITLE_LOCALE, locale)
+		}
+
+		config.TitleLocale = tag.String()
+	}
 
-	return Config{
-		DatabaseUri:        dbUri,
-		DatabaseName:       dbName,
-		DatabaseCollection: dbCollection,
-		ServerPort:         serverPort,
-		ServerTlsPort:      serverTlsPort,
-	}, nil
+	return config, nil
 }
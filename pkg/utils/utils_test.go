@@ -3,7 +3,9 @@ package utils
 import (
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/notes-project/api/pkg/constants"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -57,11 +59,206 @@ var _ = Describe("Utils", func() {
 			})
 		})
 
+		Context("Database driver", func() {
+			AfterEach(func() {
+				Expect(os.Unsetenv(DATABASE_DRIVER)).To(Succeed())
+			})
+
+			It("should default to the mongo driver when database driver is missing", func() {
+				os.Unsetenv(DATABASE_DRIVER)
+
+				config, err := GetEnvConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.DatabaseDriver).To(Equal(constants.MongoDriver))
+			})
+
+			It("should return an error when database driver is not supported", func() {
+				Expect(os.Setenv(DATABASE_DRIVER, "unknown")).To(Succeed())
+
+				_, err := GetEnvConfig()
+				Expect(err).To(MatchError(fmt.Sprintf(envVarIsInvalidErrMsg, DATABASE_DRIVER, "unknown")))
+			})
+
+			It("should not require the database connection env vars for the memory driver", func() {
+				Expect(os.Setenv(DATABASE_DRIVER, constants.MemoryDriver)).To(Succeed())
+				os.Unsetenv(DATABASE_URI)
+				os.Unsetenv(DATABASE_NAME)
+				os.Unsetenv(DATABASE_COLLECTION)
+
+				config, err := GetEnvConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.DatabaseDriver).To(Equal(constants.MemoryDriver))
+			})
+
+			It("should require the data directory for the file driver", func() {
+				Expect(os.Setenv(DATABASE_DRIVER, constants.FileDriver)).To(Succeed())
+				os.Unsetenv(DATABASE_URI)
+
+				_, err := GetEnvConfig()
+				Expect(err).To(MatchError(fmt.Sprintf(envVarIsEmptyErrMsg, DATABASE_URI)))
+			})
+
+			It("should not require the database name for the file driver", func() {
+				Expect(os.Setenv(DATABASE_DRIVER, constants.FileDriver)).To(Succeed())
+				os.Unsetenv(DATABASE_NAME)
+
+				config, err := GetEnvConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.DatabaseUri).To(Equal("dbUri"))
+				Expect(config.DatabaseCollection).To(Equal("dbCollection"))
+			})
+
+			It("should not require the database name for the postgres driver", func() {
+				Expect(os.Setenv(DATABASE_DRIVER, constants.PostgresDriver)).To(Succeed())
+				os.Unsetenv(DATABASE_NAME)
+
+				config, err := GetEnvConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.DatabaseDriver).To(Equal(constants.PostgresDriver))
+			})
+
+			It("should require the table name for the postgres driver", func() {
+				Expect(os.Setenv(DATABASE_DRIVER, constants.PostgresDriver)).To(Succeed())
+				os.Unsetenv(DATABASE_COLLECTION)
+
+				_, err := GetEnvConfig()
+				Expect(err).To(MatchError(fmt.Sprintf(envVarIsEmptyErrMsg, DATABASE_COLLECTION)))
+			})
+
+			It("should still require the server port for the memory driver", func() {
+				Expect(os.Setenv(DATABASE_DRIVER, constants.MemoryDriver)).To(Succeed())
+				os.Unsetenv(SERVER_PORT)
+
+				_, err := GetEnvConfig()
+				Expect(err).To(MatchError(fmt.Sprintf(envVarIsEmptyErrMsg, SERVER_PORT)))
+			})
+		})
+
+		Context("Revisions retention", func() {
+			AfterEach(func() {
+				Expect(os.Unsetenv(REVISIONS_MAX_COUNT)).To(Succeed())
+				Expect(os.Unsetenv(REVISIONS_MAX_AGE)).To(Succeed())
+			})
+
+			It("should keep the revisions forever when the env vars are missing", func() {
+				config, err := GetEnvConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.RevisionsMaxCount).To(BeZero())
+				Expect(config.RevisionsMaxAge).To(BeZero())
+			})
+
+			It("should parse the maximum count and age", func() {
+				Expect(os.Setenv(REVISIONS_MAX_COUNT, "10")).To(Succeed())
+				Expect(os.Setenv(REVISIONS_MAX_AGE, "720h")).To(Succeed())
+
+				config, err := GetEnvConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.RevisionsMaxCount).To(Equal(int64(10)))
+				Expect(config.RevisionsMaxAge).To(Equal(720 * time.Hour))
+			})
+
+			It("should return an error when the maximum count is invalid", func() {
+				Expect(os.Setenv(REVISIONS_MAX_COUNT, "-1")).To(Succeed())
+
+				_, err := GetEnvConfig()
+				Expect(err).To(MatchError(fmt.Sprintf(envVarIsInvalidErrMsg, REVISIONS_MAX_COUNT, "-1")))
+			})
+
+			It("should return an error when the maximum age is invalid", func() {
+				Expect(os.Setenv(REVISIONS_MAX_AGE, "month")).To(Succeed())
+
+				_, err := GetEnvConfig()
+				Expect(err).To(MatchError(fmt.Sprintf(envVarIsInvalidErrMsg, REVISIONS_MAX_AGE, "month")))
+			})
+		})
+
+		Context("Trash retention", func() {
+			AfterEach(func() {
+				Expect(os.Unsetenv(TRASH_RETENTION)).To(Succeed())
+			})
+
+			It("should use the default retention when the env var is missing", func() {
+				config, err := GetEnvConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.TrashRetention).To(Equal(defaultTrashRetention))
+			})
+
+			It("should parse the retention", func() {
+				Expect(os.Setenv(TRASH_RETENTION, "0")).To(Succeed())
+
+				config, err := GetEnvConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.TrashRetention).To(BeZero())
+			})
+
+			It("should return an error when the retention is invalid", func() {
+				Expect(os.Setenv(TRASH_RETENTION, "-1h")).To(Succeed())
+
+				_, err := GetEnvConfig()
+				Expect(err).To(MatchError(fmt.Sprintf(envVarIsInvalidErrMsg, TRASH_RETENTION, "-1h")))
+			})
+		})
+
+		Context("Max page size", func() {
+			AfterEach(func() {
+				Expect(os.Unsetenv(MAX_PAGE_SIZE)).To(Succeed())
+			})
+
+			It("should use the default max page size when the env var is missing", func() {
+				config, err := GetEnvConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.MaxPageSize).To(Equal(int64(defaultMaxPageSize)))
+			})
+
+			It("should parse the max page size", func() {
+				Expect(os.Setenv(MAX_PAGE_SIZE, "500")).To(Succeed())
+
+				config, err := GetEnvConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.MaxPageSize).To(Equal(int64(500)))
+			})
+
+			It("should return an error when the max page size is invalid", func() {
+				Expect(os.Setenv(MAX_PAGE_SIZE, "0")).To(Succeed())
+
+				_, err := GetEnvConfig()
+				Expect(err).To(MatchError(fmt.Sprintf(envVarIsInvalidErrMsg, MAX_PAGE_SIZE, "0")))
+			})
+		})
+
+		Context("Title locale", func() {
+			AfterEach(func() {
+				Expect(os.Unsetenv(TITLE_LOCALE)).To(Succeed())
+			})
+
+			It("should compare the titles byte by byte when the env var is missing", func() {
+				config, err := GetEnvConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.TitleLocale).To(BeEmpty())
+			})
+
+			It("should parse the locale into its canonical form", func() {
+				Expect(os.Setenv(TITLE_LOCALE, "de_at")).To(Succeed())
+
+				config, err := GetEnvConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.TitleLocale).To(Equal("de-AT"))
+			})
+
+			It("should return an error when the locale is invalid", func() {
+				Expect(os.Setenv(TITLE_LOCALE, "not a locale")).To(Succeed())
+
+				_, err := GetEnvConfig()
+				Expect(err).To(MatchError(fmt.Sprintf(envVarIsInvalidErrMsg, TITLE_LOCALE, "not a locale")))
+			})
+		})
+
 	})
 
 })
 
 var _ = AfterSuite(func() {
+	Expect(os.Unsetenv(DATABASE_DRIVER)).To(Succeed())
 	Expect(os.Unsetenv(DATABASE_URI)).To(Succeed())
 	Expect(os.Unsetenv(DATABASE_NAME)).To(Succeed())
 	Expect(os.Unsetenv(DATABASE_COLLECTION)).To(Succeed())